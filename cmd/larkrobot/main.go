@@ -3,10 +3,21 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	larkchunking "github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/chunking"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/digest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/memory"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/messages"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/music"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/persona"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/reminder"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/stock"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/asr"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/gotify"
@@ -18,10 +29,9 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/retriver"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/interfaces/lark"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
 
-	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
-	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
-	larkws "github.com/larksuite/oapi-sdk-go/v3/ws"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -36,34 +46,77 @@ func main() {
 	db.Init(config.DBConfig)
 	opensearch.Init(config.OpensearchConfig)
 	ark_dal.Init(config.ArkConfig)
+	asr.Init(config.ASRConfig)
 	miniodal.Init(config.MinioConfig)
 	retriver.Init()
 	neteaseapi.Init()
 	aktool.Init()
 	gotify.Init()
 	larkchunking.Init()
+	persona.Init()
+	memory.Init()
+	reminder.Init()
+	digest.Init()
+	music.Init()
+	stock.Init()
+	messages.Init()
 	lark_dal.Init()
 
-	go registerHandlers(config)
-	select {}
+	registerHandlers(config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := xlifecycle.Default.Start(ctx); err != nil {
+		panic(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-xlifecycle.Default.Failed():
+		logs.L().Error("a component failed, shutting down")
+	}
+	logs.L().Info("shutting down, draining in-flight events", zap.Int("in_flight", xlifecycle.Default.InFlight()))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	report := xlifecycle.Default.Shutdown(shutdownCtx)
+	if report.Clean() {
+		logs.L().Info(report.String())
+	} else {
+		logs.L().Error(report.String())
+	}
+	logs.L().Sync()
+	cancel()
+	os.Exit(report.ExitCode())
 }
 
+// shutdownTimeout 收到退出信号后排空任务、刷新缓冲的总时长
+const shutdownTimeout = 25 * time.Second
+
 func registerHandlers(config *config.BaseConfig) {
-	eventHandler := dispatcher.
-		NewEventDispatcher("", "").
-		OnP2MessageReactionCreatedV1(lark.MessageReactionHandler).
-		OnP2MessageReceiveV1(lark.MessageV2Handler).
-		OnP2ApplicationAppVersionAuditV6(lark.AuditV6Handler).
-		OnP2CardActionTrigger(lark.CardActionHandler)
+	larkConf := config.LarkConfig
+	eventHandler := lark.NewEventDispatcher(larkConf)
 
-	cli := larkws.NewClient(config.LarkConfig.AppID, config.LarkConfig.AppSecret,
-		larkws.WithEventHandler(eventHandler),
-		larkws.WithLogLevel(larkcore.LogLevelInfo),
-	)
+	if larkConf.UseHTTP() {
+		httpServer := lark.NewHTTPServer(larkConf, eventHandler)
+		xlifecycle.Register("lark_http", xlifecycle.PhaseIntake,
+			func(context.Context) error {
+				// 端口绑定失败时直接启动失败, 绑定成功后才视为就绪
+				if err := httpServer.Listen(); err != nil {
+					return err
+				}
+				go func() {
+					if err := httpServer.Serve(); err != nil {
+						xlifecycle.Fail("lark_http", err)
+					}
+				}()
+				return nil
+			},
+			httpServer.Shutdown,
+		)
+	}
 
-	// 启动客户端
-	err := cli.Start(context.Background())
-	if err != nil {
-		panic(err)
+	if larkConf.UseWS() {
+		cli := lark.NewWSClient(larkConf, eventHandler)
+		xlifecycle.Register("lark_ws", xlifecycle.PhaseIntake, cli.Start, cli.Stop)
 	}
 }
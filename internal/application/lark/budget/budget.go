@@ -0,0 +1,211 @@
+// Package budget 主动行为预算: 复读、表情、关键词回复、模仿聊天等非用户触发的行为共享按群、按用户的令牌桶,
+// 同一条消息上的多个行为先按优先级竞价, 避免同时触发或刷屏
+package budget
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	chatKeyPrefix = "budget:chat:"
+	userKeyPrefix = "budget:user:"
+	msgKeyPrefix  = "budget:msg:"
+	bidKeyPrefix  = "budget:bid:"
+
+	// msgKeyTTL 单条消息相关 key 的保留时间, 只需覆盖竞价与执行
+	msgKeyTTL = time.Minute
+)
+
+// 被拦下的原因
+const (
+	ReasonOutranked = "outranked"
+	ReasonMessage   = "message_limit"
+	ReasonChat      = "chat_budget"
+	ReasonUser      = "user_budget"
+	ReasonError     = "error"
+)
+
+// Action 一种主动行为
+type Action struct {
+	Name     string
+	Priority xhandler.Priority
+}
+
+var (
+	Repeat    = Action{Name: "repeat", Priority: xhandler.PriorityLow}
+	React     = Action{Name: "react", Priority: xhandler.PriorityLow}
+	Imitate   = Action{Name: "imitate", Priority: xhandler.PriorityNormal}
+	WordReply = Action{Name: "word_reply", Priority: xhandler.PriorityHigh}
+	// Proposal 根据聊天内容主动提出的建议, 如提醒
+	Proposal = Action{Name: "proposal", Priority: xhandler.PriorityNormal}
+)
+
+var suppressedCounter = sync.OnceValue(func() metric.Int64Counter {
+	counter, _ := otel.M().Int64Counter("spontaneous_action_suppressed", metric.WithDescription("spontaneous bot actions suppressed by budget"))
+	return counter
+})
+
+// settings 生效的预算配置
+type settings struct {
+	ChatBudget, UserBudget, PerMessage int
+	Window, Settle                     time.Duration
+}
+
+func loadSettings(c *config.RateConfig) settings {
+	s := settings{ChatBudget: 6, UserBudget: 3, PerMessage: 1, Window: time.Minute, Settle: 150 * time.Millisecond}
+	if c == nil {
+		return s
+	}
+	if c.ChatBudget > 0 {
+		s.ChatBudget = c.ChatBudget
+	}
+	if c.UserBudget > 0 {
+		s.UserBudget = c.UserBudget
+	}
+	if c.PerMessageLimit > 0 {
+		s.PerMessage = c.PerMessageLimit
+	}
+	if c.BudgetWindowSec > 0 {
+		s.Window = time.Duration(c.BudgetWindowSec) * time.Second
+	}
+	if c.SettleMs > 0 {
+		s.Settle = time.Duration(c.SettleMs) * time.Millisecond
+	}
+	return s
+}
+
+// reserve 低优先级行为需要给更重要的行为保留的令牌数
+//
+//	@param capacity int 桶容量
+//	@param p xhandler.Priority
+//	@return float64
+func reserve(capacity int, p xhandler.Priority) float64 {
+	if p >= xhandler.PriorityNormal {
+		return 0
+	}
+	return float64(capacity / 3)
+}
+
+// acquireScript 原子地检查竞价排名、单条消息上限和群/用户令牌桶, 全部通过才扣减
+//
+//	KEYS: bid, msg, chat, user
+//	ARGV: member, now_ms, window_ms, per_msg, chat_cap, user_cap, chat_reserve, user_reserve, msg_ttl_ms
+var acquireScript = redis.NewScript(`
+local per_msg = tonumber(ARGV[4])
+local rank = redis.call('ZREVRANK', KEYS[1], ARGV[1])
+if rank and rank >= per_msg then
+	return 'outranked'
+end
+local used = tonumber(redis.call('GET', KEYS[2]) or '0')
+if used >= per_msg then
+	return 'message_limit'
+end
+
+local now = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local function refill(key, cap)
+	local v = redis.call('HMGET', key, 'tokens', 'ts')
+	local tokens = tonumber(v[1])
+	local ts = tonumber(v[2])
+	if not tokens or not ts then
+		return cap
+	end
+	return math.min(cap, tokens + (now - ts) * cap / window)
+end
+
+local chat_cap = tonumber(ARGV[5])
+local user_cap = tonumber(ARGV[6])
+local chat_tokens = refill(KEYS[3], chat_cap)
+if chat_tokens < 1 + tonumber(ARGV[7]) then
+	return 'chat_budget'
+end
+local user_tokens = refill(KEYS[4], user_cap)
+if user_tokens < 1 + tonumber(ARGV[8]) then
+	return 'user_budget'
+end
+
+redis.call('HSET', KEYS[3], 'tokens', chat_tokens - 1, 'ts', now)
+redis.call('PEXPIRE', KEYS[3], window * 2)
+redis.call('HSET', KEYS[4], 'tokens', user_tokens - 1, 'ts', now)
+redis.call('PEXPIRE', KEYS[4], window * 2)
+redis.call('INCR', KEYS[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[9])
+return ''
+`)
+
+// Acquire 为一次主动行为申请预算, 在决定触发之后、真正发送之前调用
+//
+//	同一条消息上的行为会先竞价并等待 SettleMs, 只有排名在 PerMessageLimit 之内的才继续检查令牌桶;
+//	被拦下时在当前 trace 上记录事件, Redis 出错时不放行
+//	@param ctx context.Context
+//	@param action Action
+//	@param chatID string
+//	@param userID string 触发消息的发送者
+//	@param msgID string 触发消息
+//	@return bool 是否可以执行
+func Acquire(ctx context.Context, action Action, chatID, userID, msgID string) bool {
+	s := loadSettings(config.Get().RateConfig)
+	rdb := redis_dal.GetRedisClient()
+	bidKey := bidKeyPrefix + msgID
+
+	// 分数为优先级, 相同时 ZREVRANK 按行为名逆序, 结果是确定的
+	pipe := rdb.TxPipeline()
+	pipe.ZAddNX(ctx, bidKey, redis.Z{Score: float64(action.Priority), Member: action.Name})
+	pipe.PExpire(ctx, bidKey, msgKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logs.L().Ctx(ctx).Warn("budget bid failed", zap.Error(err))
+		suppress(ctx, action, chatID, ReasonError)
+		return false
+	}
+
+	select {
+	case <-ctx.Done():
+		suppress(ctx, action, chatID, ReasonError)
+		return false
+	case <-time.After(s.Settle):
+	}
+
+	reason, err := acquireScript.Run(ctx, rdb,
+		[]string{bidKey, msgKeyPrefix + msgID, chatKeyPrefix + chatID, userKeyPrefix + userID},
+		action.Name,
+		time.Now().UnixMilli(),
+		s.Window.Milliseconds(),
+		s.PerMessage,
+		s.ChatBudget,
+		s.UserBudget,
+		reserve(s.ChatBudget, action.Priority),
+		reserve(s.UserBudget, action.Priority),
+		msgKeyTTL.Milliseconds(),
+	).Text()
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("budget acquire failed", zap.Error(err))
+		reason = ReasonError
+	}
+	if reason != "" {
+		suppress(ctx, action, chatID, reason)
+		return false
+	}
+	trace.SpanFromContext(ctx).AddEvent("spontaneous action admitted", trace.WithAttributes(attribute.String("action", action.Name)))
+	return true
+}
+
+func suppress(ctx context.Context, action Action, chatID, reason string) {
+	attrs := []attribute.KeyValue{attribute.String("action", action.Name), attribute.String("reason", reason)}
+	trace.SpanFromContext(ctx).AddEvent("spontaneous action suppressed", trace.WithAttributes(attrs...))
+	suppressedCounter().Add(ctx, 1, metric.WithAttributes(attrs...))
+	logs.L().Ctx(ctx).Info("spontaneous action suppressed",
+		zap.String("action", action.Name), zap.String("chat_id", chatID), zap.String("reason", reason))
+}
@@ -0,0 +1,128 @@
+package budget
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLoadSettings(t *testing.T) {
+	s := loadSettings(nil)
+	if s.ChatBudget != 6 || s.UserBudget != 3 || s.PerMessage != 1 || s.Window != time.Minute {
+		t.Fatalf("unexpected defaults: %+v", s)
+	}
+	s = loadSettings(&config.RateConfig{ChatBudget: 10, BudgetWindowSec: 30, SettleMs: 50})
+	if s.ChatBudget != 10 || s.UserBudget != 3 || s.Window != 30*time.Second || s.Settle != 50*time.Millisecond {
+		t.Fatalf("unexpected settings: %+v", s)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	if got := reserve(6, xhandler.PriorityLow); got != 2 {
+		t.Fatalf("low reserve = %v, want 2", got)
+	}
+	for _, p := range []xhandler.Priority{xhandler.PriorityNormal, xhandler.PriorityHigh} {
+		if got := reserve(6, p); got != 0 {
+			t.Fatalf("reserve(%v) = %v, want 0", p, got)
+		}
+	}
+}
+
+// useBudget 使用 miniredis 和给定的预算配置
+func useBudget(t *testing.T, rate string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[rate_config]\n"+rate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config.LoadFile(path)
+	mr := miniredis.RunT(t)
+	prev := redis_dal.RedisClient
+	redis_dal.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redis_dal.RedisClient = prev })
+}
+
+func TestAcquirePerMessage(t *testing.T) {
+	useBudget(t, "per_message_limit = 1\nsettle_ms = 50\n")
+	ctx := context.Background()
+
+	// 同一条消息上同时竞价, 只有优先级最高的行为通过
+	results := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, action := range []Action{Repeat, React, WordReply} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok := Acquire(ctx, action, "chat_a", "ou_a", "om_1")
+			mu.Lock()
+			results[action.Name] = ok
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if !results[WordReply.Name] || results[Repeat.Name] || results[React.Name] {
+		t.Fatalf("Acquire() results = %v, only %s should pass", results, WordReply.Name)
+	}
+	// 竞价结束后到来的行为也不能超过单条消息上限
+	if Acquire(ctx, Imitate, "chat_a", "ou_a", "om_1") {
+		t.Error("Acquire() after the message limit is used should fail")
+	}
+	if !Acquire(ctx, Imitate, "chat_a", "ou_a", "om_2") {
+		t.Error("Acquire() on another message should pass")
+	}
+}
+
+func TestAcquireBuckets(t *testing.T) {
+	useBudget(t, "chat_budget = 3\nuser_budget = 2\nper_message_limit = 5\nsettle_ms = 1\n")
+	ctx := context.Background()
+	msg := 0
+	acquire := func(action Action, chatID, userID string) bool {
+		msg++
+		return Acquire(ctx, action, chatID, userID, "om_"+strconv.Itoa(msg))
+	}
+
+	// 群桶: 不同用户共享群内的 3 个令牌
+	for i, user := range []string{"ou_1", "ou_2", "ou_3"} {
+		if !acquire(WordReply, "chat_a", user) {
+			t.Fatalf("Acquire() #%d in chat_a should pass", i+1)
+		}
+	}
+	if acquire(WordReply, "chat_a", "ou_4") {
+		t.Error("Acquire() should fail once chat_a is out of budget")
+	}
+	if !acquire(WordReply, "chat_b", "ou_4") {
+		t.Error("Acquire() in chat_b should not be limited by chat_a")
+	}
+
+	// 用户桶: 同一用户跨群共享 2 个令牌, ou_4 已用掉一个
+	if !acquire(WordReply, "chat_c", "ou_4") {
+		t.Error("Acquire() for ou_4's second action should pass")
+	}
+	if acquire(WordReply, "chat_d", "ou_4") {
+		t.Error("Acquire() should fail once ou_4 is out of budget in any chat")
+	}
+
+	// 低优先级行为给重要行为保留 chat_budget/3 个令牌
+	for i := range 2 {
+		if !acquire(Repeat, "chat_e", "ou_low"+strconv.Itoa(i)) {
+			t.Fatalf("low priority Acquire() #%d should pass", i+1)
+		}
+	}
+	if acquire(Repeat, "chat_e", "ou_low2") {
+		t.Error("low priority Acquire() should not use the reserved token")
+	}
+	if !acquire(WordReply, "chat_e", "ou_high") {
+		t.Error("high priority Acquire() should use the reserved token")
+	}
+}
@@ -0,0 +1,123 @@
+// Package cardaction 卡片按钮回调的分发: 按钮的 value 中以 "action" 字段标识处理方, 各功能在 Init 时注册
+package cardaction
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ActionKey 按钮 value 中标识处理方的字段
+const ActionKey = "action"
+
+// asyncTimeout Async 中后台处理的超时
+const asyncTimeout = 2 * time.Minute
+
+// Handler 处理一次按钮点击, 返回的卡片会替换原卡片
+type Handler func(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error)
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string]Handler{}
+)
+
+// Register 注册按钮回调
+//
+//	@param action string 按钮 value 中 action 字段的值
+//	@param h Handler
+func Register(action string, h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers[action] = h
+}
+
+// Value 构造按钮的回调 value
+//
+//	@param action string
+//	@param kv ...string 额外的键值对
+//	@return map[string]any
+func Value(action string, kv ...string) map[string]any {
+	v := map[string]any{ActionKey: action}
+	for i := 0; i+1 < len(kv); i += 2 {
+		v[kv[i]] = kv[i+1]
+	}
+	return v
+}
+
+// String 读取回调 value 中的字符串字段
+func String(event *callback.CardActionTriggerEvent, key string) string {
+	if event.Event == nil || event.Event.Action == nil {
+		return ""
+	}
+	s, _ := event.Event.Action.Value[key].(string)
+	return s
+}
+
+// Dispatch 按 action 分发按钮回调, 未注册的 action 忽略
+//
+//	@param ctx context.Context
+//	@param event *callback.CardActionTriggerEvent
+//	@return resp *callback.CardActionTriggerResponse
+//	@return err error
+func Dispatch(ctx context.Context, event *callback.CardActionTriggerEvent) (resp *callback.CardActionTriggerResponse, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	action := String(event, ActionKey)
+	span.SetAttributes(attribute.String("action", action))
+	mu.RLock()
+	h, ok := handlers[action]
+	mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	resp, err = h(ctx, event)
+	if err != nil {
+		return &callback.CardActionTriggerResponse{
+			Toast: &callback.Toast{Type: "error", Content: fmt.Sprintf("操作失败: %v", err)},
+		}, nil
+	}
+	return resp, nil
+}
+
+// Async 在后台执行耗时的回调处理(多次外部请求、上传图片等), 调用方随即返回 toast, 不占用飞书约 3 秒的回调窗口;
+// fn 失败时把原因发到群里
+//
+//	@param ctx context.Context
+//	@param event *callback.CardActionTriggerEvent
+//	@param fn func(ctx context.Context) error
+func Async(ctx context.Context, event *callback.CardActionTriggerEvent, fn func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	xlifecycle.Go(func() {
+		ctx, cancel := context.WithTimeout(ctx, asyncTimeout)
+		defer cancel()
+		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+		defer span.End()
+
+		err := fn(ctx)
+		if err == nil {
+			return
+		}
+		span.RecordError(err)
+		logs.L().Ctx(ctx).Warn("card action failed", zap.String("action", String(event, ActionKey)), zap.Error(err))
+		if event.Event == nil || event.Event.Context == nil {
+			return
+		}
+		content := larkmsg.NewTextMsgBuilder().Text(fmt.Sprintf("操作失败: %v", err)).Build()
+		if _, err := larkmsg.CreateMsgRawContentType(ctx, event.Event.Context.OpenChatID, larkim.MsgTypeText, content, "actionErr"+event.Event.Token); err != nil {
+			logs.L().Ctx(ctx).Warn("reply card action error failed", zap.Error(err))
+		}
+	})
+}
@@ -2,9 +2,11 @@ package larkchunking
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xchunk"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 )
 
@@ -12,7 +14,24 @@ var M *xchunk.Management
 
 func Init() {
 	M = xchunk.NewManagement()
-	ctx, span := otel.T().Start(context.Background(), reflecting.GetCurrentFunc())
-	defer span.End()
-	M.StartBackgroundCleaner(ctx)
+
+	var cancel context.CancelFunc
+	xlifecycle.Register("larkchunking", xlifecycle.PhaseWorker,
+		func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+			defer span.End()
+			M.StartBackgroundCleaner(ctx)
+			return nil
+		},
+		func(ctx context.Context) error {
+			cancel()
+			lost, err := M.Flush(ctx)
+			if err != nil {
+				return fmt.Errorf("flush chunk buffers, lost %d: %w", lost, err)
+			}
+			return nil
+		},
+	)
 }
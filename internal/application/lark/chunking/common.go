@@ -62,7 +62,7 @@ func buildLineCommon(
 			}
 			fallthrough
 		default:
-			content := strings.ReplaceAll(msgItem.Content, "\n", "<换行>")
+			content := strings.ReplaceAll(msgItem.PlainText(), "\n", "<换行>")
 			if strings.TrimSpace(content) != "" {
 				tmpList = append(tmpList, content)
 			}
@@ -19,6 +19,12 @@ import (
 
 type LarkMessageEvent struct {
 	*larkim.P2MessageReceiveV1
+	// ImageText 图片理解结果, 见 vision.Render
+	ImageText string
+	// Transcript 语音转写结果
+	Transcript string
+	// Forwarded 合并转发展开后的聊天记录, 见 larkcontent.ExpandMergeForward
+	Forwarded string
 }
 
 func (m *LarkMessageEvent) GroupID() (res string) {
@@ -69,12 +75,21 @@ func (m *LarkMessageEvent) BuildLine() (line string) {
 			}
 			fallthrough
 		default:
-			content := strings.ReplaceAll(msgItem.Content, "\n", "<换行>")
+			content := strings.ReplaceAll(msgItem.PlainText(), "\n", "<换行>")
 			if strings.TrimSpace(content) != "" {
 				tmpList = append(tmpList, content)
 			}
 		}
 	}
+	if m.Forwarded != "" {
+		tmpList = []string{strings.ReplaceAll(m.Forwarded, "\n", "<换行>")}
+	}
+	if m.Transcript != "" {
+		tmpList = []string{"[语音] " + strings.ReplaceAll(m.Transcript, "\n", "<换行>")}
+	}
+	if m.ImageText != "" {
+		tmpList = append(tmpList, m.ImageText)
+	}
 	userName := ""
 	if *m.Event.Sender.SenderId.OpenId == config.Get().LarkConfig.BotOpenID {
 		userName = "机器人"
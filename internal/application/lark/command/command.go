@@ -1,8 +1,17 @@
 package command
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/handlers"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/timezone"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 )
 
@@ -13,94 +22,293 @@ var LarkRootCommand *xcommand.Command[*larkim.P2MessageReceiveV1]
 
 var newCmd = xcommand.NewCommand[*larkim.P2MessageReceiveV1]
 
+// newTypedCmd 参数由 A 声明的命令, 见 xcommand.ArgSpec
+func newTypedCmd[A any](name string, fn xcommand.TypedCommandFunc[*larkim.P2MessageReceiveV1, A]) *xcommand.Command[*larkim.P2MessageReceiveV1] {
+	return xcommand.NewTypedCommand(name, fn)
+}
+
+// resolveMention 将参数中的 @成员(消息文本中的 @_user_N)解析为 open_id
+func resolveMention(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, raw string) (string, bool) {
+	for _, mention := range data.Event.Message.Mentions {
+		if mention.Key != nil && *mention.Key == raw && mention.Id != nil && mention.Id.OpenId != nil {
+			return *mention.Id.OpenId, true
+		}
+	}
+	return "", false
+}
+
+// timeOptions 时间参数按群的时区(见 /timezone)解析, since @msg 指回复的那条消息的发送时间
+func timeOptions(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData) xtime.Options {
+	return xtime.Options{
+		Loc: timezone.For(ctx, *data.Event.Message.ChatId),
+		Anchor: func(ref string) (time.Time, bool) {
+			parentID := data.Event.Message.ParentId
+			if ref != "@msg" || parentID == nil || *parentID == "" {
+				return time.Time{}, false
+			}
+			resp := larkmsg.GetMsgFullByID(ctx, *parentID)
+			if resp == nil || !resp.Success() || len(resp.Data.Items) == 0 || resp.Data.Items[0].CreateTime == nil {
+				return time.Time{}, false
+			}
+			ms, err := strconv.ParseInt(*resp.Data.Items[0].CreateTime, 10, 64)
+			if err != nil {
+				return time.Time{}, false
+			}
+			return time.UnixMilli(ms), true
+		},
+	}
+}
+
 func init() {
 	LarkRootCommand = xcommand.
 		NewRootCommand(larkCommandNilFunc).
 		AddSubCommand(
-			newCmd("debug", larkCommandNilFunc).
+			newCmd("debug", larkCommandNilFunc).AddDesc("调试工具").
 				AddSubCommand(
-					newCmd("msgid", handlers.DebugGetIDHandler),
+					newCmd("msgid", handlers.DebugGetIDHandler).AddDesc("查看所回复消息的 ID"),
 				).
 				AddSubCommand(
-					newCmd("chatid", handlers.DebugGetGroupIDHandler),
+					newCmd("chatid", handlers.DebugGetGroupIDHandler).AddDesc("查看本群的 ID"),
 				).
 				AddSubCommand(
-					newCmd("panic", handlers.DebugTryPanicHandler),
+					newCmd("panic", handlers.DebugTryPanicHandler).AddDesc("触发一次 panic"),
 				).
 				AddSubCommand(
-					newCmd("trace", handlers.DebugTraceHandler),
+					newCmd("trace", handlers.DebugTraceHandler).AddDesc("查看所回复消息的 Trace"),
 				).
 				AddSubCommand(
-					newCmd("revert", handlers.DebugRevertHandler),
+					newCmd("revert", handlers.DebugRevertHandler).AddDesc("撤回所回复的机器人消息"),
 				).
 				AddSubCommand(
-					newCmd("repeat", handlers.DebugRepeatHandler),
+					newCmd("repeat", handlers.DebugRepeatHandler).AddDesc("复读所回复的消息"),
 				).
 				AddSubCommand(
-					newCmd("image", handlers.DebugImageHandler),
+					newTypedCmd("image", handlers.DebugImageHandler).AddDesc("查看所回复消息中的图片"),
 				).
 				AddSubCommand(
-					newCmd("conver", handlers.DebugConversationHandler),
+					newCmd("conver", handlers.DebugConversationHandler).AddDesc("查看所回复消息的上下文"),
 				),
 		).
 		AddSubCommand(
-			newCmd("word", larkCommandNilFunc).
+			newCmd("word", larkCommandNilFunc).AddAliases("复读").AddDesc("复读词与复读概率").
 				AddSubCommand(
-					newCmd("add", handlers.WordAddHandler).AddArgs("word", "rate"),
+					newTypedCmd("add", handlers.WordAddHandler).AddDesc("设置复读词的概率"),
 				).
 				AddSubCommand(
-					newCmd("get", handlers.WordGetHandler),
+					newCmd("get", handlers.WordGetHandler).AddDesc("查看复读词"),
 				),
 		).
 		AddSubCommand(
-			newCmd("reply", larkCommandNilFunc).
+			newCmd("reply", larkCommandNilFunc).AddAliases("回复").AddDesc("关键词自动回复").
+				AddSubCommand(
+					newTypedCmd("add", handlers.ReplyAddHandler).AddDesc("添加自动回复"),
+				).
+				AddSubCommand(
+					newCmd("get", handlers.ReplyListHandler).AddDesc("查看自动回复"),
+				).
+				AddSubCommand(
+					newCmd("list", handlers.ReplyListHandler).AddDesc("查看自动回复"),
+				).
 				AddSubCommand(
-					newCmd("add", handlers.ReplyAddHandler).AddArgs("word", "reply", "type"),
+					newTypedCmd("del", handlers.ReplyDelHandler).AddDesc("删除自动回复"),
 				).
 				AddSubCommand(
-					newCmd("get", handlers.ReplyGetHandler),
+					newTypedCmd("test", handlers.ReplyTestHandler).AddDesc("测试一条消息会命中哪些自动回复"),
 				),
 		).
 		AddSubCommand(
-			newCmd("image", larkCommandNilFunc).
+			newCmd("image", larkCommandNilFunc).AddAliases("图片").AddDesc("图片素材").
+				AddSubCommand(
+					newTypedCmd("add", handlers.ImageAddHandler).AddDesc("添加图片"),
+				).
+				AddSubCommand(
+					newCmd("get", handlers.ImageGetHandler).AddDesc("查看图片"),
+				).
+				AddSubCommand(newCmd("del", handlers.ImageDelHandler).AddDesc("删除图片")),
+		).
+		AddSubCommand(
+			newTypedCmd("music", handlers.MusicSearchHandler).AddAliases("点歌").AddDesc("搜索音乐, --type=album|artist|playlist 搜索专辑、歌手、歌单; 歌名与子命令同名时用 /music -- 下一首").
+				AddSubCommand(
+					newTypedCmd("search", handlers.MusicSearchHandler).AddDesc("搜索音乐, --type=album|artist|playlist 搜索专辑、歌手、歌单"),
+				).
+				AddSubCommand(
+					newCmd("daily", handlers.MusicDailyHandler).AddAliases("日推").AddDesc("每日推荐"),
+				).
+				AddSubCommand(
+					newCmd("new", handlers.MusicNewHandler).AddAliases("新歌").AddDesc("新歌推荐"),
+				).
+				AddSubCommand(
+					newTypedCmd("play", handlers.MusicPlayHandler).AddAliases("播放").AddDesc("播放搜索到的第一首, 不带歌名时播放队列中的下一首"),
+				).
+				AddSubCommand(
+					newTypedCmd("add", handlers.MusicAddHandler).AddDesc("加入播放队列"),
+				).
+				AddSubCommand(
+					newCmd("queue", handlers.MusicQueueHandler).AddAliases("队列").AddDesc("查看播放队列"),
+				).
+				AddSubCommand(
+					newCmd("next", handlers.MusicNextHandler).AddAliases("下一首").AddDesc("播放队列中的下一首"),
+				).
+				AddSubCommand(
+					newCmd("clear", handlers.MusicClearHandler).AddDesc("清空播放队列"),
+				).
+				AddSubCommand(
+					newCmd("fav", larkCommandNilFunc).AddAliases("歌单").AddDesc("群歌单").
+						AddSubCommand(
+							newCmd("list", handlers.MusicFavListHandler).AddDesc("查看群歌单"),
+						).
+						AddSubCommand(
+							newTypedCmd("add", handlers.MusicFavAddHandler).AddDesc("收藏歌曲, 不带歌名时收藏正在播放的歌"),
+						).
+						AddSubCommand(
+							newTypedCmd("del", handlers.MusicFavDelHandler).AddDesc("按序号移除歌曲"),
+						),
+				).
+				AddSubCommand(
+					newTypedCmd("provider", handlers.MusicProviderHandler).AddAliases("音乐源").AddDesc("查看或切换群使用的音乐源"),
+				),
+		).
+		AddSubCommand(
+			newTypedCmd("lyrics", handlers.LyricsHandler).AddAliases("歌词").AddDesc("查看歌词, 不带歌名时查看正在播放的歌").
+				AddSubCommand(
+					newTypedCmd("guess", handlers.LyricsGuessHandler).AddAliases("猜歌").AddDesc("听歌词猜歌名, 不带关键词时从群歌单出题"),
+				),
+		).
+		AddSubCommand(
+			newTypedCmd("oneword", handlers.OneWordHandler).AddAliases("一言").AddDesc("来一句一言"),
+		).
+		AddSubCommand(
+			newTypedCmd("bb", handlers.ChatHandler("chat")).AddAliases("聊天").AddDesc("和机器人聊天"),
+		).
+		AddSubCommand(
+			newTypedCmd("mute", handlers.MuteHandler).AddAliases("闭嘴").AddDesc("让机器人安静一段时间"),
+		).
+		AddSubCommand(
+			newCmd("kb", larkCommandNilFunc).AddAliases("知识库").AddDesc("群知识库").
 				AddSubCommand(
-					newCmd("add", handlers.ImageAddHandler).AddArgs("url").AddArgs("img_key"),
+					newCmd("list", handlers.KnowledgeListHandler).AddDesc("查看知识库条目"),
 				).
 				AddSubCommand(
-					newCmd("get", handlers.ImageGetHandler),
+					newTypedCmd("search", handlers.KnowledgeSearchHandler).AddAliases("搜索").AddDesc("在知识库中查找, 附带出处"),
 				).
-				AddSubCommand(newCmd("del", handlers.ImageDelHandler)),
+				AddSubCommand(
+					newTypedCmd("forget", handlers.KnowledgeForgetHandler).AddDesc("删除知识库条目"),
+				),
 		).
 		AddSubCommand(
-			newCmd("music", handlers.MusicSearchHandler).AddArgs("type", "keywords"),
+			newCmd("persona", larkCommandNilFunc).AddAliases("人设").AddDesc("说话风格").
+				AddSubCommand(
+					newTypedCmd("show", handlers.PersonaShowHandler).AddDesc("查看学到的说话风格"),
+				).
+				AddSubCommand(
+					newTypedCmd("imitate", handlers.PersonaImitateHandler).AddDesc("模仿成员的说话风格"),
+				),
 		).
 		AddSubCommand(
-			newCmd("oneword", handlers.OneWordHandler).AddArgs("type"),
+			newTypedCmd("remind", handlers.RemindHandler).AddAliases("提醒").AddDesc("设置提醒"),
 		).
 		AddSubCommand(
-			newCmd("bb", handlers.ChatHandler("chat")).AddArgs("r", "c"),
+			newCmd("digest", larkCommandNilFunc).AddAliases("日报").AddDesc("群聊日报与周报").
+				AddSubCommand(
+					newTypedCmd("on", handlers.DigestOnHandler).AddDesc("开启日报, --weekly 开启周报"),
+				).
+				AddSubCommand(
+					newTypedCmd("off", handlers.DigestOffHandler).AddDesc("关闭日报, --weekly 关闭周报"),
+				).
+				AddSubCommand(
+					newTypedCmd("show", handlers.DigestShowHandler).AddDesc("查看某一期日报或周报"),
+				),
 		).
 		AddSubCommand(
-			newCmd("mute", handlers.MuteHandler).AddArgs("t", "cancel"),
+			newCmd("memory", larkCommandNilFunc).AddAliases("记忆").AddDesc("机器人记住的关于你的事").
+				AddSubCommand(
+					newCmd("list", handlers.MemoryListHandler).AddDesc("查看记忆"),
+				).
+				AddSubCommand(
+					newTypedCmd("forget", handlers.MemoryForgetHandler).AddDesc("删除记忆, --all 全部删除"),
+				),
 		).
 		AddSubCommand(
-			newCmd("stock", larkCommandNilFunc).
+			newCmd("stock", larkCommandNilFunc).AddAliases("行情").AddDesc("金价、股票、基金与外汇行情").
 				AddSubCommand(
-					newCmd("gold", handlers.StockHandler("gold")).
-						AddArgs(
-							"r", "h",
+					newTypedCmd("gold", handlers.GoldHandler).AddAliases("金价").AddDesc("金价走势"),
+				).
+				AddSubCommand(
+					newTypedCmd("zh_a", handlers.ZhAStockHandler).AddAliases("A股").AddDesc("沪深A股分时走势"),
+				).
+				AddSubCommand(
+					newTypedCmd("kline", handlers.MarketHandler(aktool.MarketA)).AddAliases("K线").AddDesc("沪深A股日K线, 如 /stock kline 茅台"),
+				).
+				AddSubCommand(
+					newTypedCmd("index", handlers.MarketHandler(aktool.MarketIndex)).AddAliases("指数").AddDesc("主要指数行情与日K线"),
+				).
+				AddSubCommand(
+					newTypedCmd("etf", handlers.MarketHandler(aktool.MarketETF)).AddDesc("场内ETF日K线"),
+				).
+				AddSubCommand(
+					newTypedCmd("fund", handlers.MarketHandler(aktool.MarketFund)).AddAliases("基金").AddDesc("开放式基金净值走势"),
+				).
+				AddSubCommand(
+					newTypedCmd("hk", handlers.MarketHandler(aktool.MarketHK)).AddAliases("港股").AddDesc("港股日K线"),
+				).
+				AddSubCommand(
+					newTypedCmd("us", handlers.MarketHandler(aktool.MarketUS)).AddAliases("美股").AddDesc("美股日K线"),
+				).
+				AddSubCommand(
+					newTypedCmd("fx", handlers.MarketHandler(aktool.MarketFX)).AddAliases("外汇").AddDesc("外汇行情与日K线"),
+				).
+				AddSubCommand(
+					newTypedCmd("search", handlers.StockSearchHandler).AddAliases("搜索").AddDesc("按代码或名称搜索某个市场的品种, 默认 A 股, --market=hk|us|... 指定市场"),
+				).
+				AddSubCommand(
+					newCmd("watch", larkCommandNilFunc).AddAliases("自选").AddDesc("本群或个人(--me)的自选股").
+						AddSubCommand(
+							newTypedCmd("add", handlers.StockWatchAddHandler).AddDesc("添加自选股"),
+						).
+						AddSubCommand(
+							newTypedCmd("del", handlers.StockWatchDelHandler).AddDesc("删除自选股"),
+						).
+						AddSubCommand(
+							newTypedCmd("list", handlers.StockWatchListHandler).AddDesc("自选股行情"),
 						),
 				).
 				AddSubCommand(
-					newCmd("zh_a", handlers.StockHandler("a")).
-						AddArgs(
-							"code", "days",
+					newCmd("alert", larkCommandNilFunc).AddAliases("盯盘").AddDesc("股价提醒, 交易时段内触发时发到本群或私聊").
+						AddSubCommand(
+							newTypedCmd("add", handlers.StockAlertAddHandler).AddDesc("添加提醒, 如 /stock alert add --code=600519 --above=1800"),
+						).
+						AddSubCommand(
+							newTypedCmd("del", handlers.StockAlertDelHandler).AddDesc("删除提醒"),
+						).
+						AddSubCommand(
+							newCmd("list", handlers.StockAlertListHandler).AddDesc("查看本群的提醒"),
 						),
 				),
 		).
 		AddSubCommand(
-			newCmd("talkrate", handlers.TrendHandler).
-				AddArgs("days", "interval"),
-		).AddSubCommand(newCmd("wc", handlers.WordCloudHandler).AddArgs("mtop", "ctop", "interval", "days", "st", "et", "chat_id", "sort"))
+			newTypedCmd("talkrate", handlers.TrendHandler).AddAliases("水群").AddDesc("群聊发言趋势"),
+		).
+		AddSubCommand(
+			newTypedCmd("wc", handlers.WordCloudHandler).AddAliases("词云").AddDesc("群聊词云与话题"),
+		).
+		AddSubCommand(
+			newTypedCmd("timezone", handlers.TimezoneHandler).AddAliases("时区").AddDesc("查看或设置本群的时区, 时间参数按该时区解析"),
+		).
+		AddSubCommand(
+			newCmd("macro", larkCommandNilFunc).AddAliases("宏").AddDesc("本群的命令宏, 发送 /名字 执行").
+				AddSubCommand(
+					newTypedCmd("add", MacroAddHandler).AddDesc(`添加宏, 如 /macro add morning "/stock gold; /oneword"`),
+				).
+				AddSubCommand(
+					newCmd("list", MacroListHandler).AddDesc("查看本群的宏"),
+				).
+				AddSubCommand(
+					newTypedCmd("del", MacroDelHandler).AddDesc("删除宏"),
+				),
+		).
+		AddSubCommand(
+			newCmd("help", HelpHandler).AddAliases("帮助").AddDesc("查看全部命令"),
+		)
+	LarkRootCommand.WithUserResolver(resolveMention).WithTimeResolver(timeOptions)
 	LarkRootCommand.BuildChain()
 }
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"context"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.uber.org/zap"
+)
+
+// HelpHandler /help 列出全部命令; /help <命令> 展示该命令的用法
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func HelpHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if len(args) > 0 {
+		target, ok := LarkRootCommand.Find(args...)
+		if !ok {
+			return LarkRootCommand.Execute(ctx, data, metaData, append(args, "--help"))
+		}
+		return &xcommand.UsageError{Kind: xerror.ErrCheckUsage, Command: target.Path(), Usage: target.FormatUsage(), Args: target.Args}
+	}
+	content, err := sonic.MarshalString(HelpCard(ctx))
+	if err != nil {
+		return err
+	}
+	_, err = larkmsg.ReplyMsgRawContentType(ctx, *data.Event.Message.MessageId, larkim.MsgTypeInteractive, content, "_help", false)
+	return err
+}
+
+// commandDescs command_infos 表中维护的命令说明, 以不带 / 的命令路径(如 "stock gold")为键, 优先于代码中的 Desc
+func commandDescs(ctx context.Context) map[string]string {
+	descs := make(map[string]string)
+	rows, err := query.Q.CommandInfo.WithContext(ctx).Find()
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("load command infos failed", zap.Error(err))
+		return descs
+	}
+	for _, row := range rows {
+		if row.CommandDesc != "" {
+			descs[row.CommandName] = row.CommandDesc
+		}
+	}
+	return descs
+}
+
+// HelpCard 遍历 LarkRootCommand 生成的命令列表卡片(JSON 2.0)
+//
+//	@param ctx context.Context
+//	@return map[string]any
+func HelpCard(ctx context.Context) map[string]any {
+	descs := commandDescs(ctx)
+	rows := make([]any, 0)
+	LarkRootCommand.Walk(func(c *xcommand.Command[*larkim.P2MessageReceiveV1]) {
+		path := c.Path()
+		desc := c.Desc
+		if d, ok := descs[strings.TrimPrefix(path, "/")]; ok {
+			desc = d
+		}
+		rows = append(rows, map[string]any{
+			"command": path,
+			"aliases": strings.Join(c.AliasPaths(), " "),
+			"desc":    desc,
+		})
+	})
+	column := func(name, display string) map[string]any {
+		return map[string]any{"name": name, "display_name": display, "data_type": "text", "width": "auto"}
+	}
+	return map[string]any{
+		"schema": "2.0",
+		"header": map[string]any{
+			"title":    map[string]any{"tag": "plain_text", "content": "命令列表"},
+			"template": "blue",
+		},
+		"body": map[string]any{"elements": []any{
+			map[string]any{"tag": "markdown", "content": "发送 `/help <命令>` 或 `<命令> --help` 查看参数说明"},
+			map[string]any{
+				"tag":       "table",
+				"page_size": 20,
+				"columns":   []any{column("command", "命令"), column("aliases", "别名"), column("desc", "说明")},
+				"rows":      rows,
+			},
+		}},
+	}
+}
@@ -0,0 +1,160 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/macro"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// macroForbidden 不能出现在宏中的命令: 宏管理本身与调试命令
+var macroForbidden = []string{"macro", "debug"}
+
+// IsCommand 文本是否为命令或本群的宏; 拼写相近的命令只在以 / 开头或 @ 机器人时算作命令, 由执行时给出 "did you mean"
+//
+//	@param ctx context.Context
+//	@param event *larkim.P2MessageReceiveV1
+//	@param text string 消息文本, 见 larkmsg.PreGetTextMsg
+//	@return bool
+func IsCommand(ctx context.Context, event *larkim.P2MessageReceiveV1, text string) bool {
+	if LarkRootCommand.IsCommand(ctx, text) {
+		return true
+	}
+	cmds := xcommand.GetCommand(ctx, text)
+	if len(cmds) == 0 {
+		return false
+	}
+	if m, err := macro.Get(ctx, *event.Event.Message.ChatId, cmds[0]); err == nil && m != nil {
+		return true
+	}
+	addressed := strings.HasPrefix(strings.TrimSpace(text), "/") || larkmsg.IsMentioned(event.Event.Message.Mentions)
+	return addressed && LarkRootCommand.IsMisspelled(ctx, text)
+}
+
+// CheckMacroScript 校验宏脚本: 每条都必须是命令或本群已有的宏, 且不能包含宏管理与调试命令
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param script string
+//	@return error
+func CheckMacroScript(ctx context.Context, chatID, script string) error {
+	steps := xcommand.SplitScript(script)
+	if len(steps) == 0 {
+		return errors.New("macro script is empty")
+	}
+	for _, stages := range steps {
+		for _, stage := range stages {
+			cmds := xcommand.GetCommand(ctx, stage)
+			if len(cmds) == 0 {
+				return fmt.Errorf("%q is not a command", stage)
+			}
+			if target, ok := LarkRootCommand.Find(cmds[0]); ok {
+				for _, name := range macroForbidden {
+					if target.Name == name {
+						return fmt.Errorf("/%s is not allowed in a macro", name)
+					}
+				}
+				continue
+			}
+			if m, err := macro.Get(ctx, chatID, cmds[0]); err != nil || m == nil {
+				return fmt.Errorf("/%s is neither a command nor a macro", cmds[0])
+			}
+		}
+	}
+	return nil
+}
+
+// MacroAddArgs /macro add 的参数
+type MacroAddArgs struct {
+	Text string `input:"true" help:"<名字> \"<命令脚本>\"" required:"true"`
+}
+
+// MacroDelArgs /macro del 的参数
+type MacroDelArgs struct {
+	Name string `input:"true" help:"宏的名字" required:"true"`
+}
+
+// MacroAddHandler /macro add <名字> "<命令脚本>", 脚本中 ";" 分隔依次执行的命令, "|" 将结果传给下一条命令
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MacroAddArgs
+//	@return err error
+func MacroAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MacroAddArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	name, script, ok := strings.Cut(args.Text, " ")
+	if !ok {
+		return errors.New(`usage: /macro add <name> "/cmd1; /cmd2 | /cmd3"`)
+	}
+	script = strings.Trim(strings.TrimSpace(script), `"“”`)
+	if _, ok := LarkRootCommand.Find(name); ok {
+		return fmt.Errorf("/%s is already a command", name)
+	}
+	chatID := *data.Event.Message.ChatId
+	if err = CheckMacroScript(ctx, chatID, script); err != nil {
+		return err
+	}
+	if err = macro.Save(ctx, chatID, metaData.UserID, name, script); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx, fmt.Sprintf("已保存宏 **/%s**: `%s`", name, script), *data.Event.Message.MessageId, "_macroAdd", false)
+}
+
+// MacroListHandler /macro list 本群的宏
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MacroListHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	macros, err := macro.List(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+	if len(macros) == 0 {
+		return larkmsg.ReplyCardText(ctx, `本群还没有宏, 使用 /macro add <名字> "<命令>" 添加`, *data.Event.Message.MessageId, "_macroList", false)
+	}
+	lines := make([]string, 0, len(macros))
+	for _, m := range macros {
+		lines = append(lines, fmt.Sprintf("- **/%s**: `%s` <at id=%s></at>", m.Name, m.Script, m.CreatorID))
+	}
+	return larkmsg.ReplyCardText(ctx, strings.Join(lines, "\n"), *data.Event.Message.MessageId, "_macroList", false)
+}
+
+// MacroDelHandler /macro del <名字>, 只有创建者或群主可以删除
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MacroDelArgs
+//	@return err error
+func MacroDelHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MacroDelArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if strings.ContainsAny(args.Name, " \t") {
+		return errors.New("usage: /macro del <name>")
+	}
+	if err = macro.Delete(ctx, *data.Event.Message.ChatId, metaData.UserID, args.Name); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx, fmt.Sprintf("已删除宏 **/%s**", args.Name), *data.Event.Message.MessageId, "_macroDel", false)
+}
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/macro"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+)
+
+func TestCheckMacroScript(t *testing.T) {
+	dbtest.Open(t, &model.ChatMacro{})
+	ctx := context.Background()
+	if err := macro.Save(ctx, "oc_test", "ou_a", "daily", "/stock gold"); err != nil {
+		t.Fatal(err)
+	}
+	for script, ok := range map[string]bool{
+		"/stock gold; /oneword":  true,
+		"/daily | /oneword":      true,
+		"/macro list":            false,
+		"/stock gold; /macro ls": false,
+		"":                       false,
+		"/nope":                  false,
+	} {
+		if err := CheckMacroScript(ctx, "oc_test", script); (err == nil) != ok {
+			t.Errorf("CheckMacroScript(%q) = %v, want ok=%v", script, err, ok)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
+)
+
+// UsageCard 命令用法卡片(JSON 2.0), 列出由参数声明生成的说明; 参数不合法时带上原因
+//
+//	@param e *xcommand.UsageError
+//	@return map[string]any
+func UsageCard(e *xcommand.UsageError) map[string]any {
+	elements := make([]any, 0, 3)
+	if e.Reason != "" {
+		elements = append(elements, map[string]any{
+			"tag":     "markdown",
+			"content": "<font color='red'>" + e.Reason + "</font>",
+		})
+	}
+	usage := e.Command
+	for _, spec := range e.Args {
+		if spec.Required {
+			usage += " " + spec.Flag()
+		} else {
+			usage += " [" + spec.Flag() + "]"
+		}
+	}
+	elements = append(elements, map[string]any{"tag": "markdown", "content": "`" + usage + "`"})
+
+	rows := make([]any, 0, len(e.Args))
+	for _, spec := range e.Args {
+		name := "<text>"
+		if len(spec.Names) > 0 {
+			name = "--" + strings.Join(spec.Names, " --")
+		}
+		rows = append(rows, map[string]any{
+			"name":       name,
+			"type":       string(spec.Type),
+			"default":    spec.Default,
+			"constraint": spec.Constraint(),
+			"help":       spec.Help,
+		})
+	}
+	if len(rows) > 0 {
+		column := func(name, display string) map[string]any {
+			return map[string]any{"name": name, "display_name": display, "data_type": "text", "width": "auto"}
+		}
+		elements = append(elements, map[string]any{
+			"tag":       "table",
+			"page_size": 10,
+			"columns": []any{
+				column("name", "参数"),
+				column("type", "类型"),
+				column("default", "默认"),
+				column("constraint", "约束"),
+				column("help", "说明"),
+			},
+			"rows": rows,
+		})
+	}
+	template := "blue"
+	if e.Reason != "" {
+		template = "red"
+	}
+	return map[string]any{
+		"schema": "2.0",
+		"header": map[string]any{
+			"title":    map[string]any{"tag": "plain_text", "content": e.Command + " 用法"},
+			"template": template,
+		},
+		"body": map[string]any{"elements": elements},
+	}
+}
@@ -0,0 +1,158 @@
+package digest
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+)
+
+// listLimit 话题、问题、结论等列表最多展示的条数
+const listLimit = 8
+
+// aggregate 汇总周期内各 chunk 的总结、话题、未解决的问题与结论
+//
+//	@param d *xmodel.ChatDigest
+//	@param chunks []*xmodel.MessageChunkLogV3 按时间升序
+func aggregate(d *xmodel.ChatDigest, chunks []*xmodel.MessageChunkLogV3) {
+	d.ChunkCount = len(chunks)
+	topics := make(map[string]int)
+	order := make([]string, 0)
+	for _, chunk := range chunks {
+		if s := strings.TrimSpace(chunk.Summary); s != "" {
+			d.Summaries = append(d.Summaries, s)
+		}
+		if chunk.Entities != nil {
+			for _, topic := range chunk.Entities.MainTopicsOrActivities {
+				if topic = strings.TrimSpace(topic); topic == "" {
+					continue
+				}
+				if topics[topic] == 0 {
+					order = append(order, topic)
+				}
+				topics[topic]++
+			}
+		}
+		if chunk.InteractionAnalysis != nil {
+			d.UnresolvedQuestions = appendUnique(d.UnresolvedQuestions, chunk.InteractionAnalysis.UnresolvedQuestions...)
+		}
+		if chunk.Outcomes != nil {
+			d.Conclusions = appendUnique(d.Conclusions, chunk.Outcomes.ConclusionsOrAgreements...)
+			d.UnresolvedQuestions = appendUnique(d.UnresolvedQuestions, chunk.Outcomes.OpenThreadsOrPendingPoints...)
+			for _, plan := range chunk.Outcomes.PlansAndSuggestions {
+				if plan == nil {
+					continue
+				}
+				text := strings.TrimSpace(plan.ActivityOrSuggestion)
+				if text != "" && plan.Timing != nil && plan.Timing.RawText != "" {
+					text += " (" + plan.Timing.RawText + ")"
+				}
+				d.Plans = appendUnique(d.Plans, text)
+			}
+		}
+	}
+	// 按出现次数排序, 次数相同的保持先出现的在前
+	slices.SortStableFunc(order, func(a, b string) int { return cmp.Compare(topics[b], topics[a]) })
+	d.TopTopics = order[:min(len(order), listLimit)]
+	d.UnresolvedQuestions = d.UnresolvedQuestions[:min(len(d.UnresolvedQuestions), listLimit)]
+	d.Conclusions = d.Conclusions[:min(len(d.Conclusions), listLimit)]
+	d.Plans = d.Plans[:min(len(d.Plans), listLimit)]
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" && !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// title 报告标题, 如 "群聊日报 · 10-20"、"群聊周报 · 10-13 ~ 10-19"
+func title(d *xmodel.ChatDigest) string {
+	st, _ := time.Parse(time.RFC3339, d.StartTime)
+	et, _ := time.Parse(time.RFC3339, d.EndTime)
+	st, et = st.In(utils.UTC8Loc()), et.In(utils.UTC8Loc())
+	if Kind(d.Kind) == KindWeekly {
+		return fmt.Sprintf("群聊周报 · %s ~ %s", st.Format("01-02"), et.Add(-time.Nanosecond).Format("01-02"))
+	}
+	return "群聊日报 · " + st.Format("01-02")
+}
+
+// buildInput 交给模型撰写回顾的材料
+func buildInput(d *xmodel.ChatDigest) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\n消息数: %d, 对话片段: %d\n", title(d), d.MsgCount, d.ChunkCount)
+	section := func(name string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "\n%s:\n", name)
+		for _, item := range items {
+			fmt.Fprintf(b, "- %s\n", item)
+		}
+	}
+	members := make([]string, 0, len(d.ActiveMembers))
+	for _, m := range d.ActiveMembers {
+		members = append(members, fmt.Sprintf("%s(%d条)", m.UserName, m.MsgCount))
+	}
+	section("活跃成员", members)
+	section("热门话题", d.TopTopics)
+	section("高频词", d.TopWords)
+	section("各段对话摘要", d.Summaries)
+	section("结论与约定", d.Conclusions)
+	section("计划", d.Plans)
+	section("未解决的问题", d.UnresolvedQuestions)
+	return b.String()
+}
+
+// card 报告卡片(JSON 2.0)
+func card(d *xmodel.ChatDigest) map[string]any {
+	elements := make([]any, 0)
+	markdown := func(content string) {
+		elements = append(elements, map[string]any{"tag": "markdown", "content": content})
+	}
+	list := func(name string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		markdown(fmt.Sprintf("**%s**\n- %s", name, strings.Join(items, "\n- ")))
+	}
+	if d.Recap != "" {
+		markdown(d.Recap)
+		elements = append(elements, map[string]any{"tag": "hr"})
+	}
+	if len(d.ActiveMembers) > 0 {
+		members := make([]string, 0, len(d.ActiveMembers))
+		for _, m := range d.ActiveMembers {
+			members = append(members, fmt.Sprintf("<at id=%s></at> %d", m.UserID, m.MsgCount))
+		}
+		markdown("**活跃成员**: " + strings.Join(members, " · "))
+	}
+	if len(d.TopTopics) > 0 {
+		markdown("**热门话题**: " + strings.Join(d.TopTopics, "、"))
+	}
+	if len(d.TopWords) > 0 {
+		markdown("**高频词**: " + strings.Join(d.TopWords, "、"))
+	}
+	list("结论与约定", d.Conclusions)
+	list("计划", d.Plans)
+	list("待解决的问题", d.UnresolvedQuestions)
+	template := "blue"
+	if Kind(d.Kind) == KindWeekly {
+		template = "indigo"
+	}
+	return map[string]any{
+		"schema": "2.0",
+		"header": map[string]any{
+			"title":    map[string]any{"tag": "plain_text", "content": title(d)},
+			"subtitle": map[string]any{"tag": "plain_text", "content": fmt.Sprintf("消息 %d 条 · 对话 %d 段", d.MsgCount, d.ChunkCount)},
+			"template": template,
+		},
+		"body": map[string]any{"elements": elements},
+	}
+}
@@ -0,0 +1,257 @@
+// Package digest 群聊日报与周报: 汇总周期内 chunk 的总结、话题、未解决的问题, 以及活跃成员和高频词,
+// 由模型撰写回顾后以卡片发到群里, 并归档到 OpenSearch 供错过的成员回看; 各群需通过 /digest on 开启
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/history"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/opensearch"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	"github.com/defensestation/osquery"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	topMembers = 5
+	topWords   = 10
+)
+
+// ErrEmpty 周期内没有聊天
+var ErrEmpty = errors.New("no messages in this period")
+
+const recapSysPrompt = `你是群聊的编辑, 根据给出的统计与各段对话摘要, 为错过聊天的群成员写一段回顾。
+- 用轻松自然的中文, 150 到 300 字, 可以分 2 到 4 个短段落
+- 先概括这段时间大家主要在聊什么, 再点出有结论的事情和还没解决的问题
+- 提到成员时使用材料中的名字, 不要编造材料中没有的内容
+- 直接输出正文, 不要标题, 不要重复列出统计数字`
+
+func digestID(chatID string, kind Kind, st time.Time) string {
+	return fmt.Sprintf("%s-%s-%s", chatID, kind, st.In(utils.UTC8Loc()).Format("20060102"))
+}
+
+func archiveIndex() string {
+	return config.Get().OpensearchConfig.LarkDigestIndex
+}
+
+// Build 生成群在 [st, et) 内的报告, 周期内没有消息时返回 ErrEmpty
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param kind Kind
+//	@param st time.Time
+//	@param et time.Time
+//	@return d *xmodel.ChatDigest
+//	@return err error
+func Build(ctx context.Context, chatID string, kind Kind, st, et time.Time) (d *xmodel.ChatDigest, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("kind", string(kind)))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	d = &xmodel.ChatDigest{
+		ID:         digestID(chatID, kind, st),
+		ChatID:     chatID,
+		Kind:       string(kind),
+		StartTime:  st.In(utils.UTC8Loc()).Format(time.RFC3339),
+		EndTime:    et.In(utils.UTC8Loc()).Format(time.RFC3339),
+		CreateTime: time.Now().In(utils.UTC8Loc()).Format(time.RFC3339),
+	}
+	if err = msgStats(ctx, d); err != nil {
+		return nil, err
+	}
+	if d.MsgCount == 0 {
+		return nil, ErrEmpty
+	}
+	chunks, err := loadChunks(ctx, d, loadSettings().MaxChunks)
+	if err != nil {
+		return nil, err
+	}
+	aggregate(d, chunks)
+	// 回顾写不出来时仍然发送统计部分
+	if d.Recap, err = ark_dal.ResponseWithCache(ctx, recapSysPrompt, buildInput(d), config.Get().ArkConfig.NormalModel); err != nil {
+		logs.L().Ctx(ctx).Warn("write digest recap failed", zap.String("chat_id", chatID), zap.Error(err))
+		err = nil
+	}
+	span.SetAttributes(attribute.Int("msg_count", d.MsgCount), attribute.Int("chunk_count", d.ChunkCount))
+	return d, nil
+}
+
+type statsAgg struct {
+	Total struct {
+		Value int `json:"value"`
+	} `json:"total"`
+	Members struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			DocCount int    `json:"doc_count"`
+			Names    struct {
+				Buckets []struct {
+					Key string `json:"key"`
+				} `json:"buckets"`
+			} `json:"names"`
+		} `json:"buckets"`
+	} `json:"members"`
+	Words history.TopWords `json:"words"`
+}
+
+// msgStats 统计周期内的消息数、活跃成员与高频词, 不含机器人和命令
+func msgStats(ctx context.Context, d *xmodel.ChatDigest) error {
+	req := osquery.Search().
+		Query(osquery.Bool().
+			Must(
+				osquery.Term("chat_id", d.ChatID),
+				osquery.Range("create_time_v2").Gte(d.StartTime).Lt(d.EndTime),
+			).
+			MustNot(
+				osquery.Term("is_command", true),
+				osquery.Term("user_id", config.Get().LarkConfig.BotOpenID),
+			)).
+		Size(0).
+		Aggs(
+			osquery.ValueCount("total", "chat_id"),
+			osquery.TermsAgg("members", "user_id").Size(topMembers).Aggs(
+				osquery.TermsAgg("names", "user_name").Size(1),
+			),
+			history.TopWordsAgg("words", topWords, "n", "nr", "ns", "nt", "nz", "vn", "an", "i", "l"),
+		)
+	resp, err := opensearch.SearchData(ctx, config.Get().OpensearchConfig.LarkMsgIndex, req)
+	if err != nil {
+		return err
+	}
+	agg := &statsAgg{}
+	if err = sonic.Unmarshal(resp.Aggregations, agg); err != nil {
+		return fmt.Errorf("unmarshal digest stats: %w", err)
+	}
+	d.MsgCount = agg.Total.Value
+	for _, b := range agg.Members.Buckets {
+		m := &xmodel.DigestMember{UserID: b.Key, MsgCount: b.DocCount}
+		if len(b.Names.Buckets) > 0 {
+			m.UserName = b.Names.Buckets[0].Key
+		}
+		d.ActiveMembers = append(d.ActiveMembers, m)
+	}
+	d.TopWords = agg.Words.Words()
+	return nil
+}
+
+// loadChunks 周期内的 chunk 总结, 按时间升序
+func loadChunks(ctx context.Context, d *xmodel.ChatDigest, limit int) ([]*xmodel.MessageChunkLogV3, error) {
+	req := osquery.Search().
+		Query(osquery.Bool().Must(
+			osquery.Term("group_id", d.ChatID),
+			osquery.Range("timestamp_v2").Gte(d.StartTime).Lt(d.EndTime),
+		)).
+		SourceExcludes("conversation_embedding", "msg_list").
+		Sort("timestamp_v2", osquery.OrderAsc).
+		Size(uint64(limit))
+	resp, err := opensearch.SearchData(ctx, config.Get().OpensearchConfig.LarkChunkIndex, req)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make([]*xmodel.MessageChunkLogV3, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		chunk := &xmodel.MessageChunkLogV3{}
+		if err := sonic.Unmarshal(hit.Source, chunk); err != nil {
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// Archive 归档报告, 未配置归档索引时跳过
+//
+//	@param ctx context.Context
+//	@param d *xmodel.ChatDigest
+//	@return error
+func Archive(ctx context.Context, d *xmodel.ChatDigest) error {
+	if archiveIndex() == "" {
+		return nil
+	}
+	return opensearch.InsertData(ctx, archiveIndex(), d.ID, d)
+}
+
+// Find 从归档中查找报告, 没有归档时返回 nil
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param kind Kind
+//	@param st time.Time 周期开始时间
+//	@return *xmodel.ChatDigest
+//	@return error
+func Find(ctx context.Context, chatID string, kind Kind, st time.Time) (d *xmodel.ChatDigest, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if archiveIndex() == "" {
+		return nil, nil
+	}
+	// 按天分索引, 同一份报告可能被重新生成过, 取最新的
+	resp, err := opensearch.SearchData(ctx, archiveIndex(), osquery.Search().
+		Query(osquery.IDs(digestID(chatID, kind, st))).
+		Sort("create_time", osquery.OrderDesc).
+		Size(1))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Hits.Hits) == 0 {
+		return nil, nil
+	}
+	d = &xmodel.ChatDigest{}
+	if err = sonic.Unmarshal(resp.Hits.Hits[0].Source, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Card 报告卡片的 JSON
+func Card(d *xmodel.ChatDigest) (string, error) {
+	return sonic.MarshalString(card(d))
+}
+
+// Publish 生成、归档并发送一期报告, 周期内没有消息时不发送
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param kind Kind
+//	@param st time.Time
+//	@param et time.Time
+//	@return err error
+func Publish(ctx context.Context, chatID string, kind Kind, st, et time.Time) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("kind", string(kind)))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	d, err := Build(ctx, chatID, kind, st, et)
+	if errors.Is(err, ErrEmpty) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := Archive(ctx, d); err != nil {
+		logs.L().Ctx(ctx).Warn("archive digest failed", zap.String("id", d.ID), zap.Error(err))
+	}
+	content, err := Card(d)
+	if err != nil {
+		return err
+	}
+	_, err = larkmsg.CreateMsgRawContentType(ctx, chatID, larkim.MsgTypeInteractive, content, "digest_"+d.ID)
+	return err
+}
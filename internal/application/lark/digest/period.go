@@ -0,0 +1,102 @@
+package digest
+
+import (
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+)
+
+// Kind 报告类型
+type Kind string
+
+const (
+	KindDaily  Kind = "daily"
+	KindWeekly Kind = "weekly"
+)
+
+// Kinds 全部报告类型
+var Kinds = []Kind{KindDaily, KindWeekly}
+
+// Label 展示用的报告名称
+func (k Kind) Label() string {
+	if k == KindWeekly {
+		return "周报"
+	}
+	return "日报"
+}
+
+type settings struct {
+	// DailyAt 当天 0 点到日报发送时间的偏移
+	DailyAt time.Duration
+	// WeeklyAt 周一 0 点到周报发送时间的偏移
+	WeeklyAt  time.Duration
+	MaxChunks int
+}
+
+func loadSettings() settings {
+	s := settings{DailyAt: 9 * time.Hour, WeeklyAt: 9*time.Hour + 30*time.Minute, MaxChunks: 60}
+	c := config.Get().DigestConfig
+	if c == nil {
+		return s
+	}
+	if d, ok := parseClock(c.DailyAt); ok {
+		s.DailyAt = d
+	}
+	weeklyDay := 1
+	if c.WeeklyDay >= 1 && c.WeeklyDay <= 7 {
+		weeklyDay = c.WeeklyDay
+	}
+	weeklyAt := 9*time.Hour + 30*time.Minute
+	if d, ok := parseClock(c.WeeklyAt); ok {
+		weeklyAt = d
+	}
+	s.WeeklyAt = time.Duration(weeklyDay-1)*24*time.Hour + weeklyAt
+	if c.MaxChunks > 0 {
+		s.MaxChunks = c.MaxChunks
+	}
+	return s
+}
+
+// parseClock 解析 HH:MM 为距离 0 点的时长
+func parseClock(s string) (time.Duration, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// Bounds 包含 t 的报告周期 [st, et), 日报为自然日, 周报为周一开始的自然周
+//
+//	@param kind Kind
+//	@param t time.Time
+//	@return st time.Time
+//	@return et time.Time
+func Bounds(kind Kind, t time.Time) (st, et time.Time) {
+	t = t.In(utils.UTC8Loc())
+	st = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if kind == KindWeekly {
+		st = st.AddDate(0, 0, -(int(st.Weekday())+6)%7)
+		return st, st.AddDate(0, 0, 7)
+	}
+	return st, st.AddDate(0, 0, 1)
+}
+
+// scheduled now 所在周期应当发送的报告: 覆盖上一个周期, 在本周期开始后的配置时间发送
+//
+//	@param kind Kind
+//	@param now time.Time
+//	@param s settings
+//	@return st time.Time 被总结周期的开始
+//	@return et time.Time 被总结周期的结束
+//	@return sendAt time.Time
+func scheduled(kind Kind, now time.Time, s settings) (st, et, sendAt time.Time) {
+	cur, _ := Bounds(kind, now)
+	st, et = Bounds(kind, cur.Add(-time.Nanosecond))
+	offset := s.DailyAt
+	if kind == KindWeekly {
+		offset = s.WeeklyAt
+	}
+	return st, et, cur.Add(offset)
+}
@@ -0,0 +1,69 @@
+package digest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+)
+
+func TestScheduled(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	s := settings{DailyAt: 9 * time.Hour, WeeklyAt: 9*time.Hour + 30*time.Minute, MaxChunks: 60}
+	// 2026-10-21 是周三
+	now := time.Date(2026, 10, 21, 8, 0, 0, 0, loc)
+	cases := []struct {
+		kind           Kind
+		st, et, sendAt time.Time
+	}{
+		{KindDaily, time.Date(2026, 10, 20, 0, 0, 0, 0, loc), time.Date(2026, 10, 21, 0, 0, 0, 0, loc), time.Date(2026, 10, 21, 9, 0, 0, 0, loc)},
+		{KindWeekly, time.Date(2026, 10, 12, 0, 0, 0, 0, loc), time.Date(2026, 10, 19, 0, 0, 0, 0, loc), time.Date(2026, 10, 19, 9, 30, 0, 0, loc)},
+	}
+	for _, c := range cases {
+		st, et, sendAt := scheduled(c.kind, now, s)
+		if !st.Equal(c.st) || !et.Equal(c.et) || !sendAt.Equal(c.sendAt) {
+			t.Errorf("scheduled(%s) = %v, %v, %v", c.kind, st, et, sendAt)
+		}
+	}
+	// 周日属于上周一开始的自然周
+	st, _ := Bounds(KindWeekly, time.Date(2026, 10, 25, 23, 0, 0, 0, loc))
+	if !st.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, loc)) {
+		t.Errorf("Bounds(sunday) = %v", st)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	chunks := []*xmodel.MessageChunkLogV3{
+		{
+			Summary:             "讨论周末去哪玩",
+			Entities:            &xmodel.Entities{MainTopicsOrActivities: []string{"出游", "天气"}},
+			InteractionAnalysis: &xmodel.InteractionAnalysis{UnresolvedQuestions: []string{"谁开车?"}},
+			Outcomes: &xmodel.Outcome{
+				ConclusionsOrAgreements: []string{"去爬山"},
+				PlansAndSuggestions: []*xmodel.PlansAndSuggestion{
+					{ActivityOrSuggestion: "爬山", Timing: &xmodel.Timing{RawText: "周六下午"}},
+				},
+			},
+		},
+		{
+			Summary:  "继续讨论出游",
+			Entities: &xmodel.Entities{MainTopicsOrActivities: []string{"装备", "出游"}},
+			Outcomes: &xmodel.Outcome{OpenThreadsOrPendingPoints: []string{"谁开车?", "带几瓶水"}},
+		},
+	}
+	d := &xmodel.ChatDigest{}
+	aggregate(d, chunks)
+	if d.ChunkCount != 2 || len(d.Summaries) != 2 {
+		t.Fatalf("aggregate() = %+v", d)
+	}
+	if !slices.Equal(d.TopTopics, []string{"出游", "天气", "装备"}) {
+		t.Errorf("TopTopics = %v", d.TopTopics)
+	}
+	if !slices.Equal(d.UnresolvedQuestions, []string{"谁开车?", "带几瓶水"}) {
+		t.Errorf("UnresolvedQuestions = %v", d.UnresolvedQuestions)
+	}
+	if !slices.Equal(d.Plans, []string{"爬山 (周六下午)"}) {
+		t.Errorf("Plans = %v", d.Plans)
+	}
+}
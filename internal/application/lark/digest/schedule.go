@@ -0,0 +1,161 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// functionPrefix function_enablings 中报告开关的前缀, 如 digest_daily
+	functionPrefix = "digest_"
+	// tickInterval 检查是否到了发送时间的间隔
+	tickInterval = time.Minute
+	// sentTTL 已发送标记的有效期, 覆盖一个周报周期
+	sentTTL = 8 * 24 * time.Hour
+	// retryAfter 发送失败后重试的间隔
+	retryAfter = 10 * time.Minute
+)
+
+// Init 注册定时发送报告的任务
+func Init() {
+	var cancel context.CancelFunc
+	xlifecycle.Register("digest", xlifecycle.PhaseWorker,
+		func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go scheduleLoop(ctx)
+			return nil
+		},
+		func(context.Context) error {
+			cancel()
+			return nil
+		},
+	)
+}
+
+func function(kind Kind) string {
+	return functionPrefix + string(kind)
+}
+
+// Enable 为群开启或关闭报告
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param kind Kind
+//	@param on bool
+//	@return err error
+func Enable(ctx context.Context, chatID string, kind Kind, on bool) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("kind", string(kind)), attribute.Bool("on", on))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	ins := query.Q.FunctionEnabling
+	if on {
+		return ins.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.FunctionEnabling{GuildID: chatID, Function: function(kind)})
+	}
+	_, err = ins.WithContext(ctx).Where(ins.GuildID.Eq(chatID), ins.Function.Eq(function(kind))).Delete()
+	return err
+}
+
+// Enabled 群开启了哪些报告
+func Enabled(ctx context.Context, chatID string) (kinds []Kind, err error) {
+	ins := query.Q.FunctionEnabling
+	rows, err := ins.WithContext(ctx).Where(ins.GuildID.Eq(chatID), ins.Function.In(function(KindDaily), function(KindWeekly))).Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range Kinds {
+		for _, row := range rows {
+			if row.Function == function(kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	return kinds, nil
+}
+
+// enabledChats 开启了 kind 报告的群
+func enabledChats(ctx context.Context, kind Kind) ([]string, error) {
+	ins := query.Q.FunctionEnabling
+	rows, err := ins.WithContext(ctx).Where(ins.Function.Eq(function(kind))).Find()
+	if err != nil {
+		return nil, err
+	}
+	chats := make([]string, 0, len(rows))
+	for _, row := range rows {
+		chats = append(chats, row.GuildID)
+	}
+	return chats, nil
+}
+
+func scheduleLoop(ctx context.Context) {
+	publishDue(ctx)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			publishDue(ctx)
+		}
+	}
+}
+
+// publishDue 到了发送时间后, 为开启了报告的群发送上一个周期的报告
+//
+//	每群每期通过 Redis 标记认领, 多实例或重启后不会重复发送; 发送失败时缩短标记有效期, 稍后重试
+func publishDue(ctx context.Context) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	s := loadSettings()
+	now := time.Now()
+	published := 0
+	for _, kind := range Kinds {
+		st, et, sendAt := scheduled(kind, now, s)
+		if now.Before(sendAt) {
+			continue
+		}
+		chats, err := enabledChats(ctx, kind)
+		if err != nil {
+			span.RecordError(err)
+			logs.L().Ctx(ctx).Warn("load digest chats failed", zap.Error(err))
+			return
+		}
+		for _, chatID := range chats {
+			if ctx.Err() != nil {
+				return
+			}
+			key := "digest:sent:" + digestID(chatID, kind, st)
+			ok, err := redis_dal.GetRedisClient().SetNX(ctx, key, now.Unix(), sentTTL).Result()
+			if err != nil || !ok {
+				continue
+			}
+			if err := Publish(ctx, chatID, kind, st, et); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return
+				}
+				logs.L().Ctx(ctx).Warn("publish digest failed", zap.String("chat_id", chatID), zap.String("kind", string(kind)), zap.Error(err))
+				redis_dal.GetRedisClient().Expire(ctx, key, retryAfter)
+				continue
+			}
+			published++
+		}
+	}
+	span.SetAttributes(attribute.Int("published", published))
+}
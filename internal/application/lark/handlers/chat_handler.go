@@ -9,6 +9,9 @@ import (
 	"strings"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/history"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/knowledge"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/memory"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/persona"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
@@ -43,25 +46,38 @@ const (
 	MODEL_TYPE_NORMAL = "normal"
 )
 
-func ChatHandler(chatType string) func(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
-	return func(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// ChatArgs /bb 的参数
+type ChatArgs struct {
+	Reason    bool   `arg:"r" help:"使用推理模型"`
+	NoContext bool   `arg:"c" help:"不带上下文"`
+	Text      string `input:"true" help:"聊天内容"`
+}
+
+func ChatHandler(chatType string) func(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *ChatArgs) (err error) {
+	return func(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *ChatArgs) (err error) {
 		defer func() { metaData.SkipDone = true }()
 		newChatType := chatType
 		size := new(int)
 		*size = 20
-		argMap, input := parseArgs(args...)
-		if _, ok := argMap["r"]; ok {
+		if args.Reason {
 			newChatType = MODEL_TYPE_REASON
 		}
-		if _, ok := argMap["c"]; ok {
+		if args.NoContext {
 			// no context
 			*size = 0
 		}
-		return ChatHandlerInner(ctx, event, newChatType, size, input)
+		input := args.Text
+		if piped := pipeInputText(metaData); piped != "" {
+			input = strings.TrimSpace(input + "\n\n上一条命令的结果:\n" + piped)
+		}
+		return ChatHandlerInner(ctx, event, newChatType, size, "", input)
 	}
 }
 
-func ChatHandlerInner(ctx context.Context, event *larkim.P2MessageReceiveV1, chatType string, size *int, args ...string) (err error) {
+// ChatHandlerInner 生成并发送聊天回复
+//
+//	@param imitate string 要模仿的成员 open_id, 为空时参考群整体风格
+func ChatHandlerInner(ctx context.Context, event *larkim.P2MessageReceiveV1, chatType string, size *int, imitate string, args ...string) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
 	defer func() { span.RecordError(err) }()
@@ -98,7 +114,7 @@ func ChatHandlerInner(ctx context.Context, event *larkim.P2MessageReceiveV1, cha
 		}
 	}
 	if chatType == MODEL_TYPE_REASON {
-		res, err = GenerateChatSeq(ctx, event, config.Get().ArkConfig.ReasoningModel, size, files, args...)
+		res, err = GenerateChatSeq(ctx, event, config.Get().ArkConfig.ReasoningModel, size, files, imitate, args...)
 		if err != nil {
 			return
 		}
@@ -107,7 +123,7 @@ func ChatHandlerInner(ctx context.Context, event *larkim.P2MessageReceiveV1, cha
 			return
 		}
 	} else {
-		res, err = GenerateChatSeq(ctx, event, config.Get().ArkConfig.NormalModel, size, files, args...)
+		res, err = GenerateChatSeq(ctx, event, config.Get().ArkConfig.NormalModel, size, files, imitate, args...)
 		if err != nil {
 			return err
 		}
@@ -143,7 +159,7 @@ func ChatHandlerInner(ctx context.Context, event *larkim.P2MessageReceiveV1, cha
 	return
 }
 
-func GenerateChatSeq(ctx context.Context, event *larkim.P2MessageReceiveV1, modelID string, size *int, files []string, input ...string) (res iter.Seq[*ark_dal.ModelStreamRespReasoning], err error) {
+func GenerateChatSeq(ctx context.Context, event *larkim.P2MessageReceiveV1, modelID string, size *int, files []string, imitate string, input ...string) (res iter.Seq[*ark_dal.ModelStreamRespReasoning], err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
 	defer func() { span.RecordError(err) }()
@@ -204,6 +220,14 @@ func GenerateChatSeq(ctx context.Context, event *larkim.P2MessageReceiveV1, mode
 		userName, _ := doc.Metadata["user_name"].(string)
 		return fmt.Sprintf("[%s](%s) <%s>: %s", createTime, userID, userName, doc.PageContent)
 	})
+	// 群知识库的段落带上出处, 便于回复时引用
+	passages, err := knowledge.Search(ctx, chatID, larkmsg.PreGetTextMsg(ctx, event), 3)
+	if err != nil {
+		logs.L().Ctx(ctx).Error("knowledge.Search err", zap.Error(err))
+	}
+	for _, p := range passages {
+		fullTpl.Context = append(fullTpl.Context, fmt.Sprintf("[知识库 %s]: %s", p.Citation(), p.Content))
+	}
 	fullTpl.Topics = make([]string, 0)
 	for _, doc := range docs {
 		msgID, ok := doc.Metadata["msg_id"]
@@ -224,6 +248,15 @@ func GenerateChatSeq(ctx context.Context, event *larkim.P2MessageReceiveV1, mode
 		}
 	}
 	fullTpl.Topics = utils.Dedup(fullTpl.Topics)
+	// 旧模板没有引用 Persona 时, 以上下文的形式带上
+	fullTpl.Persona = personaPrompt(ctx, chatID, imitate)
+	if fullTpl.Persona != "" && !strings.Contains(promptTemplateStr, ".Persona") {
+		fullTpl.Context = append(fullTpl.Context, "[风格] "+fullTpl.Persona)
+	}
+	fullTpl.Memories = memory.Recall(ctx, chatID, memoryTargets(event)...)
+	if !strings.Contains(promptTemplateStr, ".Memories") {
+		fullTpl.Context = append(fullTpl.Context, fullTpl.Memories...)
+	}
 	b := &strings.Builder{}
 	err = tp.Execute(b, fullTpl)
 	if err != nil {
@@ -279,3 +312,28 @@ func GenerateChatSeq(ctx context.Context, event *larkim.P2MessageReceiveV1, mode
 		}
 	}, err
 }
+
+// memoryTargets 召回记忆的成员: 发言人与被 @ 的成员
+func memoryTargets(event *larkim.P2MessageReceiveV1) []string {
+	userIDs := []string{*event.Event.Sender.SenderId.OpenId}
+	for _, mention := range event.Event.Message.Mentions {
+		if mention.Id != nil && mention.Id.OpenId != nil && *mention.Id.OpenId != config.Get().LarkConfig.BotOpenID {
+			userIDs = append(userIDs, *mention.Id.OpenId)
+		}
+	}
+	return userIDs
+}
+
+// personaPrompt 说话风格提示, 画像不可用时返回空串
+func personaPrompt(ctx context.Context, chatID, imitate string) string {
+	p, err := persona.Get(ctx, chatID, imitate)
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("get persona failed", zap.Error(err))
+		return ""
+	}
+	desc := p.Describe()
+	if desc == "" || imitate == "" {
+		return desc
+	}
+	return fmt.Sprintf("这次请完全以%s的口吻发言, 必须给出回复, 不要提及你在模仿。%s", p.UserName, desc)
+}
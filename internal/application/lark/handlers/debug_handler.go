@@ -322,7 +322,12 @@ func DebugRepeatHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, me
 	return nil
 }
 
-func DebugImageHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// DebugImageArgs /debug image 的参数
+type DebugImageArgs struct {
+	Prompt string `input:"true" help:"对图片的提问"`
+}
+
+func DebugImageHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *DebugImageArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
@@ -339,11 +344,9 @@ func DebugImageHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, met
 		// url = strings.ReplaceAll(url, "kmhomelab.cn", "kevinmatt.top")
 		urls = append(urls, url)
 	}
-	var inputPrompt string
-	if _, input := parseArgs(args...); input == "" {
+	inputPrompt := args.Prompt
+	if inputPrompt == "" {
 		inputPrompt = "图里都是些什么？"
-	} else {
-		inputPrompt = input
 	}
 
 	dataSeq, err := ark_dal.New[*larkim.P2MessageReceiveV1](
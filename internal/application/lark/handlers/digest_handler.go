@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/digest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// DigestSwitchArgs /digest on 与 /digest off 的参数
+type DigestSwitchArgs struct {
+	Weekly bool `arg:"weekly" help:"周报, 否则为日报"`
+}
+
+// DigestShowArgs /digest show 的参数
+type DigestShowArgs struct {
+	Weekly bool   `arg:"weekly" help:"周报, 否则为日报"`
+	Day    string `input:"true" help:"哪一天或哪一周, 如 2025-05-01、昨天、上周, 默认为最近一期"`
+}
+
+func digestKind(weekly bool) digest.Kind {
+	if weekly {
+		return digest.KindWeekly
+	}
+	return digest.KindDaily
+}
+
+// DigestOnHandler 为本群开启日报, --weekly 开启周报
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *DigestSwitchArgs
+//	@return err error
+func DigestOnHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *DigestSwitchArgs) (err error) {
+	return digestSwitch(ctx, data, true, digestKind(args.Weekly))
+}
+
+// DigestOffHandler 为本群关闭日报, --weekly 关闭周报
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *DigestSwitchArgs
+//	@return err error
+func DigestOffHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *DigestSwitchArgs) (err error) {
+	return digestSwitch(ctx, data, false, digestKind(args.Weekly))
+}
+
+func digestSwitch(ctx context.Context, data *larkim.P2MessageReceiveV1, on bool, kind digest.Kind) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID := *data.Event.Message.ChatId
+	if err = digest.Enable(ctx, chatID, kind, on); err != nil {
+		return err
+	}
+	kinds, err := digest.Enabled(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	labels := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		labels = append(labels, k.Label())
+	}
+	state := "已关闭"
+	if on {
+		state = "已开启"
+	}
+	text := fmt.Sprintf("本群%s%s", kind.Label(), state)
+	if len(labels) > 0 {
+		text += fmt.Sprintf(", 当前开启: %s", strings.Join(labels, "、"))
+	}
+	return larkmsg.ReplyCardText(ctx, text, *data.Event.Message.MessageId, "_digestSwitch", false)
+}
+
+// DigestShowHandler 查看某一天的日报或某一周的周报, 默认为最近一期; 没有归档时现场生成
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *DigestShowArgs
+//	@return err error
+func DigestShowHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *DigestShowArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	kind := digestKind(args.Weekly)
+	now := time.Now().In(utils.UTC8Loc())
+	day := now.AddDate(0, 0, -1)
+	if kind == digest.KindWeekly {
+		day = now.AddDate(0, 0, -7)
+	}
+	if args.Day != "" {
+		r, err := xtime.Parse(args.Day, xtime.Options{Loc: utils.UTC8Loc(), Now: now})
+		if err != nil {
+			return errors.New("usage: /digest show [--weekly] [YYYY-MM-DD|昨天|上周]")
+		}
+		day = r.Start
+	}
+	st, et := digest.Bounds(kind, day)
+	if st.After(now) {
+		return errors.New("这一期还没有开始")
+	}
+	chatID := *data.Event.Message.ChatId
+	d, err := digest.Find(ctx, chatID, kind, st)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		if d, err = digest.Build(ctx, chatID, kind, st, et); err != nil {
+			if errors.Is(err, digest.ErrEmpty) {
+				return larkmsg.ReplyCardText(ctx, "这段时间群里没有聊天", *data.Event.Message.MessageId, "_digestShow", false)
+			}
+			return err
+		}
+		// 进行中的周期还会有新消息, 只归档已结束的
+		if !et.After(now) {
+			if err := digest.Archive(ctx, d); err != nil {
+				span.RecordError(err)
+			}
+		}
+	}
+	metaData.SetResult(d)
+	content, err := digest.Card(d)
+	if err != nil {
+		return err
+	}
+	_, err = larkmsg.ReplyMsgRawContentType(ctx, *data.Event.Message.MessageId, larkim.MsgTypeInteractive, content, "_digestShow", false)
+	return err
+}
@@ -27,33 +27,36 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// ImageAddArgs /image add 的参数, 都不给出时添加话题或引用消息中的图片
+type ImageAddArgs struct {
+	URL    string `arg:"url" help:"图片链接"`
+	ImgKey string `arg:"img_key" help:"飞书图片的 image_key"`
+}
+
 // ImageAddHandler to be filled
 //
 //	@param ctx context.Context
 //	@param data *larkim.P2MessageReceiveV1
-//	@param args ...string
+//	@param args *ImageAddArgs
 //	@return error
 //	@author heyuhengmatt
 //	@update 2024-08-06 08:27:13
-func ImageAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+func ImageAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *ImageAddArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	argMap, _ := parseArgs(args...)
-	logs.L().Ctx(ctx).Info("wordAddHandler", zap.String("TraceID", span.SpanContext().TraceID().String()), zap.Any("args", argMap))
-	if len(argMap) > 0 {
+	logs.L().Ctx(ctx).Info("wordAddHandler", zap.String("TraceID", span.SpanContext().TraceID().String()), zap.Any("args", args))
+	if args.URL != "" || args.ImgKey != "" {
 		var imgKey string
 		// by url
-		picURL, ok := argMap["url"]
-		if ok {
-			imgKey = larkimg.UploadPicture2Lark(ctx, picURL)
+		if args.URL != "" {
+			imgKey = larkimg.UploadPicture2Lark(ctx, args.URL)
 		}
 		// by img_key
-		inputImgKey, ok := argMap["img_key"]
-		if ok {
-			imgKey = inputImgKey
+		if args.ImgKey != "" {
+			imgKey = args.ImgKey
 		}
 		err := createImage(ctx, *data.Event.Message.MessageId, *data.Event.Message.ChatId, imgKey, larkim.MsgTypeImage)
 		if err != nil {
@@ -121,8 +124,7 @@ func ImageGetHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaD
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
-	argMap, _ := parseArgs(args...)
-	logs.L().Ctx(ctx).Info("replyGetHandler", zap.String("TraceID", span.SpanContext().TraceID().String()), zap.Any("args", argMap))
+	logs.L().Ctx(ctx).Info("replyGetHandler", zap.String("TraceID", span.SpanContext().TraceID().String()), zap.Strings("args", args))
 	ChatID := *data.Event.Message.ChatId
 
 	lines := make([]map[string]string, 0)
@@ -164,8 +166,7 @@ func ImageDelHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaD
 	defer func() { span.RecordError(err) }()
 	defer span.RecordError(err)
 
-	argMap, _ := parseArgs(args...)
-	logs.L().Ctx(ctx).Info("replyDelHandler", zap.String("TraceID", span.SpanContext().TraceID().String()), zap.Any("args", argMap))
+	logs.L().Ctx(ctx).Info("replyDelHandler", zap.String("TraceID", span.SpanContext().TraceID().String()), zap.Strings("args", args))
 
 	if data.Event.Message.ThreadId != nil {
 		// 找到话题中的所有图片
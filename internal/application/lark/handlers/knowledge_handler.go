@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/knowledge"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// KnowledgeListHandler 列出群知识库收录的链接和文件
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func KnowledgeListHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	sources, err := knowledge.List(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+	res := "知识库还是空的, 在群里分享链接或文件后会自动收录"
+	if len(sources) > 0 {
+		sb := strings.Builder{}
+		sb.WriteString("**知识库收录**\n")
+		for idx, src := range sources {
+			fmt.Fprintf(&sb, "%d. %s `%d段` %s %s\n", idx+1, src.Title, src.Passages, src.UserName, src.CreateTime)
+			if src.Source != src.Title {
+				fmt.Fprintf(&sb, "   %s\n", src.Source)
+			}
+		}
+		sb.WriteString("\n使用 `/kb forget <序号|链接|文件名>` 移除")
+		res = sb.String()
+	}
+	return larkmsg.ReplyCardText(ctx, res, *data.Event.Message.MessageId, "_kb", false)
+}
+
+// KnowledgeSearchArgs /kb search 的参数
+type KnowledgeSearchArgs struct {
+	Query string `input:"true" help:"要查找的内容" required:"true"`
+}
+
+// knowledgeSearchSize /kb search 返回的段落数
+const knowledgeSearchSize = 5
+
+// KnowledgeSearchHandler 在群知识库中检索, 回复命中的段落及其出处
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *KnowledgeSearchArgs
+//	@return err error
+func KnowledgeSearchHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *KnowledgeSearchArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	passages, err := knowledge.Search(ctx, *data.Event.Message.ChatId, args.Query, knowledgeSearchSize)
+	if err != nil {
+		return err
+	}
+	res := "知识库中没有找到相关内容"
+	if len(passages) > 0 {
+		sb := strings.Builder{}
+		for idx, p := range passages {
+			fmt.Fprintf(&sb, "%d. %s\n   —— %s\n", idx+1, p.Content, p.Citation())
+		}
+		res = sb.String()
+	}
+	return larkmsg.ReplyCardText(ctx, res, *data.Event.Message.MessageId, "_kb", false)
+}
+
+// KnowledgeForgetArgs /kb forget 的参数
+type KnowledgeForgetArgs struct {
+	Source string `input:"true" help:"序号(见 /kb list)、链接或文件名" required:"true"`
+}
+
+// KnowledgeForgetHandler 从群知识库移除一个来源
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *KnowledgeForgetArgs
+//	@return err error
+func KnowledgeForgetHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *KnowledgeForgetArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	input := args.Source
+	chatID := *data.Event.Message.ChatId
+	srcID := knowledge.SourceIDOf(chatID, input)
+	if idx, err := strconv.Atoi(input); err == nil {
+		sources, err := knowledge.List(ctx, chatID)
+		if err != nil {
+			return err
+		}
+		if idx < 1 || idx > len(sources) {
+			return fmt.Errorf("序号 %d 不存在", idx)
+		}
+		srcID = sources[idx-1].SourceID
+	}
+	deleted, err := knowledge.Forget(ctx, chatID, srcID)
+	if err != nil {
+		return err
+	}
+	res := "没有找到对应的收录"
+	if deleted > 0 {
+		res = fmt.Sprintf("已移除 %d 个段落", deleted)
+	}
+	return larkmsg.ReplyCardText(ctx, res, *data.Event.Message.MessageId, "_kb", false)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/stock"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larkcard"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larktpl"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/vadvisor"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// spotTableLimit 行情列表、搜索结果最多展示的行数
+const spotTableLimit = 20
+
+// MarketArgs /stock index|etf|fund|hk|us|fx|kline 的参数
+type MarketArgs struct {
+	Code    string             `arg:"code" help:"代码, 如 000300、00700、AAPL、USDCNH, 也可以直接跟名称"`
+	Days    int                `arg:"days" help:"最近 N 天的日线" default:"60" min:"1"`
+	Range   xcommand.TimeRange `arg:"range,st,et"`
+	Keyword string             `input:"true" help:"代码或名称, 如 /stock hk 腾讯"`
+}
+
+// StockSearchArgs /stock search 的参数
+type StockSearchArgs struct {
+	Market  string `arg:"market" help:"搜索的市场" enum:"a|index|etf|fund|hk|us|fx" default:"a"`
+	Keyword string `input:"true" help:"代码或名称关键词" required:"true"`
+}
+
+// MarketHandler 生成某个市场的行情命令: 带代码或名称时画日K线(基金为净值走势), 指数与外汇不带参数时列出行情
+//
+//	@param market aktool.Market
+//	@return xcommand.TypedCommandFunc[*larkim.P2MessageReceiveV1, MarketArgs]
+func MarketHandler(market aktool.Market) xcommand.TypedCommandFunc[*larkim.P2MessageReceiveV1, MarketArgs] {
+	return func(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MarketArgs) error {
+		return marketHandle(ctx, data, metaData, market, args)
+	}
+}
+
+func marketHandle(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, market aktool.Market, args *MarketArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("market", string(market)))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	keyword := args.Code
+	if keyword == "" {
+		keyword = args.Keyword
+	}
+	if keyword == "" {
+		if market != aktool.MarketIndex && market != aktool.MarketFX {
+			return fmt.Errorf("需要代码或名称, 如 /stock %s --code=xxx", market)
+		}
+		spots, err := aktool.GetSpots(ctx, market)
+		if err != nil {
+			return err
+		}
+		return replySpotTable(ctx, data, spots[:min(spotTableLimit, len(spots))], "_market")
+	}
+	found, err := stock.Search(ctx, market, keyword, 1)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("%s中没有找到 %s", market.Label(), keyword)
+	}
+	spot := found[0]
+
+	st, et := GetBackDays(args.Days)
+	if !metaData.TimeRange.IsZero() {
+		st, et = metaData.TimeRange.Start, metaData.TimeRange.End
+	}
+	candles, err := aktool.GetCandles(ctx, market, spot.Code, st, et)
+	if err != nil {
+		return err
+	}
+	if len(candles) == 0 {
+		return errors.New("这段时间内没有行情数据")
+	}
+	var graph any
+	if market == aktool.MarketFund {
+		line := vadvisor.NewMultiSeriesLineGraph[string, float64](ctx)
+		for _, c := range candles {
+			line.AddData(c.Date, c.Close, "单位净值")
+			line.UpdateMinMax(c.Close)
+		}
+		graph = line
+	} else {
+		kline := vadvisor.NewCandlestickGraph[string](ctx)
+		for _, c := range candles {
+			kline.AddCandle(c.Date, c.Open, c.Close, c.High, c.Low, c.Volume)
+		}
+		graph = kline
+	}
+	last := candles[len(candles)-1]
+	content := fmt.Sprintf("%s 收盘 %s", last.Date, formatPrice(last.Close))
+	if spot.Price != 0 {
+		content = fmt.Sprintf("最新价 %s, 涨跌幅 %+.2f%%", formatPrice(spot.Price), spot.ChangePct)
+	}
+	cardContent := larkcard.NewCardBuildGraphHelper(graph).
+		SetTitle(fmt.Sprintf("%s-[%s]%s-%s", market.Label(), spot.Code, spot.Name, dateRangeLabel(st, et))).
+		SetContent(content).
+		SetStartTime(st).
+		SetEndTime(et).
+		Build(ctx)
+	if metaData != nil && metaData.Refresh {
+		return larkmsg.PatchCard(ctx, cardContent, *data.Event.Message.MessageId)
+	}
+	return larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "", false)
+}
+
+// StockSearchHandler 按代码或名称搜索某个市场的品种, 默认搜索 A 股
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *StockSearchArgs
+//	@return err error
+func StockSearchHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *StockSearchArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	market, err := aktool.ParseMarket(args.Market)
+	if err != nil {
+		return err
+	}
+	spots, err := stock.Search(ctx, market, args.Keyword, spotTableLimit)
+	if err != nil {
+		return err
+	}
+	if len(spots) == 0 {
+		return larkmsg.ReplyCardText(ctx, "没有找到 "+args.Keyword, *data.Event.Message.MessageId, "_stockSearch", false)
+	}
+	return replySpotTable(ctx, data, spots, "_stockSearch")
+}
+
+// dateRangeLabel 标题中的时间范围, 以起止日期表示; et 不含在内
+func dateRangeLabel(st, et time.Time) string {
+	return st.Format(time.DateOnly) + "~" + et.Add(-time.Nanosecond).Format(time.DateOnly)
+}
+
+// formatPrice 按接口返回的精度展示价格, 外汇报价有 4 位小数
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+// replySpotTable 以表格回复品种及最新价, 基金没有价格
+func replySpotTable(ctx context.Context, data *larkim.P2MessageReceiveV1, spots []*aktool.Spot, suffix string) error {
+	lines := make([]map[string]string, 0, len(spots))
+	for _, spot := range spots {
+		price := "-"
+		if spot.Price != 0 {
+			price = fmt.Sprintf("%s (%+.2f%%)", formatPrice(spot.Price), spot.ChangePct)
+		}
+		lines = append(lines, map[string]string{
+			"title1": spot.Market.Label(),
+			"title2": spot.Code,
+			"title3": spot.Name,
+			"title4": price,
+		})
+	}
+	cardContent := larktpl.NewCardContent(
+		ctx,
+		larktpl.FourColSheetTemplate,
+	).
+		AddVariable("title1", "市场").
+		AddVariable("title2", "代码").
+		AddVariable("title3", "名称").
+		AddVariable("title4", "最新价").
+		AddVariable("table_raw_array_1", lines)
+
+	return larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, suffix, false)
+}
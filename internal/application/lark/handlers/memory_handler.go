@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/memory"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larktpl"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// MemoryListHandler 列出机器人在本群记住的关于自己的事
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MemoryListHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	rows, err := memory.List(ctx, *data.Event.Message.ChatId, *data.Event.Sender.SenderId.OpenId)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return larkmsg.ReplyCardText(ctx, "还没有记住关于你的事", *data.Event.Message.MessageId, "_memoryList", false)
+	}
+	lines := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, map[string]string{
+			"title1": strconv.FormatInt(row.ID, 10),
+			"title2": memory.Category(row.Category).Label(),
+			"title3": row.Fact,
+			"title4": fmt.Sprintf("%s ×%d", row.LastSeenAt.Format(time.DateOnly), row.SeenCount),
+		})
+	}
+	cardContent := larktpl.NewCardContent(
+		ctx,
+		larktpl.FourColSheetTemplate,
+	).
+		AddVariable("title1", "ID").
+		AddVariable("title2", "Category").
+		AddVariable("title3", "Fact").
+		AddVariable("title4", "Last Seen").
+		AddVariable("table_raw_array_1", lines)
+
+	return larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_memoryList", false)
+}
+
+// MemoryForgetArgs /memory forget 的参数
+type MemoryForgetArgs struct {
+	All bool   `arg:"all" help:"删除全部记忆"`
+	ID  string `input:"true" help:"记忆 ID, 见 /memory list"`
+}
+
+// MemoryForgetHandler 删除关于自己的记忆, 删除后同样的内容不会再被记住
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MemoryForgetArgs
+//	@return err error
+func MemoryForgetHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MemoryForgetArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID, userID := *data.Event.Message.ChatId, *data.Event.Sender.SenderId.OpenId
+	if args.All {
+		n, err := memory.ForgetAll(ctx, chatID, userID)
+		if err != nil {
+			return err
+		}
+		return larkmsg.ReplyCardText(ctx, fmt.Sprintf("已忘记关于你的 %d 条记忆", n), *data.Event.Message.MessageId, "_memoryForget", false)
+	}
+	id, err := strconv.ParseInt(args.ID, 10, 64)
+	if err != nil {
+		return errors.New("usage: /memory forget <id> | --all, see /memory list")
+	}
+	if err = memory.Forget(ctx, chatID, userID, id); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx, fmt.Sprintf("记忆 %d 已忘记", id), *data.Event.Message.MessageId, "_memoryForget", false)
+}
@@ -3,76 +3,545 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/music"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larktpl"
-	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/neteaseapi"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 	"go.opentelemetry.io/otel/attribute"
 )
 
-func MusicSearchHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// pipedMusicList 管道中上一条命令给出的歌曲列表
+func pipedMusicList(metaData *xhandler.BaseMetaData) ([]*musicapi.Song, bool) {
+	if metaData == nil {
+		return nil, false
+	}
+	v, _ := metaData.PipeInput()
+	list, ok := v.([]*musicapi.Song)
+	return list, ok && len(list) > 0
+}
+
+// musicCardInThread 音乐卡片默认是否在话题中回复
+func musicCardInThread() bool {
+	conf := config.Get().NeteaseMusicConfig
+	return conf != nil && conf.MusicCardInThread
+}
+
+// musicSearchLimit 搜索卡片中的结果数
+const musicSearchLimit = 10
+
+// MusicSearchArgs /music search 的参数
+type MusicSearchArgs struct {
+	Type     string `arg:"type" help:"搜索类型" enum:"song|album|artist|playlist" default:"song"`
+	Pick     int    `arg:"pick" help:"只发送第 N 个结果, 可以接在管道中上一条 /music 之后" min:"1"`
+	Keywords string `input:"true" help:"关键词"`
+}
+
+// MusicTrackArgs /music play、/music add 与 /music fav add 的参数
+type MusicTrackArgs struct {
+	Name string `input:"true" help:"歌名"`
+}
+
+// MusicProviderArgs /music provider 的参数
+type MusicProviderArgs struct {
+	Name string `input:"true" help:"切换到该音乐源, 不填时查看当前音乐源"`
+}
+
+// MusicFavDelArgs /music fav del 的参数
+type MusicFavDelArgs struct {
+	Index string `input:"true" help:"序号, 见 /music fav list" required:"true"`
+}
+
+// LyricsArgs /lyrics 的参数
+type LyricsArgs struct {
+	Page int    `arg:"page" help:"歌词页码" default:"1" min:"1"`
+	Song string `input:"true" help:"歌名, 不填时取正在播放的歌"`
+}
+
+// LyricsGuessArgs /lyrics guess 的参数
+type LyricsGuessArgs struct {
+	Keyword string `input:"true" help:"出题范围的关键词, 不填时从群歌单出题"`
+}
+
+// MusicSearchHandler 在群的音乐源中搜索音乐, --pick=N 只发送第 N 个结果
+func MusicSearchHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MusicSearchArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	argsMap, input := parseArgs(args...)
-	searchType, ok := argsMap["type"]
+	input := args.Keywords
+	p, err := music.ProviderFor(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+
+	var cardContent *larktpl.TemplateCardContent
+	switch musicapi.Kind(args.Type) {
+	case musicapi.KindAlbum:
+		albums, err := p.SearchAlbums(ctx, input, musicSearchLimit)
+		if err != nil {
+			return err
+		}
+		cardContent = music.AlbumListCard(ctx, p, albums, input)
+	case musicapi.KindArtist:
+		artists, err := p.SearchArtists(ctx, input, musicSearchLimit)
+		if err != nil {
+			return err
+		}
+		if len(artists) == 0 {
+			return fmt.Errorf("no artist found for %q", input)
+		}
+		cardContent = music.ArtistListCard(ctx, p, artists, input)
+	case musicapi.KindPlaylist:
+		pp, ok := p.(musicapi.PlaylistProvider)
+		if !ok {
+			return fmt.Errorf("%s: %w", p.Name(), musicapi.ErrNotSupported)
+		}
+		playlists, err := pp.SearchPlaylists(ctx, input, musicSearchLimit)
+		if err != nil {
+			return err
+		}
+		if len(playlists) == 0 {
+			return fmt.Errorf("no playlist found for %q", input)
+		}
+		cardContent = music.PlaylistListCard(ctx, p, playlists, input)
+	case musicapi.KindSong:
+		// 管道中上一条 /music 的结果可以直接 --pick, 如 /music 晴天 | /music --pick=1
+		musicList, ok := pipedMusicList(metaData)
+		if !ok || input != "" {
+			if musicList, err = p.SearchSongs(ctx, input, musicSearchLimit); err != nil {
+				return err
+			}
+		}
+		if args.Pick > 0 {
+			if args.Pick > len(musicList) {
+				return fmt.Errorf("--pick must be between 1 and %d", len(musicList))
+			}
+			musicList = musicList[args.Pick-1 : args.Pick]
+		}
+		metaData.SetResult(musicList)
+		cardContent = music.SongListCard(ctx, p, musicList, input)
+	default:
+		return errors.New("Unknown search type")
+	}
+
+	err = larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_musicSearch", utils.GetIfInthread(ctx, metaData, musicCardInThread()))
+	if err != nil {
+		return err
+	}
+	return
+}
+
+// recommendLimit 日推与新歌卡片中的歌曲数
+const recommendLimit = 10
+
+// recommendProvider 群的音乐源, 不支持推荐时返回 musicapi.ErrNotSupported
+func recommendProvider(ctx context.Context, chatID string) (musicapi.Provider, musicapi.RecommendProvider, error) {
+	p, err := music.ProviderFor(ctx, chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rp, ok := p.(musicapi.RecommendProvider)
 	if !ok {
-		// 兼容简易搜索
-		searchType = "song"
+		return nil, nil, fmt.Errorf("%s: %w", p.Name(), musicapi.ErrNotSupported)
+	}
+	return p, rp, nil
+}
+
+// MusicDailyHandler /music daily 当前登录账号的每日推荐, 结果可以通过管道传给 /music add
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MusicDailyHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	p, rp, err := recommendProvider(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+	musicList, err := rp.DailySongs(ctx, recommendLimit)
+	if err != nil {
+		return err
 	}
+	return replyMusicList(ctx, data, metaData, p, musicList, "每日推荐", "_musicDaily")
+}
 
-	keywords := []string{input}
+// MusicNewHandler /music new 新歌推荐, 结果可以通过管道传给 /music add
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MusicNewHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
 
-	var cardContent *larktpl.TemplateCardContent
-	if searchType == "album" {
-		albumList, err := neteaseapi.NetEaseGCtx.SearchAlbumByKeyWord(ctx, keywords...)
+	p, rp, err := recommendProvider(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+	musicList, err := rp.NewSongs(ctx, recommendLimit)
+	if err != nil {
+		return err
+	}
+	return replyMusicList(ctx, data, metaData, p, musicList, "新歌速递", "_musicNew")
+}
+
+// replyMusicList 以搜索卡片的样式回复歌曲列表, 并作为命令结果供管道使用
+func replyMusicList(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, p musicapi.Provider, musicList []*musicapi.Song, title, suffix string) error {
+	if len(musicList) == 0 {
+		return errors.New("no songs found")
+	}
+	metaData.SetResult(musicList)
+	return larkmsg.ReplyCard(ctx, music.SongListCard(ctx, p, musicList, title), *data.Event.Message.MessageId, suffix, utils.GetIfInthread(ctx, metaData, musicCardInThread()))
+}
+
+// MusicProviderHandler /music provider [名称] 查看或切换群使用的音乐源
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MusicProviderArgs
+//	@return err error
+func MusicProviderHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MusicProviderArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID := *data.Event.Message.ChatId
+	if args.Name != "" {
+		if err = music.SetProvider(ctx, chatID, args.Name); err != nil {
+			return err
+		}
+		return larkmsg.ReplyCardText(ctx, "已切换音乐源: "+args.Name, *data.Event.Message.MessageId, "_musicProvider", false)
+	}
+	p, err := music.ProviderFor(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	text := fmt.Sprintf("当前音乐源: %s\n可用: %s", p.Name(), strings.Join(musicapi.Names(), ", "))
+	return larkmsg.ReplyCardText(ctx, text, *data.Event.Message.MessageId, "_musicProvider", false)
+}
+
+// musicInput 点歌的目标: 有输入时取搜索的第一首, 否则取管道中上一条 /music 的结果
+func musicInput(ctx context.Context, chatID string, metaData *xhandler.BaseMetaData, input string) ([]*music.Track, error) {
+	if input != "" {
+		tracks, err := music.Search(ctx, chatID, input)
+		if err != nil {
+			return nil, err
+		}
+		return tracks[:1], nil
+	}
+	if list, ok := pipedMusicList(metaData); ok {
+		p, err := music.ProviderFor(ctx, chatID)
+		if err != nil {
+			return nil, err
+		}
+		tracks := make([]*music.Track, 0, len(list))
+		for _, s := range list {
+			tracks = append(tracks, music.FromSong(p.Name(), s))
+		}
+		return tracks, nil
+	}
+	return nil, nil
+}
+
+func replyMusicCard(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, card map[string]any, suffix string) error {
+	content, err := sonic.MarshalString(card)
+	if err != nil {
+		return err
+	}
+	_, err = larkmsg.ReplyMsgRawContentType(ctx, *data.Event.Message.MessageId, larkim.MsgTypeInteractive, content, suffix,
+		utils.GetIfInthread(ctx, metaData, musicCardInThread()))
+	return err
+}
+
+// MusicPlayHandler /music play [歌名] 播放搜索到的第一首, 不带歌名时播放队列中的下一首
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MusicTrackArgs
+//	@return err error
+func MusicPlayHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MusicTrackArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	tracks, err := musicInput(ctx, *data.Event.Message.ChatId, metaData, args.Name)
+	if err != nil {
+		return err
+	}
+	if len(tracks) == 0 {
+		return MusicNextHandler(ctx, data, metaData)
+	}
+	t := tracks[0]
+	t.AddedBy = metaData.UserID
+	card, err := music.Play(ctx, *data.Event.Message.ChatId, t)
+	if err != nil {
+		return err
+	}
+	return replyMusicCard(ctx, data, metaData, card, "_musicPlay")
+}
+
+// MusicAddHandler /music add <歌名> 将搜索到的第一首加入队列; 管道中的搜索结果全部加入, 如 /music 周杰伦 | /music add
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MusicTrackArgs
+//	@return err error
+func MusicAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MusicTrackArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	tracks, err := musicInput(ctx, *data.Event.Message.ChatId, metaData, args.Name)
+	if err != nil {
+		return err
+	}
+	if len(tracks) == 0 {
+		return errors.New("usage: /music add <song name>")
+	}
+	names := make([]string, 0, len(tracks))
+	for _, t := range tracks {
+		t.AddedBy = metaData.UserID
+		names = append(names, t.Name)
+	}
+	chatID := *data.Event.Message.ChatId
+	added := music.Enqueue(chatID, tracks...)
+	_, upcoming := music.Queue(chatID)
+	metaData.SetResult(upcoming)
+	text := fmt.Sprintf("已加入队列 %d 首: %s, 队列中共 %d 首", added, strings.Join(names, "、"), len(upcoming))
+	if added == 0 {
+		text = fmt.Sprintf("没有加入新的歌曲, 已在队列中或队列已满 (最多 %d 首)", music.MaxQueueLen)
+	}
+	return larkmsg.ReplyCardText(ctx, text, *data.Event.Message.MessageId, "_musicAdd", false)
+}
+
+// MusicQueueHandler /music queue 查看播放队列
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MusicQueueHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	return replyMusicCard(ctx, data, metaData, music.QueueCard(*data.Event.Message.ChatId), "_musicQueue")
+}
+
+// MusicNextHandler /music next 播放队列中的下一首
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MusicNextHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID := *data.Event.Message.ChatId
+	t, ok := music.Next(chatID)
+	if !ok {
+		return larkmsg.ReplyCardText(ctx, "队列是空的, 使用 /music add <歌名> 点歌", *data.Event.Message.MessageId, "_musicNext", false)
+	}
+	card, err := music.Play(ctx, chatID, t)
+	if err != nil {
+		return err
+	}
+	return replyMusicCard(ctx, data, metaData, card, "_musicNext")
+}
+
+// MusicClearHandler /music clear 清空播放队列
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MusicClearHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	n := music.Clear(*data.Event.Message.ChatId)
+	return larkmsg.ReplyCardText(ctx, fmt.Sprintf("已清空队列中的 %d 首", n), *data.Event.Message.MessageId, "_musicClear", false)
+}
+
+// MusicFavListHandler /music fav list 查看群歌单
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func MusicFavListHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	card, err := music.FavoritesCard(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+	return replyMusicCard(ctx, data, metaData, card, "_musicFav")
+}
+
+// MusicFavAddHandler /music fav add [歌名] 收藏搜索到的第一首, 不带歌名时收藏正在播放的歌
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MusicTrackArgs
+//	@return err error
+func MusicFavAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MusicTrackArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID := *data.Event.Message.ChatId
+	tracks, err := musicInput(ctx, *data.Event.Message.ChatId, metaData, args.Name)
+	if err != nil {
+		return err
+	}
+	if len(tracks) == 0 {
+		if now, _ := music.Queue(chatID); now != nil {
+			tracks = []*music.Track{now}
+		} else {
+			return errors.New("usage: /music fav add <song name>, or play a song first")
+		}
+	}
+	names := make([]string, 0, len(tracks))
+	for _, t := range tracks {
+		added, err := music.AddFavorite(ctx, chatID, metaData.UserID, t)
 		if err != nil {
 			return err
 		}
+		if added {
+			names = append(names, t.Name)
+		}
+	}
+	text := "已经在群歌单里了"
+	if len(names) > 0 {
+		text = "已收藏到群歌单: " + strings.Join(names, "、")
+	}
+	return larkmsg.ReplyCardText(ctx, text, *data.Event.Message.MessageId, "_musicFavAdd", false)
+}
+
+// MusicFavDelHandler /music fav del <序号> 从群歌单中移除, 序号见 /music fav list
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *MusicFavDelArgs
+//	@return err error
+func MusicFavDelHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MusicFavDelArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
 
-		cardContent, err = neteaseapi.BuildMusicListCard(ctx,
-			albumList,
-			neteaseapi.MusicItemTransAlbum,
-			neteaseapi.CommentTypeAlbum,
-			keywords...,
-		)
+	chatID := *data.Event.Message.ChatId
+	tracks, err := music.Favorites(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	idx, err := strconv.Atoi(args.Index)
+	if err != nil || idx < 1 || idx > len(tracks) {
+		return fmt.Errorf("usage: /music fav del <index>, index must be between 1 and %d", len(tracks))
+	}
+	t := tracks[idx-1]
+	if err = music.RemoveFavorite(ctx, chatID, t); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx, "已从群歌单移除: "+t.Name, *data.Event.Message.MessageId, "_musicFavDel", false)
+}
+
+// LyricsHandler /lyrics [歌名] 分页的双语歌词卡片, 不带歌名时取正在播放的歌
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *LyricsArgs
+//	@return err error
+func LyricsHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *LyricsArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID := *data.Event.Message.ChatId
+	var t *music.Track
+	if args.Song != "" {
+		p, err := music.ProviderFor(ctx, chatID)
 		if err != nil {
 			return err
 		}
-	} else if searchType == "artist" {
-	} else if searchType == "playlist" {
-	} else if searchType == "song" {
-		musicList, err := neteaseapi.NetEaseGCtx.SearchMusicByKeyWord(ctx, keywords...)
+		// 歌词不需要播放链接, 无版权的歌也可以查看
+		songs, err := p.SearchSongs(ctx, args.Song, 1)
 		if err != nil {
 			return err
 		}
-		cardContent, err = neteaseapi.BuildMusicListCard(ctx,
-			musicList,
-			neteaseapi.MusicItemNoTrans,
-			neteaseapi.CommentTypeSong,
-			keywords...,
-		)
+		if len(songs) == 0 {
+			return music.ErrNotFound
+		}
+		t = music.FromSong(p.Name(), songs[0])
+	} else if list, ok := pipedMusicList(metaData); ok {
+		p, err := music.ProviderFor(ctx, chatID)
 		if err != nil {
 			return err
 		}
-	} else {
-		return errors.New("Unknown search type")
+		t = music.FromSong(p.Name(), list[0])
+	} else if t, _ = music.Queue(chatID); t == nil {
+		return errors.New("usage: /lyrics <song name>, or play a song first")
+	}
+	card, err := music.LyricsCard(ctx, chatID, t, args.Page)
+	if err != nil {
+		return err
 	}
+	return replyMusicCard(ctx, data, metaData, card, "_lyrics")
+}
+
+// LyricsGuessHandler /lyrics guess [关键词] 随机一句歌词让群成员猜歌名, 不带关键词时从群歌单出题
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *LyricsGuessArgs
+//	@return err error
+func LyricsGuessHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *LyricsGuessArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
 
-	err = larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_musicSearch", utils.GetIfInthread(ctx, metaData, config.Get().NeteaseMusicConfig.MusicCardInThread))
+	card, err := music.StartGuess(ctx, *data.Event.Message.ChatId, args.Keyword)
 	if err != nil {
 		return err
 	}
-	return
+	return replyMusicCard(ctx, data, metaData, card, "_lyricsGuess")
 }
 
 // func init() {
@@ -10,24 +10,25 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
-	"github.com/pkg/errors"
 )
 
 const (
 	MuteRedisKeyPrefix = "mute:"
 )
 
-func MuteHandler(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// MuteArgs /mute 的参数
+type MuteArgs struct {
+	T      time.Duration `arg:"t" help:"禁言时长, 如 10m、1h" default:"3m"`
+	Cancel bool          `arg:"cancel" help:"取消禁言"`
+}
+
+func MuteHandler(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *MuteArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
 	defer func() { span.RecordError(err) }()
-	var (
-		res              string
-		muteTimeDuration time.Duration
-	)
+	var res string
 	defer func() { metaData.SetExtra("mute_result", res) }()
-	argMap, _ := parseArgs(args...)
-	if _, ok := argMap["cancel"]; ok {
+	if args.Cancel {
 		// 取消禁言
 		// 先检查是否已经取消禁言
 		if ext, err := redis.GetRedisClient().
@@ -41,21 +42,13 @@ func MuteHandler(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData
 			return err
 		}
 		res = "禁言已取消"
-	} else if muteTime, ok := argMap["t"]; ok {
-		muteTimeDuration, err = time.ParseDuration(muteTime)
-		if err != nil {
-			return errors.Wrap(err, "parse time error")
-		}
-	} else {
-		muteTimeDuration = time.Minute * 3 // 默认三分钟
-	}
-	if muteTimeDuration > 0 {
+	} else if args.T > 0 {
 		if err := redis.GetRedisClient().
-			Set(ctx, MuteRedisKeyPrefix+*event.Event.Message.ChatId, 1, muteTimeDuration).
+			Set(ctx, MuteRedisKeyPrefix+*event.Event.Message.ChatId, 1, args.T).
 			Err(); err != nil {
 			return err
 		}
-		res = "已启用" + muteTimeDuration.String() + "禁言"
+		res = "已启用" + args.T.String() + "禁言"
 	}
 	err = larkmsg.ReplyCardText(ctx, res, *event.Event.Message.MessageId, "_mute", true)
 	if err != nil {
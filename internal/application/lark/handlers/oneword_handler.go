@@ -34,17 +34,20 @@ type RespBody struct {
 	Length     int         `json:"length"`
 }
 
-func OneWordHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// OneWordArgs /oneword 的参数
+type OneWordArgs struct {
+	Type string `arg:"type" help:"一言类型, 不给出时随机" enum:"二次元|游戏|文学|原创|网络|其他|影视|诗词|网易云|哲学|抖机灵"`
+}
+
+func OneWordHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *OneWordArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
 	oneWordArgs := []string{}
 
-	argsMap, _ := parseArgs(args...)
-	wordType, ok := argsMap["type"]
-	if ok {
-		switch wordType {
+	if args.Type != "" {
+		switch args.Type {
 		case "二次元":
 			oneWordArgs = append(oneWordArgs, []string{"a", "b"}...)
 		case "游戏":
@@ -75,6 +78,7 @@ func OneWordHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaDa
 		return err
 	}
 	msg := fmt.Sprintf("%s 很喜欢《%s》中的一句话\n%s", emoji.Mountain.String(), hitokotoRes.From, hitokotoRes.Hitokoto)
+	metaData.SetResult(hitokotoRes.Hitokoto)
 	_, err = larkmsg.ReplyMsgText(ctx, msg, *data.Event.Message.MessageId, "_oneWord", false)
 	return
 }
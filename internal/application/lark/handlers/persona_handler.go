@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/persona"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// mentionedMember 命令中 @ 的第一个成员(不含机器人)
+func mentionedMember(data *larkim.P2MessageReceiveV1) *larkim.MentionEvent {
+	for _, mention := range data.Event.Message.Mentions {
+		if mention.Id != nil && mention.Id.OpenId != nil && *mention.Id.OpenId != config.Get().LarkConfig.BotOpenID {
+			return mention
+		}
+	}
+	return nil
+}
+
+// stripMentions 去掉参数中的 @ 片段
+func stripMentions(input string) string {
+	fields := strings.Fields(input)
+	kept := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !strings.HasPrefix(f, "@") {
+			kept = append(kept, f)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// PersonaShowArgs /persona show 的参数
+type PersonaShowArgs struct {
+	Refresh bool   `arg:"refresh" help:"强制重新统计"`
+	Member  string `input:"true" help:"@成员, 不填时为全群"`
+}
+
+// PersonaImitateArgs /persona imitate 的参数
+type PersonaImitateArgs struct {
+	Text string `input:"true" help:"@成员 [话题]" required:"true"`
+}
+
+// PersonaShowHandler 展示群或成员的说话风格画像
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *PersonaShowArgs
+//	@return err error
+func PersonaShowHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *PersonaShowArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID := *data.Event.Message.ChatId
+	userID := ""
+	if member := mentionedMember(data); member != nil {
+		userID = *member.Id.OpenId
+	}
+	var p *persona.Profile
+	if args.Refresh {
+		p, err = persona.Refresh(ctx, chatID, userID)
+	} else {
+		p, err = persona.Get(ctx, chatID, userID)
+	}
+	if err != nil {
+		return err
+	}
+	res := "消息太少, 还看不出说话风格"
+	if !p.Empty() {
+		res = fmt.Sprintf("%s\n\n统计了最近 %d 条消息, 更新于 %s", p.Describe(), p.MsgCount, p.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+	return larkmsg.ReplyCardText(ctx, res, *data.Event.Message.MessageId, "_persona", false)
+}
+
+// PersonaImitateHandler 以某位成员的口吻说一句话
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *PersonaImitateArgs
+//	@return err error
+func PersonaImitateHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *PersonaImitateArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+	defer func() { metaData.SkipDone = true }()
+
+	member := mentionedMember(data)
+	if member == nil {
+		return errors.New("usage: /persona imitate @成员 [话题]")
+	}
+	p, err := persona.Get(ctx, *data.Event.Message.ChatId, *member.Id.OpenId)
+	if err != nil {
+		return err
+	}
+	if p.Empty() {
+		return larkmsg.ReplyCardText(ctx, "TA 的消息太少, 还学不会", *data.Event.Message.MessageId, "_persona", false)
+	}
+	size := new(int)
+	*size = 20
+	return ChatHandlerInner(ctx, data, MODEL_TYPE_NORMAL, size, *member.Id.OpenId, stripMentions(args.Text))
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/bytedance/sonic"
+)
+
+// WordCloudResult /wc 的结构化结果, 在管道中传给下一条命令
+type WordCloudResult struct {
+	StartTime string         `json:"start_time"`
+	EndTime   string         `json:"end_time"`
+	Words     []WordFreq     `json:"words"`
+	TopUsers  []UserMsgCount `json:"top_users"`
+	Summaries []string       `json:"summaries"`
+}
+
+// WordFreq 词频
+type WordFreq struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// UserMsgCount 成员发言数
+type UserMsgCount struct {
+	UserID string `json:"user_id"`
+	MsgCnt int    `json:"msg_cnt"`
+}
+
+// pipeInputText 管道中上一条命令结果的文本形式, 供 /bb 等需要文本的命令使用, 没有时为空
+func pipeInputText(metaData *xhandler.BaseMetaData) string {
+	if metaData == nil {
+		return ""
+	}
+	v, ok := metaData.PipeInput()
+	if !ok || v == nil {
+		return ""
+	}
+	switch x := v.(type) {
+	case string:
+		return x
+	case fmt.Stringer:
+		return x.String()
+	}
+	s, _ := sonic.MarshalString(v)
+	return s
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/reminder"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larktpl"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+const remindUsage = "usage: /remind <时间> <事项> [@成员], 如 /remind 明天下午3点 开会; /remind list; /remind cancel <id>"
+
+// RemindArgs /remind 的参数
+type RemindArgs struct {
+	Text string `input:"true" help:"<时间> <事项> [@成员], 或 list、cancel <id>" required:"true"`
+}
+
+// RemindHandler 创建、查看与取消提醒
+//
+//	/remind <时间> <事项> 提醒自己和 @ 的成员; /remind list 查看本群的提醒; /remind cancel <id> 取消
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *RemindArgs
+//	@return err error
+func RemindHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *RemindArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	input := strings.TrimSpace(stripMentions(args.Text))
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return errors.New(remindUsage)
+	}
+	switch fields[0] {
+	case "list":
+		return remindList(ctx, data)
+	case "cancel":
+		if len(fields) < 2 {
+			return errors.New(remindUsage)
+		}
+		id, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return errors.New(remindUsage)
+		}
+		if err = reminder.Cancel(ctx, *data.Event.Message.ChatId, *data.Event.Sender.SenderId.OpenId, id); err != nil {
+			return err
+		}
+		return larkmsg.ReplyCardText(ctx, fmt.Sprintf("提醒 %d 已取消", id), *data.Event.Message.MessageId, "_remindCancel", false)
+	}
+
+	due, content, err := reminder.ParseWhen(input, time.Now().In(utils.UTC8Loc()))
+	if err != nil {
+		if errors.Is(err, reminder.ErrPast) {
+			return errors.New("这个时间已经过去了")
+		}
+		return errors.New(remindUsage)
+	}
+	if content == "" {
+		return errors.New(remindUsage)
+	}
+	mentions := []string{*data.Event.Sender.SenderId.OpenId}
+	for _, mention := range data.Event.Message.Mentions {
+		if mention.Id != nil && mention.Id.OpenId != nil && *mention.Id.OpenId != config.Get().LarkConfig.BotOpenID {
+			mentions = append(mentions, *mention.Id.OpenId)
+		}
+	}
+	row := &model.Reminder{
+		ChatID:      *data.Event.Message.ChatId,
+		CreatorID:   *data.Event.Sender.SenderId.OpenId,
+		Content:     content,
+		DueAt:       due,
+		Mentions:    reminder.JoinMentions(mentions...),
+		SourceMsgID: *data.Event.Message.MessageId,
+	}
+	if err = reminder.Create(ctx, row); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx,
+		fmt.Sprintf("好的, 将在 %s 提醒: %s\n\n取消请发送 /remind cancel %d", reminder.FormatDue(due), content, row.ID),
+		*data.Event.Message.MessageId, "_remind", false)
+}
+
+func remindList(ctx context.Context, data *larkim.P2MessageReceiveV1) error {
+	rows, err := reminder.List(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return larkmsg.ReplyCardText(ctx, "本群还没有待发送的提醒", *data.Event.Message.MessageId, "_remindList", false)
+	}
+	lines := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, map[string]string{
+			"title1": strconv.FormatInt(row.ID, 10),
+			"title2": reminder.FormatDue(row.DueAt),
+			"title3": row.Content,
+		})
+	}
+	cardContent := larktpl.NewCardContent(
+		ctx,
+		larktpl.ThreeColSheetTemplate,
+	).
+		AddVariable("title1", "ID").
+		AddVariable("title2", "Due").
+		AddVariable("title3", "Content").
+		AddVariable("table_raw_array_1", lines)
+
+	return larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_remindList", false)
+}
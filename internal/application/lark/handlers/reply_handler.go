@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/replyrule"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkimg"
@@ -14,7 +15,6 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
-	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	"github.com/bytedance/sonic"
@@ -26,187 +26,271 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// ReplyAddArgs /reply add 的参数
+type ReplyAddArgs struct {
+	Word      string `arg:"word" help:"关键词" required:"true"`
+	Type      string `arg:"type" help:"匹配方式" enum:"substr|regex|full" required:"true"`
+	Reply     string `arg:"reply" help:"回复内容, 多条用 | 分隔, 触发时按 --rotate 轮换"`
+	ReplyType string `arg:"reply_type" help:"回复类型, img 时回复所引用的图片" enum:"text|img" default:"text"`
+	Users     string `arg:"users" help:"只对这些成员生效, 逗号分隔的 open_id, me 表示自己"`
+	Time      string `arg:"time" help:"生效时段, 如 09:00-18:00"`
+	Cooldown  int64  `arg:"cooldown" help:"冷却时间, 单位秒" min:"0"`
+	Prob      int64  `arg:"prob" help:"触发概率, 单位 %" default:"100" min:"1" max:"100"`
+	Rotate    string `arg:"rotate" help:"多条回复的轮换方式" enum:"random|round" default:"random"`
+}
+
+// ReplyDelArgs /reply del 的参数
+type ReplyDelArgs struct {
+	Rule string `input:"true" help:"规则标识, 见 /reply list" required:"true"`
+}
+
+// ReplyTestArgs /reply test 的参数
+type ReplyTestArgs struct {
+	Msg string `input:"true" help:"待测试的文本" required:"true"`
+}
+
 // ReplyAddHandler to be filled
 //
 //	@param ctx context.Context
 //	@param data *larkim.P2MessageReceiveV1
-//	@param args ...string
+//	@param args *ReplyAddArgs
 //	@return error
 //	@author heyuhengmatt
 //	@update 2024-08-06 08:27:18
-func ReplyAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+func ReplyAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *ReplyAddArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
 
-	argMap, _ := parseArgs(args...)
-	logs.L().Ctx(ctx).Info("args", zap.Any("args", argMap))
-	if len(argMap) > 0 {
-		word, ok := argMap["word"]
-		if !ok {
-			return errors.New("arg word is required")
-		}
+	logs.L().Ctx(ctx).Info("args", zap.Any("args", args))
+	rule, err := parseRuleConditions(args, *data.Event.Sender.SenderId.OpenId)
+	if err != nil {
+		return err
+	}
 
-		matchType, ok := argMap["type"]
-		if !ok {
-			return errors.New("arg type(substr, full) is required") // 临时下掉regex
-			return errors.New("arg type(substr, regex, full) is required")
-		}
-		if word == "" {
-			return errors.New("arg word is empty, please change your key word")
-		}
+	var replies []string
 
-		if matchType != string(xmodel.MatchTypeSubStr) && matchType != string(xmodel.MatchTypeRegex) && matchType != string(xmodel.MatchTypeFull) {
-			return errors.New("type must be substr, regex or full")
+	if args.ReplyType == string(xmodel.ReplyTypeImg) { // 图片类型，需要回复图片
+		if data.Event.Message.ParentId == nil {
+			return errors.New("reply_type **img** must reply to a image message")
 		}
-		replyType, ok := argMap["reply_type"]
-		if !ok {
-			replyType = string(xmodel.ReplyTypeText)
-		}
-
-		var reply string
-
-		if replyType == string(xmodel.ReplyTypeImg) { // 图片类型，需要回复图片
-			if data.Event.Message.ParentId == nil {
-				return errors.New("reply_type **img** must reply to a image message")
+		parentMsg := larkmsg.GetMsgFullByID(ctx, *data.Event.Message.ParentId)
+		if len(parentMsg.Data.Items) != 0 {
+			parentMsgItem := parentMsg.Data.Items[0]
+			contentMap := make(map[string]string)
+			err := sonic.UnmarshalString(*parentMsgItem.Body.Content, &contentMap)
+			if err != nil {
+				logs.L().Ctx(ctx).Warn("repeatMessage", zap.Error(err))
+				return err
 			}
-			parentMsg := larkmsg.GetMsgFullByID(ctx, *data.Event.Message.ParentId)
-			if len(parentMsg.Data.Items) != 0 {
-				parentMsgItem := parentMsg.Data.Items[0]
-				contentMap := make(map[string]string)
-				err := sonic.UnmarshalString(*parentMsgItem.Body.Content, &contentMap)
-				if err != nil {
-					logs.L().Ctx(ctx).Warn("repeatMessage", zap.Error(err))
+			switch *parentMsgItem.MsgType {
+			case larkim.MsgTypeSticker:
+				imgKey := contentMap["file_key"]
+				ins := query.Q.StickerMapping
+				res, err := ins.WithContext(ctx).Where(ins.StickerKey.Eq(imgKey)).First()
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 					return err
 				}
-				switch *parentMsgItem.MsgType {
-				case larkim.MsgTypeSticker:
-					imgKey := contentMap["file_key"]
-					ins := query.Q.StickerMapping
-					res, err := ins.WithContext(ctx).Where(ins.StickerKey.Eq(imgKey)).First()
-					if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-						return err
-					}
-					if res == nil {
-						if stickerFile, err := larkimg.GetMsgImages(ctx, *data.Event.Message.ParentId, contentMap["file_key"], "image"); err != nil {
-							logs.L().Ctx(ctx).Warn("repeatMessage", zap.Error(err))
-						} else {
-							newImgKey := larkimg.UploadPicture2LarkReader(ctx, stickerFile)
-							ins := query.Q.StickerMapping
-							err = ins.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&model.StickerMapping{
-								StickerKey: imgKey,
-								ImageKey:   newImgKey,
-							})
-							if err != nil {
-								return err
-							}
+				if res == nil {
+					if stickerFile, err := larkimg.GetMsgImages(ctx, *data.Event.Message.ParentId, contentMap["file_key"], "image"); err != nil {
+						logs.L().Ctx(ctx).Warn("repeatMessage", zap.Error(err))
+					} else {
+						newImgKey := larkimg.UploadPicture2LarkReader(ctx, stickerFile)
+						ins := query.Q.StickerMapping
+						err = ins.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&model.StickerMapping{
+							StickerKey: imgKey,
+							ImageKey:   newImgKey,
+						})
+						if err != nil {
+							return err
 						}
+						replies = append(replies, newImgKey)
 					}
-					reply = res.ImageKey
-				case larkim.MsgTypeImage:
-					imageFile, err := larkimg.GetMsgImages(ctx, *data.Event.Message.ParentId, contentMap["image_key"], "image")
-					if err != nil {
-						return err
-					}
-					reply = larkimg.UploadPicture2LarkReader(ctx, imageFile)
-				default:
-					return errors.New("reply_type **img** must reply to a image message")
+				} else {
+					replies = append(replies, res.ImageKey)
+				}
+			case larkim.MsgTypeImage:
+				imageFile, err := larkimg.GetMsgImages(ctx, *data.Event.Message.ParentId, contentMap["image_key"], "image")
+				if err != nil {
+					return err
 				}
+				replies = append(replies, larkimg.UploadPicture2LarkReader(ctx, imageFile))
+			default:
+				return errors.New("reply_type **img** must reply to a image message")
 			}
-		} else {
-			reply, ok = argMap["reply"]
-			if !ok {
-				return errors.New("arg reply is required")
+		}
+	} else {
+		if args.Reply == "" {
+			return errors.New("--reply is required")
+		}
+		// 多条回复用 | 分隔, 触发时按 rotate 轮换
+		for r := range strings.SplitSeq(args.Reply, "|") {
+			if r = strings.TrimSpace(r); r != "" {
+				replies = append(replies, r)
 			}
 		}
+	}
+	if len(replies) == 0 {
+		return errors.New("arg reply is empty")
+	}
 
-		ins := query.Q.QuoteReplyMsgCustom
-		if err := ins.WithContext(ctx).
-			Create(&model.QuoteReplyMsgCustom{
-				GuildID:   *data.Event.Message.ChatId,
-				MatchType: string(xmodel.WordMatchType(matchType)),
-				Keyword:   word,
-				Reply:     reply,
-				ReplyType: replyType,
-			}); err != nil {
-			return err
+	rule.ChatID = *data.Event.Message.ChatId
+	rule.MatchType = args.Type
+	rule.Keyword = args.Word
+	rule.ReplyType = args.ReplyType
+	rule.Replies, _ = sonic.MarshalString(replies)
+	if err := replyrule.Add(ctx, rule); err != nil {
+		return err
+	}
+	larkmsg.ReplyMsgText(ctx, fmt.Sprintf("回复规则 r%d 添加成功", rule.ID), *data.Event.Message.MessageId, "_replyAdd", false)
+	return nil
+}
+
+// parseRuleConditions 由参数生成规则的触发条件
+//
+//	@param args *ReplyAddArgs
+//	@param senderID string
+//	@return rule *model.ReplyRule
+//	@return err error
+func parseRuleConditions(args *ReplyAddArgs, senderID string) (rule *model.ReplyRule, err error) {
+	rule = &model.ReplyRule{
+		Probability: args.Prob,
+		Rotation:    args.Rotate,
+		CooldownSec: args.Cooldown,
+		TimeWindow:  args.Time,
+		CreatedBy:   senderID,
+	}
+	if args.Users != "" {
+		ids := make([]string, 0)
+		for id := range strings.SplitSeq(args.Users, ",") {
+			if id = strings.TrimSpace(id); id == "me" {
+				id = senderID
+			}
+			if id != "" {
+				ids = append(ids, id)
+			}
 		}
-		larkmsg.ReplyMsgText(ctx, "回复语句添加成功", *data.Event.Message.MessageId, "_replyAdd", false)
-		return nil
+		rule.UserIds = strings.Join(ids, ",")
 	}
-	return xerror.ErrArgsIncompelete
+	if args.Time != "" {
+		if _, err = replyrule.ParseTimeWindow(args.Time); err != nil {
+			return nil, err
+		}
+	}
+	return rule, nil
 }
 
-// ReplyGetHandler to be filled
+// ReplyListHandler 列出当前群可见的回复规则及命中次数
 //
 //	@param ctx context.Context
 //	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
 //	@param args ...string
-//	@return error
-func ReplyGetHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+//	@return err error
+func ReplyListHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
-	argMap, _ := parseArgs(args...)
-	logs.L().Ctx(ctx).Info("args", zap.Any("args", argMap))
-	ChatID := *data.Event.Message.ChatId
-
-	lines := make([]map[string]string, 0)
-	ins := query.Q.QuoteReplyMsgCustom
-	resListCustom, err := ins.WithContext(ctx).Where(ins.GuildID.Eq(ChatID)).Find()
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+
+	stats, err := replyrule.List(ctx, *data.Event.Message.ChatId)
+	if err != nil {
 		return err
 	}
-	for _, res := range resListCustom {
-		if res.GuildID == ChatID {
-			if res.ReplyType == larkim.MsgTypeImage {
-				if strings.HasPrefix(res.Reply, "img") {
-					res.Reply = fmt.Sprintf("![picture](%s)", res.Reply)
-				} else {
-					res.Reply = fmt.Sprintf("![picture](%s)", getImageKeyByStickerKey(res.Reply))
+	lines := make([]map[string]string, 0, len(stats))
+	for _, stat := range stats {
+		replies := make([]string, 0, len(stat.Replies))
+		for _, reply := range stat.Replies {
+			if stat.ReplyType == xmodel.ReplyTypeImg {
+				if !strings.HasPrefix(reply, "img") {
+					reply = getImageKeyByStickerKey(reply)
 				}
+				reply = fmt.Sprintf("![picture](%s)", reply)
 			}
-			lines = append(lines, map[string]string{
-				"title1": "Custom",
-				"title2": res.Keyword,
-				"title3": res.Reply,
-				"title4": string(res.MatchType),
-			})
+			replies = append(replies, reply)
 		}
-	}
-	ins2 := query.Q.QuoteReplyMsg
-	resListGlobal, err := ins2.WithContext(ctx).Find()
-	if err != nil {
-		return err
-	}
-	for _, res := range resListGlobal {
-		if string(res.ReplyType) == larkim.MsgTypeImage {
-			if strings.HasPrefix(res.Reply, "img") {
-				res.Reply = fmt.Sprintf("![picture](%s)", res.Reply)
-			} else {
-				res.Reply = fmt.Sprintf("![picture](%s)", getImageKeyByStickerKey(res.Reply))
-			}
+		key := stat.Key
+		if stat.Global {
+			key += "(Global)"
 		}
 		lines = append(lines, map[string]string{
-			"title1": "Global",
-			"title2": res.Keyword,
-			"title3": res.Reply,
-			"title4": string(res.MatchType),
+			"title1": key,
+			"title2": fmt.Sprintf("%s `%s`", stat.Keyword, stat.MatchType),
+			"title3": strings.Join(replies, "\n"),
+			"title4": strings.TrimSpace(fmt.Sprintf("hits=%d %s", stat.Hits, stat.Conditions())),
 		})
 	}
 	cardContent := larktpl.NewCardContent(
 		ctx,
 		larktpl.FourColSheetTemplate,
 	).
-		AddVariable("title1", "Scope").
+		AddVariable("title1", "Rule").
 		AddVariable("title2", "Keyword").
 		AddVariable("title3", "Reply").
-		AddVariable("title4", "MatchType").
+		AddVariable("title4", "Conditions").
 		AddVariable("table_raw_array_1", lines)
 
-	err = larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_replyGet", false)
+	return larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_replyGet", false)
+}
+
+// ReplyDelHandler 删除当前群的一条回复规则
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *ReplyDelArgs
+//	@return err error
+func ReplyDelHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *ReplyDelArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	key := args.Rule
+	if err = replyrule.Delete(ctx, *data.Event.Message.ChatId, key); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx, fmt.Sprintf("回复规则 %s 已删除", key), *data.Event.Message.MessageId, "_replyDel", false)
+}
+
+// ReplyTestHandler 试运行回复规则, 展示一句话会命中哪些规则
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *ReplyTestArgs
+//	@return err error
+func ReplyTestHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *ReplyTestArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	msg := args.Msg
+	results, err := replyrule.Test(ctx, *data.Event.Message.ChatId, *data.Event.Sender.SenderId.OpenId, msg)
 	if err != nil {
 		return err
 	}
-	return nil
+	res := "没有命中任何规则"
+	if len(results) > 0 {
+		sb := strings.Builder{}
+		sb.WriteString("**命中规则**\n")
+		for _, r := range results {
+			reply := r.Render(r.Rule.Replies[0])
+			if r.Rule.ReplyType == xmodel.ReplyTypeImg {
+				reply = "[图片]"
+			}
+			fmt.Fprintf(&sb, "- `%s` %s `%s` → %s", r.Rule.Key, r.Rule.Keyword, r.Rule.MatchType, reply)
+			if r.Global {
+				sb.WriteString(" (Global)")
+			}
+			if cond := r.Rule.Conditions(); cond != "" {
+				fmt.Fprintf(&sb, " [%s]", cond)
+			}
+			if r.Blocked != "" {
+				fmt.Fprintf(&sb, " ⛔ %s", r.Blocked)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n群内规则可触发时优先于全局规则")
+		res = sb.String()
+	}
+	return larkmsg.ReplyCardText(ctx, res, *data.Event.Message.MessageId, "_replyTest", false)
 }
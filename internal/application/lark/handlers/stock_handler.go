@@ -3,7 +3,6 @@ package handlers
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
@@ -24,31 +23,27 @@ import (
 	"go.uber.org/zap"
 )
 
-func StockHandler(stockType string) xcommand.CommandFunc[*larkim.P2MessageReceiveV1] {
-	switch stockType {
-	case "gold":
-		return GoldHandler
-	case "a":
-		return ZhAStockHandler
-	}
-	return nil
+// GoldArgs /stock gold 的参数
+type GoldArgs struct {
+	Hours int                `arg:"h" help:"最近 N 小时的实时价格" min:"1"`
+	Days  int                `arg:"d" help:"最近 N 天的历史价格" default:"30" min:"1"`
+	Range xcommand.TimeRange `arg:"range,st,et" input:"true"`
 }
 
-func GoldHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// GoldHandler 沪金所金价走势, 指定 --h 时为当天的实时价格, 否则为历史日线
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *GoldArgs
+//	@return err error
+func GoldHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *GoldArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	argMap, _ := parseArgs(args...)
-
-	var (
-		cardContent *larktpl.TemplateCardContent
-		days        int
-		hoursInt    int
-		defaultDays = 30
-		st, et      time.Time
-	)
+	var cardContent *larktpl.TemplateCardContent
 	defer func() {
 		if err != nil {
 			metaData.SetExtra("gold_result", "执行失败，错误原因"+err.Error())
@@ -56,54 +51,20 @@ func GoldHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData
 			metaData.SetExtra("gold_result", "执行成功")
 		}
 	}()
-	// 如果有st，et的配置，用st，et的配置来覆盖
-	if stStr, ok := argMap["st"]; ok {
-		if etStr, ok := argMap["et"]; ok {
-			st, err = time.Parse(time.RFC3339, stStr)
-			if err != nil {
-				return err
-			}
-			et, err = time.Parse(time.RFC3339, etStr)
-			if err != nil {
-				return err
-			}
+	st, et := metaData.TimeRange.Start, metaData.TimeRange.End
+	if args.Hours > 0 {
+		if metaData.TimeRange.IsZero() {
+			st, et = time.Now().Add(time.Duration(-args.Hours)*time.Hour), time.Now()
 		}
-	}
-
-	if hours, ok := argMap["h"]; ok {
-		if st.IsZero() || et.IsZero() {
-			hoursInt, err = strconv.Atoi(hours)
-			if err != nil || hoursInt <= 0 {
-				hoursInt = 1
-			}
-			st = time.Now().Add(time.Duration(-1*hoursInt) * time.Hour)
-			et = time.Now()
-		}
-
 		cardContent, err = GetRealtimeGoldPriceGraph(ctx, st, et)
-		fmt.Println(cardContent.String())
-		if err != nil {
-			return err
-		}
-	} else if daysStr, ok := argMap["d"]; ok {
-		if st.IsZero() || et.IsZero() {
-			days, err = strconv.Atoi(daysStr)
-			if err != nil || days <= 0 {
-				days = defaultDays
-			}
-			st = time.Now().AddDate(0, 0, -1*days)
-			et = time.Now()
-		}
-
-		cardContent, err = GetHistoryGoldGraph(ctx, st, et)
-		if err != nil {
-			return err
-		}
 	} else {
-		cardContent, err = GetHistoryGoldGraph(ctx, st, et)
-		if err != nil {
-			return err
+		if metaData.TimeRange.IsZero() {
+			st, et = GetBackDays(args.Days)
 		}
+		cardContent, err = GetHistoryGoldGraph(ctx, st, et)
+	}
+	if err != nil {
+		return err
 	}
 
 	if metaData != nil && metaData.Refresh {
@@ -119,46 +80,30 @@ func GoldHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData
 	return
 }
 
-func ZhAStockHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// ZhAStockArgs /stock zh_a 的参数
+type ZhAStockArgs struct {
+	Code  string             `arg:"code" help:"沪深A股代码, 如 600519" required:"true"`
+	Days  int                `arg:"days" help:"最近 N 天" default:"1" min:"1"`
+	Range xcommand.TimeRange `arg:"range,st,et" input:"true"`
+}
+
+// ZhAStockHandler 沪深A股的分钟级价格走势
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *ZhAStockArgs
+//	@return err error
+func ZhAStockHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *ZhAStockArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	var (
-		days                  = 1
-		defaultDays           = 1
-		st, et      time.Time = time.Now().AddDate(0, 0, -1*defaultDays), time.Now()
-		stockCode   string
-	)
-
-	argMap, _ := parseArgs(args...)
-
-	stockCode, ok := argMap["code"]
-	if !ok {
-		return fmt.Errorf("stock code is required")
-	}
-
-	if daysStr, ok := argMap["days"]; ok {
-		days, err = strconv.Atoi(daysStr)
-		if err != nil || days <= 0 {
-			days = defaultDays
-		}
-		st, et = time.Now().AddDate(0, 0, -1*days), time.Now()
-	}
-
-	// 如果有st，et的配置，用st，et的配置来覆盖
-	if stStr, ok := argMap["st"]; ok {
-		if etStr, ok := argMap["et"]; ok {
-			st, err = time.Parse(time.RFC3339, stStr)
-			if err != nil {
-				return err
-			}
-			et, err = time.Parse(time.RFC3339, etStr)
-			if err != nil {
-				return err
-			}
-		}
+	stockCode, days := args.Code, args.Days
+	st, et := GetBackDays(days)
+	if !metaData.TimeRange.IsZero() {
+		st, et = metaData.TimeRange.Start, metaData.TimeRange.End
 	}
 	graph := vadvisor.NewMultiSeriesLineGraph[string, float64](ctx)
 	stockPrice, err := aktool.GetStockPriceRT(ctx, stockCode)
@@ -196,7 +141,7 @@ func ZhAStockHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaD
 		},
 	)
 	cardContent := larkcard.NewCardBuildGraphHelper(graph).
-		SetTitle(fmt.Sprintf("沪A-[%s]%s-近<%d>天", stockCode, stockName, days)).
+		SetTitle(fmt.Sprintf("A股-[%s]%s-%s", stockCode, stockName, dateRangeLabel(st, et))).
 		SetStartTime(st).
 		SetEndTime(et).
 		Build(ctx)
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/stock"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larktpl"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// StockWatchArgs /stock watch add|del 的参数
+type StockWatchArgs struct {
+	Code string `arg:"code" help:"沪深A股代码, 如 600519" required:"true"`
+	Me   bool   `arg:"me" help:"个人自选股, 在任何群都能查看, 否则为本群的自选股"`
+}
+
+// StockWatchListArgs /stock watch list 的参数
+type StockWatchListArgs struct {
+	Me bool `arg:"me" help:"查看个人自选股, 否则为本群的自选股"`
+}
+
+// watchScope 自选股的归属, 个人自选股不区分群
+func watchScope(data *larkim.P2MessageReceiveV1, me bool) (chatID, userID, label string) {
+	if me {
+		return "", *data.Event.Sender.SenderId.OpenId, "你的自选股"
+	}
+	return *data.Event.Message.ChatId, "", "本群自选股"
+}
+
+// StockWatchAddHandler 添加自选股
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *StockWatchArgs
+//	@return err error
+func StockWatchAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *StockWatchArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID, userID, label := watchScope(data, args.Me)
+	row, added, err := stock.Watch(ctx, chatID, userID, *data.Event.Sender.SenderId.OpenId, args.Code)
+	if err != nil {
+		return err
+	}
+	text := fmt.Sprintf("%s(%s) 已在%s中", row.Name, row.Symbol, label)
+	if added {
+		text = fmt.Sprintf("已将 %s(%s) 加入%s", row.Name, row.Symbol, label)
+	}
+	return larkmsg.ReplyCardText(ctx, text, *data.Event.Message.MessageId, "_stockWatch", false)
+}
+
+// StockWatchDelHandler 删除自选股
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *StockWatchArgs
+//	@return err error
+func StockWatchDelHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *StockWatchArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID, userID, label := watchScope(data, args.Me)
+	if err = stock.Unwatch(ctx, chatID, userID, args.Code); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx, fmt.Sprintf("已将 %s 移出%s", args.Code, label), *data.Event.Message.MessageId, "_stockWatch", false)
+}
+
+// StockWatchListHandler 自选股及最新报价
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *StockWatchListArgs
+//	@return err error
+func StockWatchListHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *StockWatchListArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID, userID, label := watchScope(data, args.Me)
+	rows, err := stock.Watches(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return larkmsg.ReplyCardText(ctx, label+"是空的, 用 /stock watch add --code=600519 添加", *data.Event.Message.MessageId, "_stockWatchList", false)
+	}
+	symbols := make([]string, 0, len(rows))
+	for _, row := range rows {
+		symbols = append(symbols, row.Symbol)
+	}
+	quotes := stock.Quotes(ctx, symbols)
+	lines := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		price, pct := "-", "-"
+		if q := quotes[row.Symbol]; q != nil {
+			price, pct = fmt.Sprintf("%.2f", q.Price), fmt.Sprintf("%+.2f%%", q.ChangePct())
+		}
+		lines = append(lines, map[string]string{
+			"title1": row.Symbol,
+			"title2": row.Name,
+			"title3": price,
+			"title4": pct,
+		})
+	}
+	cardContent := larktpl.NewCardContent(
+		ctx,
+		larktpl.FourColSheetTemplate,
+	).
+		AddVariable("title1", "代码").
+		AddVariable("title2", "名称").
+		AddVariable("title3", "现价").
+		AddVariable("title4", "涨跌幅").
+		AddVariable("table_raw_array_1", lines)
+
+	return larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_stockWatchList", false)
+}
+
+// StockAlertAddArgs /stock alert add 的参数
+type StockAlertAddArgs struct {
+	Code  string `arg:"code" help:"沪深A股代码, 如 600519" required:"true"`
+	Above string `arg:"above" help:"价格涨到该值时提醒"`
+	Below string `arg:"below" help:"价格跌到该值时提醒"`
+	Move  string `arg:"move" help:"相对昨收涨跌幅达到该百分比时提醒, 如 5%"`
+	DM    bool   `arg:"dm" help:"私聊提醒自己, 否则发到本群"`
+}
+
+// StockAlertDelArgs /stock alert del 的参数
+type StockAlertDelArgs struct {
+	ID int64 `arg:"id" help:"提醒 ID, 见 /stock alert list" required:"true" min:"1"`
+}
+
+// StockAlertAddHandler 添加股价提醒, 交易时段内触发时发到本群或私聊
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *StockAlertAddArgs
+//	@return err error
+func StockAlertAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *StockAlertAddArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	kind, threshold, err := stock.ParseRule(args.Above, args.Below, args.Move)
+	if err != nil {
+		return err
+	}
+	row := &model.StockAlert{
+		ChatID:    *data.Event.Message.ChatId,
+		CreatorID: *data.Event.Sender.SenderId.OpenId,
+		Symbol:    args.Code,
+		Kind:      kind,
+		Threshold: threshold,
+		Dm:        args.DM,
+	}
+	if err = stock.AddAlert(ctx, row); err != nil {
+		return err
+	}
+	text := fmt.Sprintf("已添加提醒 %d: %s", row.ID, stock.Describe(row))
+	return larkmsg.ReplyCardText(ctx, text, *data.Event.Message.MessageId, "_stockAlert", false)
+}
+
+// StockAlertDelHandler 删除股价提醒
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *StockAlertDelArgs
+//	@return err error
+func StockAlertDelHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *StockAlertDelArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if err = stock.DeleteAlert(ctx, *data.Event.Message.ChatId, *data.Event.Sender.SenderId.OpenId, args.ID); err != nil {
+		return err
+	}
+	return larkmsg.ReplyCardText(ctx, fmt.Sprintf("提醒 %d 已删除", args.ID), *data.Event.Message.MessageId, "_stockAlert", false)
+}
+
+// StockAlertListHandler 本群的股价提醒
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args ...string
+//	@return err error
+func StockAlertListHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	rows, err := stock.Alerts(ctx, *data.Event.Message.ChatId)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return larkmsg.ReplyCardText(ctx, "本群还没有股价提醒, 用 /stock alert add --code=600519 --above=1800 添加", *data.Event.Message.MessageId, "_stockAlertList", false)
+	}
+	now := time.Now()
+	lines := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		state := "生效中"
+		if stock.Triggered(row, now) {
+			state = "已触发"
+		}
+		lines = append(lines, map[string]string{
+			"title1": strconv.FormatInt(row.ID, 10),
+			"title2": fmt.Sprintf("%s(%s)", row.Name, row.Symbol),
+			"title3": stock.Condition(row),
+			"title4": state,
+		})
+	}
+	cardContent := larktpl.NewCardContent(
+		ctx,
+		larktpl.FourColSheetTemplate,
+	).
+		AddVariable("title1", "ID").
+		AddVariable("title2", "股票").
+		AddVariable("title3", "条件").
+		AddVariable("title4", "状态").
+		AddVariable("table_raw_array_1", lines)
+
+	return larkmsg.ReplyCard(ctx, cardContent, *data.Event.Message.MessageId, "_stockAlertList", false)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/timezone"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// TimezoneArgs /timezone 的参数
+type TimezoneArgs struct {
+	Name string `input:"true" help:"IANA 时区名, 如 Asia/Shanghai、America/New_York, 不填时查看当前时区"`
+}
+
+// TimezoneHandler /timezone [时区] 查看或设置群的时区, 命令中的时间表达式按该时区解析
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *TimezoneArgs
+//	@return err error
+func TimezoneHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *TimezoneArgs) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	chatID := *data.Event.Message.ChatId
+	if args.Name != "" {
+		if err = timezone.Set(ctx, chatID, args.Name); err != nil {
+			return err
+		}
+		return larkmsg.ReplyCardText(ctx, "已切换时区: "+args.Name, *data.Event.Message.MessageId, "_timezone", false)
+	}
+	loc := timezone.For(ctx, chatID)
+	text := fmt.Sprintf("当前时区: %s, 现在是 %s", loc, time.Now().In(loc).Format("2006-01-02 15:04"))
+	return larkmsg.ReplyCardText(ctx, text, *data.Event.Message.MessageId, "_timezone", false)
+}
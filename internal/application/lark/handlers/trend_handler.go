@@ -4,7 +4,6 @@ import (
 	"cmp"
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/history"
@@ -16,6 +15,7 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/vadvisor"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	"github.com/bytedance/sonic"
@@ -25,50 +25,32 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// TrendArgs /talkrate 的参数
+type TrendArgs struct {
+	Days     int                `arg:"days" help:"统计最近 N 天" default:"7" min:"1"`
+	Interval string             `arg:"interval" help:"统计间隔, 如 1h、1d" default:"1d"`
+	Play     string             `arg:"play" help:"图表类型" enum:"line|pie|bar" default:"line"`
+	Range    xcommand.TimeRange `arg:"range,st,et" input:"true"`
+}
+
 // TrendHandler to be filled
 //
 //	@param ctx context.Context
 //	@param data *larkim.P2MessageReceiveV1
-//	@param args ...string
+//	@param args *TrendArgs
 //	@return err error
 //	@author kevinmatthe
 //	@update 2025-05-30 15:19:56
-func TrendHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+func TrendHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *TrendArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	var (
-		days     = 7
-		interval = "1d"
-		st, et   time.Time
-	)
-
-	argMap, _ := parseArgs(args...)
-	if inputInterval, ok := argMap["interval"]; ok {
-		interval = inputInterval
-	}
-	if daysStr, ok := argMap["days"]; ok {
-		days, err = strconv.Atoi(daysStr)
-		if err != nil || days <= 0 {
-			days = 30
-		}
-	}
-
-	st, et = GetBackDays(days)
-	// 如果有st，et的配置，用st，et的配置来覆盖
-	if stStr, ok := argMap["st"]; ok {
-		if etStr, ok := argMap["et"]; ok {
-			st, err = time.Parse(time.RFC3339, stStr)
-			if err != nil {
-				return err
-			}
-			et, err = time.Parse(time.RFC3339, etStr)
-			if err != nil {
-				return err
-			}
-		}
+	days, interval := args.Days, args.Interval
+	st, et := GetBackDays(days)
+	if !metaData.TimeRange.IsZero() {
+		st, et = metaData.TimeRange.Start, metaData.TimeRange.End
 	}
 	helper := &trendInternalHelper{
 		days:     days,
@@ -84,14 +66,12 @@ func TrendHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData
 		return err
 	}
 
-	if playType, ok := argMap["play"]; ok {
-		switch playType {
-		case "bar":
-			err = helper.DrawTrendBar(ctx, trend, !metaData.Refresh)
-		default:
-			err = helper.DrawTrendPie(ctx, trend, !metaData.Refresh)
-		}
-	} else {
+	switch args.Play {
+	case "bar":
+		err = helper.DrawTrendBar(ctx, trend, !metaData.Refresh)
+	case "pie":
+		err = helper.DrawTrendPie(ctx, trend, !metaData.Refresh)
+	default:
 		graph := vadvisor.NewMultiSeriesLineGraph[string, int64](ctx)
 		graph.AddPointSeries(
 			func(yield func(vadvisor.XYSUnit[string, int64]) bool) {
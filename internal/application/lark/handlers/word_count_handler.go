@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/history"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
@@ -18,6 +19,7 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/vadvisor"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 	commonutils "github.com/BetaGoRobot/go_utils/common_utils"
 	"github.com/BetaGoRobot/go_utils/reflecting"
@@ -32,67 +34,47 @@ import (
 	"go.uber.org/zap"
 )
 
-func WordCloudHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+// WordCloudArgs /wc 的参数
+type WordCloudArgs struct {
+	Days     int                `arg:"days" help:"统计最近 N 天" default:"7" min:"1"`
+	Interval string             `arg:"interval" help:"统计间隔, 如 1h、1d" default:"1d"`
+	MTop     int                `arg:"mtop" help:"展示发言最多的 N 位成员" default:"10" min:"1"`
+	CTop     int                `arg:"ctop" help:"展示 N 段对话总结" default:"5" min:"1"`
+	Sort     string             `arg:"sort" help:"对话总结的排序, hot 按消息数, time 按时间" enum:"hot|time" default:"hot"`
+	ChatID   string             `arg:"chat_id" help:"统计其他群, 默认为当前群"`
+	Range    xcommand.TimeRange `arg:"range,st,et" input:"true"`
+}
+
+// WordCloudHandler 群聊词云、活跃成员与热门对话
+//
+//	@param ctx context.Context
+//	@param data *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+//	@param args *WordCloudArgs
+//	@return err error
+func WordCloudHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *WordCloudArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
 	var (
-		days     = 7
-		interval = "1d"
-		st, et   time.Time
-	)
-	chatID := *data.Event.Message.ChatId
-	var (
-		mTop        = 10
-		cTop        = 5
-		sort Sorter = NewScriptSort(
+		days, interval        = args.Days, args.Interval
+		mTop, cTop            = args.MTop, args.CTop
+		chatID                = *data.Event.Message.ChatId
+		sort           Sorter = NewScriptSort(
 			NewScript("script").Script("doc['msg_ids'].size()").Lang("painless"), "number",
 		).Order(false)
 	)
-	argMap, _ := parseArgs(args...)
-	if inputInterval, ok := argMap["interval"]; ok {
-		interval = inputInterval
-	}
-	if daysStr, ok := argMap["days"]; ok {
-		newDays, err := strconv.Atoi(daysStr)
-		if err == nil && newDays > 0 {
-			days = newDays
-		}
-	}
-	if chatIDInput, ok := argMap["chat_id"]; ok {
-		chatID = chatIDInput
-	}
-	if mTopStr, ok := argMap["mtop"]; ok {
-		if mTop, err = strconv.Atoi(mTopStr); err != nil || mTop <= 0 {
-			mTop = 15
-		}
-	}
-	if cTopStr, ok := argMap["ctop"]; ok {
-		if cTop, err = strconv.Atoi(cTopStr); err != nil || cTop <= 0 {
-			cTop = 5
-		}
+	if args.ChatID != "" {
+		chatID = args.ChatID
 	}
-	if sortStr, ok := argMap["sort"]; ok {
-		if sortStr == "time" {
-			sort = NewFieldSort("timestamp_v2").Desc()
-		}
+	if args.Sort == "time" {
+		sort = NewFieldSort("timestamp_v2").Desc()
 	}
-
-	st, et = GetBackDays(days)
-	// 如果有st，et的配置，用st，et的配置来覆盖
-	if stStr, ok := argMap["st"]; ok {
-		if etStr, ok := argMap["et"]; ok {
-			st, err = time.ParseInLocation(time.DateTime, stStr, utils.UTC8Loc())
-			if err != nil {
-				return err
-			}
-			et, err = time.ParseInLocation(time.DateTime, etStr, utils.UTC8Loc())
-			if err != nil {
-				return err
-			}
-		}
+	st, et := GetBackDays(days)
+	if !metaData.TimeRange.IsZero() {
+		st, et = metaData.TimeRange.Start, metaData.TimeRange.End
 	}
 
 	helper := &trendInternalHelper{
@@ -114,7 +96,7 @@ func WordCloudHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, meta
 		return
 	}
 	wordCloud := vadvisor.NewWordCloudChartsGraphWithPlayer[string, int]()
-	for _, bucket := range wc.Dimension.Dimension.Dimension.Buckets {
+	for _, bucket := range wc.Dimension.Filtered.Top.Buckets {
 		wordCloud.AddData("user_name",
 			&vadvisor.ValueUnit[string, int]{
 				XField:      bucket.Key,
@@ -124,6 +106,20 @@ func WordCloudHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, meta
 	}
 	wordCloud.Build(ctx)
 
+	result := &WordCloudResult{StartTime: st.Format(time.DateTime), EndTime: et.Format(time.DateTime)}
+	for _, bucket := range wc.Dimension.Filtered.Top.Buckets {
+		result.Words = append(result.Words, WordFreq{Word: bucket.Key, Count: bucket.DocCount})
+	}
+	for _, item := range userList {
+		if len(item.User) > 0 {
+			result.TopUsers = append(result.TopUsers, UserMsgCount{UserID: item.User[0].ID, MsgCnt: item.MsgCnt})
+		}
+	}
+	for _, chunk := range chunks {
+		result.Summaries = append(result.Summaries, chunk.ChunkLog.Summary)
+	}
+	metaData.SetResult(result)
+
 	tpl := larktpl.GetTemplateV2[larktpl.WordCountCardVars[xmodel.MessageChunkLogV3]](ctx, larktpl.WordCountTemplate)
 	cardVar := &larktpl.WordCountCardVars[xmodel.MessageChunkLogV3]{
 		UserList:  userList,
@@ -147,20 +143,7 @@ func WordCloudHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, meta
 }
 
 type WordCountType struct {
-	Dimension struct {
-		DocCount  int `json:"doc_count"`
-		Dimension struct {
-			DocCount  int `json:"doc_count"`
-			Dimension struct {
-				DocCountErrorUpperBound int `json:"doc_count_error_upper_bound"`
-				SumOtherDocCount        int `json:"sum_other_doc_count"`
-				Buckets                 []struct {
-					Key      string `json:"key"`
-					DocCount int    `json:"doc_count"`
-				} `json:"buckets"`
-			} `json:"dimension"`
-		} `json:"dimension"`
-	} `json:"dimension"`
+	Dimension history.TopWords `json:"dimension"`
 }
 
 // Style 定义了每个意图的展示风格，包括短语和颜色。
@@ -332,42 +315,6 @@ func genHotRate(ctx context.Context, helper *trendInternalHelper, top int) (user
 }
 
 func genWordCount(ctx context.Context, chatID string, st, et time.Time) (wc WordCountType, err error) {
-	// 统计用户发送的
-	tagsToInclude := []interface{}{
-		"n", "nr", "ns", "nt", "nz",
-		"v", "vd", "vn",
-		"a", "ad", "an",
-		"i", "l",
-	}
-	// 1. 构建最内层的聚合：统计词频 (word_counts)
-	// 这是一个 terms aggregation
-	wordCountsAgg := osquery.TermsAgg("dimension", "raw_message_jieba_tag.word").Size(100) // 返回前 50 个
-
-	// 2. 构建中间层的聚合：根据词性进行过滤 (filtered_tags)
-	// 这是一个 filter aggregation
-	filteredTagsAgg := osquery.FilterAgg(
-		"dimension",
-		osquery.Bool().Must(
-			osquery.Terms("raw_message_jieba_tag.tag", tagsToInclude...),
-			osquery.CustomAgg("script", map[string]any{
-				"script": map[string]any{
-					"script": map[string]any{
-						"source": "doc['raw_message_jieba_tag.word'].value.length() > 1",
-						"lang":   "painless",
-					},
-				},
-			}),
-		),
-	).Aggs(wordCountsAgg)
-
-	// 3. 构建最外层的聚合：处理嵌套字段 (word_cloud_tags)
-	// 这是一个 nested aggregation
-	wordCloudTagsAgg := osquery.NestedAgg(
-		"dimension",
-		"raw_message_jieba_tag",
-	).Aggs(filteredTagsAgg)
-
-	// 4. 构建最终的查询对象
 	query := osquery.Query(osquery.Bool().
 		Must(
 			osquery.Term("chat_id", chatID),
@@ -376,7 +323,7 @@ func genWordCount(ctx context.Context, chatID string, st, et time.Time) (wc Word
 				Lte(et.Format(time.RFC3339)),
 		)).
 		Size(0). // 设置 size 为 0，表示不返回任何文档，只关心聚合结果
-		Aggs(wordCloudTagsAgg)
+		Aggs(history.TopWordsAgg("dimension", 100))
 
 	rawQuery, err := query.MarshalJSON()
 	if err != nil {
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"strconv"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
@@ -10,54 +9,42 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larktpl"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
-	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
-	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 	"go.opentelemetry.io/otel/attribute"
-	"go.uber.org/zap"
 	"gorm.io/gorm/clause"
 )
 
+// WordAddArgs /word add 的参数
+type WordAddArgs struct {
+	Word string `arg:"word" help:"复读词" required:"true"`
+	Rate int64  `arg:"rate" help:"复读概率, 单位 %" required:"true" min:"0" max:"100"`
+}
+
 // WordAddHandler to be filled
 //
 //	@param ctx context.Context
 //	@param data *larkim.P2MessageReceiveV1
-//	@param args ...string
+//	@param args *WordAddArgs
 //	@return error
 //	@author heyuhengmatt
 //	@update 2024-08-06 08:27:09
-func WordAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) (err error) {
+func WordAddHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args *WordAddArgs) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	if len(args) < 2 {
-		return errors.ErrUnsupported
-	}
-	argMap, _ := parseArgs(args...)
-	logs.L().Ctx(ctx).Info("args", zap.Any("args", argMap))
-
-	word, ok := argMap["word"]
-	if !ok {
-		return errors.New("word is required")
-	}
-	rate, ok := argMap["rate"]
-	if !ok {
-		return errors.New("rate is required")
-	}
-
 	ChatID := *data.Event.Message.ChatId
 	return query.Q.RepeatWordsRateCustom.WithContext(ctx).Clauses(clause.OnConflict{
 		UpdateAll: true,
 	}).Create(&model.RepeatWordsRateCustom{
 		GuildID: ChatID,
-		Word:    word,
-		Rate:    int64(utils.MustAtoI(rate)),
+		Word:    args.Word,
+		Rate:    args.Rate,
 	})
 }
 
@@ -74,8 +61,6 @@ func WordGetHandler(ctx context.Context, data *larkim.P2MessageReceiveV1, metaDa
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(data)))
 	defer span.End()
 	defer func() { span.RecordError(err) }()
-	argMap, _ := parseArgs(args...)
-	logs.L().Ctx(ctx).Info("args", zap.Any("args", argMap))
 	ChatID := *data.Event.Message.ChatId
 
 	lines := make([]map[string]string, 0)
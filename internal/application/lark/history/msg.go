@@ -329,7 +329,7 @@ func FilterMessage(hits []opensearchapi.SearchHit) (msgList []*OpensearchMsgLog)
 				}
 				fallthrough
 			default:
-				content := strings.ReplaceAll(msgItem.Content, "\n", "<换行>")
+				content := strings.ReplaceAll(msgItem.PlainText(), "\n", "<换行>")
 				if strings.TrimSpace(content) != "" {
 					tmpList = append(tmpList, content)
 				}
@@ -8,12 +8,13 @@ import (
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/timezone"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/opensearch"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/go_utils/reflecting"
-	"github.com/bytedance/gg/gresult"
 	"github.com/bytedance/sonic"
 	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/model"
 	"github.com/yanyiwu/gojieba"
@@ -39,8 +40,34 @@ type HybridSearchRequest struct {
 	TopK      int      `json:"top_k"`
 	UserID    string   `json:"user_id,omitempty"`
 	ChatID    string   `json:"chat_id,omitempty"`
-	StartTime string   `json:"start_time,omitempty"`
-	EndTime   string   `json:"end_time,omitempty"`
+	// StartTime、EndTime 起止时间, 可以是 "2006-01-02 15:04:05" 或表达式(分别取其起点与终点), 见 xtime.Parse
+	StartTime string `json:"start_time,omitempty"`
+	EndTime   string `json:"end_time,omitempty"`
+}
+
+// Range 按 loc 解析请求中的起止时间, 未指定的一端为零值
+//
+//	@param now time.Time
+//	@param loc *time.Location
+//	@return r xtime.Range
+//	@return err error
+func (req HybridSearchRequest) Range(now time.Time, loc *time.Location) (r xtime.Range, err error) {
+	opt := xtime.Options{Loc: loc, Now: now}
+	if req.StartTime != "" {
+		st, err := xtime.Parse(req.StartTime, opt)
+		if err != nil {
+			return xtime.Range{}, fmt.Errorf("start_time: %w", err)
+		}
+		r.Start = st.Start
+	}
+	if req.EndTime != "" {
+		et, err := xtime.Parse(req.EndTime, opt)
+		if err != nil {
+			return xtime.Range{}, fmt.Errorf("end_time: %w", err)
+		}
+		r.End = et.End
+	}
+	return r, nil
 }
 
 type EmbeddingFunc func(ctx context.Context, text string) (vector []float32, tokenUsage model.Usage, err error)
@@ -66,19 +93,15 @@ func HybridSearch(ctx context.Context, req HybridSearchRequest, embeddingFunc Em
 		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"chat_id": req.ChatID}})
 	}
 
-	if req.StartTime != "" {
-		if parseStartTime := parseTimeFormat(req.StartTime, time.DateTime); !parseStartTime.IsErr() {
-			filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"create_time_v2": map[string]interface{}{"gte": parseStartTime.Value().Format(time.RFC3339)}}})
-		} else {
-			filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"create_time_v2": map[string]interface{}{"gte": time.Now().Add(-1 * time.Hour * 24 * 7).Format(time.RFC3339)}}})
-		}
+	timeRange, err := req.Range(time.Now(), timezone.For(ctx, req.ChatID))
+	if err != nil {
+		return nil, err
 	}
-	if req.EndTime != "" {
-		if parseEndTime := parseTimeFormat(req.EndTime, time.DateTime); !parseEndTime.IsErr() {
-			filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"create_time_v2": map[string]interface{}{"lte": parseEndTime.Value().Format(time.RFC3339)}}})
-		} else {
-			filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"create_time_v2": map[string]interface{}{"lte": time.Now().Add(-1 * time.Hour * 24 * 7).Format(time.RFC3339)}}})
-		}
+	if !timeRange.Start.IsZero() {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"create_time_v2": map[string]interface{}{"gte": timeRange.Start.Format(time.RFC3339)}}})
+	}
+	if !timeRange.End.IsZero() {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"create_time_v2": map[string]interface{}{"lt": timeRange.End.Format(time.RFC3339)}}})
 	}
 
 	queryTerms := make([]string, 0)
@@ -160,7 +183,3 @@ func HybridSearch(ctx context.Context, req HybridSearchRequest, embeddingFunc Em
 
 	return resultList, nil
 }
-
-func parseTimeFormat(s, fmt string) gresult.R[time.Time] {
-	return gresult.Of(time.Parse(fmt, s))
-}
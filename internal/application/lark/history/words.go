@@ -0,0 +1,60 @@
+package history
+
+import "github.com/defensestation/osquery"
+
+// WordTags 词云与常用词统计的词性: 名词、动词、形容词与成语习语
+var WordTags = []any{
+	"n", "nr", "ns", "nt", "nz",
+	"v", "vd", "vn",
+	"a", "ad", "an",
+	"i", "l",
+}
+
+// TopWordsAgg 消息分词的词频聚合, 只统计给定词性且长度大于 1 的词, 结果用 TopWords 解析
+//
+//	@param name string 聚合名
+//	@param size uint64 返回的词数
+//	@param tags ...any 参与统计的词性, 为空时使用 WordTags
+//	@return osquery.Aggregation
+func TopWordsAgg(name string, size uint64, tags ...any) osquery.Aggregation {
+	if len(tags) == 0 {
+		tags = WordTags
+	}
+	return osquery.NestedAgg(name, "raw_message_jieba_tag").Aggs(
+		osquery.FilterAgg("filtered", osquery.Bool().Must(
+			osquery.Terms("raw_message_jieba_tag.tag", tags...),
+			osquery.CustomAgg("script", map[string]any{
+				"script": map[string]any{
+					"script": map[string]any{
+						"source": "doc['raw_message_jieba_tag.word'].value.length() > 1",
+						"lang":   "painless",
+					},
+				},
+			}),
+		)).Aggs(osquery.TermsAgg("top", "raw_message_jieba_tag.word").Size(size)),
+	)
+}
+
+// TopWords TopWordsAgg 的聚合结果
+type TopWords struct {
+	Filtered struct {
+		Top struct {
+			Buckets []WordBucket `json:"buckets"`
+		} `json:"top"`
+	} `json:"filtered"`
+}
+
+// WordBucket 一个词及其出现的次数
+type WordBucket struct {
+	Key      string `json:"key"`
+	DocCount int    `json:"doc_count"`
+}
+
+// Words 按出现次数从多到少的词
+func (w *TopWords) Words() []string {
+	res := make([]string, 0, len(w.Filtered.Top.Buckets))
+	for _, b := range w.Filtered.Top.Buckets {
+		res = append(res, b.Key)
+	}
+	return res
+}
@@ -0,0 +1,49 @@
+package history
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestTopWordsAgg(t *testing.T) {
+	agg := TopWordsAgg("words", 5, "n")
+	raw, err := sonic.Marshal(agg.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := struct {
+		Nested struct {
+			Path string `json:"path"`
+		} `json:"nested"`
+		Aggs struct {
+			Filtered struct {
+				Aggs struct {
+					Top struct {
+						Terms struct {
+							Field string `json:"field"`
+							Size  int    `json:"size"`
+						} `json:"terms"`
+					} `json:"top"`
+				} `json:"aggs"`
+			} `json:"filtered"`
+		} `json:"aggs"`
+	}{}
+	if err = sonic.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+	terms := spec.Aggs.Filtered.Aggs.Top.Terms
+	if agg.Name() != "words" || spec.Nested.Path != "raw_message_jieba_tag" || terms.Field != "raw_message_jieba_tag.word" || terms.Size != 5 {
+		t.Errorf("unexpected aggregation %s", raw)
+	}
+
+	resp := `{"doc_count": 9, "filtered": {"doc_count": 4, "top": {"buckets": [{"key": "火锅", "doc_count": 3}, {"key": "周末", "doc_count": 1}]}}}`
+	words := &TopWords{}
+	if err = sonic.UnmarshalString(resp, words); err != nil {
+		t.Fatal(err)
+	}
+	if got := words.Words(); !slices.Equal(got, []string{"火锅", "周末"}) {
+		t.Errorf("Words() = %v", got)
+	}
+}
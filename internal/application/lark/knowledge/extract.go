@@ -0,0 +1,213 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-resty/resty/v2"
+	"github.com/tmc/langchaingo/documentloaders"
+	"github.com/tmc/langchaingo/textsplitter"
+)
+
+const (
+	// maxSourceBytes 单个链接/文件的大小上限
+	maxSourceBytes = 10 << 20
+	// maxRedirects 抓取链接时最多跟随的重定向次数
+	maxRedirects = 5
+	// maxPassages 单个来源最多索引的段落数
+	maxPassages    = 200
+	passageSize    = 500
+	passageOverlap = 50
+)
+
+var (
+	errUnsupported    = errors.New("unsupported content type")
+	errPrivateAddress = errors.New("non-public address")
+	// cgnat 运营商级 NAT 地址段, 云上常用作内部网络
+	cgnat      = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+	urlPattern = regexp.MustCompile(`https?://[^\s<>"'\x{3000}-\x{303F}\x{FF00}-\x{FFEF}]+`)
+	// skipHosts 需要登录态的站点, 抓到的只是登录页
+	skipHosts = []string{"feishu.cn", "larksuite.com", "larkoffice.com"}
+	// textExts 按纯文本处理的附件
+	textExts = map[string]bool{".txt": true, ".md": true, ".csv": true, ".json": true, ".log": true, ".yaml": true, ".yml": true}
+)
+
+// document 抽取出的可读文本
+type document struct {
+	Title string
+	Text  string
+}
+
+// extractURLs 从文本中提取可抓取的链接, 去重并保持顺序
+func extractURLs(text string) []string {
+	res := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, raw := range urlPattern.FindAllString(text, -1) {
+		raw = strings.TrimRight(raw, ".,;:!?)]}>")
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" || seen[raw] || isSkippedHost(u.Hostname()) {
+			continue
+		}
+		seen[raw] = true
+		res = append(res, raw)
+	}
+	return res
+}
+
+func isSkippedHost(host string) bool {
+	for _, h := range skipHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicIP 是否为公网地址, 内网、回环、链路本地、CGNAT 等地址都不允许抓取
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !cgnat.Contains(ip)
+}
+
+// dialControl 在建立连接前检查实际要连接的 IP, 解析结果在检查后变化(DNS rebinding)或重定向到内网时同样会被拒绝
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
+
+// fetchClient 抓取分享链接的客户端: 不走代理, 每次建连检查目标 IP, 重定向只允许 http/https 且不超过 maxRedirects 次
+var fetchClient = sync.OnceValue(func() *resty.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: dialControl}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: fetchTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       time.Minute,
+	}
+	return resty.NewWithClient(&http.Client{Transport: transport}).SetRedirectPolicy(
+		resty.FlexibleRedirectPolicy(maxRedirects),
+		resty.RedirectPolicyFunc(func(req *http.Request, _ []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			if isSkippedHost(req.URL.Hostname()) {
+				return fmt.Errorf("redirect to skipped host %s", req.URL.Hostname())
+			}
+			return nil
+		}),
+	)
+})
+
+// extract 根据 content-type 或文件名抽取文本, 支持 html、pdf 与纯文本
+func extract(ctx context.Context, data []byte, contentType, name string) (*document, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml" || ext == ".html" || ext == ".htm":
+		return extractHTML(data)
+	case mediaType == "application/pdf" || ext == ".pdf":
+		return extractPDF(ctx, data)
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || textExts[ext]:
+		if !utf8.Valid(data) {
+			return nil, errUnsupported
+		}
+		return &document{Title: name, Text: string(data)}, nil
+	}
+	return nil, errUnsupported
+}
+
+func extractHTML(data []byte) (*document, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	doc.Find("script, style, noscript, nav, header, footer, iframe, svg").Remove()
+	title := strings.TrimSpace(doc.Find("title").First().Text())
+	if og, ok := doc.Find(`meta[property="og:title"]`).Attr("content"); ok && strings.TrimSpace(og) != "" {
+		title = strings.TrimSpace(og)
+	}
+	// 块级元素之间补换行, 否则相邻段落的文本会粘在一起
+	doc.Find("p, div, li, br, tr, pre, blockquote, h1, h2, h3, h4, h5, h6").AfterHtml("\n")
+	root := doc.Find("article").First()
+	if root.Length() == 0 {
+		root = doc.Find("body")
+	}
+	lines := make([]string, 0)
+	for line := range strings.SplitSeq(root.Text(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return &document{Title: title, Text: strings.Join(lines, "\n")}, nil
+}
+
+func extractPDF(ctx context.Context, data []byte) (*document, error) {
+	pages, err := documentloaders.NewPDF(bytes.NewReader(data), int64(len(data))).Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sb := strings.Builder{}
+	for _, page := range pages {
+		sb.WriteString(page.PageContent)
+		sb.WriteString("\n")
+	}
+	return &document{Text: sb.String()}, nil
+}
+
+// split 按段落切分, 超出 maxPassages 的部分丢弃
+func split(text string) ([]string, error) {
+	splitter := textsplitter.NewRecursiveCharacter(
+		textsplitter.WithChunkSize(passageSize),
+		textsplitter.WithChunkOverlap(passageOverlap),
+		textsplitter.WithLenFunc(utf8.RuneCountInString),
+		textsplitter.WithSeparators([]string{"\n\n", "\n", "。", "！", "？", ". ", " ", ""}),
+	)
+	passages, err := splitter.SplitText(text)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(passages))
+	for _, p := range passages {
+		if p = strings.TrimSpace(p); p != "" {
+			res = append(res, p)
+		}
+		if len(res) >= maxPassages {
+			break
+		}
+	}
+	return res, nil
+}
+
+// readLimited 读取不超过 maxSourceBytes 的内容
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSourceBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSourceBytes {
+		return nil, fmt.Errorf("content exceeds %d bytes", maxSourceBytes)
+	}
+	return data, nil
+}
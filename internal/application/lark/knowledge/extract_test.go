@@ -0,0 +1,93 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+func TestExtractURLs(t *testing.T) {
+	got := extractURLs("看看这个 https://example.com/a?b=1, 还有（https://example.com/a?b=1）和 https://xx.feishu.cn/docx/abc 以及 http://go.dev/doc.")
+	want := []string{"https://example.com/a?b=1", "http://go.dev/doc"}
+	if !slices.Equal(got, want) {
+		t.Errorf("extractURLs() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractHTML(t *testing.T) {
+	html := `<html><head><title>标题</title><script>var a = 1;</script></head>
+<body><nav>导航</nav><article><h1>正文标题</h1>
+<p>第一段   内容</p><style>.x{}</style><p>第二段</p></article><footer>页脚</footer></body></html>`
+	doc, err := extract(context.Background(), []byte(html), "text/html; charset=utf-8", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "标题" {
+		t.Errorf("title = %q", doc.Title)
+	}
+	if doc.Text != "正文标题\n第一段 内容\n第二段" {
+		t.Errorf("text = %q", doc.Text)
+	}
+}
+
+func TestExtractUnsupported(t *testing.T) {
+	_, err := extract(context.Background(), []byte{0x89, 0x50}, "image/png", "a.png")
+	if !errors.Is(err, errUnsupported) {
+		t.Errorf("expected errUnsupported, got %v", err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	text := strings.Repeat("这是一个用于测试切分的句子。", 200)
+	passages, err := split(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passages) < 2 {
+		t.Fatalf("expected multiple passages, got %d", len(passages))
+	}
+	for _, p := range passages {
+		if n := utf8.RuneCountInString(p); n > passageSize {
+			t.Errorf("passage too long: %d", n)
+		}
+	}
+}
+
+func TestSourcesPostLink(t *testing.T) {
+	content := `{"title":"","content":[[{"tag":"text","text":"看看 "},{"tag":"a","text":"这篇","href":"https://example.com/post"}]]}`
+	msgType := larkim.MsgTypePost
+	got := sources(&larkim.EventMessage{MessageType: &msgType, Content: &content})
+	if len(got) != 1 || got[0].Source != "https://example.com/post" {
+		t.Errorf("sources(post) = %+v", got)
+	}
+}
+
+func TestFetchRejectsPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer srv.Close()
+	if _, err := fetchURL(context.Background(), srv.URL); !errors.Is(err, errPrivateAddress) {
+		t.Errorf("fetchURL(%s) error = %v, want errPrivateAddress", srv.URL, err)
+	}
+
+	for addr, wantErr := range map[string]bool{
+		"127.0.0.1:80":       true,
+		"10.0.0.1:443":       true,
+		"169.254.169.254:80": true,
+		"100.64.1.1:80":      true,
+		"[::1]:443":          true,
+		"93.184.216.34:443":  false,
+	} {
+		if err := dialControl("tcp", addr, nil); (err != nil) != wantErr {
+			t.Errorf("dialControl(%s) = %v, want error %v", addr, err, wantErr)
+		}
+	}
+}
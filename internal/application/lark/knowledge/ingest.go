@@ -0,0 +1,218 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkimg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larkcontent"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkuser"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/opensearch"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	SourceTypeURL  = "url"
+	SourceTypeFile = "file"
+
+	fetchTimeout = 20 * time.Second
+	// maxSourcesPerMsg 单条消息最多收录的链接数
+	maxSourcesPerMsg = 3
+)
+
+type fileContent struct {
+	FileKey  string `json:"file_key"`
+	FileName string `json:"file_name"`
+}
+
+// source 待收录的来源
+type source struct {
+	Type    string
+	Source  string // 链接或文件名
+	FileKey string
+}
+
+// sources 提取消息中可以收录的链接和文件
+//
+//	@param msg *larkim.EventMessage
+//	@return []source
+func sources(msg *larkim.EventMessage) []source {
+	msgType := utils.AddrOrNil(msg.MessageType)
+	content := utils.AddrOrNil(msg.Content)
+	res := make([]source, 0)
+	if msgType == larkim.MsgTypeFile {
+		file := &fileContent{}
+		if err := sonic.UnmarshalString(content, file); err == nil && file.FileKey != "" {
+			res = append(res, source{Type: SourceTypeFile, Source: file.FileName, FileKey: file.FileKey})
+		}
+		return res
+	}
+	if msgType != larkim.MsgTypeText && msgType != larkim.MsgTypePost {
+		return res
+	}
+	// 富文本中的链接渲染为 "文字(链接)", 直接从纯文本中提取
+	for _, u := range extractURLs(larkcontent.PlainText(msgType, content)) {
+		if len(res) >= maxSourcesPerMsg {
+			break
+		}
+		res = append(res, source{Type: SourceTypeURL, Source: u})
+	}
+	return res
+}
+
+// HasSource 消息中是否包含可收录的链接或文件
+func HasSource(msg *larkim.EventMessage) bool {
+	return len(sources(msg)) > 0
+}
+
+// sourceID 同一个群内同一来源只保留一份
+func sourceID(chatID, src string) string {
+	sum := sha1.Sum([]byte(chatID + "\x00" + src))
+	return hex.EncodeToString(sum[:])
+}
+
+// IngestMessage 收录消息中分享的链接与文件到群知识库
+//
+//	单个来源失败不影响其他来源
+//	@param ctx context.Context
+//	@param event *larkim.P2MessageReceiveV1
+func IngestMessage(ctx context.Context, event *larkim.P2MessageReceiveV1) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	if config.Get().OpensearchConfig.LarkKnowledgeIndex == "" {
+		return
+	}
+	msg := event.Event.Message
+	for _, src := range sources(msg) {
+		if err := ingest(ctx, event, src); err != nil {
+			logs.L().Ctx(ctx).Warn("ingest knowledge source failed", zap.String("source", src.Source), zap.Error(err))
+		}
+	}
+}
+
+func ingest(ctx context.Context, event *larkim.P2MessageReceiveV1, src source) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("source").String(src.Source))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	msg := event.Event.Message
+	var doc *document
+	switch src.Type {
+	case SourceTypeURL:
+		doc, err = fetchURL(ctx, src.Source)
+	case SourceTypeFile:
+		doc, err = fetchFile(ctx, utils.AddrOrNil(msg.MessageId), src)
+	}
+	if err != nil {
+		return err
+	}
+	if doc.Title == "" {
+		doc.Title = src.Source
+	}
+	passages, err := split(doc.Text)
+	if err != nil {
+		return err
+	}
+	if len(passages) == 0 {
+		return errors.New("no readable text")
+	}
+
+	chatID := utils.AddrOrNil(msg.ChatId)
+	userID := utils.AddrOrNil(event.Event.Sender.SenderId.OpenId)
+	userName := ""
+	if userInfo, err := larkuser.GetUserInfoCache(ctx, chatID, userID); err == nil && userInfo != nil {
+		userName = utils.AddrOrNil(userInfo.Name)
+	}
+	srcID := sourceID(chatID, src.Source)
+	// 重复分享时覆盖旧内容
+	if _, err := Forget(ctx, chatID, srcID); err != nil {
+		logs.L().Ctx(ctx).Warn("forget old passages failed", zap.String("source_id", srcID), zap.Error(err))
+	}
+	createTime := time.Now().In(utils.UTC8Loc()).Format(time.RFC3339)
+	index := config.Get().OpensearchConfig.LarkKnowledgeIndex
+	for seq, passage := range passages {
+		embedded, _, err := ark_dal.EmbeddingText(ctx, doc.Title+"\n"+passage)
+		if err != nil {
+			return err
+		}
+		if err := ensureTemplate(ctx, len(embedded)); err != nil {
+			return fmt.Errorf("put knowledge index template: %w", err)
+		}
+		err = opensearch.InsertData(ctx, index, fmt.Sprintf("%s-%d", srcID, seq), &xmodel.KnowledgePassage{
+			ChatID:     chatID,
+			SourceID:   srcID,
+			SourceType: src.Type,
+			Source:     src.Source,
+			Title:      doc.Title,
+			MessageID:  utils.AddrOrNil(msg.MessageId),
+			UserID:     userID,
+			UserName:   userName,
+			Seq:        seq,
+			Content:    passage,
+			Embedding:  embedded,
+			CreateTime: createTime,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	logs.L().Ctx(ctx).Info("knowledge source ingested", zap.String("source", src.Source), zap.Int("passages", len(passages)))
+	return nil
+}
+
+func fetchURL(ctx context.Context, rawURL string) (*document, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	resp, err := fetchClient().R().SetContext(ctx).SetDoNotParseResponse(true).Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	body := resp.RawBody()
+	defer body.Close()
+	if resp.IsError() {
+		return nil, fmt.Errorf("fetch %s: status %d", rawURL, resp.StatusCode())
+	}
+	data, err := readLimited(body)
+	if err != nil {
+		return nil, err
+	}
+	return extract(ctx, data, resp.Header().Get("Content-Type"), u.Path)
+}
+
+func fetchFile(ctx context.Context, msgID string, src source) (*document, error) {
+	file, err := larkimg.GetMsgImages(ctx, msgID, src.FileKey, larkim.MsgTypeFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readLimited(file)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := extract(ctx, data, "", src.Source)
+	if err != nil {
+		return nil, err
+	}
+	doc.Title = src.Source
+	return doc, nil
+}
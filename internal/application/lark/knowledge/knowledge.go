@@ -0,0 +1,254 @@
+// Package knowledge 群知识库: 收录群里分享的链接与文件, 切分为段落并向量化, 供对话与检索引用
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/opensearch"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	"go.uber.org/zap"
+)
+
+// Passage 检索命中的段落
+type Passage struct {
+	*xmodel.KnowledgePassage
+	Score float64 `json:"score"`
+}
+
+// Citation 引用标记, 如 《标题》(https://...), 对话与 /kb search 的回答都以此标注来源
+func (p *Passage) Citation() string {
+	if p.Title == p.Source {
+		return fmt.Sprintf("《%s》", p.Title)
+	}
+	return fmt.Sprintf("《%s》(%s)", p.Title, p.Source)
+}
+
+// SourceSummary 知识库中的一个来源
+type SourceSummary struct {
+	SourceID   string `json:"source_id"`
+	SourceType string `json:"source_type"`
+	Source     string `json:"source"`
+	Title      string `json:"title"`
+	UserName   string `json:"user_name"`
+	CreateTime string `json:"create_time"`
+	Passages   int    `json:"passages"`
+}
+
+func index() string {
+	return config.Get().OpensearchConfig.LarkKnowledgeIndex
+}
+
+// templateReady 本进程是否已写入索引模板
+var templateReady atomic.Bool
+
+// ensureTemplate 写入知识库索引模板: 段落按天写入 <index>-yyyy-mm-dd, 模板为其声明 knn 向量映射,
+// 并挂上与配置同名的别名供检索与删除; 首次收录时按实际的向量维度写入, 失败时下次收录重试
+//
+//	@param ctx context.Context
+//	@param dimension int 向量维度
+//	@return error
+func ensureTemplate(ctx context.Context, dimension int) error {
+	if templateReady.Load() {
+		return nil
+	}
+	if err := opensearch.PutIndexTemplate(ctx, index(), templateBody(index(), dimension)); err != nil {
+		return err
+	}
+	templateReady.Store(true)
+	return nil
+}
+
+func templateBody(name string, dimension int) map[string]any {
+	keyword := map[string]any{"type": "keyword"}
+	return map[string]any{
+		"index_patterns": []string{name + "-*"},
+		"template": map[string]any{
+			"settings": map[string]any{"index": map[string]any{"knn": true}},
+			"aliases":  map[string]any{name: map[string]any{}},
+			"mappings": map[string]any{
+				"properties": map[string]any{
+					"chat_id":     keyword,
+					"source_id":   keyword,
+					"source_type": keyword,
+					"source":      keyword,
+					"message_id":  keyword,
+					"user_id":     keyword,
+					"user_name":   keyword,
+					"title":       map[string]any{"type": "text"},
+					"content":     map[string]any{"type": "text"},
+					"seq":         map[string]any{"type": "integer"},
+					"create_time": map[string]any{"type": "date"},
+					"embedding": map[string]any{
+						"type":      "knn_vector",
+						"dimension": dimension,
+						"method":    map[string]any{"name": "hnsw", "space_type": "cosinesimil", "engine": "lucene"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Enabled 是否配置了知识库索引
+func Enabled() bool {
+	return index() != ""
+}
+
+// SourceIDOf 来源在群内的ID
+//
+//	@param chatID string
+//	@param src string 链接或文件名
+//	@return string
+func SourceIDOf(chatID, src string) string {
+	return sourceID(chatID, src)
+}
+
+// Search 在群知识库中混合检索(向量+关键词)
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param query string
+//	@param k int
+//	@return passages []*Passage
+//	@return err error
+func Search(ctx context.Context, chatID, query string, k int) (passages []*Passage, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if !Enabled() || strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	vec, _, err := ark_dal.EmbeddingText(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	req := map[string]any{
+		"size":    k,
+		"_source": map[string]any{"excludes": []string{"embedding"}},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []map[string]any{{"term": map[string]any{"chat_id": chatID}}},
+				"should": []map[string]any{
+					{"knn": map[string]any{"embedding": map[string]any{"vector": vec, "k": k, "boost": 2.0}}},
+					{"match": map[string]any{"content": query}},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+	}
+	resp, err := opensearch.SearchData(ctx, index(), req)
+	if err != nil {
+		return nil, err
+	}
+	for _, hit := range resp.Hits.Hits {
+		p := &Passage{KnowledgePassage: &xmodel.KnowledgePassage{}, Score: float64(hit.Score)}
+		if err := sonic.Unmarshal(hit.Source, p.KnowledgePassage); err != nil {
+			logs.L().Ctx(ctx).Warn("unmarshal knowledge passage failed", zap.Error(err))
+			continue
+		}
+		passages = append(passages, p)
+	}
+	return passages, nil
+}
+
+type listAggs struct {
+	Sources struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			DocCount int    `json:"doc_count"`
+			Latest   struct {
+				Hits struct {
+					Hits []struct {
+						Source *SourceSummary `json:"_source"`
+					} `json:"hits"`
+				} `json:"hits"`
+			} `json:"latest"`
+		} `json:"buckets"`
+	} `json:"sources"`
+}
+
+// List 列出群知识库中的来源, 按收录时间倒序
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return res []*SourceSummary
+//	@return err error
+func List(ctx context.Context, chatID string) (res []*SourceSummary, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if !Enabled() {
+		return nil, nil
+	}
+	req := map[string]any{
+		"size":  0,
+		"query": map[string]any{"term": map[string]any{"chat_id": chatID}},
+		"aggs": map[string]any{
+			"sources": map[string]any{
+				"terms": map[string]any{"field": "source_id", "size": 50, "order": map[string]any{"latest_time": "desc"}},
+				"aggs": map[string]any{
+					"latest_time": map[string]any{"max": map[string]any{"field": "create_time"}},
+					"latest": map[string]any{"top_hits": map[string]any{
+						"size":    1,
+						"_source": []string{"source_id", "source_type", "source", "title", "user_name", "create_time"},
+					}},
+				},
+			},
+		},
+	}
+	resp, err := opensearch.SearchData(ctx, index(), req)
+	if err != nil {
+		return nil, err
+	}
+	aggs := &listAggs{}
+	if err = sonic.Unmarshal(resp.Aggregations, aggs); err != nil {
+		return nil, err
+	}
+	for _, bucket := range aggs.Sources.Buckets {
+		if len(bucket.Latest.Hits.Hits) == 0 || bucket.Latest.Hits.Hits[0].Source == nil {
+			continue
+		}
+		summary := bucket.Latest.Hits.Hits[0].Source
+		summary.Passages = bucket.DocCount
+		res = append(res, summary)
+	}
+	return res, nil
+}
+
+// Forget 删除群知识库中的一个来源
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param srcID string
+//	@return deleted int 删除的段落数
+//	@return err error
+func Forget(ctx context.Context, chatID, srcID string) (deleted int, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if !Enabled() {
+		return 0, nil
+	}
+	return opensearch.DeleteByQuery(ctx, index(), map[string]any{
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []map[string]any{
+					{"term": map[string]any{"chat_id": chatID}},
+					{"term": map[string]any{"source_id": srcID}},
+				},
+			},
+		},
+	})
+}
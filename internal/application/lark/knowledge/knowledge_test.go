@@ -0,0 +1,25 @@
+package knowledge
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTemplateBody(t *testing.T) {
+	body := templateBody("lark_knowledge", 1024)
+	if got := body["index_patterns"].([]string); !slices.Equal(got, []string{"lark_knowledge-*"}) {
+		t.Errorf("index_patterns = %v", got)
+	}
+	tpl := body["template"].(map[string]any)
+	if _, ok := tpl["aliases"].(map[string]any)["lark_knowledge"]; !ok {
+		t.Error("template should alias daily indices to the configured name")
+	}
+	props := tpl["mappings"].(map[string]any)["properties"].(map[string]any)
+	embedding := props["embedding"].(map[string]any)
+	if embedding["type"] != "knn_vector" || embedding["dimension"] != 1024 {
+		t.Errorf("embedding mapping = %v", embedding)
+	}
+	if props["chat_id"].(map[string]any)["type"] != "keyword" {
+		t.Errorf("chat_id mapping = %v", props["chat_id"])
+	}
+}
@@ -0,0 +1,154 @@
+// Package macro 群内自定义的命令宏: 一个名字对应一段命令脚本(如 "/stock gold; /oneword"),
+// 发送 /名字 时展开执行; 宏可以引用其他宏, 展开时检查循环与深度
+package macro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkchat"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxDepth 宏嵌套展开的最大层数
+	MaxDepth = 3
+	// MaxScriptLen 宏脚本的最大长度
+	MaxScriptLen = 500
+)
+
+var (
+	// ErrRecursion 宏直接或间接引用了自己, 或嵌套过深
+	ErrRecursion = errors.New("macro recursion")
+	// ErrPermission 只有创建者或群主可以修改、删除宏
+	ErrPermission = errors.New("only the creator or the chat owner can change this macro")
+
+	nameRe = regexp.MustCompile(`^[\p{Han}\w]{1,20}$`)
+)
+
+// ValidName 宏名只能由中文、字母、数字与下划线组成, 不超过 20 个字符
+func ValidName(name string) bool {
+	return nameRe.MatchString(name)
+}
+
+// Get 群内名为 name 的宏, 不存在时返回 nil
+func Get(ctx context.Context, chatID, name string) (m *model.ChatMacro, err error) {
+	ins := query.Q.ChatMacro
+	m, err = ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.Name.Eq(name)).Take()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// List 群内的全部宏, 按名称排序
+func List(ctx context.Context, chatID string) (macros []*model.ChatMacro, err error) {
+	ins := query.Q.ChatMacro
+	return ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID)).Order(ins.Name).Find()
+}
+
+// canModify 创建者与群主可以修改宏
+func canModify(ctx context.Context, m *model.ChatMacro, userID string) bool {
+	if m.CreatorID == userID {
+		return true
+	}
+	ownerID, err := larkchat.GetChatOwnerID(ctx, m.ChatID)
+	return err == nil && ownerID == userID
+}
+
+// Save 新增或覆盖宏, 覆盖他人创建的宏需要权限, 脚本需由调用方校验
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 操作者 open_id
+//	@param name string
+//	@param script string
+//	@return err error
+func Save(ctx context.Context, chatID, userID, name, script string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("name", name))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if !ValidName(name) {
+		return fmt.Errorf("invalid macro name %q", name)
+	}
+	if len(script) > MaxScriptLen {
+		return fmt.Errorf("macro script is longer than %d", MaxScriptLen)
+	}
+	old, err := Get(ctx, chatID, name)
+	if err != nil {
+		return err
+	}
+	if old != nil && !canModify(ctx, old, userID) {
+		return ErrPermission
+	}
+	ins := query.Q.ChatMacro
+	return ins.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chat_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"script", "creator_id", "updated_at"}),
+	}).Create(&model.ChatMacro{ChatID: chatID, Name: name, Script: script, CreatorID: userID})
+}
+
+// Delete 删除宏, 需要权限
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 操作者 open_id
+//	@param name string
+//	@return err error
+func Delete(ctx context.Context, chatID, userID, name string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("name", name))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	old, err := Get(ctx, chatID, name)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return fmt.Errorf("macro %q not found", name)
+	}
+	if !canModify(ctx, old, userID) {
+		return ErrPermission
+	}
+	ins := query.Q.ChatMacro
+	_, err = ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.Name.Eq(name)).Delete()
+	return err
+}
+
+type stackKey struct{}
+
+// Enter 进入宏 name 的展开, 返回记录了展开栈的 ctx; 宏已在栈中或超过 MaxDepth 时返回 ErrRecursion
+//
+//	@param ctx context.Context
+//	@param name string
+//	@return context.Context
+//	@return error
+func Enter(ctx context.Context, name string) (context.Context, error) {
+	stack, _ := ctx.Value(stackKey{}).([]string)
+	if slices.Contains(stack, name) {
+		return ctx, fmt.Errorf("%w: %s -> %s", ErrRecursion, strings.Join(stack, " -> "), name)
+	}
+	if len(stack) >= MaxDepth {
+		return ctx, fmt.Errorf("%w: nested deeper than %d", ErrRecursion, MaxDepth)
+	}
+	return context.WithValue(ctx, stackKey{}, append(slices.Clone(stack), name)), nil
+}
+
+// Depth 当前宏展开的层数, 不在宏中时为 0
+func Depth(ctx context.Context) int {
+	stack, _ := ctx.Value(stackKey{}).([]string)
+	return len(stack)
+}
@@ -0,0 +1,30 @@
+package macro
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEnter(t *testing.T) {
+	ctx, err := Enter(context.Background(), "morning")
+	if err != nil || Depth(ctx) != 1 {
+		t.Fatalf("Enter(morning) = %v, depth %d", err, Depth(ctx))
+	}
+	if _, err := Enter(ctx, "morning"); !errors.Is(err, ErrRecursion) {
+		t.Errorf("Enter(morning) again = %v, want ErrRecursion", err)
+	}
+	ctx, _ = Enter(ctx, "a")
+	ctx, _ = Enter(ctx, "b")
+	if _, err := Enter(ctx, "c"); !errors.Is(err, ErrRecursion) {
+		t.Errorf("Enter() beyond MaxDepth = %v, want ErrRecursion", err)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, want := range map[string]bool{"morning": true, "早安": true, "a_1": true, "": false, "a b": false, "/x": false} {
+		if got := ValidName(name); got != want {
+			t.Errorf("ValidName(%q) = %v", name, got)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"cmp"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/bytedance/sonic"
+)
+
+const (
+	factMinLen = 2
+	factMaxLen = 100
+)
+
+// Category 记忆的类别
+type Category string
+
+const (
+	CategoryPreference Category = "preference"
+	CategoryBirthday   Category = "birthday"
+	CategoryCommitment Category = "commitment"
+	CategoryProfile    Category = "profile"
+)
+
+var categoryLabels = map[Category]string{
+	CategoryPreference: "喜好",
+	CategoryBirthday:   "生日",
+	CategoryCommitment: "约定",
+	CategoryProfile:    "个人情况",
+}
+
+// Label 类别的中文名, 未知类别原样返回
+func (c Category) Label() string {
+	if label, ok := categoryLabels[c]; ok {
+		return label
+	}
+	return string(c)
+}
+
+// speakerPattern chunk 消息行的发言人, 格式见 larkchunking 的 BuildLine: [时间](open_id) <名字>: 内容
+var speakerPattern = regexp.MustCompile(`^\[[^\]]*\]\(([^)\s]+)\) <([^>]*)>:`)
+
+// Fact 从一段对话中抽取出的关于某位成员的事实
+type Fact struct {
+	UserID   string   `json:"user_id"`
+	UserName string   `json:"-"`
+	Category Category `json:"category"`
+	Fact     string   `json:"fact"`
+}
+
+// speakers 统计 chunk 中的发言人
+//
+//	@param lines []string chunk 的消息行
+//	@param exclude ...string 不产生记忆的发言人, 如机器人
+//	@return map[string]string open_id -> 名字
+func speakers(lines []string, exclude ...string) map[string]string {
+	res := make(map[string]string)
+	for _, line := range lines {
+		m := speakerPattern.FindStringSubmatch(line)
+		if m == nil || slices.Contains(exclude, m[1]) {
+			continue
+		}
+		res[m[1]] = m[2]
+	}
+	return res
+}
+
+// buildInput 拼接交给模型的对话内容, 附上 chunk 总结出的结论与计划便于识别约定
+func buildInput(chunk *xmodel.MessageChunkLogV3) string {
+	sb := &strings.Builder{}
+	if chunk.Summary != "" {
+		fmt.Fprintf(sb, "摘要: %s\n", chunk.Summary)
+	}
+	if o := chunk.Outcomes; o != nil {
+		for _, c := range o.ConclusionsOrAgreements {
+			fmt.Fprintf(sb, "结论: %s\n", c)
+		}
+		for _, p := range o.PlansAndSuggestions {
+			if p == nil {
+				continue
+			}
+			fmt.Fprintf(sb, "计划: %s", p.ActivityOrSuggestion)
+			if p.Proposer != nil {
+				fmt.Fprintf(sb, " (提出者 %s)", p.Proposer.Name)
+			}
+			if p.Timing != nil && p.Timing.NormalizedDate != "" {
+				fmt.Fprintf(sb, " (时间 %s)", p.Timing.NormalizedDate)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("聊天记录:\n")
+	sb.WriteString(strings.Join(chunk.MsgList, "\n"))
+	return sb.String()
+}
+
+// parseFacts 解析模型输出, 丢弃发言人不在对话中的、类别未知的和过长过短的事实
+//
+//	@param res string 模型输出的 JSON, 允许带 markdown 代码块
+//	@param who map[string]string 对话中的发言人, 见 speakers
+//	@return []*Fact
+//	@return error
+func parseFacts(res string, who map[string]string) ([]*Fact, error) {
+	res = strings.TrimSpace(res)
+	res = strings.TrimPrefix(strings.TrimSuffix(res, "```"), "```")
+	res = strings.TrimPrefix(res, "json")
+	out := struct {
+		Facts []*Fact `json:"facts"`
+	}{}
+	if err := sonic.UnmarshalString(res, &out); err != nil {
+		return nil, err
+	}
+	facts := make([]*Fact, 0, len(out.Facts))
+	seen := make(map[string]bool)
+	for _, f := range out.Facts {
+		if f == nil {
+			continue
+		}
+		name, ok := who[f.UserID]
+		if !ok {
+			continue
+		}
+		if _, ok := categoryLabels[f.Category]; !ok {
+			continue
+		}
+		f.Fact = strings.TrimSpace(f.Fact)
+		if n := utf8.RuneCountInString(f.Fact); n < factMinLen || n > factMaxLen {
+			continue
+		}
+		key := f.UserID + normalize(f.Fact)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		f.UserName = name
+		facts = append(facts, f)
+	}
+	return facts, nil
+}
+
+// normalize 去掉空白、标点与大小写差异, 用于判断两条事实是否相同
+func normalize(fact string) string {
+	sb := &strings.Builder{}
+	for _, r := range strings.ToLower(fact) {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// hashFact 同一个群里同一位成员的同一条事实只保留一行
+func hashFact(chatID, userID, fact string) string {
+	sum := sha1.Sum([]byte(chatID + "\x00" + userID + "\x00" + normalize(fact)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// pick 选出注入 prompt 的记忆: 过期的约定不再提起, 反复出现和最近出现的优先
+//
+//	@param rows []*model.UserMemory 同一位成员的记忆
+//	@param limit int
+//	@param commitmentTTL time.Duration
+//	@param now time.Time
+//	@return []*model.UserMemory
+func pick(rows []*model.UserMemory, limit int, commitmentTTL time.Duration, now time.Time) []*model.UserMemory {
+	res := make([]*model.UserMemory, 0, len(rows))
+	for _, row := range rows {
+		if row.DeletedAt.Valid {
+			continue
+		}
+		if Category(row.Category) == CategoryCommitment && now.Sub(row.LastSeenAt) > commitmentTTL {
+			continue
+		}
+		res = append(res, row)
+	}
+	slices.SortFunc(res, func(a, b *model.UserMemory) int {
+		if c := cmp.Compare(b.SeenCount, a.SeenCount); c != 0 {
+			return c
+		}
+		return b.LastSeenAt.Compare(a.LastSeenAt)
+	})
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// describe 渲染为注入 prompt 的一行
+func describe(row *model.UserMemory) string {
+	return fmt.Sprintf("[记忆] %s (%s, 记录于 %s)", row.Fact, Category(row.Category).Label(), row.LastSeenAt.Format(time.DateOnly))
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"gorm.io/gorm"
+)
+
+func TestSpeakers(t *testing.T) {
+	lines := []string{
+		"[2026-10-01 12:00:00](ou_a) <小明>: 我不吃香菜",
+		"[2026-10-01 12:00:05](cli_bot) <机器人>: 记住了",
+		"[2026-10-01 12:00:09](ou_b) <小红>: 周五我把照片发群里",
+		"没有格式的行",
+	}
+	got := speakers(lines, "cli_bot")
+	if len(got) != 2 || got["ou_a"] != "小明" || got["ou_b"] != "小红" {
+		t.Fatalf("speakers() = %v", got)
+	}
+}
+
+func TestParseFacts(t *testing.T) {
+	who := map[string]string{"ou_a": "小明"}
+	res := "```json\n" + `{"facts": [
+		{"user_id": "ou_a", "category": "preference", "fact": "小明不吃香菜"},
+		{"user_id": "ou_a", "category": "preference", "fact": "小明 不吃香菜。"},
+		{"user_id": "ou_x", "category": "profile", "fact": "小王在上海"},
+		{"user_id": "ou_a", "category": "mood", "fact": "小明今天很开心"},
+		{"user_id": "ou_a", "category": "birthday", "fact": "小明的生日是3月2日"}
+	]}` + "\n```"
+	facts, err := parseFacts(res, who)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(facts) != 2 {
+		t.Fatalf("parseFacts() = %d facts, want 2", len(facts))
+	}
+	if facts[0].UserName != "小明" || facts[1].Category != CategoryBirthday {
+		t.Fatalf("parseFacts() = %+v, %+v", facts[0], facts[1])
+	}
+}
+
+func TestHashFact(t *testing.T) {
+	if hashFact("oc_1", "ou_a", "小明不吃香菜") != hashFact("oc_1", "ou_a", " 小明 不吃香菜! ") {
+		t.Fatal("hash should ignore spaces and punctuation")
+	}
+	if hashFact("oc_1", "ou_a", "小明不吃香菜") == hashFact("oc_2", "ou_a", "小明不吃香菜") {
+		t.Fatal("hash should differ across chats")
+	}
+}
+
+func TestPick(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	rows := []*model.UserMemory{
+		{ID: 1, Category: string(CategoryPreference), SeenCount: 1, LastSeenAt: now.AddDate(0, 0, -100)},
+		{ID: 2, Category: string(CategoryCommitment), SeenCount: 5, LastSeenAt: now.AddDate(0, 0, -40)},
+		{ID: 3, Category: string(CategoryProfile), SeenCount: 3, LastSeenAt: now.AddDate(0, 0, -1)},
+		{ID: 4, Category: string(CategoryProfile), SeenCount: 9, DeletedAt: gorm.DeletedAt{Valid: true}},
+		{ID: 5, Category: string(CategoryCommitment), SeenCount: 1, LastSeenAt: now.AddDate(0, 0, -2)},
+	}
+	got := pick(rows, 2, 30*24*time.Hour, now)
+	if len(got) != 2 || got[0].ID != 3 || got[1].ID != 5 {
+		ids := make([]int64, 0, len(got))
+		for _, r := range got {
+			ids = append(ids, r.ID)
+		}
+		t.Fatalf("pick() = %v, want [3 5]", ids)
+	}
+}
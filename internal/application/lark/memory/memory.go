@@ -0,0 +1,262 @@
+// Package memory 成员长期记忆: chunk 总结入库后, 由模型从对话中抽取成员的喜好、生日、约定等长期事实,
+// 去重后存入 user_memories, 聊天时按发言人与被 @ 的成员召回注入 prompt; 成员可通过 /memory 查看与删除
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	larkchunking "github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/chunking"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xpool"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// extractWorkers 同时进行的记忆抽取数
+	extractWorkers = 2
+	// extractQueue 排队等待抽取的 chunk 上限, 超出时丢弃, 只影响记忆的完整性
+	extractQueue = 64
+)
+
+var ErrNotFound = errors.New("memory not found")
+
+const extractSysPrompt = `你是群聊记忆整理助手。阅读一段群聊记录, 找出关于发言成员本人的、长期有效的事实, 输出 JSON: {"facts": [{"user_id": "...", "category": "...", "fact": "..."}]}
+- user_id: 聊天记录中圆括号里的发言人 ID, 只能使用记录中出现过的 ID
+- category: preference(喜好、厌恶、习惯) / birthday(生日、纪念日) / commitment(答应要做的事或约定, 写明时间) / profile(职业、所在城市、宠物等个人情况) 之一
+- fact: 一句简短的中文陈述, 以成员名字开头, 如 "小明不吃香菜"、"小红答应周五前把照片发到群里"
+- 只记录成员自己说出或明确确认的事实, 不要推测, 不要记录玩笑、一时的情绪和一次性的闲聊
+- 机器人的发言不产生记忆
+- 没有值得记住的内容时输出 {"facts": []}
+只输出 JSON, 不要输出其他内容`
+
+type settings struct {
+	Disabled      bool
+	RecallLimit   int
+	CommitmentTTL time.Duration
+}
+
+func loadSettings() settings {
+	s := settings{RecallLimit: 5, CommitmentTTL: 30 * 24 * time.Hour}
+	c := config.Get().MemoryConfig
+	if c == nil {
+		return s
+	}
+	s.Disabled = c.Disabled
+	if c.RecallLimit > 0 {
+		s.RecallLimit = c.RecallLimit
+	}
+	if c.CommitmentDays > 0 {
+		s.CommitmentTTL = time.Duration(c.CommitmentDays) * 24 * time.Hour
+	}
+	return s
+}
+
+// extractPool 记忆抽取任务, 同一群按 chunk 顺序处理
+var extractPool *xpool.Pool
+
+// Init 在 chunk 写入索引后抽取记忆, 需在 larkchunking.Init 之后调用
+func Init() {
+	extractPool = xpool.New("memory_extract", extractWorkers, extractQueue, extractQueue)
+	larkchunking.M.OnIndexed(Remember)
+}
+
+// Remember 将 chunk 的记忆抽取放入队列后立即返回, 作为 xchunk.IndexedHook 注册
+//
+//	抽取需要调用模型, 不能阻塞 chunk 合并
+//	@param ctx context.Context
+//	@param chunk *xmodel.MessageChunkLogV3
+func Remember(ctx context.Context, chunk *xmodel.MessageChunkLogV3) {
+	if loadSettings().Disabled {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	done := xlifecycle.Default.Track()
+	err := extractPool.Submit(ctx, chunk.GroupID, func() {
+		defer done()
+		if _, err := remember(ctx, chunk); err != nil {
+			logs.L().Ctx(ctx).Warn("remember chunk failed", zap.String("chat_id", chunk.GroupID), zap.Error(err))
+		}
+	})
+	if err != nil {
+		done()
+		logs.L().Ctx(ctx).Warn("queue memory extraction failed", zap.String("chat_id", chunk.GroupID), zap.Error(err))
+	}
+}
+
+func remember(ctx context.Context, chunk *xmodel.MessageChunkLogV3) (saved int, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chunk.GroupID), attribute.String("chunk_id", chunk.ID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	who := speakers(chunk.MsgList, config.Get().LarkConfig.AppID, config.Get().LarkConfig.BotOpenID)
+	if len(who) == 0 {
+		return 0, nil
+	}
+	res, err := ark_dal.ResponseWithCache(ctx, extractSysPrompt, buildInput(chunk), config.Get().ArkConfig.ChunkModel)
+	if err != nil {
+		return 0, err
+	}
+	facts, err := parseFacts(res, who)
+	if err != nil {
+		return 0, err
+	}
+	saved, err = save(ctx, chunk.GroupID, chunk.ID, facts)
+	span.SetAttributes(attribute.Int("facts", len(facts)), attribute.Int("saved", saved))
+	return saved, err
+}
+
+// save 写入记忆, 已有的相同事实只刷新出现次数与时间, 被成员删除过的不再记录
+//
+//	@return saved 新增或刷新的条数
+func save(ctx context.Context, chatID, chunkID string, facts []*Fact) (saved int, err error) {
+	ins := query.Q.UserMemory
+	now := time.Now()
+	for _, f := range facts {
+		hash := hashFact(chatID, f.UserID, f.Fact)
+		// 已删除的记忆也要查出来, 避免被再次记录
+		rows, err := ins.WithContext(ctx).Unscoped().Where(ins.Hash.Eq(hash)).Find()
+		if err != nil {
+			return saved, err
+		}
+		if len(rows) > 0 {
+			if rows[0].DeletedAt.Valid {
+				continue
+			}
+			_, err := ins.WithContext(ctx).Where(ins.ID.Eq(rows[0].ID)).UpdateSimple(
+				ins.SeenCount.Add(1),
+				ins.LastSeenAt.Value(now),
+				ins.UserName.Value(f.UserName),
+				ins.SourceChunk.Value(chunkID),
+			)
+			if err != nil {
+				return saved, err
+			}
+			saved++
+			continue
+		}
+		err = ins.WithContext(ctx).Create(&model.UserMemory{
+			ChatID:      chatID,
+			UserID:      f.UserID,
+			UserName:    f.UserName,
+			Category:    string(f.Category),
+			Fact:        f.Fact,
+			Hash:        hash,
+			SourceChunk: chunkID,
+			SeenCount:   1,
+			LastSeenAt:  now,
+		})
+		if err != nil {
+			return saved, err
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// List 成员在群里的全部记忆, 最近出现的在前
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string
+//	@return []*model.UserMemory
+//	@return error
+func List(ctx context.Context, chatID, userID string) (res []*model.UserMemory, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	res, err = live(ctx, chatID, userID)
+	slices.SortFunc(res, func(a, b *model.UserMemory) int { return b.LastSeenAt.Compare(a.LastSeenAt) })
+	return res, err
+}
+
+// Recall 召回注入聊天 prompt 的记忆, 出错时只记录日志
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userIDs ...string 发言人与被 @ 的成员
+//	@return []string 渲染好的记忆行
+func Recall(ctx context.Context, chatID string, userIDs ...string) []string {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	s := loadSettings()
+	res := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		rows, err := live(ctx, chatID, userID)
+		if err != nil {
+			span.RecordError(err)
+			logs.L().Ctx(ctx).Warn("recall memories failed", zap.Error(err))
+			continue
+		}
+		for _, row := range pick(rows, s.RecallLimit, s.CommitmentTTL, time.Now()) {
+			res = append(res, describe(row))
+		}
+	}
+	span.SetAttributes(attribute.Int("recalled", len(res)))
+	return res
+}
+
+// Forget 删除成员自己的一条记忆, 删除后同样的事实不会再被记录
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string
+//	@param id int64 见 /memory list
+//	@return error 不是该成员的记忆时返回 ErrNotFound
+func Forget(ctx context.Context, chatID, userID string, id int64) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	// 软删除, 保留 hash 用于拦截再次抽取
+	ins := query.Q.UserMemory
+	info, err := ins.WithContext(ctx).Where(ins.ID.Eq(id), ins.ChatID.Eq(chatID), ins.UserID.Eq(userID)).Delete()
+	if err != nil {
+		return err
+	}
+	if info.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ForgetAll 删除成员在群里的全部记忆
+//
+//	@return n 删除的条数
+func ForgetAll(ctx context.Context, chatID, userID string) (n int, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	ins := query.Q.UserMemory
+	info, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.UserID.Eq(userID)).Delete()
+	if err != nil {
+		return 0, err
+	}
+	return int(info.RowsAffected), nil
+}
+
+// live 成员在群里未删除的记忆, 按 ID 排序
+func live(ctx context.Context, chatID, userID string) ([]*model.UserMemory, error) {
+	ins := query.Q.UserMemory
+	return ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.UserID.Eq(userID)).Order(ins.ID).Find()
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+)
+
+func TestSaveAndForget(t *testing.T) {
+	dbtest.Open(t, &model.UserMemory{})
+	ctx := context.Background()
+	facts := []*Fact{
+		{UserID: "ou_a", UserName: "小明", Category: CategoryPreference, Fact: "小明不吃香菜"},
+		{UserID: "ou_a", UserName: "小明", Category: CategoryProfile, Fact: "小明住在杭州"},
+	}
+	if saved, err := save(ctx, "chat_a", "c1", facts); err != nil || saved != 2 {
+		t.Fatalf("save() = %d, %v", saved, err)
+	}
+	if _, err := save(ctx, "chat_a", "c2", facts[:1]); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := List(ctx, "chat_a", "ou_a")
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("List() = %d rows, %v", len(rows), err)
+	}
+	if rows[0].Fact != "小明不吃香菜" || rows[0].SeenCount != 2 {
+		t.Fatalf("List()[0] = %+v, the repeated fact should be refreshed", rows[0])
+	}
+	if rows, _ := List(ctx, "chat_b", "ou_a"); len(rows) != 0 {
+		t.Fatalf("List(chat_b) = %d rows, want none", len(rows))
+	}
+
+	if err = Forget(ctx, "chat_a", "ou_b", rows[0].ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Forget() by another member = %v, want ErrNotFound", err)
+	}
+	if err = Forget(ctx, "chat_a", "ou_a", rows[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	// 删除过的事实不会再被记录
+	if saved, err := save(ctx, "chat_a", "c3", facts[:1]); err != nil || saved != 0 {
+		t.Fatalf("save() of a forgotten fact = %d, %v", saved, err)
+	}
+	if n, err := ForgetAll(ctx, "chat_a", "ou_a"); err != nil || n != 1 {
+		t.Fatalf("ForgetAll() = %d, %v", n, err)
+	}
+	if rows, _ := List(ctx, "chat_a", "ou_a"); len(rows) != 0 {
+		t.Fatalf("List() after ForgetAll = %d rows, want none", len(rows))
+	}
+}
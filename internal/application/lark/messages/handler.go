@@ -6,11 +6,14 @@ import (
 	"time"
 
 	larkchunking "github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/chunking"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/knowledge"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/messages/ops"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/vision"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkchat"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larkcontent"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkuser"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/opensearch"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
@@ -19,6 +22,7 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
 
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
@@ -27,8 +31,8 @@ import (
 	"go.uber.org/zap"
 )
 
-// Handler  消息处理器
-var Handler = &xhandler.Processor[larkim.P2MessageReceiveV1, xhandler.BaseMetaData]{}
+// Handler  消息处理流水线, 只读定义, 每个事件通过 Handler.Run 独立执行
+var Handler = xhandler.NewProcessor[larkim.P2MessageReceiveV1, xhandler.BaseMetaData]()
 
 type (
 	OpBase = xhandler.OperatorBase[larkim.P2MessageReceiveV1, xhandler.BaseMetaData]
@@ -40,7 +44,7 @@ func larkDeferFunc(ctx context.Context, err error, event *larkim.P2MessageReceiv
 }
 
 func CollectMessage(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData) {
-	go func() {
+	err := submitTracked(ctx, collectPool, utils.AddrOrNil(event.Event.Message.MessageId), func() {
 		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 		defer span.End()
 
@@ -74,6 +78,10 @@ func CollectMessage(ctx context.Context, event *larkim.P2MessageReceiveV1, metaD
 			TraceID:     span.SpanContext().TraceID().String(),
 		}
 		content := larkmsg.PreGetTextMsg(ctx, event)
+		insights := vision.DescribeMessage(ctx, event.Event.Message)
+		if imageText := vision.Render(insights); imageText != "" {
+			content = strings.TrimSpace(content + " " + imageText)
+		}
 		embedded, usage, err := ark_dal.EmbeddingText(ctx, content)
 		if err != nil {
 			logs.L().Ctx(ctx).Error("EmbeddingText error", zap.Error(err))
@@ -110,6 +118,7 @@ func CollectMessage(ctx context.Context, event *larkim.P2MessageReceiveV1, metaD
 				TokenUsage:           usage,
 				IsCommand:            metaData.IsCommand,
 				MainCommand:          metaData.MainCommand,
+				ImageInsights:        insights,
 			},
 		)
 		if err != nil {
@@ -129,26 +138,84 @@ func CollectMessage(ctx context.Context, event *larkim.P2MessageReceiveV1, metaD
 		if err != nil {
 			logs.L().Ctx(ctx).Error("AddDocuments error", zap.Error(err))
 		}
-	}()
+	})
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("collect message dropped", zap.Error(err))
+	}
+}
+
+// SubmitChunk 将消息提交到 chunk 合并
+//
+//	带图片/语音/合并转发的消息需要先完成图片理解、语音转写与展开, 与 CollectMessage 使用同一个 key 提交到 collectPool,
+//	排在其后执行, 可以直接命中缓存; 合并时按时间戳排序, 不影响会话内顺序
+//	@param ctx context.Context
+//	@param event *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+func SubmitChunk(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData) {
+	if metaData.IsCommand { // 过滤Command
+		return
+	}
+	msg := event.Event.Message
+	isAudio := utils.AddrOrNil(msg.MessageType) == larkim.MsgTypeAudio
+	isForward := utils.AddrOrNil(msg.MessageType) == larkcontent.MsgTypeMergeForward
+	if !isAudio && !isForward && !vision.HasImage(ctx, msg) {
+		larkchunking.M.SubmitMessage(ctx, &larkchunking.LarkMessageEvent{P2MessageReceiveV1: event})
+		return
+	}
+	err := submitTracked(ctx, collectPool, utils.AddrOrNil(msg.MessageId), func() {
+		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+		defer span.End()
+
+		chunkMsg := &larkchunking.LarkMessageEvent{P2MessageReceiveV1: event}
+		switch {
+		case isAudio:
+			chunkMsg.Transcript, _ = larkmsg.TranscribeAudio(ctx, msg)
+		case isForward:
+			chunkMsg.Forwarded, _ = larkcontent.ExpandMergeForward(ctx, utils.AddrOrNil(msg.MessageId))
+		default:
+			chunkMsg.ImageText = vision.Render(vision.DescribeMessage(ctx, msg))
+		}
+		larkchunking.M.SubmitMessage(ctx, chunkMsg)
+	})
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("chunk message dropped", zap.Error(err))
+	}
+}
+
+// IngestKnowledge 收录消息中分享的链接和文件到群知识库
+//
+//	@param ctx context.Context
+//	@param event *larkim.P2MessageReceiveV1
+//	@param metaData *xhandler.BaseMetaData
+func IngestKnowledge(ctx context.Context, event *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData) {
+	if metaData.IsCommand || !knowledge.Enabled() || !knowledge.HasSource(event.Event.Message) {
+		return
+	}
+	err := submitTracked(ctx, collectPool, utils.AddrOrNil(event.Event.Message.MessageId), func() {
+		knowledge.IngestMessage(ctx, event)
+	})
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("knowledge ingest dropped", zap.Error(err))
+	}
 }
 
 func init() {
 	Handler = Handler.
 		OnPanic(larkDeferFunc).
 		WithMetaDataProcess(metaInit).
-		WithPreRun(func(p *xhandler.Processor[larkim.P2MessageReceiveV1, xhandler.BaseMetaData]) {
-			go func() { utils.AddTrace2DB(p, *p.Data().Event.Message.MessageId) }()
+		WithShedding(underPressure).
+		WithPreRun(func(e *xhandler.Execution[larkim.P2MessageReceiveV1, xhandler.BaseMetaData]) {
+			xlifecycle.Go(func() { utils.AddTrace2DB(e, *e.Data().Event.Message.MessageId) })
+			ops.AnswerGuess(e.Context, e.Data(), e.MetaData())
 		}).
 		WithDefer(CollectMessage).
-		WithDefer(func(ctx context.Context, event *larkim.P2MessageReceiveV1, meta *xhandler.BaseMetaData) {
-			if !meta.IsCommand { // 过滤Command
-				larkchunking.M.SubmitMessage(ctx, &larkchunking.LarkMessageEvent{P2MessageReceiveV1: event})
-			}
-		}).
+		WithDefer(SubmitChunk).
+		WithDefer(IngestKnowledge).
 		AddParallelStages(&ops.RecordMsgOperator{}).
 		AddParallelStages(&ops.RepeatMsgOperator{}).
 		AddParallelStages(&ops.ReactMsgOperator{}).
 		AddParallelStages(&ops.WordReplyMsgOperator{}).
+		AddParallelStages(&ops.LyricsGuessOperator{}).
 		AddParallelStages(&ops.ReplyChatOperator{}).
 		AddParallelStages(&ops.CommandOperator{}).
 		AddParallelStages(&ops.ChatMsgOperator{})
@@ -7,6 +7,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/budget"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/command"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/handlers"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
@@ -50,7 +51,11 @@ func (r *ChatMsgOperator) PreRun(ctx context.Context, event *larkim.P2MessageRec
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	if command.LarkRootCommand.IsCommand(ctx, larkmsg.PreGetTextMsg(ctx, event)) {
+	if meta.Handled {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Handled")
+	}
+
+	if command.IsCommand(ctx, event, larkmsg.PreGetTextMsg(ctx, event)) {
 		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Mentioned")
 	}
 	return
@@ -83,9 +88,9 @@ func (r *ChatMsgOperator) Run(ctx context.Context, event *larkim.P2MessageReceiv
 		realRate = int(config.Rate)
 	}
 
-	if utils.Prob(float64(realRate) / 100) {
+	if utils.Prob(float64(realRate)/100) && acquireBudget(ctx, budget.Imitate, event) {
 		// sendMsg
-		err := handlers.ChatHandler("chat")(ctx, event, meta)
+		err := handlers.ChatHandler("chat")(ctx, event, meta, &handlers.ChatArgs{})
 		if err != nil {
 			return err
 		}
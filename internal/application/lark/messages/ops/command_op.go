@@ -7,6 +7,7 @@ import (
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/command"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/consts"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/macro"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
@@ -14,7 +15,9 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
 	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 	"github.com/pkg/errors"
@@ -48,7 +51,11 @@ func (r *CommandOperator) PreRun(ctx context.Context, event *larkim.P2MessageRec
 	defer func() { span.RecordError(err) }()
 	defer span.RecordError(err)
 
-	if !command.LarkRootCommand.IsCommand(ctx, larkmsg.PreGetTextMsg(ctx, event)) {
+	if meta.Handled {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Handled")
+	}
+
+	if !command.IsCommand(ctx, event, larkmsg.PreGetTextMsg(ctx, event)) {
 		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Mentioned")
 	}
 	return
@@ -71,6 +78,7 @@ func (r *CommandOperator) Run(ctx context.Context, event *larkim.P2MessageReceiv
 	return ExecuteFromRawCommand(ctx, event, meta, rawCommand)
 }
 
+// ExecuteFromRawCommand 执行命令文本, 支持 ";" 分隔依次执行、"|" 将结果通过 BaseMetaData.Extra 传给下一条命令, 以及本群的宏
 func ExecuteFromRawCommand(ctx context.Context, event *larkim.P2MessageReceiveV1, meta *xhandler.BaseMetaData, rawCommand string) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(event)))
@@ -80,36 +88,103 @@ func ExecuteFromRawCommand(ctx context.Context, event *larkim.P2MessageReceiveV1
 
 	rawCommand = strings.ReplaceAll(rawCommand, "<b>", " ")
 	rawCommand = strings.ReplaceAll(rawCommand, "</b>", " ")
-	ctx = context.WithValue(ctx, consts.ContextVarSrcCmd, rawCommand)
-	commands := xcommand.GetCommand(ctx, rawCommand)
-	if len(commands) > 0 {
-		meta.IsCommand = true
-		var reactionID string
-		reactionID, err = larkmsg.AddReaction(ctx, "OnIt", *event.Event.Message.MessageId)
-		if err != nil {
-			logs.L().Ctx(ctx).Error("Add reaction to msg failed", zap.Error(err))
+	steps := xcommand.SplitScript(rawCommand)
+	if len(xcommand.GetCommand(ctx, rawCommand)) == 0 || len(steps) == 0 {
+		return
+	}
+	meta.IsCommand = true
+	var reactionID string
+	reactionID, err = larkmsg.AddReaction(ctx, "OnIt", *event.Event.Message.MessageId)
+	if err != nil {
+		logs.L().Ctx(ctx).Error("Add reaction to msg failed", zap.Error(err))
+	} else {
+		defer larkmsg.RemoveReactionAsync(ctx, reactionID, *event.Event.Message.MessageId)
+	}
+	err = executeScript(ctx, event, meta, rawCommand)
+	if err != nil {
+		span.RecordError(err)
+		single := len(steps) == 1 && len(steps[0]) == 1
+		if errors.Is(err, xerror.ErrCommandNotFound) && single {
+			meta.IsCommand = false
+			notFound := (*xcommand.NotFoundError)(nil)
+			if larkmsg.IsMentioned(event.Event.Message.Mentions) || (errors.As(err, &notFound) && len(notFound.Suggestions) > 0) {
+				larkmsg.ReplyCardText(ctx, err.Error(), *event.Event.Message.MessageId, "_OpErr", true)
+				return
+			}
+		} else if usageErr := (*xcommand.UsageError)(nil); errors.As(err, &usageErr) && len(usageErr.Args) > 0 {
+			// 声明了参数的命令回复用法卡片
+			card, _ := sonic.MarshalString(command.UsageCard(usageErr))
+			larkmsg.ReplyMsgRawContentType(ctx, *event.Event.Message.MessageId, larkim.MsgTypeInteractive, card, "_OpErr", true)
+			return
 		} else {
-			defer larkmsg.RemoveReactionAsync(ctx, reactionID, *event.Event.Message.MessageId)
+			text := fmt.Sprintf("%v\n[Jaeger Trace](%s)", err.Error(), utils.GenTraceURL(span.SpanContext().TraceID().String()))
+			larkmsg.ReplyCardText(ctx, text, *event.Event.Message.MessageId, "_OpErr", true)
+			logs.L().Ctx(ctx).Error("CommandOperator", zap.Error(err), zap.String("TraceID", span.SpanContext().TraceID().String()))
+			return
 		}
-		err = command.LarkRootCommand.Execute(ctx, event, meta, commands)
-		if err != nil {
-			span.RecordError(err)
-			if errors.Is(err, xerror.ErrCommandNotFound) {
-				meta.IsCommand = false
-				if larkmsg.IsMentioned(event.Event.Message.Mentions) {
-					larkmsg.ReplyCardText(ctx, err.Error(), *event.Event.Message.MessageId, "_OpErr", true)
-					return
-				}
-			} else {
-				text := fmt.Sprintf("%v\n[Jaeger Trace](%s)", err.Error(), utils.GenTraceURL(span.SpanContext().TraceID().String()))
-				larkmsg.ReplyCardText(ctx, text, *event.Event.Message.MessageId, "_OpErr", true)
-				logs.L().Ctx(ctx).Error("CommandOperator", zap.Error(err), zap.String("TraceID", span.SpanContext().TraceID().String()))
-				return
+	}
+	if !meta.SkipDone {
+		larkmsg.AddReactionAsync(ctx, "DONE", *event.Event.Message.MessageId)
+	}
+	return
+}
+
+// executeScript 依次执行脚本中的各个步骤, 管道中上一条命令的结果作为下一条命令的 ExtraPipeInput,
+// 最后一条命令的结果写回 meta; 任意一条失败时停止
+func executeScript(ctx context.Context, event *larkim.P2MessageReceiveV1, meta *xhandler.BaseMetaData, raw string) error {
+	steps := xcommand.SplitScript(raw)
+	if len(steps) == 1 && len(steps[0]) == 1 {
+		return executeStage(ctx, event, meta, steps[0][0])
+	}
+	input, _ := meta.PipeInput()
+	for idx, stages := range steps {
+		if idx > 0 {
+			input = nil
+		}
+		for _, stage := range stages {
+			stageMeta := *meta
+			stageMeta.Extra, stageMeta.TimeRange = nil, xtime.Range{}
+			if input != nil {
+				stageMeta.SetExtra(xhandler.ExtraPipeInput, input)
 			}
+			if err := executeStage(ctx, event, &stageMeta, stage); err != nil {
+				return fmt.Errorf("%s: %w", stage, err)
+			}
+			meta.SkipDone = meta.SkipDone || stageMeta.SkipDone
+			input, _ = stageMeta.GetExtra(xhandler.ExtraResult)
+		}
+	}
+	if input != nil {
+		meta.SetResult(input)
+	}
+	return nil
+}
+
+// executeStage 执行一条命令, 命令名不存在但是本群的宏时展开执行
+func executeStage(ctx context.Context, event *larkim.P2MessageReceiveV1, meta *xhandler.BaseMetaData, raw string) error {
+	ctx = context.WithValue(ctx, consts.ContextVarSrcCmd, raw)
+	commands := xcommand.GetCommand(ctx, raw)
+	if len(commands) == 0 {
+		return fmt.Errorf("%q is not a command", raw)
+	}
+	if _, ok := command.LarkRootCommand.Find(commands[0]); !ok {
+		m, err := macro.Get(ctx, *event.Event.Message.ChatId, commands[0])
+		if err != nil {
+			return err
 		}
-		if !meta.SkipDone {
-			larkmsg.AddReactionAsync(ctx, "DONE", *event.Event.Message.MessageId)
+		if m != nil {
+			if len(commands) > 1 {
+				return fmt.Errorf("macro /%s takes no arguments", m.Name)
+			}
+			if ctx, err = macro.Enter(ctx, m.Name); err != nil {
+				return err
+			}
+			// 执行时再次校验, 宏保存后命令可能已变化
+			if err = command.CheckMacroScript(ctx, m.ChatID, m.Script); err != nil {
+				return fmt.Errorf("macro /%s: %w", m.Name, err)
+			}
+			return executeScript(ctx, event, meta, m.Script)
 		}
 	}
-	return
+	return command.LarkRootCommand.Execute(ctx, event, meta, commands)
 }
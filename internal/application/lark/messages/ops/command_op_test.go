@@ -0,0 +1,113 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/command"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/macro"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xcommand"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// useTestRoot 用只记录调用的命令替换根命令: /echo 以参数为结果, /upper 将管道输入转为大写, /fail 总是失败
+func useTestRoot(t *testing.T) *[]string {
+	t.Helper()
+	ran := make([]string, 0)
+	echo := func(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) error {
+		ran = append(ran, "echo "+strings.Join(args, " "))
+		metaData.SetResult(strings.Join(args, " "))
+		return nil
+	}
+	upper := func(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) error {
+		input, _ := metaData.PipeInput()
+		s, _ := input.(string)
+		ran = append(ran, "upper "+s)
+		metaData.SetResult(strings.ToUpper(s))
+		return nil
+	}
+	fail := func(ctx context.Context, data *larkim.P2MessageReceiveV1, metaData *xhandler.BaseMetaData, args ...string) error {
+		ran = append(ran, "fail")
+		return errors.New("failed")
+	}
+	root := xcommand.NewRootCommand[*larkim.P2MessageReceiveV1](nil).
+		AddSubCommand(xcommand.NewCommand("echo", echo)).
+		AddSubCommand(xcommand.NewCommand("upper", upper)).
+		AddSubCommand(xcommand.NewCommand("fail", fail))
+	root.BuildChain()
+	prev := command.LarkRootCommand
+	command.LarkRootCommand = root
+	t.Cleanup(func() { command.LarkRootCommand = prev })
+	return &ran
+}
+
+func testEvent(chatID string) *larkim.P2MessageReceiveV1 {
+	return &larkim.P2MessageReceiveV1{Event: &larkim.P2MessageReceiveV1Data{Message: &larkim.EventMessage{ChatId: &chatID}}}
+}
+
+func TestExecuteScript(t *testing.T) {
+	dbtest.Open(t, &model.ChatMacro{})
+	ran := useTestRoot(t)
+	ctx, event := context.Background(), testEvent("oc_test")
+
+	for _, c := range []struct {
+		script string
+		ran    []string
+		result string
+	}{
+		{"/echo hi | /upper", []string{"echo hi", "upper hi"}, "HI"},
+		{"/echo a; /echo b", []string{"echo a", "echo b"}, "b"},
+		// 上一步的结果不传给 ";" 之后的命令
+		{"/echo a; /upper", []string{"echo a", "upper "}, ""},
+	} {
+		*ran = (*ran)[:0]
+		meta := &xhandler.BaseMetaData{}
+		if err := executeScript(ctx, event, meta, c.script); err != nil {
+			t.Fatalf("executeScript(%q) error = %v", c.script, err)
+		}
+		result, _ := meta.GetExtra(xhandler.ExtraResult)
+		if !slices.Equal(*ran, c.ran) || result != c.result {
+			t.Errorf("executeScript(%q) ran %v, result %v", c.script, *ran, result)
+		}
+	}
+
+	*ran = (*ran)[:0]
+	if err := executeScript(ctx, event, &xhandler.BaseMetaData{}, "/fail; /echo x"); err == nil || len(*ran) != 1 {
+		t.Errorf("executeScript(/fail; /echo x) = %v, ran %v, want to stop at the failure", err, *ran)
+	}
+}
+
+func TestExecuteStageMacro(t *testing.T) {
+	dbtest.Open(t, &model.ChatMacro{})
+	ran := useTestRoot(t)
+	ctx, event := context.Background(), testEvent("oc_test")
+	for name, script := range map[string]string{"greet": "/echo hello | /upper", "twice": "/greet; /greet", "loop": "/loop"} {
+		if err := macro.Save(ctx, "oc_test", "ou_a", name, script); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	meta := &xhandler.BaseMetaData{}
+	if err := executeStage(ctx, event, meta, "/twice"); err != nil {
+		t.Fatalf("executeStage(/twice) error = %v", err)
+	}
+	if result, _ := meta.GetExtra(xhandler.ExtraResult); result != "HELLO" || len(*ran) != 4 {
+		t.Errorf("executeStage(/twice) result %v, ran %v", result, *ran)
+	}
+	if err := executeStage(ctx, event, meta, "/loop"); !errors.Is(err, macro.ErrRecursion) {
+		t.Errorf("executeStage(/loop) = %v, want ErrRecursion", err)
+	}
+	if err := executeStage(ctx, event, meta, "/greet now"); err == nil {
+		t.Error("executeStage(/greet now) should fail, macros take no arguments")
+	}
+	// 其他群的宏不可见
+	if err := executeStage(ctx, testEvent("oc_other"), meta, "/greet"); err == nil {
+		t.Error("executeStage(/greet) in another chat should fail")
+	}
+}
@@ -0,0 +1,91 @@
+package ops
+
+import (
+	"context"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/command"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/music"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"github.com/pkg/errors"
+)
+
+var _ Op = &LyricsGuessOperator{}
+
+// LyricsGuessOperator 回复猜中歌名的消息, 校验在 AnswerGuess 中完成
+type LyricsGuessOperator struct {
+	OpBase
+}
+
+func (r *LyricsGuessOperator) Name() string {
+	return "LyricsGuessOperator"
+}
+
+// extraGuessRound 流水线开始前猜中的一轮猜歌, 见 AnswerGuess
+const extraGuessRound = "lyrics_guess_round"
+
+// AnswerGuess 在并行阶段开始前校验猜歌的回答, 猜中时标记消息已处理, 由 LyricsGuessOperator 回复, 其余会回复的算子跳过
+//
+//	@param ctx context.Context
+//	@param event *larkim.P2MessageReceiveV1
+//	@param meta *xhandler.BaseMetaData
+func AnswerGuess(ctx context.Context, event *larkim.P2MessageReceiveV1, meta *xhandler.BaseMetaData) {
+	chatID := *event.Event.Message.ChatId
+	if !music.Guessing(chatID) {
+		return
+	}
+	text := larkmsg.PreGetTextMsg(ctx, event)
+	if command.IsCommand(ctx, event, text) {
+		return
+	}
+	if round := music.Answer(chatID, text); round != nil {
+		meta.Handled = true
+		meta.SetExtra(extraGuessRound, round)
+	}
+}
+
+// PreRun 只处理 AnswerGuess 中猜中的消息
+//
+//	@receiver r *LyricsGuessOperator
+//	@param ctx context.Context
+//	@param event *larkim.P2MessageReceiveV1
+//	@return err error
+func (r *LyricsGuessOperator) PreRun(ctx context.Context, event *larkim.P2MessageReceiveV1, meta *xhandler.BaseMetaData) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if !meta.Handled {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Answered")
+	}
+	return
+}
+
+// Run 回复答案卡片
+//
+//	@receiver r *LyricsGuessOperator
+//	@param ctx context.Context
+//	@param event *larkim.P2MessageReceiveV1
+//	@return err error
+func (r *LyricsGuessOperator) Run(ctx context.Context, event *larkim.P2MessageReceiveV1, meta *xhandler.BaseMetaData) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	val, _ := meta.GetExtra(extraGuessRound)
+	round, ok := val.(*music.Round)
+	if !ok {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Answered")
+	}
+	content, err := sonic.MarshalString(music.AnswerCard(round, *event.Event.Sender.SenderId.OpenId))
+	if err != nil {
+		return err
+	}
+	_, err = larkmsg.ReplyMsgRawContentType(ctx, *event.Event.Message.MessageId, larkim.MsgTypeInteractive, content, "_lyricsGuess", false)
+	return err
+}
@@ -3,12 +3,14 @@ package ops
 import (
 	"context"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/budget"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	"github.com/bytedance/sonic"
@@ -29,6 +31,11 @@ func (r *ReactMsgOperator) Name() string {
 	return "ReactMsgOperator"
 }
 
+// Priority 非必要的互动, 负载高时可丢弃
+func (r *ReactMsgOperator) Priority() xhandler.Priority {
+	return xhandler.PriorityLow
+}
+
 // PreRun Repeat
 //
 //	@receiver r
@@ -40,6 +47,9 @@ func (r *ReactMsgOperator) PreRun(ctx context.Context, event *larkim.P2MessageRe
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
+	if meta.Handled {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Handled")
+	}
 	return
 }
 
@@ -60,6 +70,9 @@ func (r *ReactMsgOperator) Run(ctx context.Context, event *larkim.P2MessageRecei
 	// 开始摇骰子, 默认概率10%
 	realRate := config.Get().RateConfig.ReactionDefaultRate
 	if utils.Prob(float64(realRate) / 100) {
+		if !acquireBudget(ctx, budget.React, event) {
+			return
+		}
 		_, err := larkmsg.AddReaction(ctx, larkmsg.GetRandomEmoji(), *event.Event.Message.MessageId)
 		if err != nil {
 			logs.L().Ctx(ctx).Error("reactMessage error", zap.Error(err), zap.String("TraceID", span.SpanContext().TraceID().String()))
@@ -73,7 +86,7 @@ func (r *ReactMsgOperator) Run(ctx context.Context, event *larkim.P2MessageRecei
 				logs.L().Ctx(ctx).Error("reactMessage error", zap.Error(err), zap.String("TraceID", span.SpanContext().TraceID().String()))
 				return err
 			}
-			if len(res) == 0 {
+			if len(res) == 0 || !acquireBudget(ctx, budget.React, event) {
 				return nil
 			}
 			target := utils.SampleSlice(res)
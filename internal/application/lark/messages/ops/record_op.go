@@ -2,10 +2,13 @@ package ops
 
 import (
 	"context"
+	"errors"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/asr"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkimg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
@@ -77,6 +80,12 @@ func (r *RecordMsgOperator) Run(ctx context.Context, event *larkim.P2MessageRece
 		}
 	}
 	msg := event.Event.Message
+	if msg != nil && *msg.MessageType == larkim.MsgTypeAudio {
+		// 转写结果落库, 后续入库、回复读取 PreGetTextMsg 时直接命中
+		if _, err := larkmsg.TranscribeAudio(ctx, msg); err != nil && !errors.Is(err, asr.ErrDisabled) {
+			logs.L().Ctx(ctx).Warn("transcribe audio error", zap.Error(err))
+		}
+	}
 	if msg != nil && *msg.MessageType == larkim.MsgTypeSticker {
 		contentMap := make(map[string]string)
 		err := sonic.UnmarshalString(*msg.Content, &contentMap)
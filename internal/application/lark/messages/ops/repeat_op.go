@@ -9,6 +9,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/budget"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/command"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/handlers"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
@@ -40,6 +41,11 @@ func (r *RepeatMsgOperator) Name() string {
 	return "RepeatMsgOperator"
 }
 
+// Priority 非必要的互动, 负载高时可丢弃
+func (r *RepeatMsgOperator) Priority() xhandler.Priority {
+	return xhandler.PriorityLow
+}
+
 // PreRun Repeat
 //
 //	@receiver r *RepeatMsgOperator
@@ -53,7 +59,11 @@ func (r *RepeatMsgOperator) PreRun(ctx context.Context, event *larkim.P2MessageR
 	defer span.End()
 	defer func() { span.RecordError(err) }()
 
-	if command.LarkRootCommand.IsCommand(ctx, larkmsg.PreGetTextMsg(ctx, event)) {
+	if meta.Handled {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Handled")
+	}
+
+	if command.IsCommand(ctx, event, larkmsg.PreGetTextMsg(ctx, event)) {
 		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Mentioned")
 	}
 	if ext, err := redis_dal.GetRedisClient().
@@ -108,7 +118,7 @@ func (r *RepeatMsgOperator) Run(ctx context.Context, event *larkim.P2MessageRece
 		}
 	}
 
-	if utils.Prob(float64(realRate) / 100) {
+	if utils.Prob(float64(realRate)/100) && acquireBudget(ctx, budget.Repeat, event) {
 		msgType := strings.ToLower(*event.Event.Message.MessageType)
 		if msgType == "text" {
 			m, err := utils.JSON2Map(*event.Event.Message.Content)
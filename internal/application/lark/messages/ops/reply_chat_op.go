@@ -2,7 +2,6 @@ package ops
 
 import (
 	"context"
-	"strings"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/command"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/handlers"
@@ -45,11 +44,16 @@ func (r *ReplyChatOperator) PreRun(ctx context.Context, event *larkim.P2MessageR
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
 	defer func() { span.RecordError(err) }()
+
+	if meta.Handled {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Handled")
+	}
+
 	if *event.Event.Message.ChatType != "p2p" && !larkmsg.IsMentioned(event.Event.Message.Mentions) {
 		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Mentioned")
 	}
 
-	if command.LarkRootCommand.IsCommand(ctx, larkmsg.PreGetTextMsg(ctx, event)) {
+	if command.IsCommand(ctx, event, larkmsg.PreGetTextMsg(ctx, event)) {
 		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Mentioned")
 	}
 	return
@@ -77,7 +81,7 @@ func (r *ReplyChatOperator) Run(ctx context.Context, event *larkim.P2MessageRece
 
 	msg := larkmsg.PreGetTextMsg(ctx, event)
 	msg = larkmsg.TrimAtMsg(ctx, msg)
-	err = handlers.ChatHandler("chat")(ctx, event, meta, strings.Split(msg, " ")...)
+	err = handlers.ChatHandler("chat")(ctx, event, meta, &handlers.ChatArgs{Text: msg})
 	if !meta.SkipDone {
 		larkmsg.AddReactionAsync(ctx, "DONE", *event.Event.Message.MessageId)
 	}
@@ -4,15 +4,14 @@ import (
 	"context"
 	"strings"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/budget"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/command"
-	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/replyrule"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
-	"gorm.io/gorm"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
-	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
 	"github.com/BetaGoRobot/go_utils/reflecting"
@@ -50,7 +49,11 @@ func (r *WordReplyMsgOperator) PreRun(ctx context.Context, event *larkim.P2Messa
 	defer func() { span.RecordError(err) }()
 	defer span.RecordError(err)
 
-	if command.LarkRootCommand.IsCommand(ctx, larkmsg.PreGetTextMsg(ctx, event)) {
+	if meta.Handled {
+		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Handled")
+	}
+
+	if command.IsCommand(ctx, event, larkmsg.PreGetTextMsg(ctx, event)) {
 		return errors.Wrap(xerror.ErrStageSkip, r.Name()+" Not Mentioned")
 	}
 	return
@@ -69,56 +72,33 @@ func (r *WordReplyMsgOperator) Run(ctx context.Context, event *larkim.P2MessageR
 	defer span.RecordError(err)
 
 	msg := larkmsg.PreGetTextMsg(ctx, event)
-	var replyItem *xmodel.ReplyNType
-	// 检查定制化逻辑, Key为GuildID, 拿到GUI了dID下的所有SubStr配置
-	ins := query.Q.QuoteReplyMsgCustom
-	customConfig, err := ins.WithContext(ctx).Where(ins.GuildID.Eq(*event.Event.Message.ChatId)).Find()
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		logs.L().Ctx(ctx).Error("get quote reply config from db failed", zap.Error(err))
-		return
-	}
-	replyList := make([]*xmodel.ReplyNType, 0)
-	for _, data := range customConfig {
-		if CheckQuoteKeywordMatch(msg, data.Keyword, xmodel.WordMatchType(data.MatchType)) {
-			replyList = append(replyList, &xmodel.ReplyNType{Reply: data.Reply, ReplyType: xmodel.ReplyType(data.ReplyType)})
-		}
-	}
-
-	if len(replyList) == 0 {
-		// 无定制化逻辑，走通用判断
-		ins := query.Q.QuoteReplyMsg
-		data, err := ins.WithContext(ctx).Find()
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			logs.L().Ctx(ctx).Error("FindByCacheFunc error", zap.Error(err))
-			return err
-		}
-		for _, d := range data {
-			if CheckQuoteKeywordMatch(msg, d.Keyword, xmodel.WordMatchType(d.MatchType)) {
-				replyList = append(replyList, &xmodel.ReplyNType{Reply: d.Reply, ReplyType: xmodel.ReplyType(d.ReplyType)})
-			}
-		}
+	replyItem, err := replyrule.Pick(ctx, *event.Event.Message.ChatId, *event.Event.Sender.SenderId.OpenId, msg, func() bool {
+		return acquireBudget(ctx, budget.WordReply, event)
+	})
+	if err != nil {
+		logs.L().Ctx(ctx).Error("pick reply rule failed", zap.Error(err))
+		return err
 	}
-	if len(replyList) > 0 {
-		replyItem = utils.SampleSlice(replyList)
+	if replyItem != nil {
 		_, subSpan := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 		defer subSpan.End()
-		if replyItem.ReplyType == xmodel.ReplyTypeText {
-			_, err := larkmsg.ReplyMsgText(ctx, replyItem.Reply, *event.Event.Message.MessageId, "_wordReply", false)
+		if replyItem.Type == xmodel.ReplyTypeText {
+			_, err := larkmsg.ReplyMsgText(ctx, replyItem.Text, *event.Event.Message.MessageId, "_wordReply", false)
 			if err != nil {
 				logs.L().Ctx(ctx).Error("ReplyMessage error", zap.Error(err), zap.String("TraceID", span.SpanContext().TraceID().String()))
 				return err
 			}
-		} else if replyItem.ReplyType == xmodel.ReplyTypeImg {
+		} else if replyItem.Type == xmodel.ReplyTypeImg {
 			var msgType, content string
-			if strings.HasPrefix(replyItem.Reply, "img") {
+			if strings.HasPrefix(replyItem.Text, "img") {
 				msgType = larkim.MsgTypeImage
 				content, _ = sonic.MarshalString(map[string]string{
-					"image_key": replyItem.Reply,
+					"image_key": replyItem.Text,
 				})
 			} else {
 				msgType = larkim.MsgTypeSticker
 				content, _ = sonic.MarshalString(map[string]string{
-					"file_key": replyItem.Reply,
+					"file_key": replyItem.Text,
 				})
 			}
 			_, err := larkmsg.ReplyMsgRawContentType(ctx, *event.Event.Message.MessageId, msgType, content, "_wordReply", false)
@@ -133,16 +113,3 @@ func (r *WordReplyMsgOperator) Run(ctx context.Context, event *larkim.P2MessageR
 	}
 	return
 }
-
-func CheckQuoteKeywordMatch(msg string, keyword string, matchType xmodel.WordMatchType) bool {
-	switch matchType {
-	case xmodel.MatchTypeFull:
-		return msg == keyword
-	case xmodel.MatchTypeSubStr:
-		return strings.Contains(msg, keyword)
-	case xmodel.MatchTypeRegex:
-		return utils.RegexpMatch(msg, keyword)
-	default:
-		panic("unknown match type" + matchType)
-	}
-}
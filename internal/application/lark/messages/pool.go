@@ -0,0 +1,51 @@
+package messages
+
+import (
+	"context"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xpool"
+)
+
+var (
+	// msgPool 消息处理, 同一会话保序
+	msgPool *xpool.Pool
+	// collectPool 消息入库/向量化, 无序
+	collectPool *xpool.Pool
+	shedRatio   float64
+)
+
+func Init() {
+	conf := config.Get().GetWorkerPoolConfig()
+	msgPool = xpool.New("lark_message", conf.Workers, conf.MaxQueue, conf.PerChatQueue)
+	collectPool = xpool.New("lark_collect", conf.Workers, conf.MaxQueue, conf.MaxQueue)
+	shedRatio = conf.ShedRatio
+}
+
+// Submit 将消息处理提交到任务池, 同一 chatID 的消息按提交顺序处理
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param fn func()
+//	@return error 队列已满时返回
+func Submit(ctx context.Context, chatID string, fn func()) error {
+	return submitTracked(ctx, msgPool, chatID, fn)
+}
+
+func submitTracked(ctx context.Context, pool *xpool.Pool, key string, fn func()) error {
+	done := xlifecycle.Default.Track()
+	err := pool.Submit(ctx, key, func() {
+		defer done()
+		fn()
+	})
+	if err != nil {
+		done()
+	}
+	return err
+}
+
+// underPressure 任务池负载超过阈值时丢弃低优先级算子
+func underPressure() bool {
+	return msgPool != nil && msgPool.Pressure() >= shedRatio
+}
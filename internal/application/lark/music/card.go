@@ -0,0 +1,228 @@
+package music
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/cardaction"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+)
+
+const (
+	// maxLyricsLen 卡片中歌词的最大长度, 超出部分截断
+	maxLyricsLen = 2000
+	// maxCardTracks 列表卡片中最多展示的歌曲数
+	maxCardTracks = 20
+	// maxArtistSongs 歌手卡片中展示的热门歌曲数
+	maxArtistSongs = 10
+	// maxArtistAlbums 歌手卡片中展示的专辑数
+	maxArtistAlbums = 5
+	// maxPlaylistTracks 歌单卡片中展示的歌曲数
+	maxPlaylistTracks = 10
+)
+
+// trackValue 按钮回调中携带歌曲信息, 处理时无需再查询详情
+func trackValue(action string, t *Track) map[string]any {
+	return cardaction.Value(action, "id", t.ID, "name", t.Name, "artist", t.Artist, "pic", t.PicURL, "provider", t.Provider)
+}
+
+func button(label, kind string, value map[string]any) map[string]any {
+	return map[string]any{
+		"tag":       "button",
+		"text":      map[string]any{"tag": "plain_text", "content": label},
+		"type":      kind,
+		"behaviors": []any{map[string]any{"type": "callback", "value": value}},
+	}
+}
+
+func urlButton(label, url string) map[string]any {
+	return map[string]any{
+		"tag":       "button",
+		"text":      map[string]any{"tag": "plain_text", "content": label},
+		"type":      "primary",
+		"behaviors": []any{map[string]any{"type": "open_url", "default_url": url}},
+	}
+}
+
+// buttonRow 一行按钮
+func buttonRow(buttons ...map[string]any) map[string]any {
+	columns := make([]any, 0, len(buttons))
+	for _, b := range buttons {
+		columns = append(columns, map[string]any{"tag": "column", "width": "auto", "elements": []any{b}})
+	}
+	return map[string]any{"tag": "column_set", "columns": columns}
+}
+
+func markdown(content string) map[string]any {
+	return map[string]any{"tag": "markdown", "content": content}
+}
+
+func card(title, template string, elements []any) map[string]any {
+	return map[string]any{
+		"schema": "2.0",
+		"header": map[string]any{
+			"title":    map[string]any{"tag": "plain_text", "content": title},
+			"template": template,
+		},
+		"body": map[string]any{"elements": elements},
+	}
+}
+
+func trackLine(i int, t *Track) string {
+	return fmt.Sprintf("%d. **%s** - %s", i+1, t.Name, t.Artist)
+}
+
+// playerCard 播放卡片: 封面、播放链接、歌词, 以及下一首、收藏按钮
+//
+//	@param t *Track
+//	@param url string 解析出的播放链接
+//	@param imgKey string 封面, 为空时不展示
+//	@param lyrics string
+//	@param upcoming int 队列中剩余的歌曲数
+//	@return map[string]any
+func playerCard(t *Track, url, imgKey, lyrics string, upcoming int) map[string]any {
+	info := fmt.Sprintf("**%s**\n%s", t.Name, t.Artist)
+	if t.AddedBy != "" {
+		info += fmt.Sprintf("\n点歌: <at id=%s></at>", t.AddedBy)
+	}
+	top := []any{map[string]any{"tag": "column", "width": "weighted", "weight": 1, "elements": []any{markdown(info)}}}
+	if imgKey != "" {
+		cover := map[string]any{
+			"tag":     "img",
+			"img_key": imgKey,
+			"alt":     map[string]any{"tag": "plain_text", "content": t.Name},
+			"size":    "medium",
+		}
+		top = append([]any{map[string]any{"tag": "column", "width": "auto", "elements": []any{cover}}}, top...)
+	}
+	elements := []any{map[string]any{"tag": "column_set", "columns": top}}
+	if lyrics = strings.TrimSpace(lyrics); lyrics != "" {
+		if r := []rune(lyrics); len(r) > maxLyricsLen {
+			lyrics = string(r[:maxLyricsLen]) + "\n..."
+		}
+		elements = append(elements, map[string]any{
+			"tag":      "collapsible_panel",
+			"expanded": false,
+			"header":   map[string]any{"title": map[string]any{"tag": "plain_text", "content": "歌词"}},
+			"elements": []any{markdown(lyrics)},
+		})
+	}
+	next := "下一首"
+	if upcoming > 0 {
+		next = fmt.Sprintf("下一首 (还有 %d 首)", upcoming)
+	}
+	elements = append(elements, buttonRow(
+		urlButton("播放", url),
+		button(next, "default", cardaction.Value(actionNext)),
+		button("收藏", "default", trackValue(actionFav, t)),
+	))
+	return card("正在播放", "purple", elements)
+}
+
+// queueCard 正在播放的歌与待播队列
+func queueCard(now *Track, upcoming []*Track) map[string]any {
+	elements := make([]any, 0, 3)
+	if now != nil {
+		elements = append(elements, markdown(fmt.Sprintf("**正在播放**: %s - %s", now.Name, now.Artist)))
+	}
+	if len(upcoming) == 0 {
+		elements = append(elements, markdown("队列是空的, 使用 `/music add <歌名>` 点歌"))
+		return card("播放队列", "purple", elements)
+	}
+	lines := make([]string, 0, len(upcoming))
+	for i, t := range upcoming {
+		if i == maxCardTracks {
+			lines = append(lines, fmt.Sprintf("... 还有 %d 首", len(upcoming)-maxCardTracks))
+			break
+		}
+		lines = append(lines, trackLine(i, t))
+	}
+	elements = append(elements,
+		markdown(strings.Join(lines, "\n")),
+		buttonRow(
+			button("下一首", "primary", cardaction.Value(actionNext)),
+			button("清空", "danger", cardaction.Value(actionClear)),
+		),
+	)
+	return card("播放队列", "purple", elements)
+}
+
+// trackListCard 专辑或收藏歌单, 每首歌带播放、加入队列按钮; removable 时额外带移除按钮
+func trackListCard(title string, tracks []*Track, removable bool) map[string]any {
+	elements := make([]any, 0, len(tracks)+1)
+	if len(tracks) == 0 {
+		elements = append(elements, markdown("还没有歌曲, 在播放卡片上点击 **收藏** 或使用 `/music fav add <歌名>` 添加"))
+	}
+	for i, t := range tracks {
+		if i == maxCardTracks {
+			elements = append(elements, markdown(fmt.Sprintf("... 还有 %d 首", len(tracks)-maxCardTracks)))
+			break
+		}
+		elements = append(elements, trackRow(i, t, removable))
+	}
+	return card(title, "purple", elements)
+}
+
+// trackRow 列表中的一首歌, 带播放、加入队列按钮; removable 时额外带移除按钮
+func trackRow(i int, t *Track, removable bool) map[string]any {
+	columns := []any{
+		map[string]any{"tag": "column", "width": "weighted", "weight": 1, "vertical_align": "center", "elements": []any{markdown(trackLine(i, t))}},
+		map[string]any{"tag": "column", "width": "auto", "elements": []any{button("播放", "primary_text", trackValue(actionPlay, t))}},
+		map[string]any{"tag": "column", "width": "auto", "elements": []any{button("加入队列", "text", trackValue(actionAdd, t))}},
+	}
+	if removable {
+		columns = append(columns, map[string]any{"tag": "column", "width": "auto", "elements": []any{button("移除", "danger_text", trackValue(actionUnfav, t))}})
+	}
+	return map[string]any{"tag": "column_set", "columns": columns}
+}
+
+// artistCard 歌手卡片: 头像与简介、热门歌曲、最近的专辑
+func artistCard(provider string, a *musicapi.Artist, imgKey string, songs []*Track, albums []*musicapi.Album) map[string]any {
+	info := fmt.Sprintf("**%s**", a.Name)
+	if len(a.Alias) > 0 {
+		info += "\n" + strings.Join(a.Alias, " / ")
+	}
+	info += fmt.Sprintf("\n单曲 %d · 专辑 %d", a.MusicSize, a.AlbumSize)
+	top := []any{map[string]any{"tag": "column", "width": "weighted", "weight": 1, "elements": []any{markdown(info)}}}
+	if imgKey != "" {
+		avatar := map[string]any{
+			"tag":     "img",
+			"img_key": imgKey,
+			"alt":     map[string]any{"tag": "plain_text", "content": a.Name},
+			"size":    "medium",
+		}
+		top = append([]any{map[string]any{"tag": "column", "width": "auto", "elements": []any{avatar}}}, top...)
+	}
+	elements := []any{map[string]any{"tag": "column_set", "columns": top}}
+	if bio := strings.TrimSpace(a.Brief); bio != "" {
+		if r := []rune(bio); len(r) > maxLyricsLen {
+			bio = string(r[:maxLyricsLen]) + "..."
+		}
+		elements = append(elements, map[string]any{
+			"tag":      "collapsible_panel",
+			"expanded": false,
+			"header":   map[string]any{"title": map[string]any{"tag": "plain_text", "content": "简介"}},
+			"elements": []any{markdown(bio)},
+		})
+	}
+	if len(songs) > 0 {
+		elements = append(elements, markdown("**热门歌曲**"))
+		for i, t := range songs {
+			elements = append(elements, trackRow(i, t, false))
+		}
+	}
+	if len(albums) > 0 {
+		elements = append(elements, markdown("**专辑**"))
+		for _, album := range albums {
+			name := album.Name
+			if !album.PublishTime.IsZero() {
+				name += fmt.Sprintf(" (%d)", album.PublishTime.Year())
+			}
+			elements = append(elements, map[string]any{"tag": "column_set", "columns": []any{
+				map[string]any{"tag": "column", "width": "weighted", "weight": 1, "vertical_align": "center", "elements": []any{markdown(name)}},
+				map[string]any{"tag": "column", "width": "auto", "elements": []any{button("查看专辑", "primary_text", cardaction.Value(actionAlbum, "type", "album", "id", album.ID, "provider", provider))}},
+			}})
+		}
+	}
+	return card(a.Name, "purple", elements)
+}
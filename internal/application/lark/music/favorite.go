@@ -0,0 +1,91 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm/clause"
+)
+
+// MaxFavorites 每个群收藏歌单的最大长度
+const MaxFavorites = 200
+
+// ErrFavoritesFull 收藏歌单已满
+var ErrFavoritesFull = fmt.Errorf("favorites are limited to %d songs", MaxFavorites)
+
+// Favorites 群的收藏歌单, 按收藏时间排序
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return []*Track
+//	@return error
+func Favorites(ctx context.Context, chatID string) (tracks []*Track, err error) {
+	ins := query.Q.ChatMusicFavorite
+	rows, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID)).Order(ins.CreatedAt, ins.ID).Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tracks = append(tracks, &Track{ID: row.SongID, Name: row.Name, Artist: row.Artist, PicURL: row.PicURL, Provider: row.Provider, AddedBy: row.AddedBy})
+	}
+	return tracks, nil
+}
+
+// AddFavorite 收藏到群歌单, 已收藏的歌不重复添加
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 操作者 open_id
+//	@param t *Track
+//	@return added bool 此前未收藏
+//	@return err error
+func AddFavorite(ctx context.Context, chatID, userID string, t *Track) (added bool, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("song_id", t.ID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	ins := query.Q.ChatMusicFavorite
+	count, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID)).Count()
+	if err != nil {
+		return false, err
+	}
+	if count >= MaxFavorites {
+		return false, ErrFavoritesFull
+	}
+	row := &model.ChatMusicFavorite{ChatID: chatID, Provider: t.Provider, SongID: t.ID, Name: t.Name, Artist: t.Artist, PicURL: t.PicURL, AddedBy: userID}
+	if err = ins.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(row); err != nil {
+		return false, err
+	}
+	// 冲突时不会插入, 也不会回填 ID
+	return row.ID != 0, nil
+}
+
+// RemoveFavorite 从群歌单中移除
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param t *Track
+//	@return err error
+func RemoveFavorite(ctx context.Context, chatID string, t *Track) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("song_id", t.ID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	ins := query.Q.ChatMusicFavorite
+	info, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.Provider.Eq(t.Provider), ins.SongID.Eq(t.ID)).Delete()
+	if err != nil {
+		return err
+	}
+	if info.RowsAffected == 0 {
+		return errors.New("song is not in favorites")
+	}
+	return nil
+}
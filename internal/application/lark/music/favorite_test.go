@@ -0,0 +1,73 @@
+package music
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+)
+
+func TestFavoritesPerChat(t *testing.T) {
+	dbtest.Open(t, &model.ChatMusicFavorite{})
+	ctx := context.Background()
+	add := func(chatID, id string) bool {
+		t.Helper()
+		added, err := AddFavorite(ctx, chatID, "ou_user", &Track{ID: id, Name: "song" + id, Provider: "netease"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return added
+	}
+	ids := func(chatID string) []string {
+		t.Helper()
+		tracks, err := Favorites(ctx, chatID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := make([]string, 0, len(tracks))
+		for _, tr := range tracks {
+			res = append(res, tr.ID)
+		}
+		return res
+	}
+
+	if !add("chat_a", "1") || !add("chat_a", "2") {
+		t.Fatal("AddFavorite(chat_a) should add new songs")
+	}
+	if add("chat_a", "1") {
+		t.Error("AddFavorite(chat_a, 1) twice should not add")
+	}
+	// 先查一次 A 群, 使查询进入缓存; B 群不能读到 A 群的歌单
+	if got := ids("chat_a"); !slices.Equal(got, []string{"1", "2"}) {
+		t.Fatalf("chat_a favorites = %v", got)
+	}
+	if got := ids("chat_b"); len(got) != 0 {
+		t.Fatalf("chat_b favorites = %v, want empty", got)
+	}
+	if !add("chat_b", "1") {
+		t.Fatal("AddFavorite(chat_b, 1) should add: favorites are per chat")
+	}
+	if got := ids("chat_b"); !slices.Equal(got, []string{"1"}) {
+		t.Fatalf("chat_b favorites after add = %v", got)
+	}
+
+	// 按序号移除 B 群的第 1 首, 不影响 A 群
+	tracks, err := Favorites(ctx, "chat_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFavorite(ctx, "chat_b", tracks[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids("chat_b"); len(got) != 0 {
+		t.Errorf("chat_b favorites after remove = %v", got)
+	}
+	if got := ids("chat_a"); !slices.Equal(got, []string{"1", "2"}) {
+		t.Errorf("chat_a favorites after chat_b remove = %v", got)
+	}
+	if err := RemoveFavorite(ctx, "chat_b", tracks[0]); err == nil {
+		t.Error("RemoveFavorite twice should fail")
+	}
+}
@@ -0,0 +1,340 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/cardaction"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/bytedance/sonic"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.uber.org/zap"
+)
+
+const (
+	// guessTTL 一轮猜歌的时长, 超时后无人猜中自动公布答案
+	guessTTL = 3 * time.Minute
+	// guessMinLen 出题的歌词行至少的字数, 太短的行没有辨识度
+	guessMinLen = 4
+	// guessMaxHints 每轮最多的提示次数
+	guessMaxHints = 3
+	// guessCandidates 按关键词出题时从多少首搜索结果中随机选歌
+	guessCandidates = 10
+)
+
+var (
+	// ErrGuessing 群里已经有一轮猜歌在进行
+	ErrGuessing = errors.New("a lyrics guessing round is already running")
+	// ErrNoGuess 群里没有进行中的猜歌
+	ErrNoGuess = errors.New("no lyrics guessing round is running")
+)
+
+// titleSuffixRe 歌名中括号里的版本、副标题, 如 (Live), 猜歌时不要求
+var titleSuffixRe = regexp.MustCompile(`[(（\[【].*?[)）\]】]`)
+
+// Round 一轮猜歌: 随机一句歌词, 群成员直接发送歌名作答
+type Round struct {
+	Track *Track
+	// Line 出题的歌词在 lines 中的下标, 提示依次给出后面的行
+	Line     int
+	Hints    int
+	lines    []musicapi.LyricLine
+	expireAt time.Time
+	// ended 已猜中、公布或超时, 超时的定时器据此判断是否还需公布答案
+	ended bool
+	timer *time.Timer
+}
+
+// Question 题面与已给出的提示
+func (r *Round) Question() []string {
+	res := make([]string, 0, r.Hints+1)
+	for _, line := range r.lines[r.Line : r.Line+r.Hints+1] {
+		res = append(res, line.Text)
+	}
+	return res
+}
+
+// guesses 各群进行中的猜歌, 只保存在进程内
+var guesses = struct {
+	mu    sync.Mutex
+	chats map[string]*Round
+}{chats: make(map[string]*Round)}
+
+// current 群里进行中的一轮, 超时的视为已结束, 由 expire 移除并公布答案; 调用方持锁
+func current(chatID string) *Round {
+	r := guesses.chats[chatID]
+	if r == nil || time.Now().After(r.expireAt) {
+		return nil
+	}
+	return r
+}
+
+// end 结束一轮猜歌; 调用方持锁
+func end(chatID string, r *Round) {
+	r.ended = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	if guesses.chats[chatID] == r {
+		delete(guesses.chats, chatID)
+	}
+}
+
+// expire 超时无人猜中时结束这一轮并在群里公布答案
+//
+//	@param chatID string
+//	@param r *Round
+func expire(chatID string, r *Round) {
+	guesses.mu.Lock()
+	if r.ended {
+		guesses.mu.Unlock()
+		return
+	}
+	end(chatID, r)
+	guesses.mu.Unlock()
+
+	ctx := context.Background()
+	content, err := sonic.MarshalString(AnswerCard(r, ""))
+	if err == nil {
+		_, err = larkmsg.CreateMsgRawContentType(ctx, chatID, larkim.MsgTypeInteractive, content, fmt.Sprintf("guess%d%s", r.expireAt.Unix(), chatID))
+	}
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("post expired lyrics guess failed", zap.String("chat_id", chatID), zap.Error(err))
+	}
+}
+
+// normalizeTitle 比较歌名前的归一化: 去掉括号中的副标题、空白与标点, 转为小写
+func normalizeTitle(s string) string {
+	s = titleSuffixRe.ReplaceAllString(s, "")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// isCorrect 答案中包含歌名即算猜中, 如 "是晴天吧"; 单字的歌名要求完全一致, 避免随口一句话就猜中
+func isCorrect(answer, title string) bool {
+	title, answer = normalizeTitle(title), normalizeTitle(answer)
+	if len([]rune(title)) < 2 {
+		return title != "" && answer == title
+	}
+	return strings.Contains(answer, title)
+}
+
+// pickLine 随机选一行出题: 要有足够的字数, 不能直接包含歌名, 后面还要留出提示的行
+//
+//	@return int 没有合适的行时返回 -1
+func pickLine(lines []musicapi.LyricLine, title string) int {
+	candidates := make([]int, 0, len(lines))
+	for i, line := range lines[:max(len(lines)-guessMaxHints, 0)] {
+		if len([]rune(normalizeTitle(line.Text))) >= guessMinLen && !isCorrect(line.Text, title) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+	return candidates[rand.IntN(len(candidates))]
+}
+
+// guessPool 出题的候选歌曲: 有关键词时取搜索结果, 否则取群歌单
+func guessPool(ctx context.Context, chatID, keywords string) ([]*Track, error) {
+	if keywords == "" {
+		tracks, err := Favorites(ctx, chatID)
+		if err != nil {
+			return nil, err
+		}
+		if len(tracks) == 0 {
+			return nil, errors.New("群歌单是空的, 请带上关键词, 如 /lyrics guess 周杰伦")
+		}
+		return tracks, nil
+	}
+	p, err := ProviderFor(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	songs, err := p.SearchSongs(ctx, keywords, guessCandidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(songs) == 0 {
+		return nil, ErrNotFound
+	}
+	return fromSongs(p.Name(), songs), nil
+}
+
+// StartGuess 开始一轮猜歌, 从候选歌曲中随机选一首有歌词的歌出题
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param keywords string 为空时从群歌单出题
+//	@return map[string]any 题目卡片
+//	@return error
+func StartGuess(ctx context.Context, chatID, keywords string) (map[string]any, error) {
+	guesses.mu.Lock()
+	running := current(chatID) != nil
+	guesses.mu.Unlock()
+	if running {
+		return nil, ErrGuessing
+	}
+	pool, err := guessPool(ctx, chatID, keywords)
+	if err != nil {
+		return nil, err
+	}
+	rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	for _, t := range pool {
+		p, err := trackProvider(ctx, chatID, t)
+		if err != nil {
+			return nil, err
+		}
+		lines, err := LyricsOf(ctx, p, t.ID)
+		if err != nil {
+			continue
+		}
+		idx := pickLine(lines, t.Name)
+		if idx < 0 {
+			continue
+		}
+		r := &Round{Track: t, Line: idx, lines: lines, expireAt: time.Now().Add(guessTTL)}
+		guesses.mu.Lock()
+		defer guesses.mu.Unlock()
+		// 查歌词期间可能已有人开了一轮
+		if current(chatID) != nil {
+			return nil, ErrGuessing
+		}
+		guesses.chats[chatID] = r
+		r.timer = time.AfterFunc(guessTTL, func() { xlifecycle.Go(func() { expire(chatID, r) }) })
+		return guessCard(r), nil
+	}
+	return nil, errors.New("没有找到适合出题的歌词")
+}
+
+// Guessing 群里是否有进行中的猜歌
+func Guessing(chatID string) bool {
+	guesses.mu.Lock()
+	defer guesses.mu.Unlock()
+	return current(chatID) != nil
+}
+
+// Answer 校验群成员的回答, 猜中时结束这一轮
+//
+//	@param chatID string
+//	@param answer string
+//	@return *Round 猜中的一轮, 没猜中或没有进行中的猜歌时为 nil
+func Answer(chatID, answer string) *Round {
+	guesses.mu.Lock()
+	defer guesses.mu.Unlock()
+	r := current(chatID)
+	if r == nil || !isCorrect(answer, r.Track.Name) {
+		return nil
+	}
+	end(chatID, r)
+	return r
+}
+
+// Hint 多给一行歌词作为提示
+//
+//	@param chatID string
+//	@return map[string]any 更新后的题目卡片
+//	@return error
+func Hint(chatID string) (map[string]any, error) {
+	guesses.mu.Lock()
+	defer guesses.mu.Unlock()
+	r := current(chatID)
+	if r == nil {
+		return nil, ErrNoGuess
+	}
+	if r.Hints >= guessMaxHints {
+		return nil, fmt.Errorf("最多提示 %d 次", guessMaxHints)
+	}
+	r.Hints++
+	return guessCard(r), nil
+}
+
+// Reveal 公布答案并结束这一轮
+//
+//	@param chatID string
+//	@return *Round
+//	@return error
+func Reveal(chatID string) (*Round, error) {
+	guesses.mu.Lock()
+	defer guesses.mu.Unlock()
+	r := current(chatID)
+	if r == nil {
+		return nil, ErrNoGuess
+	}
+	end(chatID, r)
+	return r, nil
+}
+
+// guessCard 题目卡片: 歌词与已给出的提示, 带提示、公布答案按钮
+func guessCard(r *Round) map[string]any {
+	question := r.Question()
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "**%s**", question[0])
+	for _, hint := range question[1:] {
+		fmt.Fprintf(b, "\n%s", hint)
+	}
+	elements := []any{
+		markdown(b.String()),
+		markdown(fmt.Sprintf("<font color='grey'>直接发送歌名作答, %d 分钟内有效</font>", int(guessTTL/time.Minute))),
+	}
+	buttons := make([]map[string]any, 0, 2)
+	if r.Hints < guessMaxHints {
+		buttons = append(buttons, button(fmt.Sprintf("提示 (%d/%d)", r.Hints, guessMaxHints), "default", cardaction.Value(actionGuessHint)))
+	}
+	buttons = append(buttons, button("公布答案", "danger", cardaction.Value(actionGuessReveal)))
+	return card("听歌词猜歌名", "turquoise", append(elements, buttonRow(buttons...)))
+}
+
+// AnswerCard 公布答案: 歌名与播放、歌词按钮
+//
+//	@param r *Round
+//	@param winner string 猜中的成员 open_id, 为空表示无人猜中
+//	@return map[string]any
+func AnswerCard(r *Round, winner string) map[string]any {
+	text := "没有人猜中"
+	if winner != "" {
+		text = fmt.Sprintf("<at id=%s></at> 猜中了!", winner)
+	}
+	t := r.Track
+	elements := []any{
+		markdown(fmt.Sprintf("%s\n答案是 **%s** - %s", text, t.Name, t.Artist)),
+		markdown(fmt.Sprintf("<font color='grey'>%s</font>", strings.Join(r.Question(), "\n"))),
+		buttonRow(
+			button("播放", "primary", trackValue(actionPlay, t)),
+			button("歌词", "default", trackValue(actionLyrics, t)),
+		),
+	}
+	return card("听歌词猜歌名", "turquoise", elements)
+}
+
+func onGuessHint(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	card, err := Hint(chatOf(event))
+	if err != nil {
+		return nil, err
+	}
+	return replaceCard(card), nil
+}
+
+// onGuessReveal 题目卡片上的 "公布答案": 原卡片替换为答案
+func onGuessReveal(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	r, err := Reveal(chatOf(event))
+	if err != nil {
+		return nil, err
+	}
+	return replaceCard(AnswerCard(r, "")), nil
+}
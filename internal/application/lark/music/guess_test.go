@@ -0,0 +1,80 @@
+package music
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+)
+
+func TestIsCorrect(t *testing.T) {
+	tests := []struct {
+		answer, title string
+		want          bool
+	}{
+		{"是晴天吧", "晴天", true},
+		{"晴天", "晴天 (Live)", true},
+		{"love story!", "Love Story", true},
+		{"下雨天", "晴天", false},
+		{"我觉得是", "我", false},
+		{"我", "我", true},
+	}
+	for _, tt := range tests {
+		if got := isCorrect(tt.answer, tt.title); got != tt.want {
+			t.Errorf("isCorrect(%q, %q) = %v, want %v", tt.answer, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestGuessRound(t *testing.T) {
+	lines := []musicapi.LyricLine{
+		{Text: "晴天"}, {Text: "故事的小黄花"}, {Text: "从出生那年就飘着"}, {Text: "童年的荡秋千"}, {Text: "随记忆一直晃到现在"},
+	}
+	// 含歌名的行与末尾留给提示的行不会被选中
+	for range 20 {
+		if idx := pickLine(lines, "晴天"); idx != 1 {
+			t.Fatalf("pickLine() = %d, want 1", idx)
+		}
+	}
+	if idx := pickLine(lines[:2], "晴天"); idx != -1 {
+		t.Fatalf("pickLine(short) = %d, want -1", idx)
+	}
+
+	chatID := "oc_guess_test"
+	guesses.chats[chatID] = &Round{Track: &Track{Name: "晴天"}, Line: 1, lines: lines, expireAt: time.Now().Add(guessTTL)}
+	if _, err := Hint(chatID); err != nil {
+		t.Fatalf("Hint() error = %v", err)
+	}
+	if r := Answer(chatID, "七里香"); r != nil {
+		t.Fatal("Answer(wrong) should not end the round")
+	}
+	r := Answer(chatID, "晴天!")
+	if r == nil || len(r.Question()) != 2 {
+		t.Fatalf("Answer() = %+v", r)
+	}
+	if Guessing(chatID) {
+		t.Fatal("round should end after a correct answer")
+	}
+	// 猜中后超时的定时器不再公布答案
+	if !r.ended {
+		t.Fatal("answered round should be marked ended")
+	}
+	expire(chatID, r)
+
+	guesses.chats[chatID] = &Round{Track: &Track{Name: "晴天"}, lines: lines, expireAt: time.Now().Add(-time.Second)}
+	if _, err := Reveal(chatID); err != ErrNoGuess {
+		t.Fatalf("Reveal(expired) error = %v", err)
+	}
+}
+
+func TestLyricsCardPages(t *testing.T) {
+	lines := make([]musicapi.LyricLine, lyricsPageSize+1)
+	if n := lyricsPages(lines); n != 2 {
+		t.Fatalf("lyricsPages() = %d, want 2", n)
+	}
+	if n := lyricsPages(nil); n != 1 {
+		t.Fatalf("lyricsPages(nil) = %d, want 1", n)
+	}
+	// 超出范围的页码取最后一页, 不会越界
+	lyricsCard(&Track{ID: "1"}, lines, 5)
+}
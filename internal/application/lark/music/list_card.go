@@ -0,0 +1,148 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkimg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larktpl"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.uber.org/zap"
+)
+
+// maxCommentLen 列表卡片中热门评论的最大长度
+const maxCommentLen = 50
+
+// listItem 列表卡片中的一行
+type listItem struct {
+	ID       string
+	Title    string
+	Subtitle string
+	PicURL   string
+	ImageKey string
+	// Desc 没有热门评论时展示的说明
+	Desc string
+	// Unavailable 歌曲没有播放链接
+	Unavailable bool
+}
+
+// listButton 各类资源的按钮文案与回调 action
+var listButton = map[musicapi.Kind][2]string{
+	musicapi.KindSong:     {"点击播放", actionPlay},
+	musicapi.KindAlbum:    {"查看专辑", actionAlbum},
+	musicapi.KindArtist:   {"查看歌手", actionArtist},
+	musicapi.KindPlaylist: {"查看歌单", actionPlaylist},
+}
+
+// SongListCard 歌曲的搜索结果卡片
+//
+//	@param ctx context.Context
+//	@param p musicapi.Provider
+//	@param songs []*musicapi.Song
+//	@param title string 卡片标题中的搜索词
+//	@return *larktpl.TemplateCardContent
+func SongListCard(ctx context.Context, p musicapi.Provider, songs []*musicapi.Song, title string) *larktpl.TemplateCardContent {
+	items := make([]*listItem, 0, len(songs))
+	for _, s := range songs {
+		items = append(items, &listItem{ID: s.ID, Title: s.Name, Subtitle: s.Artist, PicURL: s.PicURL, ImageKey: s.ImageKey, Unavailable: s.URL == ""})
+	}
+	return listCard(ctx, p, musicapi.KindSong, items, title)
+}
+
+// AlbumListCard 专辑的搜索结果卡片
+func AlbumListCard(ctx context.Context, p musicapi.Provider, albums []*musicapi.Album, title string) *larktpl.TemplateCardContent {
+	items := make([]*listItem, 0, len(albums))
+	for _, a := range albums {
+		items = append(items, &listItem{ID: a.ID, Title: "[" + a.Type + "] " + a.Name, Subtitle: a.Artist, PicURL: a.PicURL})
+	}
+	return listCard(ctx, p, musicapi.KindAlbum, items, title)
+}
+
+// ArtistListCard 歌手的搜索结果卡片, 别名作为副标题, 单曲与专辑数作为说明
+func ArtistListCard(ctx context.Context, p musicapi.Provider, artists []*musicapi.Artist, title string) *larktpl.TemplateCardContent {
+	items := make([]*listItem, 0, len(artists))
+	for _, a := range artists {
+		items = append(items, &listItem{
+			ID:       a.ID,
+			Title:    a.Name,
+			Subtitle: strings.Join(a.Alias, " / "),
+			PicURL:   a.PicURL,
+			Desc:     fmt.Sprintf("单曲 %d · 专辑 %d", a.MusicSize, a.AlbumSize),
+		})
+	}
+	return listCard(ctx, p, musicapi.KindArtist, items, title)
+}
+
+// PlaylistListCard 歌单的搜索结果卡片, 创建者作为副标题
+func PlaylistListCard(ctx context.Context, p musicapi.Provider, playlists []*musicapi.Playlist, title string) *larktpl.TemplateCardContent {
+	items := make([]*listItem, 0, len(playlists))
+	for _, pl := range playlists {
+		items = append(items, &listItem{
+			ID:       pl.ID,
+			Title:    pl.Name,
+			Subtitle: "by " + pl.Creator,
+			PicURL:   pl.PicURL,
+			Desc:     fmt.Sprintf("%d 首 · 播放 %d 次", pl.TrackCount, pl.PlayCount),
+		})
+	}
+	return listCard(ctx, p, musicapi.KindPlaylist, items, title)
+}
+
+// listCard 每行并发获取热门评论、上传封面, 按原顺序渲染
+func listCard(ctx context.Context, p musicapi.Provider, kind musicapi.Kind, items []*listItem, title string) *larktpl.TemplateCardContent {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	button := listButton[kind]
+	lines := make([]map[string]any, len(items))
+	wg := &sync.WaitGroup{}
+	for idx, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if item.ImageKey == "" && item.PicURL != "" {
+				item.ImageKey = larkimg.UploadPicture2Lark(ctx, item.PicURL)
+			}
+			line := map[string]any{
+				"idx":         idx,
+				"field_1":     fmt.Sprintf("**%s**\n**%s**", item.Title, item.Subtitle),
+				"field_2":     map[string]any{"img_key": item.ImageKey},
+				"button_info": button[0],
+				"element_id":  item.ID,
+				"button_val": map[string]string{
+					"action":   button[1],
+					"type":     string(kind),
+					"id":       item.ID,
+					"provider": p.Name(),
+				},
+			}
+			if item.Desc != "" {
+				line["field_3"] = item.Desc
+			}
+			comment, err := p.HotComment(ctx, kind, item.ID)
+			if err != nil {
+				logs.L().Ctx(ctx).Error("HotComment Error", zap.Error(err))
+			}
+			if comment != nil {
+				line["field_3"] = comment.Content
+				if r := []rune(comment.Content); len(r) > maxCommentLen {
+					line["field_3"] = string(r[:maxCommentLen]) + "..."
+				}
+				line["comment_time"] = comment.Time
+			}
+			if item.Unavailable {
+				line["button_info"] = "歌曲无效"
+			}
+			lines[idx] = line
+		}()
+	}
+	wg.Wait()
+	return larktpl.NewCardContent(ctx, larktpl.AlbumListTemplate).
+		AddVariable("object_list_1", lines).
+		AddVariable("query", fmt.Sprintf("[%s]", title))
+}
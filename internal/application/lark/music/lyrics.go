@@ -0,0 +1,128 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/cardaction"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/cache"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+)
+
+// lyricsPageSize 歌词卡片每页的行数
+const lyricsPageSize = 15
+
+// ErrNoLyrics 歌曲没有歌词, 通常是纯音乐
+var ErrNoLyrics = errors.New("song has no lyrics")
+
+// lyricsTTL 歌词基本不变, 翻页、猜歌会反复读取同一首歌
+const lyricsTTL = 6 * time.Hour
+
+// LyricsOf 解析后的歌词, 按音乐源与歌曲 ID 缓存
+//
+//	@param ctx context.Context
+//	@param p musicapi.Provider
+//	@param songID string
+//	@return []musicapi.LyricLine
+//	@return error
+func LyricsOf(ctx context.Context, p musicapi.Provider, songID string) ([]musicapi.LyricLine, error) {
+	lines, err := cache.GetOrExecute(ctx, p.Name()+":"+songID, lyricsTTL, func() ([]musicapi.LyricLine, error) {
+		lyrics, err := p.Lyrics(ctx, songID)
+		if err != nil {
+			return nil, err
+		}
+		return lyrics.Lines(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, ErrNoLyrics
+	}
+	return lines, nil
+}
+
+// LyricsCard 歌曲的歌词卡片
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param t *Track
+//	@param page int 从 1 开始, 超出范围时取最近的一页
+//	@return map[string]any
+//	@return error
+func LyricsCard(ctx context.Context, chatID string, t *Track, page int) (map[string]any, error) {
+	p, err := trackProvider(ctx, chatID, t)
+	if err != nil {
+		return nil, err
+	}
+	t.Provider = p.Name()
+	lines, err := LyricsOf(ctx, p, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return lyricsCard(t, lines, page), nil
+}
+
+// lyricsPages 歌词的总页数
+func lyricsPages(lines []musicapi.LyricLine) int {
+	return max((len(lines)+lyricsPageSize-1)/lyricsPageSize, 1)
+}
+
+// lyricsCard 分页的双语歌词, 每行带时间, 译文以灰色跟在原文后
+func lyricsCard(t *Track, lines []musicapi.LyricLine, page int) map[string]any {
+	pages := lyricsPages(lines)
+	page = min(max(page, 1), pages)
+	b := &strings.Builder{}
+	for _, line := range lines[(page-1)*lyricsPageSize : min(page*lyricsPageSize, len(lines))] {
+		fmt.Fprintf(b, "<font color='grey'>%s</font>  %s\n", musicapi.FormatLyricTime(line.Time), line.Text)
+		if line.Translation != "" {
+			fmt.Fprintf(b, "<font color='grey'>%s</font>\n", line.Translation)
+		}
+	}
+	elements := []any{markdown(fmt.Sprintf("**%s**\n%s", t.Name, t.Artist)), markdown(b.String())}
+	if pages > 1 {
+		pageValue := func(page int) map[string]any {
+			v := trackValue(actionLyrics, t)
+			v["page"] = strconv.Itoa(page)
+			return v
+		}
+		buttons := make([]map[string]any, 0, 2)
+		if page > 1 {
+			buttons = append(buttons, button("上一页", "default", pageValue(page-1)))
+		}
+		if page < pages {
+			buttons = append(buttons, button("下一页", "default", pageValue(page+1)))
+		}
+		elements = append(elements, markdown(fmt.Sprintf("<font color='grey'>第 %d/%d 页</font>", page, pages)), buttonRow(buttons...))
+	}
+	return card("歌词", "purple", elements)
+}
+
+// onLyrics 歌词卡片的翻页将原卡片替换为目标页; 其他卡片上的 "歌词" 发送新的歌词卡片
+func onLyrics(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	t, err := trackFromEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	page, err := strconv.Atoi(cardaction.String(event, "page"))
+	if err != nil {
+		card, err := LyricsCard(ctx, chatOf(event), t, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err = sendCard(ctx, event, card); err != nil {
+			return nil, err
+		}
+		return toast("success", "已发送歌词"), nil
+	}
+	card, err := LyricsCard(ctx, chatOf(event), t, page)
+	if err != nil {
+		return nil, err
+	}
+	return replaceCard(card), nil
+}
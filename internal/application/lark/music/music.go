@@ -0,0 +1,451 @@
+// Package music 群内点歌: 每个群一个待播队列, 播放卡片带解析好的播放链接与歌词;
+// 另有持久化在 Postgres 的群收藏歌单, 搜索、专辑、播放卡片上的按钮都在这里处理.
+// 歌曲来自群所选的音乐源 (musicapi.Provider), 未选择时使用默认音乐源
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/cardaction"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkimg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	actionPlay     = "music.play"
+	actionAlbum    = "music.album"
+	actionArtist   = "music.artist"
+	actionPlaylist = "music.playlist"
+	actionAdd      = "music.add"
+	actionNext     = "music.next"
+	actionClear    = "music.clear"
+	actionFav      = "music.fav"
+	actionUnfav    = "music.unfav"
+	actionLyrics   = "music.lyrics"
+	// actionGuessHint 猜歌卡片上的 "提示"
+	actionGuessHint = "music.guess.hint"
+	// actionGuessReveal 猜歌卡片上的 "公布答案"
+	actionGuessReveal = "music.guess.reveal"
+)
+
+var (
+	// ErrUnavailable 歌曲没有可用的播放链接, 通常是无版权或需要会员
+	ErrUnavailable = errors.New("song is unavailable")
+	// ErrNotFound 找不到歌曲
+	ErrNotFound = errors.New("song not found")
+)
+
+// Init 没有注册任何音乐源时注册一个空的本地音乐源, 并注册卡片按钮回调
+func Init() {
+	if musicapi.Default() == nil {
+		logs.L().Warn("no music provider is registered, fallback to an empty local provider")
+		musicapi.Register(musicapi.NewLocal())
+	}
+	registerCardActions()
+}
+
+// FromSong 音乐源的歌曲转为 Track
+//
+//	@param provider string 音乐源名称
+//	@param s *musicapi.Song
+//	@return *Track
+func FromSong(provider string, s *musicapi.Song) *Track {
+	return &Track{ID: s.ID, Name: s.Name, Artist: s.Artist, PicURL: s.PicURL, Provider: provider}
+}
+
+func fromSongs(provider string, songs []*musicapi.Song) []*Track {
+	tracks := make([]*Track, 0, len(songs))
+	for _, s := range songs {
+		tracks = append(tracks, FromSong(provider, s))
+	}
+	return tracks
+}
+
+// Lookup 按歌曲 ID 查询详情
+//
+//	@param ctx context.Context
+//	@param p musicapi.Provider
+//	@param songID string
+//	@return *Track
+//	@return error
+func Lookup(ctx context.Context, p musicapi.Provider, songID string) (*Track, error) {
+	song, err := p.SongDetail(ctx, songID)
+	if errors.Is(err, musicapi.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return FromSong(p.Name(), song), nil
+}
+
+// Search 在群的音乐源中按关键词搜索可以播放的歌曲
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param keywords string
+//	@return []*Track
+//	@return error
+func Search(ctx context.Context, chatID, keywords string) ([]*Track, error) {
+	p, err := ProviderFor(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	songs, err := p.SearchSongs(ctx, keywords, maxCardTracks)
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]*Track, 0, len(songs))
+	for _, s := range songs {
+		// 没有播放链接的搜索结果无法播放, 跳过
+		if s.URL != "" {
+			tracks = append(tracks, FromSong(p.Name(), s))
+		}
+	}
+	if len(tracks) == 0 {
+		return nil, ErrNotFound
+	}
+	return tracks, nil
+}
+
+// AlbumTracks 专辑中的全部歌曲
+//
+//	@param ctx context.Context
+//	@param p musicapi.Provider
+//	@param albumID string
+//	@return []*Track
+//	@return error
+func AlbumTracks(ctx context.Context, p musicapi.Provider, albumID string) ([]*Track, error) {
+	songs, err := p.AlbumSongs(ctx, albumID)
+	if err != nil {
+		return nil, err
+	}
+	return fromSongs(p.Name(), songs), nil
+}
+
+// Play 解析播放链接与歌词, 设为群内正在播放的歌, 返回播放卡片
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param t *Track
+//	@return card map[string]any
+//	@return err error
+func Play(ctx context.Context, chatID string, t *Track) (card map[string]any, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("song_id", t.ID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	p, err := trackProvider(ctx, chatID, t)
+	if err != nil {
+		return nil, err
+	}
+	t.Provider = p.Name()
+	urls, err := p.SongURLs(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	url := urls[t.ID]
+	if url == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, t.Name)
+	}
+	// 歌词只是附带的, 获取失败不影响播放
+	lyrics, _ := p.Lyrics(ctx, t.ID)
+	imgKey := ""
+	if t.PicURL != "" {
+		imgKey = larkimg.UploadPicture2Lark(ctx, t.PicURL)
+	}
+	SetNow(chatID, t)
+	_, upcoming := Queue(chatID)
+	return playerCard(t, url, imgKey, lyrics.Plain(), len(upcoming)), nil
+}
+
+// ArtistCard 歌手卡片: 简介、热门歌曲与最近的专辑
+//
+//	@param ctx context.Context
+//	@param p musicapi.Provider
+//	@param artistID string
+//	@return map[string]any
+//	@return error
+func ArtistCard(ctx context.Context, p musicapi.Provider, artistID string) (map[string]any, error) {
+	detail, err := p.ArtistDetail(ctx, artistID, maxArtistAlbums)
+	if err != nil {
+		return nil, err
+	}
+	songs := fromSongs(p.Name(), detail.HotSongs[:min(maxArtistSongs, len(detail.HotSongs))])
+	imgKey := ""
+	if detail.Artist.PicURL != "" {
+		imgKey = larkimg.UploadPicture2Lark(ctx, detail.Artist.PicURL)
+	}
+	return artistCard(p.Name(), detail.Artist, imgKey, songs, detail.Albums), nil
+}
+
+// QueueCard 群的播放队列卡片
+func QueueCard(chatID string) map[string]any {
+	return queueCard(Queue(chatID))
+}
+
+// AlbumCard 专辑歌曲列表卡片
+func AlbumCard(tracks []*Track) map[string]any {
+	return trackListCard("专辑", tracks, false)
+}
+
+// FavoritesCard 群收藏歌单卡片
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return map[string]any
+//	@return error
+func FavoritesCard(ctx context.Context, chatID string) (map[string]any, error) {
+	tracks, err := Favorites(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	return trackListCard(fmt.Sprintf("群歌单 (%d)", len(tracks)), tracks, true), nil
+}
+
+func registerCardActions() {
+	cardaction.Register(actionPlay, onPlay)
+	cardaction.Register(actionAlbum, onAlbum)
+	cardaction.Register(actionArtist, onArtist)
+	cardaction.Register(actionPlaylist, onPlaylist)
+	cardaction.Register(actionAdd, onAdd)
+	cardaction.Register(actionNext, onNext)
+	cardaction.Register(actionClear, onClear)
+	cardaction.Register(actionFav, onFav)
+	cardaction.Register(actionUnfav, onUnfav)
+	cardaction.Register(actionLyrics, onLyrics)
+	cardaction.Register(actionGuessHint, onGuessHint)
+	cardaction.Register(actionGuessReveal, onGuessReveal)
+}
+
+func chatOf(event *callback.CardActionTriggerEvent) string {
+	if event.Event == nil || event.Event.Context == nil {
+		return ""
+	}
+	return event.Event.Context.OpenChatID
+}
+
+func operatorOf(event *callback.CardActionTriggerEvent) string {
+	if event.Event == nil || event.Event.Operator == nil {
+		return ""
+	}
+	return event.Event.Operator.OpenID
+}
+
+// providerFromEvent 按钮所属的音乐源, 没有记录时使用群的音乐源
+func providerFromEvent(ctx context.Context, event *callback.CardActionTriggerEvent) (musicapi.Provider, error) {
+	if p, ok := musicapi.Get(cardaction.String(event, "provider")); ok {
+		return p, nil
+	}
+	return ProviderFor(ctx, chatOf(event))
+}
+
+// trackFromEvent 按钮中的歌曲; 搜索卡片的按钮只带 ID, 需要查询详情
+func trackFromEvent(ctx context.Context, event *callback.CardActionTriggerEvent) (*Track, error) {
+	id := cardaction.String(event, "id")
+	if id == "" {
+		return nil, ErrNotFound
+	}
+	p, err := providerFromEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	t := &Track{ID: id, Name: cardaction.String(event, "name"), Artist: cardaction.String(event, "artist"), PicURL: cardaction.String(event, "pic"), Provider: p.Name()}
+	if t.Name == "" {
+		if t, err = Lookup(ctx, p, id); err != nil {
+			return nil, err
+		}
+	}
+	t.AddedBy = operatorOf(event)
+	return t, nil
+}
+
+func toast(kind, content string) *callback.CardActionTriggerResponse {
+	return &callback.CardActionTriggerResponse{Toast: &callback.Toast{Type: kind, Content: content}}
+}
+
+func replaceCard(card map[string]any) *callback.CardActionTriggerResponse {
+	return &callback.CardActionTriggerResponse{Card: &callback.Card{Type: "raw", Data: card}}
+}
+
+// sendCard 将卡片作为新消息发到群里; 以回调的 token 作为幂等键, 重试的回调不会重复发送
+func sendCard(ctx context.Context, event *callback.CardActionTriggerEvent, card map[string]any) error {
+	content, err := sonic.MarshalString(card)
+	if err != nil {
+		return err
+	}
+	_, err = larkmsg.CreateMsgRawContentType(ctx, chatOf(event), larkim.MsgTypeInteractive, content, "music"+event.Event.Token)
+	return err
+}
+
+// onPlay 搜索、专辑、歌单卡片上的 "播放": 后台取播放地址、歌词并发送新的播放卡片, 原卡片保留
+func onPlay(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	cardaction.Async(ctx, event, func(ctx context.Context) (err error) {
+		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+		defer span.End()
+		defer func() { span.RecordError(err) }()
+
+		t, err := trackFromEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+		card, err := Play(ctx, chatOf(event), t)
+		if err != nil {
+			return err
+		}
+		return sendCard(ctx, event, card)
+	})
+	return toast("info", "正在准备播放"), nil
+}
+
+// onAlbum 搜索卡片上的 "查看专辑": 后台发送专辑歌曲列表
+func onAlbum(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	cardaction.Async(ctx, event, func(ctx context.Context) (err error) {
+		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+		defer span.End()
+		defer func() { span.RecordError(err) }()
+
+		p, err := providerFromEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+		tracks, err := AlbumTracks(ctx, p, cardaction.String(event, "id"))
+		if err != nil {
+			return err
+		}
+		return sendCard(ctx, event, AlbumCard(tracks))
+	})
+	return toast("info", "正在获取专辑"), nil
+}
+
+// onArtist 搜索卡片上的 "查看歌手": 后台发送歌手卡片
+func onArtist(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	cardaction.Async(ctx, event, func(ctx context.Context) (err error) {
+		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+		defer span.End()
+		defer func() { span.RecordError(err) }()
+
+		p, err := providerFromEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+		card, err := ArtistCard(ctx, p, cardaction.String(event, "id"))
+		if err != nil {
+			return err
+		}
+		return sendCard(ctx, event, card)
+	})
+	return toast("info", "正在获取歌手信息"), nil
+}
+
+// onPlaylist 搜索卡片上的 "查看歌单": 后台以搜索卡片的样式回复歌单中的歌曲
+func onPlaylist(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	cardaction.Async(ctx, event, func(ctx context.Context) (err error) {
+		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+		defer span.End()
+		defer func() { span.RecordError(err) }()
+
+		p, err := providerFromEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+		pp, ok := p.(musicapi.PlaylistProvider)
+		if !ok {
+			return fmt.Errorf("%s: %w", p.Name(), musicapi.ErrNotSupported)
+		}
+		songs, err := pp.PlaylistSongs(ctx, cardaction.String(event, "id"), maxPlaylistTracks)
+		if err != nil {
+			return err
+		}
+		if len(songs) == 0 {
+			return errors.New("歌单是空的")
+		}
+		return larkmsg.ReplyCard(ctx, SongListCard(ctx, p, songs, "歌单"), event.Event.Context.OpenMessageID, "_musicPlaylist"+event.Event.Token, false)
+	})
+	return toast("info", "正在获取歌单"), nil
+}
+
+func onAdd(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	t, err := trackFromEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if Enqueue(chatOf(event), t) == 0 {
+		return toast("info", "已在队列中或队列已满"), nil
+	}
+	_, upcoming := Queue(chatOf(event))
+	return toast("success", fmt.Sprintf("已加入队列, 第 %d 首", len(upcoming))), nil
+}
+
+// onNext 播放卡片、队列卡片上的 "下一首": 出队后立即返回, 后台把原卡片更新为下一首的播放卡片
+func onNext(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	t, ok := Next(chatOf(event))
+	if !ok {
+		return toast("info", "队列是空的"), nil
+	}
+	cardaction.Async(ctx, event, func(ctx context.Context) (err error) {
+		ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+		defer span.End()
+		defer func() { span.RecordError(err) }()
+
+		card, err := Play(ctx, chatOf(event), t)
+		if err != nil {
+			return err
+		}
+		content, err := sonic.MarshalString(card)
+		if err != nil {
+			return err
+		}
+		return larkmsg.PatchCardRaw(ctx, content, event.Event.Context.OpenMessageID)
+	})
+	return toast("success", "下一首: "+t.Name), nil
+}
+
+func onClear(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	Clear(chatOf(event))
+	return replaceCard(QueueCard(chatOf(event))), nil
+}
+
+func onFav(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	t, err := trackFromEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	added, err := AddFavorite(ctx, chatOf(event), operatorOf(event), t)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return toast("info", "已经在群歌单里了"), nil
+	}
+	return toast("success", "已收藏到群歌单"), nil
+}
+
+// onUnfav 歌单卡片上的 "移除": 原卡片替换为移除后的歌单
+func onUnfav(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+	t, err := trackFromEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if err := RemoveFavorite(ctx, chatOf(event), t); err != nil {
+		return nil, err
+	}
+	card, err := FavoritesCard(ctx, chatOf(event))
+	if err != nil {
+		return nil, err
+	}
+	resp := replaceCard(card)
+	resp.Toast = &callback.Toast{Type: "success", Content: "已移除"}
+	return resp, nil
+}
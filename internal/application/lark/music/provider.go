@@ -0,0 +1,76 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// providerPrefix function_enablings 中群所选音乐源的前缀, 如 music_provider_netease
+const providerPrefix = "music_provider_"
+
+// ErrNoProvider 没有注册任何音乐源
+var ErrNoProvider = errors.New("no music provider is registered")
+
+// ProviderFor 群使用的音乐源; 未选择或所选的音乐源未注册时使用默认音乐源
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return musicapi.Provider
+//	@return error
+func ProviderFor(ctx context.Context, chatID string) (musicapi.Provider, error) {
+	ins := query.Q.FunctionEnabling
+	rows, err := ins.WithContext(ctx).Where(ins.GuildID.Eq(chatID), ins.Function.Like(providerPrefix+"%")).Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if p, ok := musicapi.Get(strings.TrimPrefix(row.Function, providerPrefix)); ok {
+			return p, nil
+		}
+	}
+	if p := musicapi.Default(); p != nil {
+		return p, nil
+	}
+	return nil, ErrNoProvider
+}
+
+// trackProvider 歌曲所属的音乐源, 未记录时使用群的音乐源
+func trackProvider(ctx context.Context, chatID string, t *Track) (musicapi.Provider, error) {
+	if p, ok := musicapi.Get(t.Provider); ok {
+		return p, nil
+	}
+	return ProviderFor(ctx, chatID)
+}
+
+// SetProvider 为群选择音乐源
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param name string 已注册的音乐源名称
+//	@return err error
+func SetProvider(ctx context.Context, chatID, name string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("provider", name))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if _, ok := musicapi.Get(name); !ok {
+		return fmt.Errorf("unknown music provider %q, available: %s", name, strings.Join(musicapi.Names(), ", "))
+	}
+	return query.Q.Transaction(func(tx *query.Query) error {
+		ins := tx.FunctionEnabling
+		if _, err := ins.WithContext(ctx).Where(ins.GuildID.Eq(chatID), ins.Function.Like(providerPrefix+"%")).Delete(); err != nil {
+			return err
+		}
+		return ins.WithContext(ctx).Create(&model.FunctionEnabling{GuildID: chatID, Function: providerPrefix + name})
+	})
+}
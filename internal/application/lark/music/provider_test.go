@@ -0,0 +1,67 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+)
+
+func TestLookupLocal(t *testing.T) {
+	ctx := context.Background()
+	p := musicapi.NewLocal(
+		&musicapi.LocalSong{Song: musicapi.Song{ID: "1", Name: "晴天", Artist: "周杰伦"}, AlbumID: "a1"},
+		&musicapi.LocalSong{Song: musicapi.Song{ID: "2", Name: "东风破", Artist: "周杰伦"}, AlbumID: "a1"},
+	)
+	p.AddAlbum(&musicapi.Album{ID: "a1", Name: "叶惠美"})
+
+	track, err := Lookup(ctx, p, "1")
+	if err != nil || track.Name != "晴天" || track.Provider != musicapi.LocalName {
+		t.Fatalf("Lookup() = %+v, %v", track, err)
+	}
+	if _, err = Lookup(ctx, p, "404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup(404) error = %v", err)
+	}
+	tracks, err := AlbumTracks(ctx, p, "a1")
+	if err != nil || len(tracks) != 2 || tracks[1].Name != "东风破" {
+		t.Errorf("AlbumTracks() = %v, %v", tracks, err)
+	}
+}
+
+// namedLocal 换了名称的本地音乐源, 用于区分群所选的音乐源
+type namedLocal struct {
+	*musicapi.Local
+	name string
+}
+
+func (n namedLocal) Name() string { return n.name }
+
+func TestProviderPerChat(t *testing.T) {
+	dbtest.Open(t, &model.FunctionEnabling{})
+	ctx := context.Background()
+	musicapi.Register(musicapi.NewLocal())
+	musicapi.Register(namedLocal{musicapi.NewLocal(), "other"})
+
+	if err := SetProvider(ctx, "chat_a", "other"); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := ProviderFor(ctx, "chat_a"); err != nil || p.Name() != "other" {
+		t.Fatalf("ProviderFor(chat_a) = %v, %v", p, err)
+	}
+	if p, err := ProviderFor(ctx, "chat_b"); err != nil || p.Name() == "other" {
+		t.Fatalf("ProviderFor(chat_b) = %v, %v, want the default provider", p, err)
+	}
+	// 切换后立即生效, 不读到切换前的结果
+	if err := SetProvider(ctx, "chat_a", musicapi.LocalName); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := ProviderFor(ctx, "chat_a"); err != nil || p.Name() != musicapi.LocalName {
+		t.Fatalf("ProviderFor(chat_a) after switch = %v, %v", p, err)
+	}
+	if err := SetProvider(ctx, "chat_a", "missing"); err == nil {
+		t.Error("SetProvider(missing) should fail")
+	}
+}
@@ -0,0 +1,119 @@
+package music
+
+import (
+	"slices"
+	"sync"
+)
+
+// MaxQueueLen 每个群待播队列的最大长度
+const MaxQueueLen = 50
+
+// Track 一首歌, 队列与收藏中保存的信息足够直接渲染卡片, 播放时再解析链接
+type Track struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Artist string `json:"artist"`
+	PicURL string `json:"pic_url"`
+	// Provider 歌曲所属的音乐源, 同一首歌在不同音乐源中的 ID 不同
+	Provider string `json:"provider"`
+	// AddedBy 点歌的成员 open_id
+	AddedBy string `json:"added_by"`
+}
+
+// same 是否为同一音乐源中的同一首歌
+func (t *Track) same(o *Track) bool {
+	return t.ID == o.ID && t.Provider == o.Provider
+}
+
+// playlist 群内正在播放的歌与待播队列
+type playlist struct {
+	now      *Track
+	upcoming []*Track
+}
+
+// queues 各群的播放队列, 只保存在进程内, 重启后清空
+type queues struct {
+	mu    sync.Mutex
+	chats map[string]*playlist
+}
+
+var q = &queues{chats: make(map[string]*playlist)}
+
+func (qs *queues) chat(chatID string) *playlist {
+	if qs.chats[chatID] == nil {
+		qs.chats[chatID] = &playlist{}
+	}
+	return qs.chats[chatID]
+}
+
+// Enqueue 将歌曲加入群的待播队列末尾, 已在队列中的跳过, 超过 MaxQueueLen 的丢弃
+//
+//	@param chatID string
+//	@param tracks ...*Track
+//	@return added int 实际加入的数量
+func Enqueue(chatID string, tracks ...*Track) (added int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p := q.chat(chatID)
+	for _, t := range tracks {
+		if len(p.upcoming) >= MaxQueueLen {
+			break
+		}
+		if slices.ContainsFunc(p.upcoming, t.same) {
+			continue
+		}
+		p.upcoming = append(p.upcoming, t)
+		added++
+	}
+	return added
+}
+
+// Queue 群内正在播放的歌与待播队列
+//
+//	@param chatID string
+//	@return now *Track 没有在播放时为 nil
+//	@return upcoming []*Track
+func Queue(chatID string) (now *Track, upcoming []*Track) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p := q.chat(chatID)
+	return p.now, slices.Clone(p.upcoming)
+}
+
+// Next 切到队列中的下一首
+//
+//	@param chatID string
+//	@return *Track
+//	@return bool 队列为空时返回 false, 正在播放的歌不变
+func Next(chatID string) (*Track, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p := q.chat(chatID)
+	if len(p.upcoming) == 0 {
+		return nil, false
+	}
+	p.now, p.upcoming = p.upcoming[0], p.upcoming[1:]
+	return p.now, true
+}
+
+// SetNow 直接播放 t, 不影响待播队列; t 在队列中时一并移除
+func SetNow(chatID string, t *Track) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p := q.chat(chatID)
+	p.now = t
+	p.upcoming = slices.DeleteFunc(p.upcoming, t.same)
+}
+
+// Clear 清空待播队列
+//
+//	@param chatID string
+//	@return int 清掉的数量
+func Clear(chatID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p := q.chat(chatID)
+	n := len(p.upcoming)
+	p.upcoming = nil
+	return n
+}
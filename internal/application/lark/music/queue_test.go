@@ -0,0 +1,47 @@
+package music
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	chatID := "oc_queue_test"
+	a, b, c := &Track{ID: "1", Name: "a"}, &Track{ID: "2", Name: "b"}, &Track{ID: "3", Name: "c"}
+
+	if _, ok := Next(chatID); ok {
+		t.Fatal("Next() on empty queue should fail")
+	}
+	if added := Enqueue(chatID, a, b, a); added != 2 {
+		t.Fatalf("Enqueue() = %d, want 2", added)
+	}
+	// 不同音乐源中 ID 相同的歌不是同一首
+	other := &Track{ID: "1", Name: "a", Provider: "local"}
+	if added := Enqueue(chatID, other); added != 1 {
+		t.Fatalf("Enqueue(other provider) = %d, want 1", added)
+	}
+	SetNow(chatID, other)
+	if now, ok := Next(chatID); !ok || now.ID != "1" {
+		t.Fatalf("Next() = %v, %v", now, ok)
+	}
+	Enqueue(chatID, c)
+	// 直接播放队列中的歌会将其移出队列
+	SetNow(chatID, c)
+	now, upcoming := Queue(chatID)
+	if now.ID != "3" || len(upcoming) != 1 || upcoming[0].ID != "2" {
+		t.Fatalf("Queue() = %v, %v", now, upcoming)
+	}
+	if n := Clear(chatID); n != 1 {
+		t.Fatalf("Clear() = %d, want 1", n)
+	}
+	if now, upcoming = Queue(chatID); now.ID != "3" || len(upcoming) != 0 {
+		t.Fatalf("Queue() after Clear = %v, %v", now, upcoming)
+	}
+
+	for i := range MaxQueueLen + 5 {
+		Enqueue(chatID, &Track{ID: fmt.Sprint(100 + i)})
+	}
+	if _, upcoming = Queue(chatID); len(upcoming) != MaxQueueLen {
+		t.Fatalf("queue length = %d, want %d", len(upcoming), MaxQueueLen)
+	}
+}
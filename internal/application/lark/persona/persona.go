@@ -0,0 +1,269 @@
+// Package persona 说话风格画像: 基于 OpenSearch 中已索引的聊天记录, 为每个群和群内活跃用户统计常用词、表情、
+// 句长与口头禅, 定期刷新并缓存在 Redis, 供聊天 prompt 注入与 /persona 命令使用
+package persona
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/history"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkuser"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/opensearch"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	"github.com/defensestation/osquery"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	keyPrefix = "persona:"
+	// minMessages 少于该消息数时不生成画像
+	minMessages = 20
+	// sampleSize 用于统计句长、表情与口头禅的最近消息数
+	sampleSize = 400
+	topWords   = 12
+	// activeChats 每轮刷新最多处理的活跃群数
+	activeChats = 50
+)
+
+type settings struct {
+	Refresh      time.Duration
+	LookbackDays int
+	UsersPerChat int
+}
+
+func loadSettings() settings {
+	s := settings{Refresh: 6 * time.Hour, LookbackDays: 30, UsersPerChat: 10}
+	c := config.Get().PersonaConfig
+	if c == nil {
+		return s
+	}
+	if c.RefreshIntervalMin > 0 {
+		s.Refresh = time.Duration(c.RefreshIntervalMin) * time.Minute
+	}
+	if c.LookbackDays > 0 {
+		s.LookbackDays = c.LookbackDays
+	}
+	if c.UsersPerChat > 0 {
+		s.UsersPerChat = c.UsersPerChat
+	}
+	return s
+}
+
+// Init 注册后台刷新任务
+func Init() {
+	var cancel context.CancelFunc
+	xlifecycle.Register("persona", xlifecycle.PhaseWorker,
+		func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go refreshLoop(ctx)
+			return nil
+		},
+		func(context.Context) error {
+			cancel()
+			return nil
+		},
+	)
+}
+
+func refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(loadSettings().Refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := refreshActive(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logs.L().Ctx(ctx).Warn("refresh persona profiles failed", zap.Error(err))
+		}
+	}
+}
+
+func profileKey(chatID, userID string) string {
+	if userID == "" {
+		return keyPrefix + "chat:" + chatID
+	}
+	return keyPrefix + "user:" + chatID + ":" + userID
+}
+
+// Get 获取画像, 缓存未命中时现场生成
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 为空表示群整体
+//	@return p *Profile 样本不足时 p.Empty() 为 true
+//	@return err error
+func Get(ctx context.Context, chatID, userID string) (p *Profile, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	cached, err := redis_dal.GetRedisClient().Get(ctx, profileKey(chatID, userID)).Result()
+	if err == nil {
+		p = &Profile{}
+		if err = sonic.UnmarshalString(cached, p); err == nil {
+			return p, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		logs.L().Ctx(ctx).Warn("get persona cache failed", zap.Error(err))
+	}
+	return Refresh(ctx, chatID, userID)
+}
+
+// Refresh 重新生成画像并写入缓存
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 为空表示群整体
+//	@return p *Profile
+//	@return err error
+func Refresh(ctx context.Context, chatID, userID string) (p *Profile, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("user_id", userID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	s := loadSettings()
+	p, err = build(ctx, chatID, userID, s.LookbackDays)
+	if err != nil {
+		return nil, err
+	}
+	// 过期时间取两个刷新周期, 不活跃的群自然淘汰
+	if err := redis_dal.GetRedisClient().Set(ctx, profileKey(chatID, userID), p, 2*s.Refresh).Err(); err != nil {
+		logs.L().Ctx(ctx).Warn("set persona cache failed", zap.Error(err))
+	}
+	return p, nil
+}
+
+// MarshalBinary 实现 encoding.BinaryMarshaler, 便于直接写入 Redis
+func (p *Profile) MarshalBinary() ([]byte, error) {
+	return sonic.Marshal(p)
+}
+
+type wordAgg struct {
+	Words history.TopWords `json:"words"`
+}
+
+func build(ctx context.Context, chatID, userID string, lookbackDays int) (p *Profile, err error) {
+	must := []osquery.Mappable{
+		osquery.Term("chat_id", chatID),
+		osquery.Range("create_time_v2").Gte(time.Now().AddDate(0, 0, -lookbackDays).Format(time.RFC3339)),
+	}
+	mustNot := []osquery.Mappable{osquery.Term("is_command", true)}
+	if userID != "" {
+		must = append(must, osquery.Term("user_id", userID))
+	} else {
+		mustNot = append(mustNot, osquery.Term("user_id", config.Get().LarkConfig.BotOpenID))
+	}
+	req := osquery.Search().
+		Query(osquery.Bool().Must(must...).MustNot(mustNot...)).
+		SourceIncludes("raw_message", "user_name").
+		Sort("create_time", osquery.OrderDesc).
+		Size(sampleSize).
+		Aggs(history.TopWordsAgg("words", topWords))
+	resp, err := opensearch.SearchData(ctx, config.Get().OpensearchConfig.LarkMsgIndex, req)
+	if err != nil {
+		return nil, err
+	}
+
+	p = &Profile{ChatID: chatID, UserID: userID, MsgCount: resp.Hits.Total.Value, UpdatedAt: time.Now()}
+	messages := make([]string, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		doc := struct {
+			RawMessage string `json:"raw_message"`
+			UserName   string `json:"user_name"`
+		}{}
+		if err := sonic.Unmarshal(hit.Source, &doc); err != nil {
+			continue
+		}
+		if p.UserName == "" && userID != "" {
+			p.UserName = doc.UserName
+		}
+		messages = append(messages, doc.RawMessage)
+	}
+	// 旧消息可能没有 user_name, 从群成员列表补上
+	if p.UserName == "" && userID != "" {
+		if member, err := larkuser.GetUserMemberFromChat(ctx, chatID, userID); err == nil && member != nil {
+			p.UserName = utils.AddrOrNil(member.Name)
+		}
+	}
+	analyze(messages, p)
+
+	agg := &wordAgg{}
+	if err := sonic.Unmarshal(resp.Aggregations, agg); err != nil {
+		logs.L().Ctx(ctx).Warn("unmarshal persona words failed", zap.Error(err))
+	}
+	p.TopWords = agg.Words.Words()
+	return p, nil
+}
+
+type activeAgg struct {
+	Chats struct {
+		Buckets []struct {
+			Key   string `json:"key"`
+			Users struct {
+				Buckets []struct {
+					Key string `json:"key"`
+				} `json:"buckets"`
+			} `json:"users"`
+		} `json:"buckets"`
+	} `json:"chats"`
+}
+
+// refreshActive 刷新最近一个刷新周期内有发言的群, 以及群里最活跃的用户
+func refreshActive(ctx context.Context) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	s := loadSettings()
+	req := osquery.Search().
+		Query(osquery.Bool().
+			Must(osquery.Range("create_time_v2").Gte(time.Now().Add(-s.Refresh).Format(time.RFC3339))).
+			MustNot(osquery.Term("user_id", config.Get().LarkConfig.BotOpenID))).
+		Size(0).
+		Aggs(osquery.TermsAgg("chats", "chat_id").Size(activeChats).Aggs(
+			osquery.TermsAgg("users", "user_id").Size(uint64(s.UsersPerChat)),
+		))
+	resp, err := opensearch.SearchData(ctx, config.Get().OpensearchConfig.LarkMsgIndex, req)
+	if err != nil {
+		return err
+	}
+	agg := &activeAgg{}
+	if err = sonic.Unmarshal(resp.Aggregations, agg); err != nil {
+		return fmt.Errorf("unmarshal active chats: %w", err)
+	}
+	refreshed := 0
+	for _, chat := range agg.Chats.Buckets {
+		// 空 userID 表示群整体
+		targets := []string{""}
+		for _, u := range chat.Users.Buckets {
+			targets = append(targets, u.Key)
+		}
+		for _, userID := range targets {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if _, err := Refresh(ctx, chat.Key, userID); err != nil {
+				logs.L().Ctx(ctx).Warn("refresh persona failed", zap.String("chat_id", chat.Key), zap.String("user_id", userID), zap.Error(err))
+				continue
+			}
+			refreshed++
+		}
+	}
+	span.SetAttributes(attribute.Int("refreshed", refreshed))
+	return nil
+}
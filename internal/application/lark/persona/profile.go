@@ -0,0 +1,156 @@
+package persona
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// catchphraseMaxLen 口头禅的最大长度, 更长的重复消息多半是复读或转发
+	catchphraseMaxLen = 12
+	topEmojis         = 8
+	topCatchphrases   = 6
+	topSamples        = 5
+)
+
+var (
+	// larkEmojiPattern 飞书文本中的表情, 如 [微笑] [OK]
+	larkEmojiPattern = regexp.MustCompile(`\[[\p{Han}A-Za-z]{1,6}\]`)
+	mentionPattern   = regexp.MustCompile(`@\S+`)
+)
+
+// Profile 一个用户或一个群的说话风格
+type Profile struct {
+	ChatID string `json:"chat_id"`
+	// UserID 为空表示群整体的风格
+	UserID   string `json:"user_id,omitempty"`
+	UserName string `json:"user_name,omitempty"`
+
+	MsgCount     int       `json:"msg_count"`
+	AvgLen       float64   `json:"avg_len"`
+	TopWords     []string  `json:"top_words"`
+	Emojis       []string  `json:"emojis"`
+	Catchphrases []string  `json:"catchphrases"`
+	Samples      []string  `json:"samples"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Empty 样本太少, 画像没有参考价值
+func (p *Profile) Empty() bool {
+	return p == nil || p.MsgCount < minMessages
+}
+
+// Describe 渲染为注入 prompt 的风格描述
+//
+//	@receiver p *Profile
+//	@return string
+func (p *Profile) Describe() string {
+	if p.Empty() {
+		return ""
+	}
+	sb := &strings.Builder{}
+	who := "群友们"
+	if p.UserID != "" {
+		who = p.UserName
+		if who == "" {
+			who = "该成员"
+		}
+	}
+	fmt.Fprintf(sb, "%s的说话风格: 平均每句 %.0f 字", who, p.AvgLen)
+	if len(p.TopWords) > 0 {
+		fmt.Fprintf(sb, "; 常用词: %s", strings.Join(p.TopWords, "、"))
+	}
+	if len(p.Emojis) > 0 {
+		fmt.Fprintf(sb, "; 常用表情: %s", strings.Join(p.Emojis, " "))
+	}
+	if len(p.Catchphrases) > 0 {
+		fmt.Fprintf(sb, "; 口头禅: %s", strings.Join(p.Catchphrases, " / "))
+	}
+	if len(p.Samples) > 0 {
+		fmt.Fprintf(sb, "; 例句: 「%s」", strings.Join(p.Samples, "」「"))
+	}
+	return sb.String()
+}
+
+// analyze 从消息样本中统计句长、表情、口头禅与例句
+//
+//	@param messages []string 按时间倒序
+//	@param p *Profile 结果写入 p
+func analyze(messages []string, p *Profile) {
+	var (
+		totalLen     int
+		counted      int
+		emojiCount   = map[string]int{}
+		phraseCount  = map[string]int{}
+		samples      = make([]string, 0, topSamples)
+		seenSamples  = map[string]struct{}{}
+		sampleMinLen = 4
+	)
+	for _, msg := range messages {
+		msg = strings.TrimSpace(mentionPattern.ReplaceAllString(msg, ""))
+		if msg == "" {
+			continue
+		}
+		for _, e := range larkEmojiPattern.FindAllString(msg, -1) {
+			emojiCount[e]++
+		}
+		for _, r := range msg {
+			if isEmojiRune(r) {
+				emojiCount[string(r)]++
+			}
+		}
+		text := strings.TrimSpace(larkEmojiPattern.ReplaceAllString(msg, ""))
+		n := utf8.RuneCountInString(text)
+		if n == 0 {
+			continue
+		}
+		totalLen += n
+		counted++
+		if n <= catchphraseMaxLen {
+			phraseCount[text]++
+		}
+		if len(samples) < topSamples && n >= sampleMinLen && n <= 40 && !strings.Contains(text, "http") {
+			if _, ok := seenSamples[text]; !ok {
+				seenSamples[text] = struct{}{}
+				samples = append(samples, text)
+			}
+		}
+	}
+	if counted > 0 {
+		p.AvgLen = float64(totalLen) / float64(counted)
+	}
+	p.Emojis = topKeys(emojiCount, 2, topEmojis)
+	p.Catchphrases = topKeys(phraseCount, 2, topCatchphrases)
+	p.Samples = samples
+}
+
+// isEmojiRune 粗略判断 unicode 表情
+func isEmojiRune(r rune) bool {
+	return unicode.Is(unicode.So, r) && r > 0x2000
+}
+
+// topKeys 出现次数不少于 minCount 的前 n 个 key, 次数相同时按字典序
+func topKeys(count map[string]int, minCount, n int) []string {
+	keys := make([]string, 0, len(count))
+	for k, c := range count {
+		if c >= minCount {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := cmp.Compare(count[b], count[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
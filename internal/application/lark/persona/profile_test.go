@@ -0,0 +1,54 @@
+package persona
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	messages := []string{
+		"@小明 绝了[笑哭]",
+		"绝了",
+		"今天的需求又改了一遍[笑哭]",
+		"🐶🐶",
+		"我觉得这个方案可以再想想",
+		"绝了[捂脸]",
+		"https://example.com 看看这个",
+	}
+	p := &Profile{}
+	analyze(messages, p)
+
+	if !slices.Equal(p.Emojis, []string{"[笑哭]", "🐶"}) {
+		t.Fatalf("Emojis = %v", p.Emojis)
+	}
+	if !slices.Equal(p.Catchphrases, []string{"绝了"}) {
+		t.Fatalf("Catchphrases = %v", p.Catchphrases)
+	}
+	if len(p.Samples) == 0 || p.Samples[0] != "今天的需求又改了一遍" {
+		t.Fatalf("Samples = %v", p.Samples)
+	}
+	for _, s := range p.Samples {
+		if strings.Contains(s, "http") {
+			t.Fatalf("sample with link: %q", s)
+		}
+	}
+	if p.AvgLen <= 0 {
+		t.Fatalf("AvgLen = %v", p.AvgLen)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if got := (&Profile{MsgCount: 3}).Describe(); got != "" {
+		t.Fatalf("Describe() of sparse profile = %q, want empty", got)
+	}
+	p := &Profile{UserID: "ou_a", UserName: "小明", MsgCount: 100, AvgLen: 8, Catchphrases: []string{"绝了"}}
+	got := p.Describe()
+	if !strings.HasPrefix(got, "小明的说话风格") || !strings.Contains(got, "口头禅: 绝了") {
+		t.Fatalf("Describe() = %q", got)
+	}
+	p.UserName = ""
+	if got := p.Describe(); !strings.HasPrefix(got, "该成员的说话风格") {
+		t.Fatalf("Describe() without a name = %q", got)
+	}
+}
@@ -0,0 +1,235 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/budget"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/cardaction"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher/callback"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	actionConfirm = "reminder.confirm"
+	actionDismiss = "reminder.dismiss"
+)
+
+// proposal 从 chunk 的计划中识别出的待建议提醒
+type proposal struct {
+	Content  string
+	DueAt    time.Time
+	Mentions []string
+}
+
+// proposals 挑出有明确时间、且时间在 [now+minLead, now+maxAhead] 内的计划
+//
+//	@param chunk *xmodel.MessageChunkLogV3
+//	@param now time.Time
+//	@param minLead time.Duration
+//	@param maxAhead time.Duration
+//	@return []*proposal
+func proposals(chunk *xmodel.MessageChunkLogV3, now time.Time, minLead, maxAhead time.Duration) []*proposal {
+	if chunk.Outcomes == nil {
+		return nil
+	}
+	// 只 @ 确实在这段对话里发过言的成员, 模型给出的 user_id 可能是名字
+	lines := strings.Join(chunk.MsgList, "\n")
+	spoke := func(u *xmodel.User) bool {
+		return u != nil && u.UserID != "" && strings.Contains(lines, "("+u.UserID+")")
+	}
+	res := make([]*proposal, 0)
+	for _, plan := range chunk.Outcomes.PlansAndSuggestions {
+		if plan == nil || plan.Timing == nil || plan.Timing.NormalizedDate == "" || strings.TrimSpace(plan.ActivityOrSuggestion) == "" {
+			continue
+		}
+		due, rest, err := ParseWhen(plan.Timing.NormalizedDate, now)
+		if err != nil || rest != "" || due.Before(now.Add(minLead)) || due.After(now.Add(maxAhead)) {
+			continue
+		}
+		p := &proposal{Content: strings.TrimSpace(plan.ActivityOrSuggestion), DueAt: due}
+		if spoke(plan.Proposer) {
+			p.Mentions = append(p.Mentions, plan.Proposer.UserID)
+		}
+		for _, u := range plan.ParticipantsInvolved {
+			if spoke(u) {
+				p.Mentions = append(p.Mentions, u.UserID)
+			}
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// Propose 对 chunk 中带时间的计划发送提醒建议卡片, 作为 xchunk.IndexedHook 注册
+//
+//	@param ctx context.Context
+//	@param chunk *xmodel.MessageChunkLogV3
+func Propose(ctx context.Context, chunk *xmodel.MessageChunkLogV3) {
+	s := loadSettings()
+	if s.DisableProposal || len(chunk.MsgIDs) == 0 {
+		return
+	}
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chunk.GroupID))
+	defer span.End()
+
+	srcMsgID := chunk.MsgIDs[len(chunk.MsgIDs)-1]
+	for _, p := range proposals(chunk, time.Now().In(utils.UTC8Loc()), s.MinLead, s.MaxAhead) {
+		if err := propose(ctx, chunk, srcMsgID, p); err != nil {
+			span.RecordError(err)
+			logs.L().Ctx(ctx).Warn("propose reminder failed", zap.String("chat_id", chunk.GroupID), zap.Error(err))
+		}
+	}
+}
+
+func propose(ctx context.Context, chunk *xmodel.MessageChunkLogV3, srcMsgID string, p *proposal) error {
+	row := &model.Reminder{
+		ChatID:      chunk.GroupID,
+		Content:     p.Content,
+		DueAt:       p.DueAt,
+		Mentions:    JoinMentions(p.Mentions...),
+		Status:      StatusProposed,
+		SourceMsgID: srcMsgID,
+		SourceChunk: chunk.ID,
+	}
+	if len(p.Mentions) > 0 {
+		row.CreatorID = p.Mentions[0]
+	}
+	ins := query.Q.Reminder
+	n, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(row.ChatID), ins.Content.Eq(row.Content), ins.DueAt.Eq(row.DueAt)).Count()
+	if err != nil || n > 0 {
+		return err
+	}
+	// 建议属于主动行为, 与复读、表情等共享预算
+	if !budget.Acquire(ctx, budget.Proposal, row.ChatID, row.CreatorID, srcMsgID) {
+		return nil
+	}
+	if err := Create(ctx, row); err != nil {
+		return err
+	}
+	card, err := sonic.MarshalString(proposalCard(row, ""))
+	if err != nil {
+		return err
+	}
+	_, err = larkmsg.ReplyMsgRawContentType(ctx, srcMsgID, larkim.MsgTypeInteractive, card, fmt.Sprintf("_propose%d", row.ID), false)
+	return err
+}
+
+// proposalCard 提醒建议卡片; result 非空时表示已处理, 按钮替换为结果
+func proposalCard(row *model.Reminder, result string) map[string]any {
+	who := make([]string, 0)
+	for _, userID := range Mentions(row) {
+		who = append(who, fmt.Sprintf("<at id=%s></at>", userID))
+	}
+	text := fmt.Sprintf("**事项**: %s\n**时间**: %s", row.Content, FormatDue(row.DueAt))
+	if len(who) > 0 {
+		text += "\n**提醒**: " + strings.Join(who, " ")
+	}
+	elements := []any{map[string]any{"tag": "markdown", "content": text}}
+	if result != "" {
+		elements = append(elements, map[string]any{"tag": "markdown", "content": result})
+	} else {
+		id := strconv.FormatInt(row.ID, 10)
+		button := func(label, kind, action string) map[string]any {
+			return map[string]any{
+				"tag":       "button",
+				"text":      map[string]any{"tag": "plain_text", "content": label},
+				"type":      kind,
+				"behaviors": []any{map[string]any{"type": "callback", "value": cardaction.Value(action, "id", id)}},
+			}
+		}
+		elements = append(elements, map[string]any{
+			"tag": "column_set",
+			"columns": []any{
+				map[string]any{"tag": "column", "width": "auto", "elements": []any{button("到时提醒", "primary", actionConfirm)}},
+				map[string]any{"tag": "column", "width": "auto", "elements": []any{button("不用了", "default", actionDismiss)}},
+			},
+		})
+	}
+	return map[string]any{
+		"schema": "2.0",
+		"header": map[string]any{
+			"title":    map[string]any{"tag": "plain_text", "content": "要设个提醒吗?"},
+			"template": "blue",
+		},
+		"body": map[string]any{"elements": elements},
+	}
+}
+
+func registerCardActions() {
+	cardaction.Register(actionConfirm, func(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+		return answer(ctx, event, StatusPending, "好的, 到时提醒")
+	})
+	cardaction.Register(actionDismiss, func(ctx context.Context, event *callback.CardActionTriggerEvent) (*callback.CardActionTriggerResponse, error) {
+		return answer(ctx, event, StatusDismissed, "好的, 不提醒了")
+	})
+}
+
+// answer 处理建议卡片上的按钮, 任何群成员都可以确认或拒绝
+func answer(ctx context.Context, event *callback.CardActionTriggerEvent, to, toast string) (resp *callback.CardActionTriggerResponse, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	id, err := strconv.ParseInt(cardaction.String(event, "id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	operator, chatID := "", ""
+	if event.Event.Operator != nil {
+		operator = event.Event.Operator.OpenID
+	}
+	if event.Event.Context != nil {
+		chatID = event.Event.Context.OpenChatID
+	}
+	row, err := get(ctx, chatID, id)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	if row.Status == StatusProposed {
+		if !row.DueAt.After(time.Now()) {
+			to = StatusExpired
+		}
+		if changed, err = transition(ctx, row, StatusProposed, to); err != nil {
+			return nil, err
+		}
+		if !changed {
+			// 已被其他人或其他实例处理, 重新读取结果
+			if row, err = get(ctx, chatID, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	result, toastType := "已确认, 到时会提醒", "success"
+	switch row.Status {
+	case StatusDismissed, StatusCanceled:
+		result = "已取消"
+	case StatusSent, StatusFailed:
+		result = "已提醒"
+	case StatusExpired:
+		result, toast, toastType = "已过期", "时间已经过了", "warning"
+	}
+	if changed && operator != "" {
+		result += fmt.Sprintf(" (<at id=%s></at>)", operator)
+	}
+	return &callback.CardActionTriggerResponse{
+		Toast: &callback.Toast{Type: toastType, Content: toast},
+		Card:  &callback.Card{Type: "raw", Data: proposalCard(row, result)},
+	}, nil
+}
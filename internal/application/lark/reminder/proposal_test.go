@@ -0,0 +1,49 @@
+package reminder
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+)
+
+func TestProposals(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2026, 10, 21, 14, 0, 0, 0, loc)
+	chunk := &xmodel.MessageChunkLogV3{
+		MsgList: []string{
+			"[2026-10-21 13:50:00](ou_a) <小明>: 周六下午三点去爬山吧",
+			"[2026-10-21 13:51:00](ou_b) <小红>: 好",
+		},
+		Outcomes: &xmodel.Outcome{PlansAndSuggestions: []*xmodel.PlansAndSuggestion{
+			{
+				ActivityOrSuggestion: "去爬山",
+				Proposer:             &xmodel.User{UserID: "ou_a", Name: "小明"},
+				ParticipantsInvolved: []*xmodel.User{{UserID: "ou_b"}, {UserID: "小王"}},
+				Timing:               &xmodel.Timing{RawText: "周六下午三点", NormalizedDate: "2026-10-24 15:00"},
+			},
+			// 时间太近
+			{ActivityOrSuggestion: "点外卖", Timing: &xmodel.Timing{NormalizedDate: "2026-10-21 14:10"}},
+			// 没有明确时间
+			{ActivityOrSuggestion: "改天聚餐", Timing: &xmodel.Timing{RawText: "改天"}},
+		}},
+	}
+	got := proposals(chunk, now, 30*time.Minute, 60*24*time.Hour)
+	if len(got) != 1 {
+		t.Fatalf("proposals() = %d, want 1", len(got))
+	}
+	p := got[0]
+	if p.Content != "去爬山" || !p.DueAt.Equal(time.Date(2026, 10, 24, 15, 0, 0, 0, loc)) {
+		t.Fatalf("proposal = %+v", p)
+	}
+	if !slices.Equal(p.Mentions, []string{"ou_a", "ou_b"}) {
+		t.Fatalf("Mentions = %v", p.Mentions)
+	}
+}
+
+func TestJoinMentions(t *testing.T) {
+	if got := JoinMentions("ou_a", "", "ou_b", "ou_a"); got != "ou_a,ou_b" {
+		t.Fatalf("JoinMentions() = %q", got)
+	}
+}
@@ -0,0 +1,337 @@
+// Package reminder 提醒: 成员通过 /remind 用自然语言时间创建提醒, 机器人也会根据 chunk 总结出的带时间的计划建议提醒;
+// 提醒持久化在 reminders 表, 由后台调度在到期时 @ 相关成员发送, 重启后从表中恢复
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	larkchunking "github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/chunking"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkuser"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// 提醒的状态
+const (
+	// StatusProposed 机器人建议的提醒, 等待成员确认
+	StatusProposed = "proposed"
+	StatusPending  = "pending"
+	StatusSent     = "sent"
+	// StatusFailed 发送失败, 在 retryWindow 内会重试
+	StatusFailed   = "failed"
+	StatusCanceled = "canceled"
+	// StatusDismissed 成员拒绝了建议
+	StatusDismissed = "dismissed"
+	// StatusExpired 建议到时间仍没有人确认
+	StatusExpired = "expired"
+)
+
+const (
+	// retryBackoff 发送失败后再次重试的间隔
+	retryBackoff = time.Minute
+	// retryWindow 到期后超过这个时间仍发送失败的提醒不再重试
+	retryWindow = time.Hour
+)
+
+var (
+	ErrNotFound   = errors.New("reminder not found")
+	ErrNotAllowed = errors.New("only the creator or reminded members can cancel it")
+)
+
+type settings struct {
+	PollInterval    time.Duration
+	DisableProposal bool
+	MinLead         time.Duration
+	MaxAhead        time.Duration
+}
+
+func loadSettings() settings {
+	s := settings{PollInterval: 15 * time.Second, MinLead: 30 * time.Minute, MaxAhead: 60 * 24 * time.Hour}
+	c := config.Get().ReminderConfig
+	if c == nil {
+		return s
+	}
+	s.DisableProposal = c.DisableProposal
+	if c.PollIntervalSec > 0 {
+		s.PollInterval = time.Duration(c.PollIntervalSec) * time.Second
+	}
+	if c.ProposalMinLeadMin > 0 {
+		s.MinLead = time.Duration(c.ProposalMinLeadMin) * time.Minute
+	}
+	if c.ProposalMaxDays > 0 {
+		s.MaxAhead = time.Duration(c.ProposalMaxDays) * 24 * time.Hour
+	}
+	return s
+}
+
+// Init 注册调度任务、建议提醒的 chunk 回调与卡片按钮, 需在 larkchunking.Init 之后调用
+func Init() {
+	larkchunking.M.OnIndexed(Propose)
+	registerCardActions()
+
+	var cancel context.CancelFunc
+	xlifecycle.Register("reminder", xlifecycle.PhaseWorker,
+		func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go scheduleLoop(ctx)
+			return nil
+		},
+		func(context.Context) error {
+			cancel()
+			return nil
+		},
+	)
+}
+
+// transition 条件更新状态, 多个实例同时处理同一条提醒时只有一个会成功
+//
+//	@return ok 状态是否由 from 变为 to
+func transition(ctx context.Context, row *model.Reminder, from, to string) (ok bool, err error) {
+	ins := query.Q.Reminder
+	info, err := ins.WithContext(ctx).Where(ins.ID.Eq(row.ID), ins.Status.Eq(from)).Update(ins.Status, to)
+	if err != nil {
+		return false, err
+	}
+	if info.RowsAffected == 0 {
+		return false, nil
+	}
+	row.Status = to
+	return true, nil
+}
+
+// get 按 ID 查找群里的提醒
+func get(ctx context.Context, chatID string, id int64) (*model.Reminder, error) {
+	ins := query.Q.Reminder
+	rows, err := ins.WithContext(ctx).Where(ins.ID.Eq(id), ins.ChatID.Eq(chatID)).Find()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return rows[0], nil
+}
+
+// Create 创建一条提醒
+//
+//	@param ctx context.Context
+//	@param row *model.Reminder Status 为空时为 StatusPending
+//	@return error
+func Create(ctx context.Context, row *model.Reminder) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if row.Status == "" {
+		row.Status = StatusPending
+	}
+	return query.Q.Reminder.WithContext(ctx).Create(row)
+}
+
+// List 群里未发送的提醒, 按到期时间排序
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return []*model.Reminder
+//	@return error
+func List(ctx context.Context, chatID string) (res []*model.Reminder, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	ins := query.Q.Reminder
+	return ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.Status.Eq(StatusPending)).Order(ins.DueAt).Find()
+}
+
+// Cancel 取消一条提醒, 只有创建者和被提醒的成员可以取消
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 操作人
+//	@param id int64
+//	@return error
+func Cancel(ctx context.Context, chatID, userID string, id int64) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	row, err := get(ctx, chatID, id)
+	if err != nil {
+		return err
+	}
+	if row.Status != StatusPending {
+		return ErrNotFound
+	}
+	if row.CreatorID != userID && !slices.Contains(Mentions(row), userID) {
+		return ErrNotAllowed
+	}
+	ok, err := transition(ctx, row, StatusPending, StatusCanceled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Mentions 提醒时需要 @ 的成员
+func Mentions(row *model.Reminder) []string {
+	if row.Mentions == "" {
+		return nil
+	}
+	return strings.Split(row.Mentions, ",")
+}
+
+// JoinMentions 去重后拼接为 Mentions 字段
+func JoinMentions(userIDs ...string) string {
+	res := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id != "" && !slices.Contains(res, id) {
+			res = append(res, id)
+		}
+	}
+	return strings.Join(res, ",")
+}
+
+// FormatDue 展示用的提醒时间
+func FormatDue(t time.Time) string {
+	return t.In(utils.UTC8Loc()).Format("2006-01-02 15:04")
+}
+
+func scheduleLoop(ctx context.Context) {
+	// 启动时立即检查一次, 补发停机期间到期的提醒
+	deliverDue(ctx)
+	ticker := time.NewTicker(loadSettings().PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deliverDue(ctx)
+		}
+	}
+}
+
+// dueReminders 需要发送的提醒: 到期未发送的, 以及到期不久、距上次失败已超过 retryBackoff 的
+//
+//	@param ctx context.Context
+//	@param now time.Time
+//	@return []*model.Reminder 按到期时间排序
+//	@return error
+func dueReminders(ctx context.Context, now time.Time) ([]*model.Reminder, error) {
+	ins := query.Q.Reminder
+	due, err := ins.WithContext(ctx).Where(ins.Status.Eq(StatusPending), ins.DueAt.Lte(now)).Find()
+	if err != nil {
+		return nil, err
+	}
+	failed, err := ins.WithContext(ctx).Where(
+		ins.Status.Eq(StatusFailed),
+		ins.DueAt.Gt(now.Add(-retryWindow)),
+		ins.UpdatedAt.Lte(now.Add(-retryBackoff)),
+	).Find()
+	if err != nil {
+		return nil, err
+	}
+	due = append(due, failed...)
+	slices.SortFunc(due, func(a, b *model.Reminder) int { return a.DueAt.Compare(b.DueAt) })
+	return due, nil
+}
+
+// expireProposals 到时间仍没有人确认的建议不再接受确认
+//
+//	@param ctx context.Context
+//	@param now time.Time
+//	@return int64 过期的条数
+//	@return error
+func expireProposals(ctx context.Context, now time.Time) (int64, error) {
+	ins := query.Q.Reminder
+	info, err := ins.WithContext(ctx).Where(ins.Status.Eq(StatusProposed), ins.DueAt.Lte(now)).Update(ins.Status, StatusExpired)
+	return info.RowsAffected, err
+}
+
+// deliverDue 发送所有到期的提醒, 先抢占状态再发送, 多个实例不会重复发送
+func deliverDue(ctx context.Context) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	now := time.Now()
+	if n, err := expireProposals(ctx, now); err != nil {
+		logs.L().Ctx(ctx).Warn("expire reminder proposals failed", zap.Error(err))
+	} else {
+		span.SetAttributes(attribute.Int64("expired", n))
+	}
+	due, err := dueReminders(ctx, now)
+	if err != nil {
+		span.RecordError(err)
+		logs.L().Ctx(ctx).Warn("load due reminders failed", zap.Error(err))
+		return
+	}
+	delivered := 0
+	for _, row := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		ok, err := transition(ctx, row, row.Status, StatusSent)
+		if err != nil {
+			logs.L().Ctx(ctx).Warn("claim reminder failed", zap.Int64("id", row.ID), zap.Error(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := deliver(ctx, row); err != nil {
+			logs.L().Ctx(ctx).Error("deliver reminder failed", zap.Int64("id", row.ID), zap.Error(err))
+			if _, err := transition(ctx, row, StatusSent, StatusFailed); err != nil {
+				logs.L().Ctx(ctx).Warn("mark reminder failed", zap.Int64("id", row.ID), zap.Error(err))
+			}
+			continue
+		}
+		delivered++
+	}
+	span.SetAttributes(attribute.Int("delivered", delivered))
+}
+
+// deliver @ 相关成员发送提醒, 有来源消息时回复在来源消息下
+func deliver(ctx context.Context, row *model.Reminder) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Int64("id", row.ID), attribute.String("chat_id", row.ChatID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	b := larkmsg.NewTextMsgBuilder().Text("⏰ 提醒: " + row.Content)
+	if late := time.Since(row.DueAt); late > time.Hour {
+		b.Text(fmt.Sprintf(" (原定 %s)", FormatDue(row.DueAt)))
+	}
+	for _, userID := range Mentions(row) {
+		name := ""
+		if info, err := larkuser.GetUserInfoCache(ctx, row.ChatID, userID); err == nil && info != nil && info.Name != nil {
+			name = *info.Name
+		}
+		b.Text(" ").AtUser(userID, name)
+	}
+	content := b.Build()
+	suffix := fmt.Sprintf("_remind%d", row.ID)
+	if row.SourceMsgID != "" {
+		_, err = larkmsg.ReplyMsgRawContentType(ctx, row.SourceMsgID, larkim.MsgTypeText, content, suffix, false)
+		return err
+	}
+	return larkmsg.CreateMsgTextRaw(ctx, content, suffix, row.ChatID)
+}
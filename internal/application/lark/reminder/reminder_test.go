@@ -0,0 +1,75 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+)
+
+func TestDueRemindersAndExpiry(t *testing.T) {
+	dbtest.Open(t, &model.Reminder{})
+	ctx := context.Background()
+	now := time.Now()
+	create := func(content, status string, due, updated time.Time) *model.Reminder {
+		t.Helper()
+		row := &model.Reminder{ChatID: "chat_a", CreatorID: "ou_a", Content: content, DueAt: due, Status: status, UpdatedAt: updated}
+		if err := Create(ctx, row); err != nil {
+			t.Fatal(err)
+		}
+		return row
+	}
+	create("due", StatusPending, now.Add(-time.Minute), now)
+	create("future", StatusPending, now.Add(time.Hour), now)
+	create("retry", StatusFailed, now.Add(-10*time.Minute), now.Add(-2*retryBackoff))
+	create("backoff", StatusFailed, now.Add(-10*time.Minute), now)
+	create("given up", StatusFailed, now.Add(-2*retryWindow), now.Add(-time.Hour))
+	proposed := create("proposed", StatusProposed, now.Add(-time.Minute), now)
+	create("upcoming proposal", StatusProposed, now.Add(time.Hour), now)
+
+	due, err := dueReminders(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(due))
+	for _, row := range due {
+		got = append(got, row.Content)
+	}
+	if len(got) != 2 || got[0] != "retry" || got[1] != "due" {
+		t.Fatalf("dueReminders() = %v, want [retry due]", got)
+	}
+
+	if n, err := expireProposals(ctx, now); err != nil || n != 1 {
+		t.Fatalf("expireProposals() = %d, %v", n, err)
+	}
+	if row, err := get(ctx, "chat_a", proposed.ID); err != nil || row.Status != StatusExpired {
+		t.Fatalf("proposal after expiry = %+v, %v", row, err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	dbtest.Open(t, &model.Reminder{})
+	ctx := context.Background()
+	row := &model.Reminder{ChatID: "chat_a", CreatorID: "ou_a", Mentions: "ou_b", Content: "开会", DueAt: time.Now().Add(time.Hour)}
+	if err := Create(ctx, row); err != nil {
+		t.Fatal(err)
+	}
+	if err := Cancel(ctx, "chat_b", "ou_a", row.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Cancel() from another chat = %v, want ErrNotFound", err)
+	}
+	if err := Cancel(ctx, "chat_a", "ou_c", row.ID); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("Cancel() by a bystander = %v, want ErrNotAllowed", err)
+	}
+	if err := Cancel(ctx, "chat_a", "ou_b", row.ID); err != nil {
+		t.Fatal(err)
+	}
+	if rows, err := List(ctx, "chat_a"); err != nil || len(rows) != 0 {
+		t.Fatalf("List() after Cancel = %d rows, %v", len(rows), err)
+	}
+	if err := Cancel(ctx, "chat_a", "ou_a", row.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Cancel() twice = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,268 @@
+package reminder
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
+)
+
+var (
+	ErrNoTime = errors.New("no time expression found")
+	ErrPast   = errors.New("time is in the past")
+)
+
+// defaultHour 只给出日期时的提醒时间
+const defaultHour = 9
+
+var (
+	absLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+	durationPattern   = regexp.MustCompile(`^(\d+|[零一二两三四五六七八九十]+|半)\s*个?\s*(分钟|分|小时|钟头|天|周|星期|礼拜)\s*(?:以)?后`)
+	enDurationPattern = regexp.MustCompile(`^(?i:in\s*)?(\d+)\s*(m|mins?|minutes?|h|hrs?|hours?|d|days?)\b`)
+	ymdPattern        = regexp.MustCompile(`^(\d{4})[-/.年](\d{1,2})[-/.月](\d{1,2})[日号]?`)
+	mdPattern         = regexp.MustCompile(`^(\d{1,2})[-/月](\d{1,2})[日号]?`)
+	dayWordPattern    = regexp.MustCompile(`^(大后天|后天|明天|明早|明晚|今天|今早|今晚|今夜)`)
+	weekdayPattern    = regexp.MustCompile(`^(下下|下|这|本)?个?(?:周|星期|礼拜)([一二三四五六日天1-7])`)
+	periodPattern     = regexp.MustCompile(`^(凌晨|早上|早晨|上午|中午|下午|傍晚|晚上|夜里)`)
+	clockPattern      = regexp.MustCompile(`^(\d{1,2})[:：](\d{2})`)
+	cnClockPattern    = regexp.MustCompile(`^(\d{1,2}|[零一二两三四五六七八九十]+)\s*[点时](?:(半)|(一刻)|(三刻)|(\d{1,2})分?|([零一二三四五六七八九十]+)分)?`)
+	// leadingVerbs 时间后面常跟的动词, 不属于提醒内容
+	leadingVerbs = []string{"提醒我们", "提醒大家", "提醒我", "提醒", "叫我", "记得"}
+)
+
+// periodHours 时段的默认钟点, 以及该时段内 12 点以前的钟点是否要加 12
+var periodHours = map[string]struct {
+	hour int
+	pm   bool
+}{
+	"凌晨": {3, false},
+	"早上": {8, false},
+	"早晨": {8, false},
+	"上午": {9, false},
+	"中午": {12, true},
+	"下午": {15, true},
+	"傍晚": {18, true},
+	"晚上": {20, true},
+	"夜里": {22, true},
+}
+
+// ParseWhen 解析文本开头的时间表达式, 如 "10分钟后"、"明天下午3点"、"下周一 9:30"、"2026-10-20 15:00"
+//
+//	@param input string
+//	@param now time.Time 当前时间, 结果使用 now 的时区
+//	@return due time.Time 提醒时间, 一定晚于 now
+//	@return rest string 时间表达式之后的内容
+//	@return err error 没有时间表达式时返回 ErrNoTime, 时间已过时返回 ErrPast
+func ParseWhen(input string, now time.Time) (due time.Time, rest string, err error) {
+	s := strings.TrimSpace(input)
+	loc := now.Location()
+
+	// 完整的时间戳, chunk 总结出的 NormalizedDate 也是这种格式
+	for _, layout := range absLayouts {
+		n := len(layout)
+		if layout == time.RFC3339 {
+			n = strings.IndexAny(s, " \t")
+			if n < 0 {
+				n = len(s)
+			}
+		}
+		if len(s) < n {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, s[:n], loc); err == nil {
+			return finish(t.In(loc), s[n:], now)
+		}
+	}
+
+	if m := durationPattern.FindStringSubmatch(s); m != nil {
+		d, ok := cnDuration(m[1], m[2])
+		if ok {
+			return finish(now.Add(d), s[len(m[0]):], now)
+		}
+	}
+	if m := enDurationPattern.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := time.Minute
+		switch strings.ToLower(m[2])[0] {
+		case 'h':
+			unit = time.Hour
+		case 'd':
+			unit = 24 * time.Hour
+		}
+		return finish(now.Add(time.Duration(n)*unit), s[len(m[0]):], now)
+	}
+
+	var (
+		day      = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		hasDay   bool
+		period   string
+		hour     = -1
+		minute   int
+		matched  bool
+		rollOver = true // 只给出钟点且已经过了时, 顺延到明天
+		bareHour bool   // "3点" 这样不带日期和时段的钟点, 可能是上午也可能是下午
+	)
+	consume := func(p *regexp.Regexp) []string {
+		m := p.FindStringSubmatch(s)
+		if m != nil {
+			s = strings.TrimSpace(s[len(m[0]):])
+			matched = true
+		}
+		return m
+	}
+
+	if m := consume(ymdPattern); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		mo, _ := strconv.Atoi(m[2])
+		d, _ := strconv.Atoi(m[3])
+		day, hasDay = time.Date(y, time.Month(mo), d, 0, 0, 0, 0, loc), true
+	} else if m := consume(mdPattern); m != nil {
+		mo, _ := strconv.Atoi(m[1])
+		d, _ := strconv.Atoi(m[2])
+		day, hasDay = time.Date(now.Year(), time.Month(mo), d, 0, 0, 0, 0, loc), true
+		if day.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)) {
+			day = day.AddDate(1, 0, 0)
+		}
+	} else if m := consume(dayWordPattern); m != nil {
+		hasDay = true
+		switch m[1] {
+		case "明天", "明早", "明晚":
+			day = day.AddDate(0, 0, 1)
+		case "后天":
+			day = day.AddDate(0, 0, 2)
+		case "大后天":
+			day = day.AddDate(0, 0, 3)
+		}
+		switch m[1] {
+		case "明早", "今早":
+			period = "早上"
+		case "明晚", "今晚", "今夜":
+			period = "晚上"
+		}
+	} else if m := consume(weekdayPattern); m != nil {
+		hasDay = true
+		day = weekday(day, m[1], m[2])
+	}
+	if hasDay {
+		rollOver = false
+	}
+
+	if m := consume(periodPattern); m != nil {
+		period = m[1]
+	}
+	if m := consume(clockPattern); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+	} else if m := consume(cnClockPattern); m != nil {
+		var ok bool
+		if hour, ok = xtime.Number(m[1]); !ok {
+			return time.Time{}, input, ErrNoTime
+		}
+		bareHour = !hasDay && period == ""
+		switch {
+		case m[2] != "":
+			minute = 30
+		case m[3] != "":
+			minute = 15
+		case m[4] != "":
+			minute = 45
+		case m[5] != "":
+			minute, _ = strconv.Atoi(m[5])
+		case m[6] != "":
+			if minute, ok = xtime.Number(m[6]); !ok {
+				return time.Time{}, input, ErrNoTime
+			}
+		}
+	}
+	if !matched {
+		return time.Time{}, input, ErrNoTime
+	}
+
+	ph, hasPeriod := periodHours[period]
+	switch {
+	case hour < 0 && hasPeriod:
+		hour = ph.hour
+	case hour < 0:
+		hour = defaultHour
+	case hasPeriod && ph.pm && hour < 12:
+		// 中午 12 点不变, 中午 1 点为 13 点
+		if period != "中午" || hour < 11 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return time.Time{}, input, ErrNoTime
+	}
+	due = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+	// 不带时段的 1-11 点取 12 小时内最近的一次, 下午说 "3点" 指今天 15 点; "12点" 仍按中午处理
+	if bareHour && hour >= 1 && hour < 12 && !due.After(now) && due.Add(12*time.Hour).After(now) {
+		due = due.Add(12 * time.Hour)
+	}
+	if rollOver && !due.After(now) {
+		due = due.AddDate(0, 0, 1)
+	}
+	return finish(due, s, now)
+}
+
+func finish(due time.Time, rest string, now time.Time) (time.Time, string, error) {
+	rest = strings.TrimSpace(rest)
+	for _, verb := range leadingVerbs {
+		if strings.HasPrefix(rest, verb) {
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, verb))
+			break
+		}
+	}
+	if !due.After(now) {
+		return due, rest, ErrPast
+	}
+	return due, rest, nil
+}
+
+// weekday 解析 "周五"、"下周一" 等, 不带前缀时取今天或之后最近的一天
+func weekday(today time.Time, prefix, name string) time.Time {
+	target := strings.Index("日一二三四五六", name) / len("一")
+	switch name {
+	case "天":
+		target = 0
+	case "1", "2", "3", "4", "5", "6", "7":
+		target, _ = strconv.Atoi(name)
+		target %= 7
+	}
+	// 以周一为一周的开始
+	offset := func(d time.Weekday) int { return (int(d) + 6) % 7 }
+	if prefix == "" {
+		return today.AddDate(0, 0, (target-int(today.Weekday())+7)%7)
+	}
+	monday := today.AddDate(0, 0, -offset(today.Weekday()))
+	switch prefix {
+	case "下":
+		monday = monday.AddDate(0, 0, 7)
+	case "下下":
+		monday = monday.AddDate(0, 0, 14)
+	}
+	return monday.AddDate(0, 0, offset(time.Weekday(target)))
+}
+
+// cnDuration "半"+"小时"、"两"+"天" 等
+func cnDuration(num, unit string) (time.Duration, bool) {
+	var u time.Duration
+	switch unit {
+	case "分钟", "分":
+		u = time.Minute
+	case "小时", "钟头":
+		u = time.Hour
+	case "天":
+		u = 24 * time.Hour
+	default:
+		u = 7 * 24 * time.Hour
+	}
+	if num == "半" {
+		return u / 2, true
+	}
+	n, ok := xtime.Number(num)
+	return time.Duration(n) * u, ok && n > 0
+}
@@ -0,0 +1,58 @@
+package reminder
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWhen(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	// 2026-10-21 是周三
+	now := time.Date(2026, 10, 21, 14, 0, 0, 0, loc)
+	at := func(m time.Month, d, h, min int) time.Time { return time.Date(2026, m, d, h, min, 0, 0, loc) }
+
+	cases := []struct {
+		input string
+		due   time.Time
+		rest  string
+	}{
+		{"10分钟后 喝水", now.Add(10 * time.Minute), "喝水"},
+		{"半小时后提醒我开会", now.Add(30 * time.Minute), "开会"},
+		{"两天后 交房租", now.Add(48 * time.Hour), "交房租"},
+		{"in 2h stand up", now.Add(2 * time.Hour), "stand up"},
+		{"明天9点 交周报", at(10, 22, 9, 0), "交周报"},
+		{"明天下午3点半开会", at(10, 22, 15, 30), "开会"},
+		{"明晚 吃火锅", at(10, 22, 20, 0), "吃火锅"},
+		{"后天 体检", at(10, 23, defaultHour, 0), "体检"},
+		{"周五 10:00 团建", at(10, 23, 10, 0), "团建"},
+		{"下周一 9:30 周会", at(10, 26, 9, 30), "周会"},
+		{"晚上8点 看球", at(10, 21, 20, 0), "看球"},
+		{"9:00 打卡", at(10, 22, 9, 0), "打卡"},
+		{"中午12点 吃饭", at(10, 22, 12, 0), "吃饭"},
+		{"10月25日 生日", at(10, 25, defaultHour, 0), "生日"},
+		{"2026-11-01 15:00 复盘", at(11, 1, 15, 0), "复盘"},
+		{"2026-11-01T15:00:00+08:00", at(11, 1, 15, 0), ""},
+		{"3点一起吃饭", at(10, 21, 15, 0), "一起吃饭"},
+		{"两点 开会", at(10, 22, 2, 0), "开会"},
+		{"12点 吃饭", at(10, 22, 12, 0), "吃饭"},
+		{"下午3点 开会", at(10, 21, 15, 0), "开会"},
+	}
+	for _, c := range cases {
+		due, rest, err := ParseWhen(c.input, now)
+		if err != nil {
+			t.Errorf("ParseWhen(%q) error: %v", c.input, err)
+			continue
+		}
+		if !due.Equal(c.due) || rest != c.rest {
+			t.Errorf("ParseWhen(%q) = %v, %q; want %v, %q", c.input, due, rest, c.due, c.rest)
+		}
+	}
+
+	if _, _, err := ParseWhen("开会", now); !errors.Is(err, ErrNoTime) {
+		t.Errorf("ParseWhen without time: err = %v", err)
+	}
+	if _, _, err := ParseWhen("今天9点 开会", now); !errors.Is(err, ErrPast) {
+		t.Errorf("ParseWhen in the past: err = %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package replyrule
+
+// acNode Aho-Corasick 自动机节点
+type acNode struct {
+	next map[rune]int
+	fail int
+	// outs 以该节点结尾的模式下标, 包含沿 fail 链继承的输出
+	outs []int
+}
+
+// acMatcher 多模式子串匹配器, 一次扫描即可找出命中的全部关键词
+type acMatcher struct {
+	nodes []acNode
+}
+
+// newACMatcher 构建自动机
+//
+//	@param patterns []string 空串会被忽略
+//	@return *acMatcher
+func newACMatcher(patterns []string) *acMatcher {
+	m := &acMatcher{nodes: []acNode{{next: map[rune]int{}}}}
+	for idx, p := range patterns {
+		if p == "" {
+			continue
+		}
+		cur := 0
+		for _, r := range p {
+			nxt, ok := m.nodes[cur].next[r]
+			if !ok {
+				m.nodes = append(m.nodes, acNode{next: map[rune]int{}})
+				nxt = len(m.nodes) - 1
+				m.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		m.nodes[cur].outs = append(m.nodes[cur].outs, idx)
+	}
+
+	// BFS 计算 fail 指针
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for f != 0 {
+				if _, ok := m.nodes[f].next[r]; ok {
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			if nxt, ok := m.nodes[f].next[r]; ok && nxt != child {
+				m.nodes[child].fail = nxt
+			}
+			m.nodes[child].outs = append(m.nodes[child].outs, m.nodes[m.nodes[child].fail].outs...)
+			queue = append(queue, child)
+		}
+	}
+	return m
+}
+
+// Match 返回 text 中出现过的模式下标, 每个模式最多返回一次, 按首次出现的位置排序
+//
+//	@receiver m *acMatcher
+//	@param text string
+//	@return []int
+func (m *acMatcher) Match(text string) []int {
+	if m == nil || len(m.nodes) <= 1 {
+		return nil
+	}
+	var (
+		res  []int
+		seen = map[int]struct{}{}
+		cur  = 0
+	)
+	for _, r := range text {
+		for cur != 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		if nxt, ok := m.nodes[cur].next[r]; ok {
+			cur = nxt
+		}
+		for _, idx := range m.nodes[cur].outs {
+			if _, ok := seen[idx]; !ok {
+				seen[idx] = struct{}{}
+				res = append(res, idx)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,413 @@
+// Package replyrule 关键词回复规则引擎: 规则编译为 Aho-Corasick 自动机、精确匹配表与带超时的正则(regexp2),
+// 命中后按用户、时间段、冷却与概率过滤, 再从多条回复中轮换选出一条并渲染模板
+package replyrule
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// engineTTL 编译结果的缓存时间
+	engineTTL = 10 * time.Minute
+
+	hitsKeyPrefix     = "reply:rule:hits:"
+	cooldownKeyPrefix = "reply:rule:cd:"
+	roundKeyPrefix    = "reply:rule:rr:"
+
+	// globalScope 全局规则的 chatID
+	globalScope = ""
+)
+
+var ErrNotDeletable = errors.New("rule not found in this chat, global rules cannot be deleted here")
+
+// Reply 选中的回复
+type Reply struct {
+	Rule *Rule
+	Text string
+	Type xmodel.ReplyType
+}
+
+// engines 编译好的规则集合, 以范围和规则内容摘要为键, 规则变更后摘要随之改变, 旧的条目自然过期
+var engines = cache.New(engineTTL, engineTTL)
+
+// engineFor 加载一个范围(群或全局)的规则并取得编译结果
+//
+//	规则每次都从 DB 读取(走查询缓存, 写入时失效), 只有编译结果按内容缓存
+//	@param ctx context.Context
+//	@param scope string 群 chatID, 全局为 globalScope
+//	@return engine *Engine
+//	@return err error
+func engineFor(ctx context.Context, scope string) (engine *Engine, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	rules, err := loadRules(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := sonic.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(raw)
+	key := scope + ":" + hex.EncodeToString(sum[:])
+	if v, ok := engines.Get(key); ok {
+		return v.(*Engine), nil
+	}
+	engine, errs := Compile(rules)
+	for _, e := range errs {
+		logs.L().Ctx(ctx).Warn("compile reply rule failed", zap.String("chat_id", scope), zap.Error(e))
+	}
+	engines.Set(key, engine, cache.DefaultExpiration)
+	return engine, nil
+}
+
+// loadRules 读取一个范围内的规则, 包括旧表中的关键词回复
+func loadRules(ctx context.Context, scope string) (rules []*Rule, err error) {
+	ins := query.Q.ReplyRule
+	rows, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(scope)).Order(ins.ID).Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		r, err := FromModel(row)
+		if err != nil {
+			logs.L().Ctx(ctx).Warn("skip bad reply rule", zap.Int64("id", row.ID), zap.Error(err))
+			continue
+		}
+		rules = append(rules, r)
+	}
+	// 兼容旧表: 全局关键词回复与群定制
+	if scope == globalScope {
+		globals, err := query.Q.QuoteReplyMsg.WithContext(ctx).Find()
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range globals {
+			rules = append(rules, legacyRule("g", globalScope, g.MatchType, g.Keyword, g.Reply, g.ReplyType))
+		}
+		return rules, nil
+	}
+	customIns := query.Q.QuoteReplyMsgCustom
+	customs, err := customIns.WithContext(ctx).Where(customIns.GuildID.Eq(scope)).Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range customs {
+		rules = append(rules, legacyRule("c", c.GuildID, c.MatchType, c.Keyword, c.Reply, c.ReplyType))
+	}
+	return rules, nil
+}
+
+// FromModel 将 reply_rules 的一行转换为规则
+//
+//	@param row *model.ReplyRule
+//	@return *Rule
+//	@return error
+func FromModel(row *model.ReplyRule) (*Rule, error) {
+	replies := make([]string, 0)
+	if err := sonic.UnmarshalString(row.Replies, &replies); err != nil {
+		return nil, err
+	}
+	window, err := ParseTimeWindow(row.TimeWindow)
+	if err != nil {
+		return nil, err
+	}
+	r := &Rule{
+		Key:         "r" + strconv.FormatInt(row.ID, 10),
+		ChatID:      row.ChatID,
+		MatchType:   xmodel.WordMatchType(row.MatchType),
+		Keyword:     row.Keyword,
+		Replies:     replies,
+		ReplyType:   xmodel.ReplyType(row.ReplyType),
+		Rotation:    Rotation(row.Rotation),
+		Window:      window,
+		Cooldown:    time.Duration(row.CooldownSec) * time.Second,
+		Probability: int(row.Probability),
+	}
+	if row.UserIds != "" {
+		r.UserIDs = strings.Split(row.UserIds, ",")
+	}
+	if r.Rotation != RotationRound {
+		r.Rotation = RotationRandom
+	}
+	if r.Probability <= 0 || r.Probability > 100 {
+		r.Probability = 100
+	}
+	return r, nil
+}
+
+func legacyRule(prefix, chatID, matchType, keyword, reply, replyType string) *Rule {
+	sum := sha1.Sum([]byte(strings.Join([]string{chatID, matchType, keyword, reply, replyType}, "\x00")))
+	return &Rule{
+		Key:         prefix + hex.EncodeToString(sum[:])[:6],
+		ChatID:      chatID,
+		MatchType:   xmodel.WordMatchType(matchType),
+		Keyword:     keyword,
+		Replies:     []string{reply},
+		ReplyType:   xmodel.ReplyType(replyType),
+		Rotation:    RotationRandom,
+		Probability: 100,
+	}
+}
+
+// Pick 为一条消息选出回复, 群内规则优先, 群内没有可用规则时再看全局规则
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 发送者 open_id
+//	@param msg string
+//	@param admit func() bool 选定要发送的规则后调用一次, 返回 false 则放弃回复且不消耗冷却, 为 nil 时总是放行
+//	@return reply *Reply 没有命中时为 nil
+//	@return err error
+func Pick(ctx context.Context, chatID, userID, msg string, admit func() bool) (reply *Reply, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	now := time.Now().In(utils.UTC8Loc())
+	admitted := admit == nil
+	for _, scope := range []string{chatID, globalScope} {
+		engine, err := engineFor(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]*Hit, 0)
+		for _, hit := range engine.Match(msg) {
+			if hit.Rule.Allow(userID, now) && utils.Prob(float64(hit.Rule.Probability)/100) {
+				candidates = append(candidates, hit)
+			}
+		}
+		rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		for _, hit := range candidates {
+			if !acquireCooldown(ctx, chatID, hit.Rule) {
+				continue
+			}
+			// 只为确定要发送的规则申请预算, 被拒时归还刚抢占的冷却
+			if !admitted && !admit() {
+				releaseCooldown(ctx, chatID, hit.Rule)
+				return nil, nil
+			}
+			admitted = true
+			reply = &Reply{
+				Rule: hit.Rule,
+				Text: hit.Render(hit.Rule.Replies[nextIndex(ctx, chatID, hit.Rule)]),
+				Type: hit.Rule.ReplyType,
+			}
+			span.SetAttributes(attribute.String("rule", hit.Rule.Key))
+			if err := redis_dal.GetRedisClient().HIncrBy(ctx, hitsKeyPrefix+chatID, hit.Rule.Key, 1).Err(); err != nil {
+				logs.L().Ctx(ctx).Warn("incr reply rule hits failed", zap.Error(err))
+			}
+			return reply, nil
+		}
+	}
+	return nil, nil
+}
+
+// acquireCooldown 抢占冷却, Redis 不可用时放行
+func acquireCooldown(ctx context.Context, chatID string, r *Rule) bool {
+	if r.Cooldown <= 0 {
+		return true
+	}
+	ok, err := redis_dal.GetRedisClient().SetNX(ctx, cooldownKeyPrefix+chatID+":"+r.Key, 1, r.Cooldown).Result()
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("acquire reply rule cooldown failed", zap.Error(err))
+		return true
+	}
+	return ok
+}
+
+// releaseCooldown 归还 acquireCooldown 抢占的冷却
+func releaseCooldown(ctx context.Context, chatID string, r *Rule) {
+	if r.Cooldown <= 0 {
+		return
+	}
+	if err := redis_dal.GetRedisClient().Del(ctx, cooldownKeyPrefix+chatID+":"+r.Key).Err(); err != nil {
+		logs.L().Ctx(ctx).Warn("release reply rule cooldown failed", zap.Error(err))
+	}
+}
+
+// nextIndex 选择回复下标, round 模式按群轮转
+func nextIndex(ctx context.Context, chatID string, r *Rule) int {
+	if len(r.Replies) <= 1 {
+		return 0
+	}
+	if r.Rotation == RotationRound {
+		n, err := redis_dal.GetRedisClient().Incr(ctx, roundKeyPrefix+chatID+":"+r.Key).Result()
+		if err == nil {
+			return int((n - 1) % int64(len(r.Replies)))
+		}
+		logs.L().Ctx(ctx).Warn("incr reply rule round failed", zap.Error(err))
+	}
+	return rand.IntN(len(r.Replies))
+}
+
+// RuleStat 规则及其在当前群的命中次数
+type RuleStat struct {
+	*Rule
+	Global bool
+	Hits   int64
+}
+
+// List 列出当前群可见的规则: 群内规则在前, 全局规则在后
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return stats []*RuleStat
+//	@return err error
+func List(ctx context.Context, chatID string) (stats []*RuleStat, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	hits, err := redis_dal.GetRedisClient().HGetAll(ctx, hitsKeyPrefix+chatID).Result()
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("get reply rule hits failed", zap.Error(err))
+	}
+	for _, scope := range []string{chatID, globalScope} {
+		engine, err := engineFor(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range engine.Rules() {
+			n, _ := strconv.ParseInt(hits[r.Key], 10, 64)
+			stats = append(stats, &RuleStat{Rule: r, Global: scope == globalScope, Hits: n})
+		}
+	}
+	return stats, nil
+}
+
+// TestResult /reply test 的单条结果
+type TestResult struct {
+	*Hit
+	Global bool
+	// Blocked 不会触发的原因, 为空表示可以触发(概率除外)
+	Blocked string
+}
+
+// Test 试运行: 列出 msg 命中的规则以及各自是否会被条件拦下, 不消耗冷却、不计数
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string
+//	@param msg string
+//	@return results []*TestResult
+//	@return err error
+func Test(ctx context.Context, chatID, userID, msg string) (results []*TestResult, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	now := time.Now().In(utils.UTC8Loc())
+	for _, scope := range []string{chatID, globalScope} {
+		engine, err := engineFor(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range engine.Match(msg) {
+			res := &TestResult{Hit: hit, Global: scope == globalScope}
+			switch {
+			case !hit.Rule.Allow(userID, now):
+				res.Blocked = "用户或时间段不满足"
+			case hit.Rule.Cooldown > 0:
+				ttl, err := redis_dal.GetRedisClient().TTL(ctx, cooldownKeyPrefix+chatID+":"+hit.Rule.Key).Result()
+				if err == nil && ttl > 0 {
+					res.Blocked = fmt.Sprintf("冷却中, 剩余 %s", ttl.Round(time.Second))
+				}
+			}
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+// Add 校验并保存一条规则
+//
+//	@param ctx context.Context
+//	@param row *model.ReplyRule
+//	@return err error
+func Add(ctx context.Context, row *model.ReplyRule) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	r, err := FromModel(row)
+	if err != nil {
+		return err
+	}
+	if _, errs := Compile([]*Rule{r}); len(errs) > 0 {
+		return errs[0]
+	}
+	if err = query.Q.ReplyRule.WithContext(ctx).Create(row); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete 删除当前群的一条规则, key 见 /reply list
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param key string
+//	@return err error
+func Delete(ctx context.Context, chatID, key string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	engine, err := engineFor(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	var target *Rule
+	for _, r := range engine.Rules() {
+		if r.Key == key {
+			target = r
+			break
+		}
+	}
+	if target == nil {
+		return ErrNotDeletable
+	}
+	if id, ok := strings.CutPrefix(key, "r"); ok {
+		ins := query.Q.ReplyRule
+		n, _ := strconv.ParseInt(id, 10, 64)
+		_, err = ins.WithContext(ctx).Where(ins.ID.Eq(n), ins.ChatID.Eq(chatID)).Delete()
+	} else {
+		ins := query.Q.QuoteReplyMsgCustom
+		_, err = ins.WithContext(ctx).Where(
+			ins.GuildID.Eq(chatID),
+			ins.MatchType.Eq(string(target.MatchType)),
+			ins.Keyword.Eq(target.Keyword),
+			ins.Reply.Eq(target.Replies[0]),
+			ins.ReplyType.Eq(string(target.ReplyType)),
+		).Delete()
+	}
+	if err != nil {
+		return err
+	}
+	redis_dal.GetRedisClient().HDel(ctx, hitsKeyPrefix+chatID, key)
+	return nil
+}
@@ -0,0 +1,112 @@
+package replyrule
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPickSeesRuleChanges(t *testing.T) {
+	dbtest.Open(t, &model.ReplyRule{}, &model.QuoteReplyMsg{}, &model.QuoteReplyMsgCustom{})
+	mr := miniredis.RunT(t)
+	prev := redis_dal.RedisClient
+	redis_dal.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redis_dal.RedisClient = prev })
+	ctx := context.Background()
+
+	pick := func(chatID, msg string) string {
+		t.Helper()
+		reply, err := Pick(ctx, chatID, "ou_a", msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply == nil {
+			return ""
+		}
+		return reply.Text
+	}
+	add := func(chatID, keyword, reply string) {
+		t.Helper()
+		err := Add(ctx, &model.ReplyRule{
+			ChatID: chatID, MatchType: "substr", Keyword: keyword, Replies: `["` + reply + `"]`,
+			ReplyType: "text", Probability: 100, Rotation: "random",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add("chat_a", "早安", "早")
+	if got := pick("chat_a", "大家早安"); got != "早" {
+		t.Fatalf("Pick(chat_a) = %q, want 早", got)
+	}
+	if got := pick("chat_b", "大家早安"); got != "" {
+		t.Fatalf("Pick(chat_b) = %q, rules of chat_a should not apply", got)
+	}
+	// 新规则立刻生效
+	add("chat_a", "晚安", "晚")
+	if got := pick("chat_a", "晚安"); got != "晚" {
+		t.Fatalf("Pick(chat_a) after Add = %q, want 晚", got)
+	}
+	add(globalScope, "在吗", "在")
+	if got := pick("chat_b", "在吗"); got != "在" {
+		t.Fatalf("Pick(chat_b) = %q, global rule should apply", got)
+	}
+
+	stats, err := List(ctx, "chat_a")
+	if err != nil || len(stats) != 3 {
+		t.Fatalf("List(chat_a) = %d rules, %v", len(stats), err)
+	}
+	if err = Delete(ctx, "chat_a", stats[0].Key); err != nil {
+		t.Fatal(err)
+	}
+	if got := pick("chat_a", "大家早安"); got != "" {
+		t.Fatalf("Pick(chat_a) after Delete = %q", got)
+	}
+	if err = Delete(ctx, "chat_a", stats[2].Key); err != ErrNotDeletable {
+		t.Fatalf("Delete(global) = %v, want ErrNotDeletable", err)
+	}
+}
+
+func TestPickAdmitsOnlySentReply(t *testing.T) {
+	dbtest.Open(t, &model.ReplyRule{}, &model.QuoteReplyMsg{}, &model.QuoteReplyMsgCustom{})
+	mr := miniredis.RunT(t)
+	prev := redis_dal.RedisClient
+	redis_dal.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redis_dal.RedisClient = prev })
+	ctx := context.Background()
+
+	err := Add(ctx, &model.ReplyRule{
+		ChatID: "chat_a", MatchType: "substr", Keyword: "早安", Replies: `["早"]`,
+		ReplyType: "text", Probability: 100, Rotation: "random", CooldownSec: 60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admits := 0
+	pick := func(allow bool) *Reply {
+		t.Helper()
+		reply, err := Pick(ctx, "chat_a", "ou_a", "早安", func() bool { admits++; return allow })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+
+	// 预算拒绝时不占用冷却, 下一条消息仍可回复
+	if pick(false) != nil {
+		t.Fatal("Pick() should give up when admit denies")
+	}
+	if pick(true) == nil || admits != 2 {
+		t.Fatalf("Pick() after a denied admit: admits = %d", admits)
+	}
+	// 冷却中的规则不消耗预算
+	if pick(true) != nil || admits != 2 {
+		t.Fatalf("Pick() during cooldown: admits = %d, want 2", admits)
+	}
+}
@@ -0,0 +1,302 @@
+package replyrule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/dlclark/regexp2"
+)
+
+const (
+	// maxRegexLen 正则模式的最大长度
+	maxRegexLen = 256
+	// maxRegexInput 正则匹配时最多扫描的消息长度(字节)
+	maxRegexInput = 4096
+	// regexTimeout 单条正则的匹配超时
+	regexTimeout = 50 * time.Millisecond
+)
+
+// Rotation 多条回复的轮换方式
+type Rotation string
+
+const (
+	RotationRandom Rotation = "random"
+	RotationRound  Rotation = "round"
+)
+
+var (
+	ErrUnknownMatchType = errors.New("unknown match type, must be substr, full or regex")
+	ErrUnsafeRegex      = errors.New("regex too long or invalid")
+	ErrBadTimeWindow    = errors.New("time window must look like 09:00-18:00")
+)
+
+// Rule 一条回复规则
+type Rule struct {
+	// Key 规则标识, 见 /reply list
+	Key       string
+	ChatID    string
+	MatchType xmodel.WordMatchType
+	Keyword   string
+	Replies   []string
+	ReplyType xmodel.ReplyType
+	Rotation  Rotation
+
+	// UserIDs 仅对这些用户生效, 为空表示所有人
+	UserIDs []string
+	// Window 生效时间段, 为空表示全天
+	Window *TimeWindow
+	// Cooldown 同一群内两次触发的最小间隔
+	Cooldown time.Duration
+	// Probability 触发概率, 0-100
+	Probability int
+}
+
+// TimeWindow 一天内的生效时间段(UTC+8), 允许跨零点, 如 22:00-02:00
+type TimeWindow struct {
+	Start, End int // 距零点的分钟数
+}
+
+// ParseTimeWindow 解析 "HH:MM-HH:MM"
+//
+//	@param s string
+//	@return *TimeWindow 空串返回 nil
+//	@return error
+func ParseTimeWindow(s string) (*TimeWindow, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, ErrBadTimeWindow
+	}
+	parse := func(v string) (int, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(v))
+		if err != nil {
+			return 0, ErrBadTimeWindow
+		}
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	w := &TimeWindow{}
+	var err error
+	if w.Start, err = parse(start); err != nil {
+		return nil, err
+	}
+	if w.End, err = parse(end); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Contains 判断时刻是否落在时间段内
+//
+//	@receiver w *TimeWindow
+//	@param t time.Time 调用方负责转换到 UTC+8
+//	@return bool
+func (w *TimeWindow) Contains(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	m := t.Hour()*60 + t.Minute()
+	if w.Start <= w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+func (w *TimeWindow) String() string {
+	if w == nil {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// Allow 检查与匹配无关的静态条件: 用户与时间段
+//
+//	@receiver r *Rule
+//	@param userID string
+//	@param now time.Time
+//	@return bool
+func (r *Rule) Allow(userID string, now time.Time) bool {
+	if len(r.UserIDs) > 0 && !slices.Contains(r.UserIDs, userID) {
+		return false
+	}
+	return r.Window.Contains(now)
+}
+
+// Conditions 条件的可读描述, 用于 /reply list 和 /reply test
+//
+//	@receiver r *Rule
+//	@return string
+func (r *Rule) Conditions() string {
+	parts := make([]string, 0)
+	if len(r.UserIDs) > 0 {
+		parts = append(parts, fmt.Sprintf("users=%d", len(r.UserIDs)))
+	}
+	if r.Window != nil {
+		parts = append(parts, "time="+r.Window.String())
+	}
+	if r.Cooldown > 0 {
+		parts = append(parts, "cooldown="+r.Cooldown.String())
+	}
+	if r.Probability < 100 {
+		parts = append(parts, "prob="+strconv.Itoa(r.Probability)+"%")
+	}
+	if len(r.Replies) > 1 {
+		parts = append(parts, "rotate="+string(r.Rotation))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Hit 一次匹配结果
+type Hit struct {
+	Rule *Rule
+	// Captures 模板变量: "0" 为命中的整段文本, 正则分组按序号和名字给出
+	Captures map[string]string
+}
+
+// Render 用捕获组渲染回复模板, 支持 $1 / ${1} / ${name}, 未知变量原样保留
+//
+//	@receiver h *Hit
+//	@param reply string
+//	@return string
+func (h *Hit) Render(reply string) string {
+	if h.Rule.ReplyType != xmodel.ReplyTypeText || !strings.Contains(reply, "$") {
+		return reply
+	}
+	return os.Expand(reply, func(name string) string {
+		if v, ok := h.Captures[name]; ok {
+			return v
+		}
+		return "${" + name + "}"
+	})
+}
+
+// Engine 编译后的规则集合, 只读, 可并发使用
+type Engine struct {
+	rules []*Rule
+
+	substr    *acMatcher
+	substrIdx []int // acMatcher 模式下标 -> rules 下标
+	full      map[string][]int
+	regex     []compiledRegex
+}
+
+type compiledRegex struct {
+	idx int
+	re  *regexp2.Regexp
+}
+
+// CompileRegex 校验并编译正则, 限制模式长度并设置匹配超时, 回溯过深的模式超时后视为未命中
+//
+//	@param pattern string
+//	@return *regexp2.Regexp
+//	@return error
+func CompileRegex(pattern string) (*regexp2.Regexp, error) {
+	if pattern == "" || len(pattern) > maxRegexLen {
+		return nil, ErrUnsafeRegex
+	}
+	re, err := regexp2.Compile(pattern, regexp2.RE2)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsafeRegex, err)
+	}
+	re.MatchTimeout = regexTimeout
+	return re, nil
+}
+
+// Compile 编译规则, 非法的规则会被跳过并在 errs 中返回
+//
+//	@param rules []*Rule
+//	@return e *Engine
+//	@return errs []error
+func Compile(rules []*Rule) (e *Engine, errs []error) {
+	e = &Engine{full: map[string][]int{}}
+	patterns := make([]string, 0)
+	for _, r := range rules {
+		if r.Keyword == "" || len(r.Replies) == 0 {
+			errs = append(errs, fmt.Errorf("rule %s: empty keyword or reply", r.Key))
+			continue
+		}
+		idx := len(e.rules)
+		switch r.MatchType {
+		case xmodel.MatchTypeSubStr:
+			patterns = append(patterns, r.Keyword)
+			e.substrIdx = append(e.substrIdx, idx)
+		case xmodel.MatchTypeFull:
+			e.full[r.Keyword] = append(e.full[r.Keyword], idx)
+		case xmodel.MatchTypeRegex:
+			re, err := CompileRegex(r.Keyword)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("rule %s: %w", r.Key, err))
+				continue
+			}
+			e.regex = append(e.regex, compiledRegex{idx: idx, re: re})
+		default:
+			errs = append(errs, fmt.Errorf("rule %s: %w", r.Key, ErrUnknownMatchType))
+			continue
+		}
+		e.rules = append(e.rules, r)
+	}
+	e.substr = newACMatcher(patterns)
+	return e, errs
+}
+
+// Rules 编译成功的规则
+func (e *Engine) Rules() []*Rule {
+	if e == nil {
+		return nil
+	}
+	return e.rules
+}
+
+// Match 找出 msg 命中的全部规则, 不检查任何条件
+//
+//	@receiver e *Engine
+//	@param msg string
+//	@return hits []*Hit 按 full、substr、regex 的顺序
+func (e *Engine) Match(msg string) (hits []*Hit) {
+	if e == nil || msg == "" {
+		return nil
+	}
+	for _, idx := range e.full[msg] {
+		hits = append(hits, &Hit{Rule: e.rules[idx], Captures: map[string]string{"0": msg}})
+	}
+	for _, p := range e.substr.Match(msg) {
+		r := e.rules[e.substrIdx[p]]
+		hits = append(hits, &Hit{Rule: r, Captures: map[string]string{"0": r.Keyword}})
+	}
+	if len(e.regex) > 0 {
+		input := msg
+		if len(input) > maxRegexInput {
+			input = strings.ToValidUTF8(input[:maxRegexInput], "")
+		}
+		for _, cr := range e.regex {
+			captures, ok := matchRegex(cr.re, input)
+			if ok {
+				hits = append(hits, &Hit{Rule: e.rules[cr.idx], Captures: captures})
+			}
+		}
+	}
+	return hits
+}
+
+// matchRegex 正则匹配, 超时或出错视为未命中
+func matchRegex(re *regexp2.Regexp, input string) (map[string]string, bool) {
+	m, err := re.FindStringMatch(input)
+	if err != nil || m == nil {
+		return nil, false
+	}
+	groups := m.Groups()
+	captures := make(map[string]string, len(groups)*2)
+	for _, g := range groups {
+		captures[strconv.Itoa(re.GroupNumberFromName(g.Name))] = g.String()
+		captures[g.Name] = g.String()
+	}
+	return captures, true
+}
@@ -0,0 +1,96 @@
+package replyrule
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+)
+
+func TestACMatcher(t *testing.T) {
+	m := newACMatcher([]string{"he", "she", "his", "hers", "", "你好"})
+	got := m.Match("ushers 说你好")
+	slices.Sort(got)
+	if want := []int{0, 1, 3, 5}; !slices.Equal(got, want) {
+		t.Fatalf("Match() = %v, want %v", got, want)
+	}
+	if got := m.Match("nothing"); len(got) != 0 {
+		t.Fatalf("Match() = %v, want none", got)
+	}
+}
+
+func TestEngineMatch(t *testing.T) {
+	rules := []*Rule{
+		{Key: "r1", MatchType: xmodel.MatchTypeSubStr, Keyword: "早安", Replies: []string{"早"}, ReplyType: xmodel.ReplyTypeText},
+		{Key: "r2", MatchType: xmodel.MatchTypeFull, Keyword: "在吗", Replies: []string{"在"}, ReplyType: xmodel.ReplyTypeText},
+		{Key: "r3", MatchType: xmodel.MatchTypeRegex, Keyword: `我是(?P<name>\p{Han}+)`, Replies: []string{"你好, ${name}"}, ReplyType: xmodel.ReplyTypeText},
+		{Key: "r4", MatchType: xmodel.MatchTypeRegex, Keyword: `(`, Replies: []string{"bad"}},
+		{Key: "r5", MatchType: "glob", Keyword: "x", Replies: []string{"bad"}},
+	}
+	e, errs := Compile(rules)
+	if len(errs) != 2 || len(e.Rules()) != 3 {
+		t.Fatalf("Compile() errs = %v, rules = %d", errs, len(e.Rules()))
+	}
+
+	keys := func(hits []*Hit) (res []string) {
+		for _, h := range hits {
+			res = append(res, h.Rule.Key)
+		}
+		return
+	}
+	if got := keys(e.Match("在吗")); !slices.Equal(got, []string{"r2"}) {
+		t.Fatalf("full match = %v", got)
+	}
+	if got := keys(e.Match("大家在吗")); len(got) != 0 {
+		t.Fatalf("full should not match substring, got %v", got)
+	}
+	hits := e.Match("早安, 我是小明")
+	if got := keys(hits); !slices.Equal(got, []string{"r1", "r3"}) {
+		t.Fatalf("match = %v", got)
+	}
+	if got := hits[1].Render(hits[1].Rule.Replies[0]); got != "你好, 小明" {
+		t.Fatalf("Render() = %q", got)
+	}
+	if got := hits[1].Render("$1 ${unknown}"); got != "小明 ${unknown}" {
+		t.Fatalf("Render() = %q", got)
+	}
+}
+
+func TestEngineRegexTimeout(t *testing.T) {
+	e, errs := Compile([]*Rule{
+		{Key: "r1", MatchType: xmodel.MatchTypeRegex, Keyword: `^(a+)+$`, Replies: []string{"x"}},
+	})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	start := time.Now()
+	if hits := e.Match(strings.Repeat("a", 64) + "b"); len(hits) != 0 {
+		t.Fatalf("Match() = %d hits, want none", len(hits))
+	}
+	if d := time.Since(start); d > 10*regexTimeout {
+		t.Fatalf("Match() took %s, should stop after %s", d, regexTimeout)
+	}
+}
+
+func TestRuleAllow(t *testing.T) {
+	w, err := ParseTimeWindow("22:00-02:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Rule{UserIDs: []string{"ou_a"}, Window: w}
+	at := func(h int) time.Time { return time.Date(2024, 1, 1, h, 30, 0, 0, time.UTC) }
+	if !r.Allow("ou_a", at(23)) || !r.Allow("ou_a", at(1)) {
+		t.Fatal("should allow inside overnight window")
+	}
+	if r.Allow("ou_a", at(12)) {
+		t.Fatal("should block outside window")
+	}
+	if r.Allow("ou_b", at(23)) {
+		t.Fatal("should block other users")
+	}
+	if _, err := ParseTimeWindow("9点-18点"); err == nil {
+		t.Fatal("expected error for bad window")
+	}
+}
@@ -0,0 +1,389 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 提醒规则的类型
+const (
+	// KindAbove 价格涨到阈值及以上
+	KindAbove = "above"
+	// KindBelow 价格跌到阈值及以下
+	KindBelow = "below"
+	// KindMove 相对昨收的涨跌幅绝对值达到阈值(%)
+	KindMove = "move"
+)
+
+const (
+	// MaxAlerts 每个群的提醒规则上限
+	MaxAlerts = 20
+	// stateFired 价格提醒已触发, 价格回到阈值另一侧后重新生效
+	stateFired = "fired"
+	// rearmRatio 价格提醒回撤超过阈值的该比例才重新生效, 避免价格在阈值附近反复穿越时刷屏
+	rearmRatio = 0.005
+)
+
+var (
+	ErrNotFound   = errors.New("stock alert not found")
+	ErrNotAllowed = errors.New("only the creator can delete a DM alert")
+	// ErrAlertsFull 群里的提醒规则已满
+	ErrAlertsFull = fmt.Errorf("alerts are limited to %d per chat", MaxAlerts)
+	// ErrRule 提醒条件需要且只能给出一个
+	ErrRule = errors.New("give exactly one of --above, --below and --move")
+)
+
+// ParseRule 由 /stock alert add 的参数得到规则类型与阈值
+//
+//	@param above string 价格上限
+//	@param below string 价格下限
+//	@param move string 涨跌幅(%), 可带 % 后缀, 正负号忽略
+//	@return kind string
+//	@return threshold float64
+//	@return err error
+func ParseRule(above, below, move string) (kind string, threshold float64, err error) {
+	given := 0
+	for _, rule := range [][2]string{{KindAbove, above}, {KindBelow, below}, {KindMove, move}} {
+		k, v := rule[0], rule[1]
+		if v == "" {
+			continue
+		}
+		given++
+		kind = k
+		if k == KindMove && v[len(v)-1] == '%' {
+			v = v[:len(v)-1]
+		}
+		if threshold, err = strconv.ParseFloat(v, 64); err != nil || math.IsNaN(threshold) || math.IsInf(threshold, 0) {
+			return "", 0, fmt.Errorf("--%s: invalid number %q", k, v)
+		}
+	}
+	if given != 1 {
+		return "", 0, ErrRule
+	}
+	if kind == KindMove {
+		threshold = math.Abs(threshold)
+	}
+	if threshold <= 0 {
+		return "", 0, fmt.Errorf("--%s must be positive", kind)
+	}
+	return kind, threshold, nil
+}
+
+// Condition 展示用的触发条件, 如 涨到 1800.00
+func Condition(row *model.StockAlert) string {
+	var cond string
+	switch row.Kind {
+	case KindAbove:
+		cond = fmt.Sprintf("涨到 %.2f", row.Threshold)
+	case KindBelow:
+		cond = fmt.Sprintf("跌到 %.2f", row.Threshold)
+	case KindMove:
+		cond = fmt.Sprintf("涨跌幅达到 %.2f%%", row.Threshold)
+	}
+	if row.Dm {
+		cond += " (私聊)"
+	}
+	return cond
+}
+
+// Describe 展示用的规则描述, 如 贵州茅台(600519) 涨到 1800.00
+func Describe(row *model.StockAlert) string {
+	return fmt.Sprintf("%s(%s) %s", row.Name, row.Symbol, Condition(row))
+}
+
+// Triggered 规则当前是否处于已触发状态: 价格提醒等待回撤, 涨跌幅提醒当天已发送
+func Triggered(row *model.StockAlert, now time.Time) bool {
+	if row.Kind == KindMove {
+		return row.State == now.In(utils.UTC8Loc()).Format(time.DateOnly)
+	}
+	return row.State == stateFired
+}
+
+// evaluate 按最新报价判断规则是否触发
+//
+//	价格提醒在穿越阈值时触发一次, 回撤超过 rearmRatio 后重新生效; 涨跌幅提醒每个交易日最多触发一次
+//
+//	@param row *model.StockAlert
+//	@param q *aktool.StockQuote
+//	@param today string 交易日, 2006-01-02
+//	@return fire bool 需要发送提醒
+//	@return state string 规则的新状态
+func evaluate(row *model.StockAlert, q *aktool.StockQuote, today string) (fire bool, state string) {
+	switch row.Kind {
+	case KindAbove, KindBelow:
+		hit, rearm := q.Price >= row.Threshold, q.Price < row.Threshold*(1-rearmRatio)
+		if row.Kind == KindBelow {
+			hit, rearm = q.Price <= row.Threshold, q.Price > row.Threshold*(1+rearmRatio)
+		}
+		switch {
+		case hit:
+			return row.State != stateFired, stateFired
+		case rearm:
+			return false, ""
+		}
+	case KindMove:
+		if q.PrevClose > 0 && math.Abs(q.ChangePct()) >= row.Threshold {
+			return row.State != today, today
+		}
+	}
+	return false, row.State
+}
+
+// inSession 是否在沪深交易所的连续竞价时段, 9:30-11:30 与 13:00-15:00, 收盘那一分钟也算在内
+func inSession(t time.Time) bool {
+	t = t.In(utils.UTC8Loc())
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	return (minute >= 9*60+30 && minute <= 11*60+30) || (minute >= 13*60 && minute <= 15*60)
+}
+
+// tradingDay 当天是否为交易日, 交易日历拉取失败或未覆盖当天时按工作日处理
+func tradingDay(ctx context.Context, t time.Time) bool {
+	dates, err := aktool.GetTradeDates(ctx)
+	if err != nil || len(dates) == 0 {
+		logs.L().Ctx(ctx).Warn("get trade dates failed, assume weekdays are trading days", zap.Error(err))
+		return true
+	}
+	day := t.In(utils.UTC8Loc()).Format(time.DateOnly)
+	if day > dates[len(dates)-1] {
+		return true
+	}
+	_, found := slices.BinarySearch(dates, day)
+	return found
+}
+
+func pollInterval() time.Duration {
+	if c := config.Get().AKToolConfig; c != nil && c.AlertPollIntervalSec > 0 {
+		return time.Duration(c.AlertPollIntervalSec) * time.Second
+	}
+	return time.Minute
+}
+
+// Init 注册轮询股价提醒的任务, 未配置 AKTool 时不启动
+func Init() {
+	if c := config.Get().AKToolConfig; c == nil || c.BaseURL == "" {
+		return
+	}
+	var cancel context.CancelFunc
+	xlifecycle.Register("stock_alert", xlifecycle.PhaseWorker,
+		func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go pollLoop(ctx)
+			return nil
+		},
+		func(context.Context) error {
+			cancel()
+			return nil
+		},
+	)
+}
+
+// transition 条件更新状态, 多个实例同时处理同一条规则时只有一个会成功
+//
+//	@return ok 状态是否由 from 变为 to
+func transition(ctx context.Context, row *model.StockAlert, from, to string) (ok bool, err error) {
+	ins := query.Q.StockAlert
+	info, err := ins.WithContext(ctx).Where(ins.ID.Eq(row.ID), ins.State.Eq(from)).Update(ins.State, to)
+	if err != nil {
+		return false, err
+	}
+	if info.RowsAffected == 0 {
+		return false, nil
+	}
+	row.State = to
+	return true, nil
+}
+
+// AddAlert 创建一条提醒规则
+//
+//	@param ctx context.Context
+//	@param row *model.StockAlert Name 为空时按代码查询
+//	@return err error
+func AddAlert(ctx context.Context, row *model.StockAlert) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", row.ChatID), attribute.String("symbol", row.Symbol))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if row.Name == "" {
+		if row.Name, err = Lookup(ctx, row.Symbol); err != nil {
+			return err
+		}
+	}
+	ins := query.Q.StockAlert
+	count, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(row.ChatID)).Count()
+	if err != nil {
+		return err
+	}
+	if count >= MaxAlerts {
+		return ErrAlertsFull
+	}
+	return ins.WithContext(ctx).Create(row)
+}
+
+// Alerts 群里的提醒规则, 按创建时间排序
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return []*model.StockAlert
+//	@return error
+func Alerts(ctx context.Context, chatID string) ([]*model.StockAlert, error) {
+	ins := query.Q.StockAlert
+	return ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID)).Order(ins.ID).Find()
+}
+
+// DeleteAlert 删除一条提醒规则, 私聊提醒只有创建者可以删除
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param userID string 操作人
+//	@param id int64
+//	@return err error
+func DeleteAlert(ctx context.Context, chatID, userID string, id int64) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.Int64("id", id))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	ins := query.Q.StockAlert
+	row, err := ins.WithContext(ctx).Where(ins.ID.Eq(id), ins.ChatID.Eq(chatID)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if row.Dm && row.CreatorID != userID {
+		return ErrNotAllowed
+	}
+	_, err = ins.WithContext(ctx).Where(ins.ID.Eq(id)).Delete()
+	return err
+}
+
+func pollLoop(ctx context.Context) {
+	checkAlerts(ctx)
+	ticker := time.NewTicker(pollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkAlerts(ctx)
+		}
+	}
+}
+
+// checkAlerts 交易时段内查询规则涉及的股票报价, 发送触发的提醒
+//
+//	状态通过条件更新认领, 多实例或重启后不会重复发送; 发送失败时恢复原状态, 下一轮重试
+func checkAlerts(ctx context.Context) {
+	now := time.Now()
+	if !inSession(now) {
+		return
+	}
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	if !tradingDay(ctx, now) {
+		return
+	}
+	rows, err := query.Q.StockAlert.WithContext(ctx).Find()
+	if err != nil {
+		span.RecordError(err)
+		logs.L().Ctx(ctx).Warn("load stock alerts failed", zap.Error(err))
+		return
+	}
+	symbols := make([]string, 0)
+	for _, row := range rows {
+		if !slices.Contains(symbols, row.Symbol) {
+			symbols = append(symbols, row.Symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return
+	}
+	quotes := Quotes(ctx, symbols)
+	today := now.In(utils.UTC8Loc()).Format(time.DateOnly)
+	fired := 0
+	for _, row := range rows {
+		if ctx.Err() != nil {
+			return
+		}
+		q := quotes[row.Symbol]
+		if q == nil {
+			continue
+		}
+		fire, state := evaluate(row, q, today)
+		if state == row.State {
+			continue
+		}
+		from := row.State
+		ok, err := transition(ctx, row, from, state)
+		if err != nil {
+			span.RecordError(err)
+			logs.L().Ctx(ctx).Warn("update stock alert failed", zap.Int64("id", row.ID), zap.Error(err))
+			continue
+		}
+		if !ok || !fire {
+			continue
+		}
+		if err := deliver(ctx, row, q, now); err != nil {
+			logs.L().Ctx(ctx).Error("deliver stock alert failed", zap.Int64("id", row.ID), zap.Error(err))
+			if _, err := transition(ctx, row, state, from); err != nil {
+				logs.L().Ctx(ctx).Warn("revert stock alert failed", zap.Int64("id", row.ID), zap.Error(err))
+			}
+			continue
+		}
+		fired++
+	}
+	span.SetAttributes(attribute.Int("symbols", len(symbols)), attribute.Int("fired", fired))
+}
+
+// deliver 发送提醒, 私聊提醒发给创建者, 否则发到创建规则的群里
+func deliver(ctx context.Context, row *model.StockAlert, q *aktool.StockQuote, now time.Time) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Int64("id", row.ID), attribute.String("chat_id", row.ChatID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	content := larkmsg.NewTextMsgBuilder().
+		Text(fmt.Sprintf("📈 股价提醒: %s\n现价 %.2f, 涨跌幅 %+.2f%%", Describe(row), q.Price, q.ChangePct())).
+		Build()
+	uuid := alertUUID(row, now)
+	if row.Dm {
+		_, err = larkmsg.CreateUserMsgRawContentType(ctx, row.CreatorID, larkim.MsgTypeText, content, uuid)
+		return err
+	}
+	_, err = larkmsg.CreateMsgRawContentType(ctx, row.ChatID, larkim.MsgTypeText, content, uuid)
+	return err
+}
+
+// alertUUID 发送提醒的幂等键, 同一次触发的重试不会重复发送;
+// 包含触发时间, 价格提醒回撤后再次触发时不会被飞书按 uuid 去重而丢弃
+func alertUUID(row *model.StockAlert, firedAt time.Time) string {
+	return fmt.Sprintf("stock_alert_%d_%s_%d", row.ID, row.State, firedAt.Unix())
+}
@@ -0,0 +1,124 @@
+package stock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+)
+
+func TestParseRule(t *testing.T) {
+	cases := []struct {
+		above, below, move string
+		kind               string
+		threshold          float64
+		ok                 bool
+	}{
+		{"1800", "", "", KindAbove, 1800, true},
+		{"", "12.5", "", KindBelow, 12.5, true},
+		{"", "", "5%", KindMove, 5, true},
+		{"", "", "-3", KindMove, 3, true},
+		{"", "", "", "", 0, false},
+		{"1800", "1700", "", "", 0, false},
+		{"abc", "", "", "", 0, false},
+		{"", "-1", "", "", 0, false},
+	}
+	for _, c := range cases {
+		kind, threshold, err := ParseRule(c.above, c.below, c.move)
+		if (err == nil) != c.ok {
+			t.Errorf("ParseRule(%q, %q, %q) err = %v, want ok %v", c.above, c.below, c.move, err, c.ok)
+			continue
+		}
+		if kind != c.kind || threshold != c.threshold {
+			t.Errorf("ParseRule(%q, %q, %q) = %s %v, want %s %v", c.above, c.below, c.move, kind, threshold, c.kind, c.threshold)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	const today = "2026-10-21"
+	row := &model.StockAlert{Kind: KindAbove, Threshold: 100}
+	// 依次给出价格, 只在第一次穿越与回撤后再次穿越时触发
+	steps := []struct {
+		price float64
+		fire  bool
+		state string
+	}{
+		{99, false, ""},
+		{100, true, stateFired},
+		{101, false, stateFired},
+		{99.8, false, stateFired},
+		{100.2, false, stateFired},
+		{99, false, ""},
+		{100.5, true, stateFired},
+	}
+	for i, step := range steps {
+		fire, state := evaluate(row, &aktool.StockQuote{Price: step.price, PrevClose: 100}, today)
+		if fire != step.fire || state != step.state {
+			t.Fatalf("step %d price %v: got %v %q, want %v %q", i, step.price, fire, state, step.fire, step.state)
+		}
+		row.State = state
+	}
+
+	below := &model.StockAlert{Kind: KindBelow, Threshold: 10}
+	if fire, _ := evaluate(below, &aktool.StockQuote{Price: 9.9, PrevClose: 10.5}, today); !fire {
+		t.Error("below alert should fire")
+	}
+
+	move := &model.StockAlert{Kind: KindMove, Threshold: 5}
+	if fire, _ := evaluate(move, &aktool.StockQuote{Price: 10.4, PrevClose: 10}, today); fire {
+		t.Error("move alert fired below threshold")
+	}
+	fire, state := evaluate(move, &aktool.StockQuote{Price: 9.4, PrevClose: 10}, today)
+	if !fire || state != today {
+		t.Fatalf("move alert = %v %q, want fire on %s", fire, state, today)
+	}
+	move.State = state
+	if fire, _ := evaluate(move, &aktool.StockQuote{Price: 9, PrevClose: 10}, today); fire {
+		t.Error("move alert fired twice on the same day")
+	}
+	if fire, _ := evaluate(move, &aktool.StockQuote{Price: 9, PrevClose: 10}, "2026-10-22"); !fire {
+		t.Error("move alert should fire again on the next day")
+	}
+}
+
+func TestInSession(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	// 2026-10-21 是周三
+	at := func(d, h, m int) time.Time { return time.Date(2026, 10, d, h, m, 0, 0, loc) }
+	cases := []struct {
+		t  time.Time
+		in bool
+	}{
+		{at(21, 9, 29), false},
+		{at(21, 9, 30), true},
+		{at(21, 11, 30), true},
+		{at(21, 12, 0), false},
+		{at(21, 13, 0), true},
+		{at(21, 15, 0), true},
+		{at(21, 15, 1), false},
+		{at(24, 10, 0), false},
+		{at(21, 1, 30).UTC(), false},
+		{time.Date(2026, 10, 21, 2, 0, 0, 0, time.UTC), true},
+	}
+	for _, c := range cases {
+		if got := inSession(c.t); got != c.in {
+			t.Errorf("inSession(%v) = %v, want %v", c.t, got, c.in)
+		}
+	}
+}
+
+func TestAlertUUID(t *testing.T) {
+	row := &model.StockAlert{ID: 12, State: stateFired}
+	first := time.Date(2026, 10, 21, 10, 0, 0, 0, time.UTC)
+	// 同一规则一小时内回撤后再次触发, 幂等键不能相同
+	if alertUUID(row, first) == alertUUID(row, first.Add(10*time.Minute)) {
+		t.Error("re-fired alert reuses the idempotency key")
+	}
+	// 飞书要求 uuid 不超过 50 个字符
+	row = &model.StockAlert{ID: 1 << 40, State: "2026-10-21"}
+	if n := len(alertUUID(row, first)); n > 50 {
+		t.Errorf("uuid length = %d", n)
+	}
+}
@@ -0,0 +1,87 @@
+package stock
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 搜索结果的匹配程度, 越小越靠前
+const (
+	matchExact = iota
+	matchPrefix
+	matchContains
+	matchNone
+)
+
+// matchRank 关键词与品种代码或名称的匹配程度, 不区分大小写; 美股代码如 105.AAPL 也匹配点号后的部分
+func matchRank(spot *aktool.Spot, keyword string) int {
+	keyword = strings.ToLower(keyword)
+	code, name := strings.ToLower(spot.Code), strings.ToLower(spot.Name)
+	ticker := code
+	if i := strings.IndexByte(code, '.'); i >= 0 {
+		ticker = code[i+1:]
+	}
+	switch {
+	case code == keyword || ticker == keyword || name == keyword:
+		return matchExact
+	case strings.HasPrefix(code, keyword) || strings.HasPrefix(ticker, keyword) || strings.HasPrefix(name, keyword):
+		return matchPrefix
+	case strings.Contains(code, keyword) || strings.Contains(name, keyword):
+		return matchContains
+	}
+	return matchNone
+}
+
+// rankSpots 按匹配程度筛选并排序, 同等匹配时保持市场顺序
+func rankSpots(spots []*aktool.Spot, keyword string, limit int) []*aktool.Spot {
+	type ranked struct {
+		spot *aktool.Spot
+		rank int
+	}
+	matched := make([]ranked, 0)
+	for _, spot := range spots {
+		if rank := matchRank(spot, keyword); rank != matchNone {
+			matched = append(matched, ranked{spot, rank})
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b ranked) int {
+		return cmp.Compare(a.rank, b.rank)
+	})
+	res := make([]*aktool.Spot, 0, min(limit, len(matched)))
+	for _, m := range matched[:min(limit, len(matched))] {
+		res = append(res, m.spot)
+	}
+	return res
+}
+
+// Search 按代码或名称在一个市场中搜索品种
+//
+//	@param ctx context.Context
+//	@param market aktool.Market 美股、基金的行情列表有上万条, 不提供跨市场搜索
+//	@param keyword string
+//	@param limit int 最多返回的条数
+//	@return []*aktool.Spot
+//	@return error
+func Search(ctx context.Context, market aktool.Market, keyword string, limit int) (res []*aktool.Spot, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("market", string(market)), attribute.String("keyword", keyword))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, nil
+	}
+	spots, err := aktool.GetSpots(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+	return rankSpots(spots, keyword, limit), nil
+}
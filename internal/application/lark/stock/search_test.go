@@ -0,0 +1,45 @@
+package stock
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
+)
+
+func TestRankSpots(t *testing.T) {
+	spots := []*aktool.Spot{
+		{Market: aktool.MarketA, Code: "600519", Name: "贵州茅台"},
+		{Market: aktool.MarketA, Code: "000858", Name: "五粮液"},
+		{Market: aktool.MarketETF, Code: "512690", Name: "酒ETF"},
+		{Market: aktool.MarketUS, Code: "105.AAPL", Name: "苹果"},
+		{Market: aktool.MarketUS, Code: "105.AAPU", Name: "Direxion Daily AAPL Bull 2X"},
+		{Market: aktool.MarketHK, Code: "00700", Name: "腾讯控股"},
+	}
+	codes := func(res []*aktool.Spot) []string {
+		out := make([]string, 0, len(res))
+		for _, s := range res {
+			out = append(out, s.Code)
+		}
+		return out
+	}
+	cases := []struct {
+		keyword string
+		limit   int
+		want    []string
+	}{
+		{"茅台", 10, []string{"600519"}},
+		{"aapl", 10, []string{"105.AAPL", "105.AAPU"}},
+		{"AAP", 10, []string{"105.AAPL", "105.AAPU"}},
+		{"00", 10, []string{"000858", "00700", "600519"}},
+		{"00", 1, []string{"000858"}},
+		{"腾讯控股", 10, []string{"00700"}},
+		{"比特币", 10, []string{}},
+	}
+	for _, c := range cases {
+		got := codes(rankSpots(spots, c.keyword, c.limit))
+		if !slices.Equal(got, c.want) {
+			t.Errorf("rankSpots(%q, %d) = %v, want %v", c.keyword, c.limit, got, c.want)
+		}
+	}
+}
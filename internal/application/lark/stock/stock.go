@@ -0,0 +1,155 @@
+// Package stock 自选股与股价提醒: 成员通过 /stock watch 维护本群或个人的自选股, 通过 /stock alert 设置价格或涨跌幅提醒;
+// 提醒持久化在 stock_alerts 表, 由后台任务在交易时段内轮询 AKTool 行情, 触发时发到群里或私聊
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/aktool"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// MaxWatches 每个群或成员的自选股上限
+const MaxWatches = 30
+
+// symbolRe 沪深A股代码
+var symbolRe = regexp.MustCompile(`^\d{6}$`)
+
+var (
+	// ErrWatchesFull 自选股已满
+	ErrWatchesFull = fmt.Errorf("watchlist is limited to %d stocks", MaxWatches)
+	// ErrUnknownSymbol 代码格式不对或查不到这只股票
+	ErrUnknownSymbol = errors.New("unknown stock symbol, expect a 6-digit A-share code such as 600519")
+)
+
+// Lookup 校验股票代码并返回股票简称
+//
+//	@param ctx context.Context
+//	@param symbol string
+//	@return string
+//	@return error
+func Lookup(ctx context.Context, symbol string) (string, error) {
+	if !symbolRe.MatchString(symbol) {
+		return "", ErrUnknownSymbol
+	}
+	name, err := aktool.GetStockSymbolInfo(ctx, symbol)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", ErrUnknownSymbol
+	}
+	return name, nil
+}
+
+// Quotes 并发查询多只股票的最新报价, 查询失败的不在结果中
+//
+//	@param ctx context.Context
+//	@param symbols []string
+//	@return map[string]*aktool.StockQuote
+func Quotes(ctx context.Context, symbols []string) map[string]*aktool.StockQuote {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		res = make(map[string]*aktool.StockQuote, len(symbols))
+	)
+	for _, symbol := range symbols {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q, err := aktool.GetStockQuote(ctx, symbol)
+			if err != nil {
+				logs.L().Ctx(ctx).Warn("get stock quote failed", zap.String("symbol", symbol), zap.Error(err))
+				return
+			}
+			mu.Lock()
+			res[symbol] = q
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return res
+}
+
+// Watches 自选股, 按添加时间排序
+//
+//	@param ctx context.Context
+//	@param chatID string 本群的自选股, 与 userID 二选一
+//	@param userID string 成员的个人自选股
+//	@return []*model.StockWatch
+//	@return error
+func Watches(ctx context.Context, chatID, userID string) ([]*model.StockWatch, error) {
+	ins := query.Q.StockWatch
+	return ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.UserID.Eq(userID)).Order(ins.CreatedAt, ins.ID).Find()
+}
+
+// Watch 添加自选股, 已添加的不重复添加
+//
+//	@param ctx context.Context
+//	@param chatID string 本群的自选股, 与 userID 二选一
+//	@param userID string 成员的个人自选股
+//	@param addedBy string 操作者 open_id
+//	@param symbol string
+//	@return row *model.StockWatch
+//	@return added bool 此前未添加
+//	@return err error
+func Watch(ctx context.Context, chatID, userID, addedBy, symbol string) (row *model.StockWatch, added bool, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("user_id", userID), attribute.String("symbol", symbol))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	name, err := Lookup(ctx, symbol)
+	if err != nil {
+		return nil, false, err
+	}
+	ins := query.Q.StockWatch
+	count, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.UserID.Eq(userID)).Count()
+	if err != nil {
+		return nil, false, err
+	}
+	if count >= MaxWatches {
+		return nil, false, ErrWatchesFull
+	}
+	row = &model.StockWatch{ChatID: chatID, UserID: userID, Symbol: symbol, Name: name, AddedBy: addedBy}
+	if err = ins.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(row); err != nil {
+		return nil, false, err
+	}
+	// 冲突时不会插入, 也不会回填 ID
+	return row, row.ID != 0, nil
+}
+
+// Unwatch 删除自选股
+//
+//	@param ctx context.Context
+//	@param chatID string 本群的自选股, 与 userID 二选一
+//	@param userID string 成员的个人自选股
+//	@param symbol string
+//	@return err error
+func Unwatch(ctx context.Context, chatID, userID, symbol string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("user_id", userID), attribute.String("symbol", symbol))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	ins := query.Q.StockWatch
+	info, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.UserID.Eq(userID), ins.Symbol.Eq(symbol)).Delete()
+	if err != nil {
+		return err
+	}
+	if info.RowsAffected == 0 {
+		return errors.New("stock is not in the watchlist")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package stock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+)
+
+func TestWatchesPerChat(t *testing.T) {
+	dbtest.Open(t, &model.StockWatch{})
+	ctx := context.Background()
+	ins := query.Q.StockWatch
+	add := func(chatID, userID, symbol string) {
+		t.Helper()
+		if err := ins.WithContext(ctx).Create(&model.StockWatch{ChatID: chatID, UserID: userID, Symbol: symbol, Name: symbol}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symbols := func(chatID, userID string) []string {
+		t.Helper()
+		rows, err := Watches(ctx, chatID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := make([]string, 0, len(rows))
+		for _, row := range rows {
+			res = append(res, row.Symbol)
+		}
+		return res
+	}
+
+	add("chat_a", "", "600519")
+	// 先查一次 A 群, 使查询进入缓存
+	if got := symbols("chat_a", ""); len(got) != 1 || got[0] != "600519" {
+		t.Fatalf("chat_a watches = %v", got)
+	}
+	if got := symbols("chat_b", ""); len(got) != 0 {
+		t.Fatalf("chat_b sees %v", got)
+	}
+	if got := symbols("", "user_a"); len(got) != 0 {
+		t.Fatalf("user_a sees %v", got)
+	}
+
+	// 写入后缓存失效, 能读到新添加的
+	add("chat_b", "", "000858")
+	add("chat_b", "", "300750")
+	if got := symbols("chat_b", ""); len(got) != 2 {
+		t.Fatalf("chat_b watches = %v", got)
+	}
+	for chatID, want := range map[string]int64{"chat_a": 1, "chat_b": 2, "chat_c": 0} {
+		count, err := ins.WithContext(ctx).Where(ins.ChatID.Eq(chatID), ins.UserID.Eq("")).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != want {
+			t.Errorf("count(%s) = %d, want %d", chatID, count, want)
+		}
+	}
+}
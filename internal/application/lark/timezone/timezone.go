@@ -0,0 +1,71 @@
+package timezone
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // 镜像中不一定带时区数据
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// zonePrefix function_enablings 中群所选时区的前缀, 如 timezone_Asia/Tokyo
+const zonePrefix = "timezone_"
+
+// For 群的时区, 用于解析命令与工具中的时间表达式; 未设置或查询失败时为 UTC+8
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@return *time.Location
+func For(ctx context.Context, chatID string) *time.Location {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID))
+	defer span.End()
+
+	if chatID == "" {
+		return utils.UTC8Loc()
+	}
+	ins := query.Q.FunctionEnabling
+	rows, err := ins.WithContext(ctx).Where(ins.GuildID.Eq(chatID), ins.Function.Like(zonePrefix+"%")).Find()
+	if err != nil {
+		span.RecordError(err)
+		return utils.UTC8Loc()
+	}
+	for _, row := range rows {
+		if loc, err := time.LoadLocation(strings.TrimPrefix(row.Function, zonePrefix)); err == nil {
+			return loc
+		}
+	}
+	return utils.UTC8Loc()
+}
+
+// Set 为群设置时区
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param name string IANA 时区名, 如 Asia/Shanghai、America/New_York
+//	@return err error
+func Set(ctx context.Context, chatID, name string) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.String("chat_id", chatID), attribute.String("timezone", name))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	// Local 取决于部署环境, 不能作为群的设置
+	if _, err = time.LoadLocation(name); err != nil || name == "" || name == "Local" {
+		return fmt.Errorf("unknown timezone %q, use an IANA name like Asia/Shanghai", name)
+	}
+	return query.Q.Transaction(func(tx *query.Query) error {
+		ins := tx.FunctionEnabling
+		if _, err := ins.WithContext(ctx).Where(ins.GuildID.Eq(chatID), ins.Function.Like(zonePrefix+"%")).Delete(); err != nil {
+			return err
+		}
+		return ins.WithContext(ctx).Create(&model.FunctionEnabling{GuildID: chatID, Function: zonePrefix + name})
+	})
+}
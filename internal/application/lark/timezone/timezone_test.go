@@ -0,0 +1,32 @@
+package timezone
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+)
+
+func TestSetAndFor(t *testing.T) {
+	dbtest.Open(t, &model.FunctionEnabling{})
+	ctx := context.Background()
+
+	if loc := For(ctx, "chat_a"); loc.String() != "UTC+8" {
+		t.Fatalf("For(chat_a) = %v, want UTC+8 by default", loc)
+	}
+	if err := Set(ctx, "chat_a", "America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	if loc := For(ctx, "chat_a"); loc.String() != "America/New_York" {
+		t.Fatalf("For(chat_a) = %v", loc)
+	}
+	if loc := For(ctx, "chat_b"); loc.String() != "UTC+8" {
+		t.Fatalf("For(chat_b) = %v, want UTC+8", loc)
+	}
+	for _, name := range []string{"", "Local", "Mars/Olympus"} {
+		if err := Set(ctx, "chat_a", name); err == nil {
+			t.Errorf("Set(%q) should fail", name)
+		}
+	}
+}
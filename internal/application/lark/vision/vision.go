@@ -0,0 +1,128 @@
+// Package vision 图片理解: 对消息中的图片生成描述与OCR文本, 供索引、向量化与 chunk 使用
+package vision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/ark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkimg"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	insightKeyPrefix = "vision:insight:"
+	insightTTL       = 7 * 24 * time.Hour
+	// maxImagesPerMsg 单条消息最多理解的图片数, 避免长图文把模型调用打满
+	maxImagesPerMsg = 4
+)
+
+// HasImage 消息中是否包含图片
+//
+//	@param ctx context.Context
+//	@param msg *larkim.EventMessage
+//	@return bool
+func HasImage(ctx context.Context, msg *larkim.EventMessage) bool {
+	seq, err := larkimg.GetAllImageFromMsgEvent(ctx, msg)
+	if err != nil || seq == nil {
+		return false
+	}
+	for range seq {
+		return true
+	}
+	return false
+}
+
+// DescribeMessage 理解消息中的全部图片, 单张失败时跳过
+//
+//	结果按 image_key 缓存在 Redis, 同一条消息重复调用不会重复请求模型
+//	@param ctx context.Context
+//	@param msg *larkim.EventMessage
+//	@return insights []*xmodel.ImageInsight
+func DescribeMessage(ctx context.Context, msg *larkim.EventMessage) (insights []*xmodel.ImageInsight) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	seq, err := larkimg.GetAllImageFromMsgEvent(ctx, msg)
+	if err != nil || seq == nil {
+		return nil
+	}
+	for imageKey := range seq {
+		if len(insights) >= maxImagesPerMsg {
+			break
+		}
+		insight, err := describe(ctx, *msg.MessageId, imageKey)
+		if err != nil {
+			logs.L().Ctx(ctx).Warn("describe image failed", zap.String("image_key", imageKey), zap.Error(err))
+			continue
+		}
+		insights = append(insights, insight)
+	}
+	return insights
+}
+
+func describe(ctx context.Context, msgID, imageKey string) (insight *xmodel.ImageInsight, err error) {
+	rdb := redis_dal.GetRedisClient()
+	key := insightKeyPrefix + imageKey
+	cached, err := rdb.Get(ctx, key).Result()
+	if err == nil {
+		insight = &xmodel.ImageInsight{}
+		if err = sonic.UnmarshalString(cached, insight); err == nil {
+			return insight, nil
+		}
+	} else if err != redis.Nil {
+		logs.L().Ctx(ctx).Warn("get image insight cache error", zap.Error(err))
+	}
+
+	dataURL, err := larkimg.DownImgAsDataURL(ctx, msgID, imageKey)
+	if err != nil {
+		return nil, err
+	}
+	insight, err = ark_dal.DescribeImage(ctx, dataURL)
+	if err != nil {
+		return nil, err
+	}
+	insight.ImageKey = imageKey
+	if s, err := sonic.MarshalString(insight); err == nil {
+		if err := rdb.Set(ctx, key, s, insightTTL).Err(); err != nil {
+			logs.L().Ctx(ctx).Warn("set image insight cache error", zap.Error(err))
+		}
+	}
+	return insight, nil
+}
+
+// Render 将理解结果渲染为一行可读文本, 用于拼接到消息内容
+//
+//	@param insights []*xmodel.ImageInsight
+//	@return string
+func Render(insights []*xmodel.ImageInsight) string {
+	parts := make([]string, 0, len(insights))
+	for _, insight := range insights {
+		if insight == nil || (insight.Caption == "" && insight.OCR == "") {
+			continue
+		}
+		sb := strings.Builder{}
+		sb.WriteString("[图片: ")
+		sb.WriteString(oneLine(insight.Caption))
+		if insight.OCR != "" {
+			fmt.Fprintf(&sb, " | 文字: %s", oneLine(insight.OCR))
+		}
+		sb.WriteString("]")
+		parts = append(parts, sb.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,20 @@
+package vision
+
+import (
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+)
+
+func TestRender(t *testing.T) {
+	got := Render([]*xmodel.ImageInsight{
+		{Caption: "一只猫", OCR: "周一\n不想上班"},
+		nil,
+		{},
+		{Caption: "截图"},
+	})
+	want := "[图片: 一只猫 | 文字: 周一 不想上班] [图片: 截图]"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
@@ -3,13 +3,10 @@ package aktool
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
-	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
-	"github.com/BetaGoRobot/go_utils/reflecting"
-	"github.com/bytedance/sonic"
-	"github.com/cloudwego/hertz/pkg/app/client"
-	"github.com/cloudwego/hertz/pkg/protocol"
 )
 
 var (
@@ -20,6 +17,8 @@ var (
 
 	StockHandlerNameRealtime = "stock_zh_a_minute"
 	StockSingleInfo          = "stock_individual_info_em"
+	StockBidAsk              = "stock_bid_ask_em"
+	TradeDateHist            = "tool_trade_date_hist_sina"
 )
 
 func Init() {
@@ -44,31 +43,31 @@ type (
 		Close    string `json:"close"`
 		Volume   string `json:"volume"`
 	}
-)
-
-func GetRealtimeGoldPrice(ctx context.Context) (res GoldPriceDataRTList, err error) {
-	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
-	defer span.End()
 
-	res = make(GoldPriceDataRTList, 0)
-	c, _ := client.NewClient()
-	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
-	req.SetRequestURI(BaseURL + PublicAPIURI + GoldHandlerNameRealtime)
-	req.SetMethod("GET")
-
-	err = c.Do(ctx, req, resp)
-	if err != nil {
-		return
-	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("get gold price failed, status code: %d", resp.StatusCode())
+	// StockQuote 个股的最新报价
+	StockQuote struct {
+		Symbol    string
+		Price     float64
+		PrevClose float64
 	}
+)
 
-	err = sonic.Unmarshal(resp.Body(), &res)
-	if err != nil {
-		return
+// ChangePct 相对昨收的涨跌幅, 单位 %
+func (q *StockQuote) ChangePct() float64 {
+	if q.PrevClose == 0 {
+		return 0
 	}
-	return
+	return (q.Price - q.PrevClose) / q.PrevClose * 100
+}
+
+// itemValue AKShare 中 item/value 两列的表, 如个股信息、五档盘口
+type itemValue []struct {
+	Item  string `json:"item"`
+	Value any    `json:"value"`
+}
+
+func GetRealtimeGoldPrice(ctx context.Context) (res GoldPriceDataRTList, err error) {
+	return get[GoldPriceDataRTList](ctx, GoldHandlerNameRealtime, nil, ttlRealtime)
 }
 
 type GoldPriceDataHS []struct {
@@ -80,28 +79,21 @@ type GoldPriceDataHS []struct {
 }
 
 func GetHistoryGoldPrice(ctx context.Context) (res GoldPriceDataHS, err error) {
-	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
-	defer span.End()
-
-	res = make(GoldPriceDataHS, 0)
-	c, _ := client.NewClient()
-	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
-	req.SetRequestURI(BaseURL + PublicAPIURI + GoldHandlerNameHistory)
-	req.SetMethod("GET")
+	return get[GoldPriceDataHS](ctx, GoldHandlerNameHistory, nil, ttlHistory)
+}
 
-	err = c.Do(ctx, req, resp)
-	if err != nil {
-		return
+// ZhASymbol 沪深京A股代码加上交易所前缀, 如 600519 -> sh600519
+func ZhASymbol(symbol string) string {
+	if symbol == "" {
+		return symbol
 	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("get gold price failed, status code: %d", resp.StatusCode())
+	switch symbol[0] {
+	case '5', '6', '9':
+		return "sh" + symbol
+	case '4', '8':
+		return "bj" + symbol
 	}
-
-	err = sonic.Unmarshal(resp.Body(), &res)
-	if err != nil {
-		return
-	}
-	return
+	return "sz" + symbol
 }
 
 /*
@@ -112,55 +104,68 @@ period	str	period='5'; choice of {'1', '5', '15', '30', '60'}; 其中 1 分钟
 adjust	str	adjust=”; choice of {”, 'qfq', 'hfq'}; ”: 不复权, 'qfq': 前复权, 'hfq': 后复权, 其中 1 分钟数据返回近 5 个交易日数据且不复权
 */
 func GetStockPriceRT(ctx context.Context, symbol string) (res StockPriceDataRTList, err error) {
-	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
-	defer span.End()
-
-	res = make(StockPriceDataRTList, 0)
-	c, _ := client.NewClient()
-	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
-	req.SetRequestURI(BaseURL + PublicAPIURI + StockHandlerNameRealtime)
-	req.SetMethod("GET")
-	req.SetQueryString(fmt.Sprintf("symbol=sh%s", symbol))
+	return get[StockPriceDataRTList](ctx, StockHandlerNameRealtime, url.Values{"symbol": {ZhASymbol(symbol)}}, ttlRealtime)
+}
 
-	err = c.Do(ctx, req, resp)
+func GetStockSymbolInfo(ctx context.Context, symbol string) (stockName string, err error) {
+	res, err := get[itemValue](ctx, StockSingleInfo, url.Values{"symbol": {symbol}}, ttlStatic)
 	if err != nil {
 		return
 	}
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("get gold price failed, status code: %d", resp.StatusCode())
-	}
-
-	err = sonic.Unmarshal(resp.Body(), &res)
-	if err != nil {
-		return
+	for _, item := range res {
+		if item.Item == "股票简称" {
+			stockName, _ = item.Value.(string)
+			return stockName, nil
+		}
 	}
 	return
 }
 
-func GetStockSymbolInfo(ctx context.Context, symbol string) (stockName string, err error) {
-	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
-	defer span.End()
-
-	res := make([]map[string]any, 0)
-	c, _ := client.NewClient()
-	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
-	req.SetRequestURI(BaseURL + PublicAPIURI + StockSingleInfo)
-	req.SetMethod("GET")
-	req.SetQueryString(fmt.Sprintf("symbol=%s", symbol))
-	err = c.Do(ctx, req, resp)
+// GetStockQuote 沪深A股的最新价与昨收, 取自五档盘口
+//
+//	@param ctx context.Context
+//	@param symbol string 股票代码, 如 600519
+//	@return quote *StockQuote
+//	@return err error
+func GetStockQuote(ctx context.Context, symbol string) (quote *StockQuote, err error) {
+	res, err := get[itemValue](ctx, StockBidAsk, url.Values{"symbol": {symbol}}, ttlRealtime)
 	if err != nil {
 		return
 	}
-	if resp.StatusCode() != 200 {
-		return "", fmt.Errorf("get stock info failed, status code: %d", resp.StatusCode())
+	quote = &StockQuote{Symbol: symbol}
+	for _, item := range res {
+		value, _ := item.Value.(float64)
+		switch item.Item {
+		case "最新":
+			quote.Price = value
+		case "昨收":
+			quote.PrevClose = value
+		}
+	}
+	// 停牌或代码有误时没有最新价
+	if quote.Price == 0 {
+		return nil, fmt.Errorf("no quote for stock %s", symbol)
 	}
-	err = sonic.Unmarshal(resp.Body(), &res)
+	return
+}
+
+// GetTradeDates 沪深交易所的交易日历, 包含当年余下的交易日
+//
+//	@param ctx context.Context
+//	@return dates []string 2006-01-02 格式, 升序
+//	@return err error
+func GetTradeDates(ctx context.Context) (dates []string, err error) {
+	res, err := get[[]struct {
+		TradeDate string `json:"trade_date"`
+	}](ctx, TradeDateHist, nil, ttlStatic)
 	if err != nil {
 		return
 	}
+	dates = make([]string, 0, len(res))
 	for _, item := range res {
-		if item["item"].(string) == "股票简称" {
-			return item["value"].(string), nil
+		// 形如 2025-05-23T00:00:00.000
+		if len(item.TradeDate) >= len(time.DateOnly) {
+			dates = append(dates, item.TradeDate[:len(time.DateOnly)])
 		}
 	}
 	return
@@ -0,0 +1,74 @@
+package aktool
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	gocache "github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 各类数据的缓存有效期
+const (
+	// ttlRealtime 单个品种的实时报价
+	ttlRealtime = 15 * time.Second
+	// ttlSpot 全市场的行情列表, 响应较大
+	ttlSpot = time.Minute
+	// ttlHistory 日线等历史数据
+	ttlHistory = 10 * time.Minute
+	// ttlStatic 股票信息、交易日历等很少变化的数据
+	ttlStatic = 12 * time.Hour
+)
+
+// respCache 接口响应的缓存, 以完整的请求地址为键
+var respCache = gocache.New(time.Minute, 10*time.Minute)
+
+// get 调用 AKTool 的公开接口并解析 JSON 响应, 相同的请求在 ttl 内直接返回缓存的结果, 调用方不要修改返回值
+//
+//	@param ctx context.Context
+//	@param handler string AKShare 的接口名, 如 stock_zh_a_hist
+//	@param params url.Values 接口参数, 可以为 nil
+//	@param ttl time.Duration 缓存有效期
+//	@return res T
+//	@return err error
+func get[T any](ctx context.Context, handler string, params url.Values, ttl time.Duration) (res T, err error) {
+	ctx, span := otel.T().Start(ctx, "aktool."+handler)
+	span.SetAttributes(attribute.String("params", params.Encode()))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	uri := BaseURL + PublicAPIURI + handler
+	if len(params) > 0 {
+		uri += "?" + params.Encode()
+	}
+	if v, ok := respCache.Get(uri); ok {
+		span.SetAttributes(attribute.Bool("cache_hit", true))
+		return v.(T), nil
+	}
+	c, err := client.NewClient()
+	if err != nil {
+		return
+	}
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+	req.SetRequestURI(uri)
+	req.SetMethod("GET")
+	if err = c.Do(ctx, req, resp); err != nil {
+		return
+	}
+	if resp.StatusCode() != 200 {
+		return res, fmt.Errorf("aktool %s failed, status code: %d", handler, resp.StatusCode())
+	}
+	if err = sonic.Unmarshal(resp.Body(), &res); err != nil {
+		return
+	}
+	respCache.Set(uri, res, ttl)
+	return res, nil
+}
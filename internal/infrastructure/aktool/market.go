@@ -0,0 +1,234 @@
+package aktool
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+)
+
+// Market 行情市场
+type Market string
+
+const (
+	MarketA     Market = "a"
+	MarketIndex Market = "index"
+	MarketETF   Market = "etf"
+	MarketFund  Market = "fund"
+	MarketHK    Market = "hk"
+	MarketUS    Market = "us"
+	MarketFX    Market = "fx"
+)
+
+// Markets 支持的市场, 按搜索时的优先级排列
+var Markets = []Market{MarketA, MarketIndex, MarketETF, MarketFund, MarketHK, MarketUS, MarketFX}
+
+// ErrUnknownMarket 不支持的市场
+var ErrUnknownMarket = errors.New("unknown market")
+
+// Label 市场的中文名
+func (m Market) Label() string {
+	switch m {
+	case MarketA:
+		return "A股"
+	case MarketIndex:
+		return "指数"
+	case MarketETF:
+		return "ETF"
+	case MarketFund:
+		return "基金"
+	case MarketHK:
+		return "港股"
+	case MarketUS:
+		return "美股"
+	case MarketFX:
+		return "外汇"
+	}
+	return string(m)
+}
+
+// Spot 行情列表中的一个品种, 基金列表没有价格
+type Spot struct {
+	Market    Market  `json:"-"`
+	Code      string  `json:"代码"`
+	Name      string  `json:"名称"`
+	Price     float64 `json:"最新价"`
+	ChangePct float64 `json:"涨跌幅"`
+}
+
+// Candle 一根日K线, 外汇没有成交量, 基金的开收高低均为单位净值
+type Candle struct {
+	Date   string  `json:"日期"`
+	Open   float64 `json:"开盘"`
+	Close  float64 `json:"收盘"`
+	High   float64 `json:"最高"`
+	Low    float64 `json:"最低"`
+	Volume float64 `json:"成交量"`
+}
+
+// marketSpec 市场对应的 AKShare 接口
+type marketSpec struct {
+	// spot 全市场行情列表
+	spot       string
+	spotParams url.Values
+	// hist 日线, 参数为 symbol、period、start_date、end_date、adjust
+	hist string
+}
+
+var marketSpecs = map[Market]*marketSpec{
+	MarketA:     {spot: "stock_zh_a_spot_em", hist: "stock_zh_a_hist"},
+	MarketIndex: {spot: "stock_zh_index_spot_em", spotParams: url.Values{"symbol": {"沪深重要指数"}}, hist: "index_zh_a_hist"},
+	MarketETF:   {spot: "fund_etf_spot_em", hist: "fund_etf_hist_em"},
+	MarketHK:    {spot: "stock_hk_spot_em", hist: "stock_hk_hist"},
+	MarketUS:    {spot: "stock_us_spot_em", hist: "stock_us_hist"},
+	MarketFX:    {spot: "forex_spot_em"},
+}
+
+const (
+	// fundList 开放式基金列表, 只有代码与名称
+	fundList = "fund_name_em"
+	// fundNav 开放式基金的净值走势
+	fundNav = "fund_open_fund_info_em"
+	// fxHist 外汇日线, 不支持日期参数
+	fxHist = "forex_hist_em"
+)
+
+// ParseMarket 由命令参数得到市场
+func ParseMarket(s string) (Market, error) {
+	for _, m := range Markets {
+		if string(m) == s || m.Label() == s {
+			return m, nil
+		}
+	}
+	return "", ErrUnknownMarket
+}
+
+// GetSpots 市场的全部品种及最新价
+//
+//	@param ctx context.Context
+//	@param market Market
+//	@return []*Spot
+//	@return error
+func GetSpots(ctx context.Context, market Market) ([]*Spot, error) {
+	if market == MarketFund {
+		rows, err := get[[]*struct {
+			Code string `json:"基金代码"`
+			Name string `json:"基金简称"`
+		}](ctx, fundList, nil, ttlStatic)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]*Spot, 0, len(rows))
+		for _, row := range rows {
+			res = append(res, &Spot{Market: market, Code: row.Code, Name: row.Name})
+		}
+		return res, nil
+	}
+	spec, ok := marketSpecs[market]
+	if !ok {
+		return nil, ErrUnknownMarket
+	}
+	rows, err := get[[]Spot](ctx, spec.spot, spec.spotParams, ttlSpot)
+	if err != nil {
+		return nil, err
+	}
+	// 缓存中的结果是共享的, 复制后再填市场
+	res := make([]*Spot, 0, len(rows))
+	for _, row := range rows {
+		row.Market = market
+		res = append(res, &row)
+	}
+	return res, nil
+}
+
+// GetCandles 品种在 [st, et] 内的日K线, 按日期升序
+//
+//	@param ctx context.Context
+//	@param market Market
+//	@param code string 行情列表中的代码, 如 600519、000300、00700、105.AAPL、USDCNH
+//	@param st time.Time
+//	@param et time.Time
+//	@return []*Candle
+//	@return error
+func GetCandles(ctx context.Context, market Market, code string, st, et time.Time) ([]*Candle, error) {
+	var (
+		res []*Candle
+		err error
+	)
+	st, et = st.In(utils.UTC8Loc()), et.In(utils.UTC8Loc())
+	switch market {
+	case MarketFund:
+		res, err = getFundNav(ctx, code)
+	case MarketFX:
+		res, err = getFxCandles(ctx, code)
+	default:
+		spec, ok := marketSpecs[market]
+		if !ok {
+			return nil, ErrUnknownMarket
+		}
+		params := url.Values{
+			"symbol":     {code},
+			"period":     {"daily"},
+			"start_date": {st.Format("20060102")},
+			"end_date":   {et.Format("20060102")},
+		}
+		if market != MarketIndex {
+			params.Set("adjust", "qfq")
+		}
+		res, err = get[[]*Candle](ctx, spec.hist, params, ttlHistory)
+	}
+	if err != nil {
+		return nil, err
+	}
+	from, to := st.Format(time.DateOnly), et.Format(time.DateOnly)
+	candles := make([]*Candle, 0, len(res))
+	for _, c := range res {
+		// 日期形如 2025-05-23T00:00:00.000
+		if len(c.Date) < len(time.DateOnly) {
+			continue
+		}
+		day := c.Date[:len(time.DateOnly)]
+		if day < from || day > to {
+			continue
+		}
+		cc := *c
+		cc.Date = day
+		candles = append(candles, &cc)
+	}
+	return candles, nil
+}
+
+func getFundNav(ctx context.Context, code string) ([]*Candle, error) {
+	rows, err := get[[]*struct {
+		Date string  `json:"净值日期"`
+		Nav  float64 `json:"单位净值"`
+	}](ctx, fundNav, url.Values{"symbol": {code}, "indicator": {"单位净值走势"}}, ttlHistory)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Candle, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, &Candle{Date: row.Date, Open: row.Nav, Close: row.Nav, High: row.Nav, Low: row.Nav})
+	}
+	return res, nil
+}
+
+func getFxCandles(ctx context.Context, code string) ([]*Candle, error) {
+	rows, err := get[[]*struct {
+		Date  string  `json:"日期"`
+		Open  float64 `json:"今开"`
+		Close float64 `json:"最新价"`
+		High  float64 `json:"最高"`
+		Low   float64 `json:"最低"`
+	}](ctx, fxHist, url.Values{"symbol": {code}}, ttlHistory)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Candle, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, &Candle{Date: row.Date, Open: row.Open, Close: row.Close, High: row.High, Low: row.Low})
+	}
+	return res, nil
+}
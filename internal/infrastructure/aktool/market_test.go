@@ -0,0 +1,111 @@
+package aktool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+)
+
+// useTestServer 以本地服务代替 AKTool, 返回各接口被请求的次数
+func useTestServer(t *testing.T, bodies map[string]string) *atomic.Int32 {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, ok := bodies[r.URL.Path[len(PublicAPIURI):]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	prev := BaseURL
+	BaseURL = srv.URL
+	respCache.Flush()
+	t.Cleanup(func() {
+		srv.Close()
+		BaseURL = prev
+		respCache.Flush()
+	})
+	return &hits
+}
+
+func TestGetCandles(t *testing.T) {
+	hits := useTestServer(t, map[string]string{
+		"stock_hk_hist": `[
+			{"日期":"2025-05-21T00:00:00.000","开盘":1,"收盘":2,"最高":2,"最低":1,"成交量":10},
+			{"日期":"2025-05-22T00:00:00.000","开盘":2,"收盘":3,"最高":3,"最低":2,"成交量":20},
+			{"日期":"2025-05-23T00:00:00.000","开盘":3,"收盘":2,"最高":3,"最低":2,"成交量":30}
+		]`,
+		"forex_hist_em": `[
+			{"日期":"2025-05-21","今开":7.1,"最新价":7.2,"最高":7.3,"最低":7.0},
+			{"日期":"2025-05-22","今开":7.2,"最新价":7.1,"最高":7.2,"最低":7.0}
+		]`,
+	})
+	ctx := context.Background()
+	st := time.Date(2025, 5, 22, 0, 0, 0, 0, utils.UTC8Loc())
+	et := time.Date(2025, 5, 23, 0, 0, 0, 0, utils.UTC8Loc())
+
+	candles, err := GetCandles(ctx, MarketHK, "00700", st, et)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 || candles[0].Date != "2025-05-22" || candles[1].Date != "2025-05-23" {
+		t.Fatalf("GetCandles(hk) = %+v", candles)
+	}
+	// 相同的请求走缓存, 修改返回值不影响缓存
+	candles[0].Close = 100
+	again, err := GetCandles(ctx, MarketHK, "00700", st, et)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 || again[0].Close != 3 {
+		t.Fatalf("cached GetCandles hits = %d, close = %v", hits.Load(), again[0].Close)
+	}
+
+	// 外汇接口不支持日期参数, 由本地按日期筛选
+	candles, err = GetCandles(ctx, MarketFX, "USDCNH", st, et)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 || candles[0].Date != "2025-05-22" || candles[0].Close != 7.1 {
+		t.Fatalf("GetCandles(fx) = %+v", candles)
+	}
+
+	if _, err := GetCandles(ctx, MarketUS, "105.AAPL", st, et); err == nil {
+		t.Fatal("GetCandles should fail on a non-200 response")
+	}
+}
+
+func TestGetCache(t *testing.T) {
+	hits := useTestServer(t, map[string]string{"tool_trade_date_hist_sina": `[{"trade_date":"2025-05-23"}]`})
+	ctx := context.Background()
+	for range 2 {
+		if _, err := get[[]map[string]string](ctx, TradeDateHist, nil, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("hits = %d, want 1", hits.Load())
+	}
+	// 参数不同的请求分别缓存, 过期后重新请求
+	params := url.Values{"symbol": {"sh"}}
+	for range 2 {
+		if _, err := get[[]map[string]string](ctx, TradeDateHist, params, 10*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := get[[]map[string]string](ctx, TradeDateHist, params, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("hits = %d, want 3", hits.Load())
+	}
+}
@@ -0,0 +1,70 @@
+package ark_dal
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/gg/gptr"
+	"github.com/bytedance/sonic"
+	"github.com/volcengine/volcengine-go-sdk/service/arkruntime/model/responses"
+	"go.uber.org/zap"
+)
+
+const visionSysPrompt = `你是图片理解助手。请阅读用户给出的图片, 输出 JSON: {"caption": "...", "ocr": "..."}
+- caption: 用一两句中文描述图片内容; 表情包/梗图需说明其表达的情绪或梗
+- ocr: 图片中出现的全部文字, 保持原文, 多行用换行分隔; 没有文字时为空字符串
+只输出 JSON, 不要输出其他内容`
+
+// DescribeImage 使用视觉模型生成图片描述与OCR文本
+//
+//	@param ctx context.Context
+//	@param image string 图片URL或 data:image/...;base64 数据
+//	@return insight *xmodel.ImageInsight
+//	@return err error
+func DescribeImage(ctx context.Context, image string) (insight *xmodel.ImageInsight, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if visionModel == "" {
+		return nil, errors.New("vision model is not configured")
+	}
+	items := append(baseInputItem(visionSysPrompt, ""), buildImageInputMessages(image)...)
+	req := &responses.ResponsesRequest{
+		Model: visionModel,
+		Input: &responses.ResponsesInput{
+			Union: &responses.ResponsesInput_ListValue{
+				ListValue: &responses.InputItemList{ListValue: items},
+			},
+		},
+		Temperature: gptr.Of(0.1),
+		Text: &responses.ResponsesText{
+			Format: &responses.TextFormat{Type: responses.TextType_json_object},
+		},
+	}
+	resp, err := client.CreateResponses(ctx, req)
+	if err != nil {
+		logs.L().Ctx(ctx).Error("vision responses error", zap.Error(err))
+		return nil, err
+	}
+	for _, output := range resp.GetOutput() {
+		msg := output.GetOutputMessage()
+		if msg == nil || len(msg.GetContent()) == 0 {
+			continue
+		}
+		text := strings.TrimSpace(msg.GetContent()[0].GetText().GetText())
+		text = strings.TrimPrefix(strings.TrimSuffix(text, "```"), "```json")
+		insight = &xmodel.ImageInsight{}
+		if err = sonic.UnmarshalString(strings.TrimSpace(text), insight); err != nil {
+			// 模型没按格式输出时, 整段当作描述
+			return &xmodel.ImageInsight{Caption: text}, nil
+		}
+		return insight, nil
+	}
+	return nil, errors.New("vision output is empty")
+}
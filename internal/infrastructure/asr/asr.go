@@ -0,0 +1,59 @@
+// Package asr 语音转写适配层, 具体实现由配置中的 provider 决定
+package asr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"go.uber.org/zap"
+)
+
+const ProviderWhisperHTTP = "whisper_http"
+
+// ErrDisabled 未配置转写服务
+var ErrDisabled = errors.New("asr is not configured")
+
+// Transcriber 语音转写
+type Transcriber interface {
+	// Name 实现名, 记录在转写结果中
+	Name() string
+	// Transcribe 将音频转写为文本
+	//
+	//	@param ctx context.Context
+	//	@param audio io.Reader 音频内容
+	//	@param fileName string 文件名, 部分实现依赖后缀识别格式
+	//	@return string
+	//	@return error
+	Transcribe(ctx context.Context, audio io.Reader, fileName string) (string, error)
+}
+
+var transcriber Transcriber
+
+func Init(conf *config.ASRConfig) {
+	if conf == nil || conf.Endpoint == "" {
+		logs.L().Info("asr config is empty, voice messages will not be transcribed")
+		return
+	}
+	timeout := time.Duration(conf.TimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	switch conf.Provider {
+	case ProviderWhisperHTTP, "":
+		transcriber = NewWhisperHTTP(conf.Endpoint, conf.Language, timeout)
+	default:
+		logs.L().Warn("unknown asr provider, voice messages will not be transcribed", zap.String("provider", conf.Provider))
+	}
+}
+
+// Get 当前生效的转写实现, 未配置时返回 ErrDisabled
+func Get() (Transcriber, error) {
+	if transcriber == nil {
+		return nil, ErrDisabled
+	}
+	return transcriber, nil
+}
@@ -0,0 +1,76 @@
+package asr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/go-resty/resty/v2"
+)
+
+// WhisperHTTP 兼容 whisper.cpp server 的 /inference 接口
+//
+//	飞书语音为 opus 格式, 服务端需开启 --convert 以便用 ffmpeg 转码
+type WhisperHTTP struct {
+	endpoint string
+	language string
+	client   *resty.Client
+}
+
+type whisperResp struct {
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+func NewWhisperHTTP(endpoint, language string, timeout time.Duration) *WhisperHTTP {
+	return &WhisperHTTP{
+		endpoint: endpoint,
+		language: language,
+		client:   resty.New().SetTimeout(timeout),
+	}
+}
+
+func (w *WhisperHTTP) Name() string {
+	return ProviderWhisperHTTP
+}
+
+func (w *WhisperHTTP) Transcribe(ctx context.Context, audio io.Reader, fileName string) (text string, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	formData := map[string]string{
+		"response_format": "json",
+		"temperature":     "0",
+	}
+	if w.language != "" {
+		formData["language"] = w.language
+	}
+	res := &whisperResp{}
+	resp, err := w.client.R().
+		SetContext(ctx).
+		SetFileReader("file", fileName, audio).
+		SetFormData(formData).
+		SetResult(res).
+		SetError(res).
+		Post(w.endpoint)
+	if err != nil {
+		return "", err
+	}
+	if resp.IsError() {
+		msg := res.Error
+		if msg == "" {
+			msg = strings.TrimSpace(resp.String())
+		}
+		return "", fmt.Errorf("whisper server returned %d: %s", resp.StatusCode(), msg)
+	}
+	if res.Error != "" {
+		return "", errors.New(res.Error)
+	}
+	return strings.TrimSpace(res.Text), nil
+}
@@ -0,0 +1,43 @@
+package asr
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWhisperHTTPTranscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, `{"error":"no file"}`, http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "voice.opus" || string(data) != "audio" || r.FormValue("language") != "zh" {
+			http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":" 你好 \n"}`))
+	}))
+	defer srv.Close()
+
+	w := NewWhisperHTTP(srv.URL, "zh", time.Second)
+	text, err := w.Transcribe(context.Background(), strings.NewReader("audio"), "voice.opus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "你好" {
+		t.Errorf("Transcribe() = %q, want %q", text, "你好")
+	}
+
+	_, err = NewWhisperHTTP(srv.URL, "en", time.Second).Transcribe(context.Background(), strings.NewReader("audio"), "voice.opus")
+	if err == nil || !strings.Contains(err.Error(), "bad request") {
+		t.Errorf("expected server error, got %v", err)
+	}
+}
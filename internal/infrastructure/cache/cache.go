@@ -23,7 +23,15 @@ func NewCacheWrapper(defaultExpiration, cleanupInterval time.Duration) *CacheWra
 	}
 }
 
-func GetOrExecute[T any](ctx context.Context, key string, fn func() (T, error)) (value T, err error) {
+// GetOrExecute 以 fn 的函数名与 key 为键缓存 fn 的结果, 出错时不缓存
+//
+//	@param ctx context.Context
+//	@param key string
+//	@param ttl time.Duration 缓存有效期, 由调用方按数据的变化频率决定
+//	@param fn func() (T, error)
+//	@return value T
+//	@return err error
+func GetOrExecute[T any](ctx context.Context, key string, ttl time.Duration, fn func() (T, error)) (value T, err error) {
 	fName := reflecting.GetFunctionName(fn)
 	if value, found := wrapper.c.Get(fName + ":" + key); found {
 		logs.L().Ctx(ctx).Info("[✅ Cache HIT] Executing function to get cache value,", zap.String("key", key), zap.String("function", fName))
@@ -36,8 +44,8 @@ func GetOrExecute[T any](ctx context.Context, key string, fn func() (T, error))
 		return
 	}
 
-	wrapper.c.Set(key, value, cache.DefaultExpiration)
-	logs.L().Ctx(ctx).Debug("📦 Cache SET", zap.String("key", key))
+	wrapper.c.Set(fName+":"+key, value, ttl)
+	logs.L().Ctx(ctx).Debug("📦 Cache SET", zap.String("key", key), zap.Duration("ttl", ttl))
 
 	return
 }
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetOrExecute(t *testing.T) {
+	ctx := context.Background()
+	wrapper.c.Flush()
+	calls := 0
+	fn := func() (int, error) {
+		calls++
+		return calls, nil
+	}
+	for range 2 {
+		if v, err := GetOrExecute(ctx, "k", 10*time.Millisecond, fn); err != nil || v != 1 {
+			t.Fatalf("GetOrExecute() = %v, %v, want 1", v, err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if v, _ := GetOrExecute(ctx, "k", time.Minute, fn); v != 2 {
+		t.Fatalf("GetOrExecute() after expiry = %v, want 2", v)
+	}
+}
@@ -26,6 +26,101 @@ type BaseConfig struct {
 	AKToolConfig       *AKToolConfig       `json:"aktool_config" yaml:"aktool_config" toml:"aktool_config"`
 	GotifyConfig       *GotifyConfig       `json:"gotify_config" yaml:"gotify_config" toml:"gotify_config"`
 	RedisConfig        *RedisConfig        `json:"redis_config" yaml:"redis_config" toml:"redis_config"`
+	WorkerPoolConfig   *WorkerPoolConfig   `json:"worker_pool_config" yaml:"worker_pool_config" toml:"worker_pool_config"`
+	ASRConfig          *ASRConfig          `json:"asr_config" yaml:"asr_config" toml:"asr_config"`
+	PersonaConfig      *PersonaConfig      `json:"persona_config" yaml:"persona_config" toml:"persona_config"`
+	MemoryConfig       *MemoryConfig       `json:"memory_config" yaml:"memory_config" toml:"memory_config"`
+	ReminderConfig     *ReminderConfig     `json:"reminder_config" yaml:"reminder_config" toml:"reminder_config"`
+	DigestConfig       *DigestConfig       `json:"digest_config" yaml:"digest_config" toml:"digest_config"`
+}
+
+// PersonaConfig 说话风格画像, 零值字段使用默认值
+type PersonaConfig struct {
+	// RefreshIntervalMin 后台刷新活跃群画像的间隔, 默认360分钟
+	RefreshIntervalMin int `json:"refresh_interval_min" yaml:"refresh_interval_min" toml:"refresh_interval_min"`
+	// LookbackDays 统计最近多少天的消息, 默认30天
+	LookbackDays int `json:"lookback_days" yaml:"lookback_days" toml:"lookback_days"`
+	// UsersPerChat 每次刷新时每个群最多刷新的活跃用户数, 默认10
+	UsersPerChat int `json:"users_per_chat" yaml:"users_per_chat" toml:"users_per_chat"`
+}
+
+// MemoryConfig 成员长期记忆, 零值字段使用默认值
+type MemoryConfig struct {
+	// Disabled 关闭从 chunk 中抽取记忆, 已有记忆仍可查看与删除
+	Disabled bool `json:"disabled" yaml:"disabled" toml:"disabled"`
+	// RecallLimit 每位成员注入聊天 prompt 的记忆条数, 默认5
+	RecallLimit int `json:"recall_limit" yaml:"recall_limit" toml:"recall_limit"`
+	// CommitmentDays 承诺类记忆的有效天数, 过期后不再注入 prompt, 默认30天
+	CommitmentDays int `json:"commitment_days" yaml:"commitment_days" toml:"commitment_days"`
+}
+
+// ReminderConfig 提醒, 零值字段使用默认值
+type ReminderConfig struct {
+	// PollIntervalSec 检查到期提醒的间隔, 默认15秒
+	PollIntervalSec int `json:"poll_interval_sec" yaml:"poll_interval_sec" toml:"poll_interval_sec"`
+	// DisableProposal 关闭根据聊天中的计划主动建议提醒
+	DisableProposal bool `json:"disable_proposal" yaml:"disable_proposal" toml:"disable_proposal"`
+	// ProposalMinLeadMin 计划距现在不足该分钟数时不再建议, 默认30分钟
+	ProposalMinLeadMin int `json:"proposal_min_lead_min" yaml:"proposal_min_lead_min" toml:"proposal_min_lead_min"`
+	// ProposalMaxDays 只建议该天数以内的计划, 默认60天
+	ProposalMaxDays int `json:"proposal_max_days" yaml:"proposal_max_days" toml:"proposal_max_days"`
+}
+
+// DigestConfig 群聊日报与周报, 零值字段使用默认值; 各群需通过 /digest on 开启
+type DigestConfig struct {
+	// DailyAt 发送前一天日报的时间, HH:MM, 默认 09:00
+	DailyAt string `json:"daily_at" yaml:"daily_at" toml:"daily_at"`
+	// WeeklyDay 发送上周周报的星期, 1-7 表示周一到周日, 默认周一
+	WeeklyDay int `json:"weekly_day" yaml:"weekly_day" toml:"weekly_day"`
+	// WeeklyAt 发送周报的时间, HH:MM, 默认 09:30
+	WeeklyAt string `json:"weekly_at" yaml:"weekly_at" toml:"weekly_at"`
+	// MaxChunks 每份报告最多参考的对话片段数, 默认60
+	MaxChunks int `json:"max_chunks" yaml:"max_chunks" toml:"max_chunks"`
+}
+
+// WorkerPoolConfig 消息处理任务池, 零值字段使用默认值
+type WorkerPoolConfig struct {
+	// Workers 全局并发上限
+	Workers int `json:"workers" yaml:"workers" toml:"workers"`
+	// MaxQueue 全局排队+执行中的消息上限
+	MaxQueue int `json:"max_queue" yaml:"max_queue" toml:"max_queue"`
+	// PerChatQueue 单个会话排队上限
+	PerChatQueue int `json:"per_chat_queue" yaml:"per_chat_queue" toml:"per_chat_queue"`
+	// ShedRatio 负载超过该比例时丢弃低优先级算子(如 react、repeat)
+	ShedRatio float64 `json:"shed_ratio" yaml:"shed_ratio" toml:"shed_ratio"`
+}
+
+// GetWorkerPoolConfig 获取任务池配置并补齐默认值
+func (c *BaseConfig) GetWorkerPoolConfig() WorkerPoolConfig {
+	conf := WorkerPoolConfig{Workers: 16, MaxQueue: 512, PerChatQueue: 64, ShedRatio: 0.6}
+	if c.WorkerPoolConfig == nil {
+		return conf
+	}
+	if c.WorkerPoolConfig.Workers > 0 {
+		conf.Workers = c.WorkerPoolConfig.Workers
+	}
+	if c.WorkerPoolConfig.MaxQueue > 0 {
+		conf.MaxQueue = c.WorkerPoolConfig.MaxQueue
+	}
+	if c.WorkerPoolConfig.PerChatQueue > 0 {
+		conf.PerChatQueue = c.WorkerPoolConfig.PerChatQueue
+	}
+	if c.WorkerPoolConfig.ShedRatio > 0 {
+		conf.ShedRatio = c.WorkerPoolConfig.ShedRatio
+	}
+	return conf
+}
+
+// ASRConfig 语音转写, 为空时不转写语音消息
+type ASRConfig struct {
+	// Provider 转写实现, 目前支持 whisper_http
+	Provider string `json:"provider" yaml:"provider" toml:"provider"`
+	// Endpoint 转写服务地址, 如 whisper.cpp server 的 http://127.0.0.1:8080/inference
+	Endpoint string `json:"endpoint" yaml:"endpoint" toml:"endpoint"`
+	// Language 识别语言, 为空时自动检测
+	Language string `json:"language" yaml:"language" toml:"language"`
+	// TimeoutSec 单次转写超时, 默认60秒
+	TimeoutSec int `json:"timeout_sec" yaml:"timeout_sec" toml:"timeout_sec"`
 }
 
 type RedisConfig struct {
@@ -43,8 +138,11 @@ type GotifyConfig struct {
 	ApplicationToken string `json:"application_token" yaml:"application_token" toml:"application_token"`
 }
 
+// AKToolConfig AKTool 行情服务, BaseURL 为空时不检查股价提醒
 type AKToolConfig struct {
 	BaseURL string `json:"base_url" yaml:"base_url" toml:"base_url"`
+	// AlertPollIntervalSec 交易时段内检查股价提醒的间隔, 默认60秒
+	AlertPollIntervalSec int `json:"alert_poll_interval_sec" yaml:"alert_poll_interval_sec" toml:"alert_poll_interval_sec"`
 }
 type NeteaseMusicConfig struct {
 	BaseURL           string `json:"base_url" yaml:"base_url" toml:"base_url"`
@@ -60,6 +158,19 @@ type RateConfig struct {
 	ReactionDefaultRate int `json:"reaction_default_rate" yaml:"reaction_default_rate" toml:"reaction_default_rate"`
 	RepeatDefaultRate   int `json:"repeat_default_rate" yaml:"repeat_default_rate" toml:"repeat_default_rate"`
 	ImitateDefaultRate  int `json:"imitate_default_rate" yaml:"imitate_default_rate" toml:"imitate_default_rate"`
+
+	// 以下为主动行为(复读、表情、关键词回复、模仿聊天)共享的预算, 零值使用默认值
+
+	// ChatBudget 每个群在一个窗口内最多的主动行为次数, 默认6
+	ChatBudget int `json:"chat_budget" yaml:"chat_budget" toml:"chat_budget"`
+	// UserBudget 同一用户的消息在一个窗口内最多引发的主动行为次数(跨群), 默认3
+	UserBudget int `json:"user_budget" yaml:"user_budget" toml:"user_budget"`
+	// BudgetWindowSec 预算窗口, 令牌在窗口内匀速恢复, 默认60秒
+	BudgetWindowSec int `json:"budget_window_sec" yaml:"budget_window_sec" toml:"budget_window_sec"`
+	// PerMessageLimit 同一条消息最多触发的主动行为数, 默认1
+	PerMessageLimit int `json:"per_message_limit" yaml:"per_message_limit" toml:"per_message_limit"`
+	// SettleMs 同一条消息上各主动行为竞价的等待时间, 默认150毫秒
+	SettleMs int `json:"settle_ms" yaml:"settle_ms" toml:"settle_ms"`
 }
 type DBConfig struct {
 	Host            string `json:"host" yaml:"host" toml:"host"`
@@ -89,6 +200,10 @@ type OpensearchConfig struct {
 	LarkCardActionIndex string `json:"lark_card_action_index" yaml:"lark_card_action_index" toml:"lark_card_action_index"`
 	LarkChunkIndex      string `json:"lark_chunk_index" yaml:"lark_chunk_index" toml:"lark_chunk_index"`
 	LarkMsgIndex        string `json:"lark_msg_index" yaml:"lark_msg_index" toml:"lark_msg_index"`
+	// LarkKnowledgeIndex 群知识库(链接、文件)的段落索引
+	LarkKnowledgeIndex string `json:"lark_knowledge_index" yaml:"lark_knowledge_index" toml:"lark_knowledge_index"`
+	// LarkDigestIndex 群聊日报、周报的归档索引
+	LarkDigestIndex string `json:"lark_digest_index" yaml:"lark_digest_index" toml:"lark_digest_index"`
 }
 
 type MinioConfig struct {
@@ -119,6 +234,52 @@ type LarkConfig struct {
 	Encryption   string `json:"encryption" yaml:"encryption" toml:"encryption"`
 	Verification string `json:"verification" yaml:"verification" toml:"verification"`
 	BotOpenID    string `json:"bot_open_id" yaml:"bot_open_id" toml:"bot_open_id"`
+
+	// EventMode 事件接入方式: ws / http / both, 为空时默认 ws
+	EventMode string `json:"event_mode" yaml:"event_mode" toml:"event_mode"`
+	// HTTPAddr http 模式下的监听地址, 如 :8080
+	HTTPAddr string `json:"http_addr" yaml:"http_addr" toml:"http_addr"`
+	// EventPath 事件回调路径
+	EventPath string `json:"event_path" yaml:"event_path" toml:"event_path"`
+	// CardPath 卡片回调路径
+	CardPath string `json:"card_path" yaml:"card_path" toml:"card_path"`
+}
+
+const (
+	LarkEventModeWS   = "ws"
+	LarkEventModeHTTP = "http"
+	LarkEventModeBoth = "both"
+)
+
+// UseWS 是否启用长连接接收事件
+func (c *LarkConfig) UseWS() bool {
+	return c.EventMode == "" || c.EventMode == LarkEventModeWS || c.EventMode == LarkEventModeBoth
+}
+
+// UseHTTP 是否启用 http 回调接收事件
+func (c *LarkConfig) UseHTTP() bool {
+	return c.EventMode == LarkEventModeHTTP || c.EventMode == LarkEventModeBoth
+}
+
+func (c *LarkConfig) GetHTTPAddr() string {
+	if c.HTTPAddr == "" {
+		return ":8080"
+	}
+	return c.HTTPAddr
+}
+
+func (c *LarkConfig) GetEventPath() string {
+	if c.EventPath == "" {
+		return "/webhook/event"
+	}
+	return c.EventPath
+}
+
+func (c *LarkConfig) GetCardPath() string {
+	if c.CardPath == "" {
+		return "/webhook/card"
+	}
+	return c.CardPath
 }
 
 func NewConfigs() *BaseConfig {
@@ -1,12 +1,70 @@
 package db
 
 import (
+	"fmt"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/jellydator/ttlcache/v3"
+	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
 )
 
 var cache = ttlcache.New(
 	ttlcache.WithTTL[string, any](60*time.Second), // 默认 TTL
 	ttlcache.WithCapacity[string, any](1000),      // 最大容量
 )
+
+const (
+	// cacheHitKey 标记本次查询命中了缓存, 查询后不再写回
+	cacheHitKey = "db:cache_hit"
+	// cacheKeyKey 本次查询的缓存键
+	cacheKeyKey = "db:cache_key"
+)
+
+// cachedResult 缓存的查询结果, 与调用方的 Dest 互不共享
+type cachedResult struct {
+	dest any
+	rows int64
+}
+
+// 写入代数: 表被写入后代数递增, 之前以旧代数为键缓存的查询随之失效;
+// 只对本实例的写入生效, 其他实例的写入最多在缓存 TTL 后可见
+var (
+	genMu     sync.Mutex
+	globalGen uint64
+	tableGen  = make(map[string]uint64)
+)
+
+// cacheKey 查询缓存的键, 包含表的写入代数与绑定参数展开后的 SQL
+func cacheKey(d *gorm.DB) string {
+	genMu.Lock()
+	g, t := globalGen, tableGen[d.Statement.Table]
+	genMu.Unlock()
+	return fmt.Sprintf("%d/%s/%d/%s", g, d.Statement.Table, t, d.Dialector.Explain(d.Statement.SQL.String(), d.Statement.Vars...))
+}
+
+// invalidate 写入后使该表的查询缓存失效, 原生 SQL 无法确定表时使全部缓存失效
+func invalidate(d *gorm.DB) {
+	genMu.Lock()
+	defer genMu.Unlock()
+	if d.Statement.Table == "" {
+		globalGen++
+		return
+	}
+	tableGen[d.Statement.Table]++
+}
+
+// cloneDest 深拷贝查询结果, 避免调用方修改返回值后污染缓存
+func cloneDest(dest any) (any, bool) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, false
+	}
+	clone := reflect.New(v.Elem().Type())
+	if err := copier.CopyWithOption(clone.Interface(), dest, copier.Option{DeepCopy: true}); err != nil {
+		return nil, false
+	}
+	return clone.Interface(), true
+}
@@ -1,11 +1,13 @@
 package db
 
 import (
+	"context"
 	"time"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
 	"github.com/jinzhu/copier"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
@@ -21,43 +23,85 @@ func Init(config *config.DBConfig) {
 	if err != nil {
 		panic(err)
 	}
+	Use(db)
+
+	xlifecycle.Register("db", xlifecycle.PhaseInfra, nil, func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+}
+
+// Use 在已打开的连接上注册查询缓存并设为默认查询, 测试中可传入其他数据库的连接
+//
+//	@param db *gorm.DB
+func Use(db *gorm.DB) {
 	// query cache callbacks...
 	db.Callback().Query().Replace("gorm:query", callbackQuery)
 	db.Callback().Query().After("gorm:after_query").Register("gorm:after_query_done", callbackAfter)
+	db.Callback().Create().After("gorm:create").Register("db:invalidate_cache", invalidate)
+	db.Callback().Update().After("gorm:update").Register("db:invalidate_cache", invalidate)
+	db.Callback().Delete().After("gorm:delete").Register("db:invalidate_cache", invalidate)
+	db.Callback().Raw().After("gorm:raw").Register("db:invalidate_cache", invalidate)
 	query.SetDefault(db)
 }
 
 func callbackQuery(d *gorm.DB) {
-	if d.Error == nil {
-		callbacks.BuildQuerySQL(d)
-		sql := d.Statement.SQL.String()
-		cacheResult := cache.Get(sql)
-		if cacheResult != nil {
-			logs.L().With(zap.Any("cache_result", cacheResult.Value())).Debug("cache hit, sql: " + sql)
-			copier.Copy(d.Statement.Dest, cacheResult.Value())
-			d.DryRun = true
-		}
-
-		if !d.DryRun && d.Error == nil {
-			rows, err := d.Statement.ConnPool.QueryContext(d.Statement.Context, d.Statement.SQL.String(), d.Statement.Vars...)
-			if err != nil {
-				d.AddError(err)
-				return
-			}
-			defer func() {
-				d.AddError(rows.Close())
-			}()
-			gorm.Scan(rows, d, 0)
-
+	if d.Error != nil {
+		return
+	}
+	callbacks.BuildQuerySQL(d)
+	if d.Error != nil || d.DryRun {
+		return
+	}
+	// 键在查询前确定, 查询期间发生的写入会使本次结果以旧代数缓存而不被读到
+	key := cacheKey(d)
+	d.InstanceSet(cacheKeyKey, key)
+	if item := cache.Get(key); item != nil {
+		cached := item.Value().(*cachedResult)
+		logs.L().With(zap.Any("cache_result", cached.dest)).Debug("cache hit, sql: " + key)
+		if err := copier.CopyWithOption(d.Statement.Dest, cached.dest, copier.Option{DeepCopy: true}); err == nil {
+			d.InstanceSet(cacheHitKey, true)
+			d.RowsAffected = cached.rows
 			if d.Statement.Result != nil {
 				d.Statement.Result.RowsAffected = d.RowsAffected
 			}
+			return
 		}
 	}
+
+	rows, err := d.Statement.ConnPool.QueryContext(d.Statement.Context, d.Statement.SQL.String(), d.Statement.Vars...)
+	if err != nil {
+		d.AddError(err)
+		return
+	}
+	defer func() {
+		d.AddError(rows.Close())
+	}()
+	gorm.Scan(rows, d, 0)
+
+	if d.Statement.Result != nil {
+		d.Statement.Result.RowsAffected = d.RowsAffected
+	}
 }
 
 func callbackAfter(d *gorm.DB) {
-	sql := d.Statement.SQL.String()
-	result := d.Statement.Dest
-	cache.Set(sql, result, time.Minute)
+	// 出错(包括 First 查不到记录)时不缓存, 命中缓存时不重复写回
+	if d.Error != nil || d.DryRun {
+		return
+	}
+	if _, hit := d.InstanceGet(cacheHitKey); hit {
+		return
+	}
+	key, ok := d.InstanceGet(cacheKeyKey)
+	if !ok {
+		return
+	}
+	dest, ok := cloneDest(d.Statement.Dest)
+	if !ok {
+		return
+	}
+	cache.Set(key.(string), &cachedResult{dest: dest, rows: d.RowsAffected}, time.Minute)
 }
@@ -0,0 +1,38 @@
+// Package dbtest 测试用的内存 SQLite 数据库, 与线上一样注册查询缓存并设为默认查询
+package dbtest
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open 打开一个独立的内存数据库并按模型建表, 测试结束时关闭; 会替换全局的 query.Q, 使用它的测试不能并行
+//
+//	@param t testing.TB
+//	@param models ...any 需要建表的模型
+//	@return *gorm.DB
+func Open(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+	dsn := "file:" + url.PathEscape(t.Name()) + "?mode=memory&cache=shared"
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存库随最后一个连接关闭而释放, 只用一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err = gdb.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	db.Use(gdb)
+	return gdb
+}
@@ -0,0 +1,16 @@
+-- 语音消息的转写文本, 按飞书的 file_key 缓存, 同一文件只转写一次
+
+CREATE TABLE IF NOT EXISTS audio_transcripts (
+    id          bigserial PRIMARY KEY,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    file_key    text   NOT NULL,
+    message_id  text   NOT NULL,
+    chat_id     text   NOT NULL,
+    duration_ms bigint NOT NULL,
+    provider    text   NOT NULL,
+    transcript  text   NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audio_transcript_file_key ON audio_transcripts (file_key);
@@ -0,0 +1,23 @@
+-- /reply 的关键词回复规则, chat_id 为空表示全局规则
+-- replies 为 JSON 字符串数组, user_ids 为逗号分隔的 open_id, time_window 形如 09:00-18:00
+
+CREATE TABLE IF NOT EXISTS reply_rules (
+    id           bigserial PRIMARY KEY,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    deleted_at   timestamptz,
+    chat_id      text    NOT NULL,
+    match_type   text    NOT NULL DEFAULT 'substr',
+    keyword      text    NOT NULL,
+    replies      text    NOT NULL,
+    reply_type   text    NOT NULL DEFAULT 'text',
+    user_ids     text    NOT NULL DEFAULT '',
+    time_window  text    NOT NULL DEFAULT '',
+    cooldown_sec bigint  NOT NULL DEFAULT 0,
+    probability  bigint  NOT NULL DEFAULT 100,
+    rotation     text    NOT NULL DEFAULT 'random',
+    created_by   text    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_reply_rule_chat ON reply_rules (chat_id);
+CREATE INDEX IF NOT EXISTS idx_reply_rule_deleted_at ON reply_rules (deleted_at);
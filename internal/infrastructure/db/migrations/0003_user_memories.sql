@@ -0,0 +1,22 @@
+-- 成员长期记忆, hash 由群、成员与归一化后的事实算出
+-- 成员删除的记忆为软删除, 保留 hash 拦截再次抽取
+
+CREATE TABLE IF NOT EXISTS user_memories (
+    id           bigserial PRIMARY KEY,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    deleted_at   timestamptz,
+    chat_id      text        NOT NULL,
+    user_id      text        NOT NULL,
+    user_name    text        NOT NULL,
+    category     text        NOT NULL,
+    fact         text        NOT NULL,
+    hash         text        NOT NULL,
+    source_chunk text        NOT NULL,
+    seen_count   bigint      NOT NULL DEFAULT 1,
+    last_seen_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_memory_hash ON user_memories (hash);
+CREATE INDEX IF NOT EXISTS idx_user_memory_member ON user_memories (chat_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_user_memory_deleted_at ON user_memories (deleted_at);
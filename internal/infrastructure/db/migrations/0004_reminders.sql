@@ -0,0 +1,21 @@
+-- /remind 创建的提醒与机器人根据计划提出的建议
+-- status: proposed / pending / sent / failed / canceled / dismissed / expired, mentions 为逗号分隔的 open_id
+
+CREATE TABLE IF NOT EXISTS reminders (
+    id            bigserial PRIMARY KEY,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    deleted_at    timestamptz,
+    chat_id       text        NOT NULL,
+    creator_id    text        NOT NULL,
+    content       text        NOT NULL,
+    due_at        timestamptz NOT NULL,
+    mentions      text        NOT NULL DEFAULT '',
+    status        text        NOT NULL,
+    source_msg_id text        NOT NULL DEFAULT '',
+    source_chunk  text        NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminder_status_due ON reminders (status, due_at);
+CREATE INDEX IF NOT EXISTS idx_reminder_chat ON reminders (chat_id);
+CREATE INDEX IF NOT EXISTS idx_reminder_deleted_at ON reminders (deleted_at);
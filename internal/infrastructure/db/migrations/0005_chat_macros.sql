@@ -0,0 +1,13 @@
+-- /macro add 保存的群命令宏, 同一个群内宏名唯一
+
+CREATE TABLE IF NOT EXISTS chat_macros (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    chat_id    text NOT NULL,
+    name       text NOT NULL,
+    script     text NOT NULL,
+    creator_id text NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_macro_name ON chat_macros (chat_id, name);
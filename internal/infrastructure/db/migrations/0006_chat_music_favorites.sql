@@ -0,0 +1,14 @@
+-- /music fav 的群收藏歌单, 同一群内歌曲唯一
+
+CREATE TABLE IF NOT EXISTS chat_music_favorites (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    chat_id    text NOT NULL,
+    song_id    text NOT NULL,
+    name       text NOT NULL,
+    artist     text NOT NULL,
+    pic_url    text NOT NULL,
+    added_by   text NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_music_favorite_song ON chat_music_favorites (chat_id, song_id);
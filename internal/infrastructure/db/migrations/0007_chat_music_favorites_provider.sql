@@ -0,0 +1,12 @@
+-- 群歌单记录歌曲所属的音乐源, 不同音乐源的歌曲 ID 可能相同
+-- 此前只有网易云音乐, 已有的行回填为 netease
+
+ALTER TABLE chat_music_favorites ADD COLUMN IF NOT EXISTS provider text;
+
+UPDATE chat_music_favorites SET provider = 'netease' WHERE provider IS NULL OR provider = '';
+
+ALTER TABLE chat_music_favorites ALTER COLUMN provider SET DEFAULT 'netease';
+ALTER TABLE chat_music_favorites ALTER COLUMN provider SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_chat_music_favorite_song;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chat_music_favorite_song ON chat_music_favorites (chat_id, provider, song_id);
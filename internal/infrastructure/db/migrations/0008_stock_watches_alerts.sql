@@ -0,0 +1,32 @@
+-- /stock watch 的自选股与 /stock alert 的股价提醒
+-- 自选股属于群(user_id 为空)或成员(chat_id 为空), 同一归属下代码唯一
+
+CREATE TABLE IF NOT EXISTS stock_watches (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    chat_id    text NOT NULL,
+    user_id    text NOT NULL,
+    symbol     text NOT NULL,
+    name       text NOT NULL,
+    added_by   text NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_watch_symbol ON stock_watches (chat_id, user_id, symbol);
+
+-- state 为空表示生效中; 价格提醒触发后为 fired, 涨跌幅提醒为触发当天的日期
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    chat_id    text             NOT NULL,
+    creator_id text             NOT NULL,
+    symbol     text             NOT NULL,
+    name       text             NOT NULL,
+    kind       text             NOT NULL,
+    threshold  double precision NOT NULL,
+    dm         boolean          NOT NULL DEFAULT false,
+    state      text             NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_alert_chat ON stock_alerts (chat_id);
@@ -19,10 +19,13 @@ var (
 	Q                     = new(Query)
 	Administrator         *administrator
 	AlertList             *alertList
+	AudioTranscript       *audioTranscript
 	CardActionRecordLog   *cardActionRecordLog
 	ChannelLog            *channelLog
 	ChannelLogExt         *channelLogExt
 	ChatContextRecord     *chatContextRecord
+	ChatMacro             *chatMacro
+	ChatMusicFavorite     *chatMusicFavorite
 	ChatRecordLog         *chatRecordLog
 	CommandInfo           *commandInfo
 	CopyWritingCustom     *copyWritingCustom
@@ -40,23 +43,31 @@ var (
 	PromptTemplateArg     *promptTemplateArg
 	QuoteReplyMsg         *quoteReplyMsg
 	QuoteReplyMsgCustom   *quoteReplyMsgCustom
+	Reminder              *reminder
+	ReplyRule             *replyRule
 	ReactImageMeterial    *reactImageMeterial
 	ReactionWhitelist     *reactionWhitelist
 	RepeatWhitelist       *repeatWhitelist
 	RepeatWordsRate       *repeatWordsRate
 	RepeatWordsRateCustom *repeatWordsRateCustom
 	StickerMapping        *stickerMapping
+	StockAlert            *stockAlert
+	StockWatch            *stockWatch
 	TemplateVersion       *templateVersion
+	UserMemory            *userMemory
 )
 
 func SetDefault(db *gorm.DB, opts ...gen.DOOption) {
 	*Q = *Use(db, opts...)
 	Administrator = &Q.Administrator
 	AlertList = &Q.AlertList
+	AudioTranscript = &Q.AudioTranscript
 	CardActionRecordLog = &Q.CardActionRecordLog
 	ChannelLog = &Q.ChannelLog
 	ChannelLogExt = &Q.ChannelLogExt
 	ChatContextRecord = &Q.ChatContextRecord
+	ChatMacro = &Q.ChatMacro
+	ChatMusicFavorite = &Q.ChatMusicFavorite
 	ChatRecordLog = &Q.ChatRecordLog
 	CommandInfo = &Q.CommandInfo
 	CopyWritingCustom = &Q.CopyWritingCustom
@@ -74,13 +85,18 @@ func SetDefault(db *gorm.DB, opts ...gen.DOOption) {
 	PromptTemplateArg = &Q.PromptTemplateArg
 	QuoteReplyMsg = &Q.QuoteReplyMsg
 	QuoteReplyMsgCustom = &Q.QuoteReplyMsgCustom
+	Reminder = &Q.Reminder
+	ReplyRule = &Q.ReplyRule
 	ReactImageMeterial = &Q.ReactImageMeterial
 	ReactionWhitelist = &Q.ReactionWhitelist
 	RepeatWhitelist = &Q.RepeatWhitelist
 	RepeatWordsRate = &Q.RepeatWordsRate
 	RepeatWordsRateCustom = &Q.RepeatWordsRateCustom
 	StickerMapping = &Q.StickerMapping
+	StockAlert = &Q.StockAlert
+	StockWatch = &Q.StockWatch
 	TemplateVersion = &Q.TemplateVersion
+	UserMemory = &Q.UserMemory
 }
 
 func Use(db *gorm.DB, opts ...gen.DOOption) *Query {
@@ -88,10 +104,13 @@ func Use(db *gorm.DB, opts ...gen.DOOption) *Query {
 		db:                    db,
 		Administrator:         newAdministrator(db, opts...),
 		AlertList:             newAlertList(db, opts...),
+		AudioTranscript:       newAudioTranscript(db, opts...),
 		CardActionRecordLog:   newCardActionRecordLog(db, opts...),
 		ChannelLog:            newChannelLog(db, opts...),
 		ChannelLogExt:         newChannelLogExt(db, opts...),
 		ChatContextRecord:     newChatContextRecord(db, opts...),
+		ChatMacro:             newChatMacro(db, opts...),
+		ChatMusicFavorite:     newChatMusicFavorite(db, opts...),
 		ChatRecordLog:         newChatRecordLog(db, opts...),
 		CommandInfo:           newCommandInfo(db, opts...),
 		CopyWritingCustom:     newCopyWritingCustom(db, opts...),
@@ -109,13 +128,18 @@ func Use(db *gorm.DB, opts ...gen.DOOption) *Query {
 		PromptTemplateArg:     newPromptTemplateArg(db, opts...),
 		QuoteReplyMsg:         newQuoteReplyMsg(db, opts...),
 		QuoteReplyMsgCustom:   newQuoteReplyMsgCustom(db, opts...),
+		Reminder:              newReminder(db, opts...),
+		ReplyRule:             newReplyRule(db, opts...),
 		ReactImageMeterial:    newReactImageMeterial(db, opts...),
 		ReactionWhitelist:     newReactionWhitelist(db, opts...),
 		RepeatWhitelist:       newRepeatWhitelist(db, opts...),
 		RepeatWordsRate:       newRepeatWordsRate(db, opts...),
 		RepeatWordsRateCustom: newRepeatWordsRateCustom(db, opts...),
 		StickerMapping:        newStickerMapping(db, opts...),
+		StockAlert:            newStockAlert(db, opts...),
+		StockWatch:            newStockWatch(db, opts...),
 		TemplateVersion:       newTemplateVersion(db, opts...),
+		UserMemory:            newUserMemory(db, opts...),
 	}
 }
 
@@ -124,10 +148,13 @@ type Query struct {
 
 	Administrator         administrator
 	AlertList             alertList
+	AudioTranscript       audioTranscript
 	CardActionRecordLog   cardActionRecordLog
 	ChannelLog            channelLog
 	ChannelLogExt         channelLogExt
 	ChatContextRecord     chatContextRecord
+	ChatMacro             chatMacro
+	ChatMusicFavorite     chatMusicFavorite
 	ChatRecordLog         chatRecordLog
 	CommandInfo           commandInfo
 	CopyWritingCustom     copyWritingCustom
@@ -145,13 +172,18 @@ type Query struct {
 	PromptTemplateArg     promptTemplateArg
 	QuoteReplyMsg         quoteReplyMsg
 	QuoteReplyMsgCustom   quoteReplyMsgCustom
+	Reminder              reminder
+	ReplyRule             replyRule
 	ReactImageMeterial    reactImageMeterial
 	ReactionWhitelist     reactionWhitelist
 	RepeatWhitelist       repeatWhitelist
 	RepeatWordsRate       repeatWordsRate
 	RepeatWordsRateCustom repeatWordsRateCustom
 	StickerMapping        stickerMapping
+	StockAlert            stockAlert
+	StockWatch            stockWatch
 	TemplateVersion       templateVersion
+	UserMemory            userMemory
 }
 
 func (q *Query) Available() bool { return q.db != nil }
@@ -161,10 +193,13 @@ func (q *Query) clone(db *gorm.DB) *Query {
 		db:                    db,
 		Administrator:         q.Administrator.clone(db),
 		AlertList:             q.AlertList.clone(db),
+		AudioTranscript:       q.AudioTranscript.clone(db),
 		CardActionRecordLog:   q.CardActionRecordLog.clone(db),
 		ChannelLog:            q.ChannelLog.clone(db),
 		ChannelLogExt:         q.ChannelLogExt.clone(db),
 		ChatContextRecord:     q.ChatContextRecord.clone(db),
+		ChatMacro:             q.ChatMacro.clone(db),
+		ChatMusicFavorite:     q.ChatMusicFavorite.clone(db),
 		ChatRecordLog:         q.ChatRecordLog.clone(db),
 		CommandInfo:           q.CommandInfo.clone(db),
 		CopyWritingCustom:     q.CopyWritingCustom.clone(db),
@@ -182,13 +217,18 @@ func (q *Query) clone(db *gorm.DB) *Query {
 		PromptTemplateArg:     q.PromptTemplateArg.clone(db),
 		QuoteReplyMsg:         q.QuoteReplyMsg.clone(db),
 		QuoteReplyMsgCustom:   q.QuoteReplyMsgCustom.clone(db),
+		Reminder:              q.Reminder.clone(db),
+		ReplyRule:             q.ReplyRule.clone(db),
 		ReactImageMeterial:    q.ReactImageMeterial.clone(db),
 		ReactionWhitelist:     q.ReactionWhitelist.clone(db),
 		RepeatWhitelist:       q.RepeatWhitelist.clone(db),
 		RepeatWordsRate:       q.RepeatWordsRate.clone(db),
 		RepeatWordsRateCustom: q.RepeatWordsRateCustom.clone(db),
 		StickerMapping:        q.StickerMapping.clone(db),
+		StockAlert:            q.StockAlert.clone(db),
+		StockWatch:            q.StockWatch.clone(db),
 		TemplateVersion:       q.TemplateVersion.clone(db),
+		UserMemory:            q.UserMemory.clone(db),
 	}
 }
 
@@ -205,10 +245,13 @@ func (q *Query) ReplaceDB(db *gorm.DB) *Query {
 		db:                    db,
 		Administrator:         q.Administrator.replaceDB(db),
 		AlertList:             q.AlertList.replaceDB(db),
+		AudioTranscript:       q.AudioTranscript.replaceDB(db),
 		CardActionRecordLog:   q.CardActionRecordLog.replaceDB(db),
 		ChannelLog:            q.ChannelLog.replaceDB(db),
 		ChannelLogExt:         q.ChannelLogExt.replaceDB(db),
 		ChatContextRecord:     q.ChatContextRecord.replaceDB(db),
+		ChatMacro:             q.ChatMacro.replaceDB(db),
+		ChatMusicFavorite:     q.ChatMusicFavorite.replaceDB(db),
 		ChatRecordLog:         q.ChatRecordLog.replaceDB(db),
 		CommandInfo:           q.CommandInfo.replaceDB(db),
 		CopyWritingCustom:     q.CopyWritingCustom.replaceDB(db),
@@ -226,23 +269,31 @@ func (q *Query) ReplaceDB(db *gorm.DB) *Query {
 		PromptTemplateArg:     q.PromptTemplateArg.replaceDB(db),
 		QuoteReplyMsg:         q.QuoteReplyMsg.replaceDB(db),
 		QuoteReplyMsgCustom:   q.QuoteReplyMsgCustom.replaceDB(db),
+		Reminder:              q.Reminder.replaceDB(db),
+		ReplyRule:             q.ReplyRule.replaceDB(db),
 		ReactImageMeterial:    q.ReactImageMeterial.replaceDB(db),
 		ReactionWhitelist:     q.ReactionWhitelist.replaceDB(db),
 		RepeatWhitelist:       q.RepeatWhitelist.replaceDB(db),
 		RepeatWordsRate:       q.RepeatWordsRate.replaceDB(db),
 		RepeatWordsRateCustom: q.RepeatWordsRateCustom.replaceDB(db),
 		StickerMapping:        q.StickerMapping.replaceDB(db),
+		StockAlert:            q.StockAlert.replaceDB(db),
+		StockWatch:            q.StockWatch.replaceDB(db),
 		TemplateVersion:       q.TemplateVersion.replaceDB(db),
+		UserMemory:            q.UserMemory.replaceDB(db),
 	}
 }
 
 type queryCtx struct {
 	Administrator         IAdministratorDo
 	AlertList             IAlertListDo
+	AudioTranscript       IAudioTranscriptDo
 	CardActionRecordLog   ICardActionRecordLogDo
 	ChannelLog            IChannelLogDo
 	ChannelLogExt         IChannelLogExtDo
 	ChatContextRecord     IChatContextRecordDo
+	ChatMacro             IChatMacroDo
+	ChatMusicFavorite     IChatMusicFavoriteDo
 	ChatRecordLog         IChatRecordLogDo
 	CommandInfo           ICommandInfoDo
 	CopyWritingCustom     ICopyWritingCustomDo
@@ -260,23 +311,31 @@ type queryCtx struct {
 	PromptTemplateArg     IPromptTemplateArgDo
 	QuoteReplyMsg         IQuoteReplyMsgDo
 	QuoteReplyMsgCustom   IQuoteReplyMsgCustomDo
+	Reminder              IReminderDo
+	ReplyRule             IReplyRuleDo
 	ReactImageMeterial    IReactImageMeterialDo
 	ReactionWhitelist     IReactionWhitelistDo
 	RepeatWhitelist       IRepeatWhitelistDo
 	RepeatWordsRate       IRepeatWordsRateDo
 	RepeatWordsRateCustom IRepeatWordsRateCustomDo
 	StickerMapping        IStickerMappingDo
+	StockAlert            IStockAlertDo
+	StockWatch            IStockWatchDo
 	TemplateVersion       ITemplateVersionDo
+	UserMemory            IUserMemoryDo
 }
 
 func (q *Query) WithContext(ctx context.Context) *queryCtx {
 	return &queryCtx{
 		Administrator:         q.Administrator.WithContext(ctx),
 		AlertList:             q.AlertList.WithContext(ctx),
+		AudioTranscript:       q.AudioTranscript.WithContext(ctx),
 		CardActionRecordLog:   q.CardActionRecordLog.WithContext(ctx),
 		ChannelLog:            q.ChannelLog.WithContext(ctx),
 		ChannelLogExt:         q.ChannelLogExt.WithContext(ctx),
 		ChatContextRecord:     q.ChatContextRecord.WithContext(ctx),
+		ChatMacro:             q.ChatMacro.WithContext(ctx),
+		ChatMusicFavorite:     q.ChatMusicFavorite.WithContext(ctx),
 		ChatRecordLog:         q.ChatRecordLog.WithContext(ctx),
 		CommandInfo:           q.CommandInfo.WithContext(ctx),
 		CopyWritingCustom:     q.CopyWritingCustom.WithContext(ctx),
@@ -294,13 +353,18 @@ func (q *Query) WithContext(ctx context.Context) *queryCtx {
 		PromptTemplateArg:     q.PromptTemplateArg.WithContext(ctx),
 		QuoteReplyMsg:         q.QuoteReplyMsg.WithContext(ctx),
 		QuoteReplyMsgCustom:   q.QuoteReplyMsgCustom.WithContext(ctx),
+		Reminder:              q.Reminder.WithContext(ctx),
+		ReplyRule:             q.ReplyRule.WithContext(ctx),
 		ReactImageMeterial:    q.ReactImageMeterial.WithContext(ctx),
 		ReactionWhitelist:     q.ReactionWhitelist.WithContext(ctx),
 		RepeatWhitelist:       q.RepeatWhitelist.WithContext(ctx),
 		RepeatWordsRate:       q.RepeatWordsRate.WithContext(ctx),
 		RepeatWordsRateCustom: q.RepeatWordsRateCustom.WithContext(ctx),
 		StickerMapping:        q.StickerMapping.WithContext(ctx),
+		StockAlert:            q.StockAlert.WithContext(ctx),
+		StockWatch:            q.StockWatch.WithContext(ctx),
 		TemplateVersion:       q.TemplateVersion.WithContext(ctx),
+		UserMemory:            q.UserMemory.WithContext(ctx),
 	}
 }
 
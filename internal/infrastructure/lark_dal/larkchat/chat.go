@@ -27,3 +27,22 @@ func GetChatName(ctx context.Context, chatID string) (chatName string) {
 	}
 	return
 }
+
+// GetChatOwnerID 群主的 open_id
+func GetChatOwnerID(ctx context.Context, chatID string) (ownerID string, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	resp, err := lark_dal.Client().Im.V1.Chat.Get(ctx, larkim.NewGetChatReqBuilder().ChatId(chatID).UserIdType(larkim.UserIdTypeOpenId).Build())
+	if err != nil {
+		return "", err
+	}
+	if !resp.Success() {
+		return "", errors.New(resp.Error())
+	}
+	if resp.Data == nil || resp.Data.OwnerId == nil {
+		return "", errors.New("chat owner not found")
+	}
+	return *resp.Data.OwnerId, nil
+}
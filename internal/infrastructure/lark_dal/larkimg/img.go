@@ -3,6 +3,7 @@ package larkimg
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -144,6 +145,35 @@ func DownImgFromMsgAsync(ctx context.Context, msgID, fileType, fileKey string) (
 	return
 }
 
+// DownImgAsDataURL 下载消息中的图片并编码为 data URL, 不落 minio, 供视觉模型直接读取
+//
+//	@param ctx context.Context
+//	@param msgID string
+//	@param imageKey string
+//	@return dataURL string
+//	@return err error
+func DownImgAsDataURL(ctx context.Context, msgID, imageKey string) (dataURL string, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("msgID").String(msgID), attribute.Key("imageKey").String(imageKey))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	file, err := GetMsgImages(ctx, msgID, imageKey, larkim.MsgTypeImage)
+	if err != nil {
+		return
+	}
+	reader, contentType, _, err := readAndDetectFormat(file)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return
+	}
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
 // 检测图片格式
 func detectImageFormat(header []byte) (string, string, error) {
 	// 检查文件头并返回格式
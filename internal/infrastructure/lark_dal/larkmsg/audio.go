@@ -0,0 +1,113 @@
+package larkmsg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/asr"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/query"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type audioContent struct {
+	FileKey  string `json:"file_key"`
+	Duration int64  `json:"duration"`
+}
+
+// transcribeGroup 同一条语音的并发转写(入库、回复)只请求一次
+var transcribeGroup singleflight.Group
+
+// TranscribeAudio 获取语音消息的转写文本, 优先读取数据库中的缓存
+//
+//	@param ctx context.Context
+//	@param msg *larkim.EventMessage 必须是 audio 类型的消息
+//	@return transcript string
+//	@return err error
+func TranscribeAudio(ctx context.Context, msg *larkim.EventMessage) (transcript string, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	if utils.AddrOrNil(msg.MessageType) != larkim.MsgTypeAudio {
+		return "", errors.New("not an audio message")
+	}
+	audio := &audioContent{}
+	if err = sonic.UnmarshalString(utils.AddrOrNil(msg.Content), audio); err != nil {
+		return "", err
+	}
+	span.SetAttributes(attribute.Key("file_key").String(audio.FileKey))
+
+	res, err, _ := transcribeGroup.Do(audio.FileKey, func() (any, error) {
+		if text, ok := savedTranscript(ctx, audio.FileKey); ok {
+			return text, nil
+		}
+
+		transcriber, err := asr.Get()
+		if err != nil {
+			return "", err
+		}
+		resp, err := lark_dal.Client().Im.V1.MessageResource.Get(ctx,
+			larkim.NewGetMessageResourceReqBuilder().
+				MessageId(utils.AddrOrNil(msg.MessageId)).
+				FileKey(audio.FileKey).
+				Type("file").
+				Build(),
+		)
+		if err != nil {
+			return "", err
+		}
+		if !resp.Success() {
+			return "", errors.New(resp.Error())
+		}
+		text, err := transcriber.Transcribe(ctx, resp.File, audio.FileKey+".opus")
+		if err != nil {
+			return "", err
+		}
+		saveTranscript(ctx, &model.AudioTranscript{
+			FileKey:    audio.FileKey,
+			MessageID:  utils.AddrOrNil(msg.MessageId),
+			ChatID:     utils.AddrOrNil(msg.ChatId),
+			DurationMs: audio.Duration,
+			Provider:   transcriber.Name(),
+			Transcript: text,
+		})
+		return text, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
+
+// savedTranscript 数据库中该语音文件的转写文本
+func savedTranscript(ctx context.Context, fileKey string) (string, bool) {
+	ins := query.Q.AudioTranscript
+	record, err := ins.WithContext(ctx).Where(ins.FileKey.Eq(fileKey)).First()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logs.L().Ctx(ctx).Warn("query audio transcript error", zap.Error(err))
+		}
+		return "", false
+	}
+	return record.Transcript, true
+}
+
+// saveTranscript 保存转写文本, file_key 唯一, 已有记录时不覆盖
+func saveTranscript(ctx context.Context, record *model.AudioTranscript) {
+	ins := query.Q.AudioTranscript
+	if err := ins.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record); err != nil {
+		logs.L().Ctx(ctx).Warn("save audio transcript error", zap.Error(err))
+	}
+}
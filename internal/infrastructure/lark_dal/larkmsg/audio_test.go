@@ -0,0 +1,32 @@
+package larkmsg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/dbtest"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/db/model"
+)
+
+func TestSavedTranscript(t *testing.T) {
+	dbtest.Open(t, &model.AudioTranscript{})
+	ctx := context.Background()
+
+	saveTranscript(ctx, &model.AudioTranscript{FileKey: "file_a", Transcript: "第一段"})
+	if text, ok := savedTranscript(ctx, "file_a"); !ok || text != "第一段" {
+		t.Fatalf("savedTranscript(file_a) = %q, %v", text, ok)
+	}
+	// file_a 的查询已进入缓存, 其它文件不能读到它的转写
+	if text, ok := savedTranscript(ctx, "file_b"); ok {
+		t.Fatalf("savedTranscript(file_b) = %q, want none", text)
+	}
+	saveTranscript(ctx, &model.AudioTranscript{FileKey: "file_b", Transcript: "第二段"})
+	if text, ok := savedTranscript(ctx, "file_b"); !ok || text != "第二段" {
+		t.Fatalf("savedTranscript(file_b) = %q, %v", text, ok)
+	}
+	// file_key 唯一, 重复保存不覆盖
+	saveTranscript(ctx, &model.AudioTranscript{FileKey: "file_a", Transcript: "覆盖"})
+	if text, _ := savedTranscript(ctx, "file_a"); text != "第一段" {
+		t.Errorf("savedTranscript(file_a) after duplicate save = %q", text)
+	}
+}
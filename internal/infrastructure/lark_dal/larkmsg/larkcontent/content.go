@@ -1,16 +1,43 @@
 package larkcontent
 
 import (
+	"fmt"
 	"iter"
+	"maps"
+	"slices"
+	"strings"
 
-	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/bytedance/sonic"
 	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
 )
 
 type MsgConstraints interface {
-	textMsg | imageMsg | fileMsg | stickerMsg | postMsg | *larkim.EventMessage
+	textMsg | imageMsg | fileMsg | stickerMsg | postMsg | *larkim.EventMessage | *larkim.Message
 }
 
+// 飞书消息类型, 见 https://open.feishu.cn/document/server-docs/im-v1/message-content-description/message_content
+const (
+	MsgTypeText         = "text"
+	MsgTypePost         = "post"
+	MsgTypeImage        = "image"
+	MsgTypeFile         = "file"
+	MsgTypeAudio        = "audio"
+	MsgTypeMedia        = "media"
+	MsgTypeSticker      = "sticker"
+	MsgTypeInteractive  = "interactive"
+	MsgTypeShareChat    = "share_chat"
+	MsgTypeShareUser    = "share_user"
+	MsgTypeMergeForward = "merge_forward"
+	MsgTypeLocation     = "location"
+	MsgTypeTodo         = "todo"
+	MsgTypeSystem       = "system"
+	MsgTypeVote         = "vote"
+	MsgTypeVideoChat    = "video_chat"
+	MsgTypeShareCalEvt  = "share_calendar_event"
+	MsgTypeCalendar     = "calendar"
+	MsgTypeGeneralCal   = "general_calendar"
+)
+
 // text类型的消息
 type textMsg struct {
 	Text string `json:"text"`
@@ -23,7 +50,8 @@ type imageMsg struct {
 
 // file类型的消息
 type fileMsg struct {
-	FileKey string `json:"file_key"`
+	FileKey  string `json:"file_key"`
+	FileName string `json:"file_name"`
 }
 
 // 表情包类型的消息
@@ -31,16 +59,102 @@ type stickerMsg struct {
 	FileKey string `json:"file_key"`
 }
 
+// 语音消息, duration 单位毫秒
+type audioMsg struct {
+	FileKey  string `json:"file_key"`
+	Duration int64  `json:"duration"`
+}
+
+// 视频消息
+type mediaMsg struct {
+	FileKey  string `json:"file_key"`
+	ImageKey string `json:"image_key"`
+	FileName string `json:"file_name"`
+	Duration int64  `json:"duration"`
+}
+
+// 群名片
+type shareChatMsg struct {
+	ChatID string `json:"chat_id"`
+}
+
+// 个人名片
+type shareUserMsg struct {
+	UserID string `json:"user_id"`
+}
+
+// 位置
+type locationMsg struct {
+	Name      string `json:"name"`
+	Longitude string `json:"longitude"`
+	Latitude  string `json:"latitude"`
+}
+
+// 任务
+type todoMsg struct {
+	TaskID  string  `json:"task_id"`
+	Summary postMsg `json:"summary"`
+	DueTime string  `json:"due_time"`
+}
+
+// 投票
+type voteMsg struct {
+	Topic   string   `json:"topic"`
+	Options []string `json:"options"`
+}
+
+// 视频会议
+type videoChatMsg struct {
+	Topic     string `json:"topic"`
+	StartTime string `json:"start_time"`
+}
+
+// 日程
+type calendarMsg struct {
+	Summary   string `json:"summary"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// 系统消息, template 中的 {from_user} 等占位符由同名字段填充
+type systemMsg struct {
+	Template   string   `json:"template"`
+	FromUser   []string `json:"from_user"`
+	ToChatters []string `json:"to_chatters"`
+	Divider    struct {
+		Text string `json:"text"`
+	} `json:"divider_text"`
+}
+
 type contentData struct {
 	Tag      string `json:"tag"`
 	Text     string `json:"text"`
+	Href     string `json:"href"`
 	ImageKey string `json:"image_key"`
 	FileKey  string `json:"file_key"`
 	UserID   string `json:"user_id"`
+	UserName string `json:"user_name"`
+	Language string `json:"language"`
+	Emoji    string `json:"emoji_type"`
 }
+
+// Item 消息中的一个内容单元
+//
+//	Tag 与消息类型/post 内元素的 tag 对应;
+//	Content 为该单元的原始值(文本、image_key、file_key、user_id等);
+//	Text 为给 prompt/索引用的可读文本
 type Item struct {
 	Tag     string `json:"tag"` // image text
 	Content string `json:"content"`
+	Text    string `json:"text,omitempty"`
+}
+
+// PlainText 可读文本, 未设置 Text 时回退到 Content
+func (i *Item) PlainText() string {
+	if i.Text != "" {
+		return i.Text
+	}
+	return i.Content
 }
 
 // 对于收到的post类型消息，可以通过这样的方式来解析其中的内容
@@ -49,6 +163,22 @@ type postMsg struct {
 	Content [][]*contentData `json:"content"`
 }
 
+// unmarshal 解析失败时返回 false, 不 panic
+func unmarshal[T any](s string) (*T, bool) {
+	t := new(T)
+	if err := sonic.UnmarshalString(s, t); err != nil {
+		return nil, false
+	}
+	return t, true
+}
+
+func formatDuration(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %ds", (ms+500)/1000)
+}
+
 // Trans2Item to be filled
 //
 //	@param msgType string
@@ -58,47 +188,212 @@ type postMsg struct {
 //	@update 2025-04-30 14:04:48
 func Trans2Item(msgType, content string) (itemList iter.Seq[*Item]) {
 	return func(yield func(*Item) bool) {
-		switch msgType {
-		case "text": // text是处理过的，直接返回
-			if !yield(&Item{Tag: "text", Content: content}) {
+		for _, item := range trans2Items(msgType, content) {
+			if !yield(item) {
 				return
 			}
-		case "post":
-			res := utils.MustUnmarshalString[postMsg](content)
-			for _, ele := range res.Content {
-				for _, ele2 := range ele {
-					switch ele2.Tag {
-					case "at":
-						if !yield(&Item{Tag: "at", Content: ele2.UserID}) {
-							return
-						}
-					case "text":
-						if !yield(&Item{Tag: "text", Content: ele2.Text}) {
-							return
-						}
-					case "image":
-						if !yield(&Item{Tag: "image", Content: ele2.ImageKey}) {
-							return
-						}
-					case "sticker":
-						if !yield(&Item{Tag: "sticker", Content: ele2.FileKey}) {
-							return
-						}
-					}
+		}
+	}
+}
+
+func trans2Items(msgType, content string) []*Item {
+	text := func(s string) []*Item { return []*Item{{Tag: "text", Content: s}} }
+	// 非 JSON 的内容视为已经渲染过的文本 (如索引里的 raw_message)
+	if msgType != MsgTypeText && !strings.HasPrefix(strings.TrimSpace(content), "{") {
+		return text(content)
+	}
+
+	switch msgType {
+	case MsgTypeText: // text是处理过的，直接返回
+		return text(content)
+	case MsgTypePost:
+		res, ok := unmarshal[postMsg](content)
+		if !ok {
+			return text(content)
+		}
+		return postItems(res)
+	case MsgTypeImage:
+		res, ok := unmarshal[imageMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "image", Content: res.ImageKey, Text: "[图片]"}}
+	case MsgTypeFile:
+		res, ok := unmarshal[fileMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "file", Content: res.FileKey, Text: fmt.Sprintf("[文件 %s]", res.FileName)}}
+	case MsgTypeSticker:
+		res, ok := unmarshal[stickerMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "sticker", Content: res.FileKey, Text: "[表情包]"}}
+	case MsgTypeAudio:
+		res, ok := unmarshal[audioMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "audio", Content: res.FileKey, Text: fmt.Sprintf("[语音%s]", formatDuration(res.Duration))}}
+	case MsgTypeMedia:
+		res, ok := unmarshal[mediaMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "media", Content: res.FileKey, Text: fmt.Sprintf("[视频 %s%s]", res.FileName, formatDuration(res.Duration))}}
+	case MsgTypeShareChat:
+		res, ok := unmarshal[shareChatMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "share_chat", Content: res.ChatID, Text: "[群名片]"}}
+	case MsgTypeShareUser:
+		res, ok := unmarshal[shareUserMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "share_user", Content: res.UserID, Text: "[个人名片]"}}
+	case MsgTypeMergeForward:
+		// 子消息需要额外拉取, 见 ExpandMergeForward
+		return []*Item{{Tag: "merge_forward", Content: content, Text: "[合并转发]"}}
+	case MsgTypeInteractive:
+		return []*Item{{Tag: "interactive", Content: content, Text: "[卡片] " + cardText(content)}}
+	case MsgTypeLocation:
+		res, ok := unmarshal[locationMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "location", Content: res.Latitude + "," + res.Longitude, Text: fmt.Sprintf("[位置 %s]", res.Name)}}
+	case MsgTypeTodo:
+		res, ok := unmarshal[todoMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "todo", Content: res.TaskID, Text: fmt.Sprintf("[任务 %s]", renderItems(postItems(&res.Summary), " "))}}
+	case MsgTypeVote:
+		res, ok := unmarshal[voteMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "vote", Content: res.Topic, Text: fmt.Sprintf("[投票 %s: %s]", res.Topic, strings.Join(res.Options, " / "))}}
+	case MsgTypeVideoChat:
+		res, ok := unmarshal[videoChatMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "video_chat", Content: res.Topic, Text: fmt.Sprintf("[视频会议 %s]", res.Topic)}}
+	case MsgTypeShareCalEvt, MsgTypeCalendar, MsgTypeGeneralCal:
+		res, ok := unmarshal[calendarMsg](content)
+		if !ok {
+			return nil
+		}
+		return []*Item{{Tag: "calendar", Content: res.Summary, Text: fmt.Sprintf("[日程 %s]", res.Summary)}}
+	case MsgTypeSystem:
+		res, ok := unmarshal[systemMsg](content)
+		if !ok {
+			return nil
+		}
+		r := strings.NewReplacer(
+			"{from_user}", strings.Join(res.FromUser, "、"),
+			"{to_chatters}", strings.Join(res.ToChatters, "、"),
+			"{divider_text}", res.Divider.Text,
+		)
+		return []*Item{{Tag: "system", Content: res.Template, Text: "[系统消息] " + r.Replace(res.Template)}}
+	}
+	return nil
+}
+
+func postItems(res *postMsg) []*Item {
+	items := make([]*Item, 0)
+	if res.Title != "" {
+		items = append(items, &Item{Tag: "title", Content: res.Title})
+	}
+	for _, ele := range res.Content {
+		for _, ele2 := range ele {
+			switch ele2.Tag {
+			case "at":
+				items = append(items, &Item{Tag: "at", Content: ele2.UserID, Text: "@" + ele2.UserName})
+			case "text", "md":
+				items = append(items, &Item{Tag: "text", Content: ele2.Text})
+			case "a":
+				items = append(items, &Item{Tag: "text", Content: ele2.Text, Text: fmt.Sprintf("%s(%s)", ele2.Text, ele2.Href)})
+			case "code_block":
+				items = append(items, &Item{Tag: "text", Content: ele2.Text})
+			case "emotion":
+				items = append(items, &Item{Tag: "emotion", Content: ele2.Emoji, Text: fmt.Sprintf("[%s]", ele2.Emoji)})
+			case "image", "img":
+				items = append(items, &Item{Tag: "image", Content: ele2.ImageKey, Text: "[图片]"})
+			case "media":
+				items = append(items, &Item{Tag: "media", Content: ele2.FileKey, Text: "[视频]"})
+			case "sticker":
+				items = append(items, &Item{Tag: "sticker", Content: ele2.FileKey, Text: "[表情包]"})
+			}
+		}
+	}
+	return items
+}
+
+// cardText 提取卡片中的所有文本, 收到的卡片内容结构不固定, 这里直接遍历 JSON 取 text/title/content 字段
+func cardText(content string) string {
+	var raw any
+	if err := sonic.UnmarshalString(content, &raw); err != nil {
+		return ""
+	}
+	texts := make([]string, 0)
+	var walk func(v any)
+	walk = func(v any) {
+		switch val := v.(type) {
+		case map[string]any:
+			for _, key := range []string{"title", "text", "content"} {
+				if s, ok := val[key].(string); ok && strings.TrimSpace(s) != "" {
+					texts = append(texts, strings.TrimSpace(s))
 				}
 			}
-		case "image":
-			res := utils.MustUnmarshalString[imageMsg](content)
-			if !yield(&Item{Tag: "image", Content: res.ImageKey}) {
-				return
+			// 按键名排序遍历, 同一张卡片每次提取的文本顺序一致
+			for _, key := range slices.Sorted(maps.Keys(val)) {
+				sub := val[key]
+				if _, isStr := sub.(string); isStr {
+					continue
+				}
+				if key == "value" || key == "behaviors" {
+					continue // 按钮回调数据不是给人看的
+				}
+				walk(sub)
 			}
-		case "file":
-			res := utils.MustUnmarshalString[fileMsg](content)
-			if !yield(&Item{Tag: "file", Content: res.FileKey}) {
-				return
+		case []any:
+			for _, sub := range val {
+				walk(sub)
 			}
 		}
 	}
+	walk(raw)
+	return strings.Join(texts, " ")
+}
+
+func renderItems(items []*Item, sep string) string {
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		if t := strings.TrimSpace(item.PlainText()); t != "" {
+			texts = append(texts, t)
+		}
+	}
+	return strings.Join(texts, sep)
+}
+
+// PlainText 将消息渲染为可读文本, 用于 prompt 与索引
+//
+//	@param msgType string
+//	@param content string 消息原始 content
+//	@return string
+func PlainText(msgType, content string) string {
+	if msgType == MsgTypeText {
+		if res, ok := unmarshal[textMsg](content); ok {
+			return res.Text
+		}
+		return content
+	}
+	return renderItems(trans2Items(msgType, content), " ")
 }
 
 // GetContentItemsSeq to be filled
@@ -0,0 +1,31 @@
+package larkcontent
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	cases := []struct {
+		name, msgType, content, want string
+	}{
+		{"text", MsgTypeText, "hello", "hello"},
+		{"audio", MsgTypeAudio, `{"file_key":"f","duration":2300}`, "[语音 2s]"},
+		{"image", MsgTypeImage, `{"image_key":"img"}`, "[图片]"},
+		{"rendered", MsgTypePost, "already rendered", "already rendered"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := PlainText(c.msgType, c.content); got != c.want {
+				t.Errorf("PlainText(%q) = %q, want %q", c.msgType, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCardTextOrder(t *testing.T) {
+	card := `{"header":{"title":{"content":"标题"}},"elements":[{"tag":"div","text":{"content":"正文"}},{"tag":"button","text":{"content":"按钮"},"value":{"text":"隐藏"}}],"body":{"content":"底部"}}`
+	want := "底部 正文 按钮 标题"
+	for range 20 {
+		if got := cardText(card); got != want {
+			t.Fatalf("cardText() = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package larkcontent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/cache"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkuser"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+	"go.uber.org/zap"
+)
+
+// maxForwardDepth 合并转发嵌套的最大展开层数
+const maxForwardDepth = 3
+
+// forwardTTL 合并转发的内容发出后不再变化, 缓存只为同一条消息被反复读取时省去请求
+const forwardTTL = 30 * time.Minute
+
+// ExpandMergeForward 拉取合并转发消息的子消息并渲染为聊天记录文本, 结果带缓存
+//
+//	@param ctx context.Context
+//	@param msgID string 合并转发消息ID
+//	@return string 形如 "[合并转发]\n[time] <name>: content" 的多行文本
+//	@return error
+func ExpandMergeForward(ctx context.Context, msgID string) (res string, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	return cache.GetOrExecute(ctx, msgID, forwardTTL, func() (string, error) {
+		return expandMergeForward(ctx, msgID)
+	})
+}
+
+func expandMergeForward(ctx context.Context, msgID string) (string, error) {
+	resp, err := lark_dal.Client().Im.V1.Message.Get(ctx, larkim.NewGetMessageReqBuilder().MessageId(msgID).Build())
+	if err != nil {
+		return "", err
+	}
+	if !resp.Success() {
+		return "", errors.New(resp.Error())
+	}
+
+	// 接口会一次返回所有层级的子消息, 通过 upper_message_id 组织成树
+	children := make(map[string][]*larkim.Message)
+	for _, item := range resp.Data.Items {
+		if upper := utils.AddrOrNil(item.UpperMessageId); upper != "" {
+			children[upper] = append(children[upper], item)
+		}
+	}
+
+	names := make(map[string]string)
+	lines := []string{"[合并转发]"}
+	var render func(parentID string, depth int)
+	render = func(parentID string, depth int) {
+		indent := strings.Repeat("  ", depth)
+		for _, sub := range children[parentID] {
+			msgType := utils.AddrOrNil(sub.MsgType)
+			content := ""
+			if sub.Body != nil {
+				content = utils.AddrOrNil(sub.Body.Content)
+			}
+			text := PlainText(msgType, content)
+			createTime := ""
+			if ts, err := strconv.ParseInt(utils.AddrOrNil(sub.CreateTime), 10, 64); err == nil {
+				createTime = time.UnixMilli(ts).In(utils.UTC8Loc()).Format(time.DateTime)
+			}
+			lines = append(lines, fmt.Sprintf("%s[%s] <%s>: %s", indent, createTime, senderName(ctx, sub, names), text))
+			if msgType == MsgTypeMergeForward && depth+1 < maxForwardDepth {
+				render(utils.AddrOrNil(sub.MessageId), depth+1)
+			}
+		}
+	}
+	render(msgID, 0)
+	return strings.Join(lines, "\n"), nil
+}
+
+// senderName 子消息可能来自机器人不在的群, 拿不到名字时回退到 open_id
+func senderName(ctx context.Context, msg *larkim.Message, names map[string]string) string {
+	if msg.Sender == nil {
+		return "unknown"
+	}
+	openID := utils.AddrOrNil(msg.Sender.Id)
+	if name, ok := names[openID]; ok {
+		return name
+	}
+	name := openID
+	if utils.AddrOrNil(msg.Sender.SenderType) == "app" {
+		name = "机器人"
+	} else if member, err := larkuser.GetUserMemberFromChat(ctx, utils.AddrOrNil(msg.ChatId), openID); err == nil && member != nil {
+		name = utils.AddrOrNil(member.Name)
+	} else if err != nil {
+		logs.L().Ctx(ctx).Debug("get forward sender name failed", zap.String("open_id", openID), zap.Error(err))
+	}
+	names[openID] = name
+	return name
+}
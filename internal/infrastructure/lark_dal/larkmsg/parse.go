@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/asr"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg/larkcontent"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	"github.com/bytedance/sonic"
 	"github.com/kevinmatthe/zaplog"
@@ -14,10 +17,35 @@ import (
 	"go.uber.org/zap"
 )
 
+// PreGetTextMsg 获取消息的可读文本: text 消息取文本, 其他类型渲染为 prompt/索引可用的文本,
+// 合并转发会展开子消息, 语音会转写为文本
 func PreGetTextMsg(ctx context.Context, event *larkim.P2MessageReceiveV1) string {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
-	return GetContentFromTextMsg(*event.Event.Message.Content)
+	msgType := utils.AddrOrNil(event.Event.Message.MessageType)
+	content := utils.AddrOrNil(event.Event.Message.Content)
+	switch msgType {
+	case larkcontent.MsgTypeText:
+		return GetContentFromTextMsg(content)
+	case larkcontent.MsgTypeAudio:
+		transcript, err := TranscribeAudio(ctx, event.Event.Message)
+		if err != nil || transcript == "" {
+			if err != nil && !errors.Is(err, asr.ErrDisabled) {
+				logs.L().Ctx(ctx).Warn("TranscribeAudio", zap.Error(err))
+			}
+			return larkcontent.PlainText(msgType, content)
+		}
+		return "[语音] " + transcript
+	case larkcontent.MsgTypeMergeForward:
+		expanded, err := larkcontent.ExpandMergeForward(ctx, utils.AddrOrNil(event.Event.Message.MessageId))
+		if err != nil {
+			logs.L().Ctx(ctx).Warn("ExpandMergeForward", zap.Error(err))
+			return larkcontent.PlainText(msgType, content)
+		}
+		return expanded
+	default:
+		return larkcontent.PlainText(msgType, content)
+	}
 }
 
 func GetContentFromTextMsg(s string) string {
@@ -22,19 +22,33 @@ import (
 //	@author kevinmatthe
 //	@update 2025-06-05 13:23:46
 func PatchCard(ctx context.Context, cardContent *larktpl.TemplateCardContent, msgID string) (err error) {
-	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	span.SetAttributes(attribute.Key("msgID").String(msgID))
 	for k, v := range cardContent.Data.TemplateVariable {
 		span.SetAttributes(attribute.Key(k).String(fmt.Sprintf("%v", v)))
 	}
 	defer span.End()
 	defer func() { span.RecordError(err) }()
+	return PatchCardRaw(ctx, cardContent.String(), msgID)
+}
+
+// PatchCardRaw 以卡片 JSON 更新已发送的卡片消息, 用于不经过模板直接构造的卡片
+//
+//	@param ctx context.Context
+//	@param content string 卡片 JSON
+//	@param msgID string
+//	@return err error
+func PatchCardRaw(ctx context.Context, content, msgID string) (err error) {
+	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("msgID").String(msgID))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
 	resp, err := lark_dal.Client().Im.V1.Message.Patch(
 		ctx, larkim.NewPatchMessageReqBuilder().
 			MessageId(msgID).
 			Body(
 				larkim.NewPatchMessageReqBodyBuilder().
-					Content(cardContent.String()).
+					Content(content).
 					Build(),
 			).
 			Build(),
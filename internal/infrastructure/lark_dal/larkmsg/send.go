@@ -68,6 +68,69 @@ func CreateMsgTextRaw(ctx context.Context, content, msgID, chatID string) (err e
 	return
 }
 
+// CreateMsgRawContentType 向群里发送任意类型的消息, content 需已序列化
+//
+//	@param ctx context.Context
+//	@param chatID string
+//	@param msgType string
+//	@param content string
+//	@param uuidKey string 幂等键, 相同的键只会发送一次
+//	@return resp *larkim.CreateMessageResp
+//	@return err error
+func CreateMsgRawContentType(ctx context.Context, chatID, msgType, content, uuidKey string) (resp *larkim.CreateMessageResp, err error) {
+	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("chatID").String(chatID), attribute.Key("msgType").String(msgType))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+	return createMsgRaw(ctx, larkim.ReceiveIdTypeChatId, chatID, msgType, content, uuidKey)
+}
+
+// CreateUserMsgRawContentType 私聊发送任意类型的消息, content 需已序列化
+//
+//	@param ctx context.Context
+//	@param openID string 接收成员的 open_id
+//	@param msgType string
+//	@param content string
+//	@param uuidKey string 幂等键, 相同的键只会发送一次
+//	@return resp *larkim.CreateMessageResp
+//	@return err error
+func CreateUserMsgRawContentType(ctx context.Context, openID, msgType, content, uuidKey string) (resp *larkim.CreateMessageResp, err error) {
+	_, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("openID").String(openID), attribute.Key("msgType").String(msgType))
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+	return createMsgRaw(ctx, larkim.ReceiveIdTypeOpenId, openID, msgType, content, uuidKey)
+}
+
+func createMsgRaw(ctx context.Context, receiveIDType, receiveID, msgType, content, uuidKey string) (resp *larkim.CreateMessageResp, err error) {
+	if len(uuidKey) > 50 {
+		uuidKey = uuidKey[:50]
+	}
+	resp, err = lark_dal.Client().Im.Message.Create(ctx,
+		larkim.NewCreateMessageReqBuilder().
+			ReceiveIdType(receiveIDType).
+			Body(
+				larkim.NewCreateMessageReqBodyBuilder().
+					ReceiveId(receiveID).
+					Content(content).
+					Uuid(utils.GenUUIDStr(uuidKey, 50)).
+					MsgType(msgType).
+					Build(),
+			).
+			Build(),
+	)
+	if err != nil {
+		logs.L().Ctx(ctx).Error("CreateMessage", zap.Error(err))
+		return nil, err
+	}
+	if !resp.Success() {
+		logs.L().Ctx(ctx).Error("CreateMessage", zap.String("respError", resp.Error()))
+		return nil, errors.New(resp.Error())
+	}
+	go RecordMessage2Opensearch(ctx, resp, content)
+	return
+}
+
 func SendAndReplyStreamingCard(ctx context.Context, msg *larkim.EventMessage, msgSeq iter.Seq[*ark_dal.ModelStreamRespReasoning], inThread bool) (err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
@@ -3,6 +3,7 @@ package larkuser
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/cache"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal"
@@ -29,11 +30,14 @@ func GetUserInfo(ctx context.Context, userID string) (user *larkcontact.User, er
 	return resp.Data.User, nil
 }
 
+// profileTTL 成员资料与群成员列表的缓存时长, 改名、进出群在几分钟内生效
+const profileTTL = 5 * time.Minute
+
 func GetUserInfoCache(ctx context.Context, chatID, userID string) (user *larkcontact.User, err error) {
 	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
 	defer span.End()
 	defer func() { span.RecordError(err) }()
-	res, err := cache.GetOrExecute(ctx, userID, func() (*larkcontact.User, error) {
+	res, err := cache.GetOrExecute(ctx, userID, profileTTL, func() (*larkcontact.User, error) {
 		return GetUserInfo(ctx, userID)
 	})
 	logs.L().Ctx(ctx).Info("GetUserInfoCache", zap.Any("user", res))
@@ -69,7 +73,7 @@ func GetUserMemberFromChat(ctx context.Context, chatID, openID string) (member *
 }
 
 func GetUserMapFromChatIDCache(ctx context.Context, chatID string) (memberMap map[string]*larkim.ListMember, err error) {
-	return cache.GetOrExecute(ctx, chatID, func() (map[string]*larkim.ListMember, error) {
+	return cache.GetOrExecute(ctx, chatID, profileTTL, func() (map[string]*larkim.ListMember, error) {
 		return GetUserMapFromChatID(ctx, chatID)
 	})
 }
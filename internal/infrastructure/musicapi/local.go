@@ -0,0 +1,215 @@
+package musicapi
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// LocalName 本地音乐源的名称
+const LocalName = "local"
+
+// LocalSong 本地曲库中的一首歌
+type LocalSong struct {
+	Song
+	AlbumID  string
+	ArtistID string
+	Lyrics   Lyrics
+	Comments []*Comment
+}
+
+// Local 内存中的曲库, 用于测试与离线运行, 不依赖任何外部服务
+type Local struct {
+	mu      sync.RWMutex
+	songs   []*LocalSong
+	albums  map[string]*Album
+	artists map[string]*Artist
+}
+
+// NewLocal 创建本地音乐源
+//
+//	@param songs ...*LocalSong 初始曲库
+//	@return *Local
+func NewLocal(songs ...*LocalSong) *Local {
+	l := &Local{albums: make(map[string]*Album), artists: make(map[string]*Artist)}
+	l.Add(songs...)
+	return l
+}
+
+// Add 向曲库中添加歌曲
+func (l *Local) Add(songs ...*LocalSong) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.songs = append(l.songs, songs...)
+}
+
+// AddAlbum 向曲库中添加专辑, 专辑中的歌曲通过 LocalSong.AlbumID 关联
+func (l *Local) AddAlbum(album *Album) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.albums[album.ID] = album
+}
+
+// AddArtist 向曲库中添加歌手, 歌手的歌曲通过 LocalSong.ArtistID 关联
+func (l *Local) AddArtist(artist *Artist) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.artists[artist.ID] = artist
+}
+
+// Name 实现 Provider
+func (l *Local) Name() string {
+	return LocalName
+}
+
+func matches(keywords string, fields ...string) bool {
+	keywords = strings.ToLower(strings.TrimSpace(keywords))
+	for _, f := range fields {
+		if keywords != "" && strings.Contains(strings.ToLower(f), keywords) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Local) song(id string) *LocalSong {
+	for _, s := range l.songs {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (l *Local) filter(fn func(*LocalSong) bool, limit int) []*Song {
+	res := make([]*Song, 0)
+	for _, s := range l.songs {
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+		if fn(s) {
+			song := s.Song
+			res = append(res, &song)
+		}
+	}
+	return res
+}
+
+// SearchSongs 实现 Provider, 匹配歌名与歌手
+func (l *Local) SearchSongs(ctx context.Context, keywords string, limit int) ([]*Song, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.filter(func(s *LocalSong) bool { return matches(keywords, s.Name, s.Artist) }, limit), nil
+}
+
+// SearchAlbums 实现 Provider, 匹配专辑名与歌手
+func (l *Local) SearchAlbums(ctx context.Context, keywords string, limit int) ([]*Album, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	res := make([]*Album, 0)
+	for _, a := range l.albums {
+		if matches(keywords, a.Name, a.Artist) {
+			res = append(res, a)
+		}
+	}
+	slices.SortFunc(res, func(a, b *Album) int { return strings.Compare(a.ID, b.ID) })
+	return res[:min(limit, len(res))], nil
+}
+
+// SearchArtists 实现 Provider, 匹配歌手名与别名
+func (l *Local) SearchArtists(ctx context.Context, keywords string, limit int) ([]*Artist, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	res := make([]*Artist, 0)
+	for _, a := range l.artists {
+		if matches(keywords, append([]string{a.Name}, a.Alias...)...) {
+			res = append(res, a)
+		}
+	}
+	slices.SortFunc(res, func(a, b *Artist) int { return strings.Compare(a.ID, b.ID) })
+	return res[:min(limit, len(res))], nil
+}
+
+// SongURLs 实现 Provider, 返回曲库中登记的链接
+func (l *Local) SongURLs(ctx context.Context, songIDs ...string) (map[string]string, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	urls := make(map[string]string, len(songIDs))
+	for _, id := range songIDs {
+		if s := l.song(id); s != nil && s.URL != "" {
+			urls[id] = s.URL
+		}
+	}
+	return urls, nil
+}
+
+// Lyrics 实现 Provider
+func (l *Local) Lyrics(ctx context.Context, songID string) (*Lyrics, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	s := l.song(songID)
+	if s == nil {
+		return nil, ErrNotFound
+	}
+	lyrics := s.Lyrics
+	return &lyrics, nil
+}
+
+// HotComment 实现 Provider, 只有歌曲有评论
+func (l *Local) HotComment(ctx context.Context, kind Kind, id string) (*Comment, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if kind != KindSong {
+		return nil, nil
+	}
+	if s := l.song(id); s != nil && len(s.Comments) > 0 {
+		return s.Comments[0], nil
+	}
+	return nil, nil
+}
+
+// SongDetail 实现 Provider
+func (l *Local) SongDetail(ctx context.Context, songID string) (*Song, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	s := l.song(songID)
+	if s == nil {
+		return nil, ErrNotFound
+	}
+	song := s.Song
+	return &song, nil
+}
+
+// AlbumSongs 实现 Provider
+func (l *Local) AlbumSongs(ctx context.Context, albumID string) ([]*Song, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if _, ok := l.albums[albumID]; !ok {
+		return nil, ErrNotFound
+	}
+	return l.filter(func(s *LocalSong) bool { return s.AlbumID == albumID }, 0), nil
+}
+
+// ArtistDetail 实现 Provider
+func (l *Local) ArtistDetail(ctx context.Context, artistID string, albumLimit int) (*ArtistDetail, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	artist, ok := l.artists[artistID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	detail := &ArtistDetail{Artist: artist, HotSongs: l.filter(func(s *LocalSong) bool { return s.ArtistID == artistID }, 0)}
+	albumIDs := make([]string, 0)
+	for _, s := range l.songs {
+		if s.ArtistID == artistID && s.AlbumID != "" && !slices.Contains(albumIDs, s.AlbumID) {
+			albumIDs = append(albumIDs, s.AlbumID)
+		}
+	}
+	for _, id := range albumIDs[:min(albumLimit, len(albumIDs))] {
+		if album, ok := l.albums[id]; ok {
+			detail.Albums = append(detail.Albums, album)
+		}
+	}
+	return detail, nil
+}
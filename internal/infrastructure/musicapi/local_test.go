@@ -0,0 +1,93 @@
+package musicapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	l := NewLocal(
+		&LocalSong{Song: Song{ID: "1", Name: "晴天", Artist: "周杰伦", URL: "http://local/1.mp3"}, AlbumID: "a1", ArtistID: "jay",
+			Lyrics:   Lyrics{Lrc: "[00:01.00]故事的小黄花\n[00:05.00]从出生那年就飘着", Translation: "[00:01.00]The little yellow flower"},
+			Comments: []*Comment{{Content: "青春"}}},
+		&LocalSong{Song: Song{ID: "2", Name: "七里香", Artist: "周杰伦"}, AlbumID: "a2", ArtistID: "jay"},
+	)
+	l.AddAlbum(&Album{ID: "a1", Name: "叶惠美", Artist: "周杰伦"})
+	l.AddAlbum(&Album{ID: "a2", Name: "七里香", Artist: "周杰伦"})
+	l.AddArtist(&Artist{ID: "jay", Name: "周杰伦", Alias: []string{"Jay Chou"}})
+
+	var p Provider = l
+	if songs, _ := p.SearchSongs(ctx, "周杰伦", 1); len(songs) != 1 || songs[0].ID != "1" {
+		t.Errorf("SearchSongs() = %v", songs)
+	}
+	if artists, _ := p.SearchArtists(ctx, "jay chou", 5); len(artists) != 1 {
+		t.Errorf("SearchArtists() = %v", artists)
+	}
+	if urls, _ := p.SongURLs(ctx, "1", "2"); len(urls) != 1 || urls["1"] == "" {
+		t.Errorf("SongURLs() = %v", urls)
+	}
+	if songs, _ := p.AlbumSongs(ctx, "a2"); len(songs) != 1 || songs[0].Name != "七里香" {
+		t.Errorf("AlbumSongs() = %v", songs)
+	}
+	if detail, _ := p.ArtistDetail(ctx, "jay", 1); len(detail.HotSongs) != 2 || len(detail.Albums) != 1 {
+		t.Errorf("ArtistDetail() = %+v", detail)
+	}
+	if c, _ := p.HotComment(ctx, KindSong, "1"); c == nil || c.Content != "青春" {
+		t.Errorf("HotComment() = %v", c)
+	}
+	if _, err := p.SongDetail(ctx, "404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SongDetail(404) error = %v", err)
+	}
+	lyrics, _ := p.Lyrics(ctx, "1")
+	if got := lyrics.Plain(); got != "故事的小黄花\nThe little yellow flower\n从出生那年就飘着\n" {
+		t.Errorf("Plain() = %q", got)
+	}
+	if _, ok := p.(PlaylistProvider); ok {
+		t.Error("Local should not support playlists")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	Register(NewLocal())
+	if p, ok := Get(LocalName); !ok || p.Name() != LocalName {
+		t.Fatalf("Get(%q) = %v, %v", LocalName, p, ok)
+	}
+	Register(NewLocal())
+	if names := Names(); strings.Count(strings.Join(names, ","), LocalName) != 1 {
+		t.Errorf("Names() = %v", names)
+	}
+	if Default() == nil {
+		t.Error("Default() = nil")
+	}
+}
+
+func TestParseLrc(t *testing.T) {
+	lrc := "[ti:晴天]\n[offset:500]\n[00:01.5]第一行\n[00:10.00][00:03.250]副歌\n[00:05:00]\n[01:02.345]最后"
+	lines := ParseLrc(lrc)
+	want := []LyricLine{
+		{Time: 1000 * time.Millisecond, Text: "第一行"},
+		{Time: 2750 * time.Millisecond, Text: "副歌"},
+		{Time: 9500 * time.Millisecond, Text: "副歌"},
+		{Time: 61845 * time.Millisecond, Text: "最后"},
+	}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("ParseLrc() = %+v, want %+v", lines, want)
+	}
+	if got := FormatLyricTime(lines[3].Time); got != "01:01" {
+		t.Errorf("FormatLyricTime() = %q", got)
+	}
+
+	l := &Lyrics{
+		Lrc:         "[00:01.00]Hello\n[00:04.00]World\n[00:08.00]Same",
+		Translation: "[00:01.02]你好\n[00:04.00]世界\n[00:06.00]多余\n[00:08.00]Same",
+	}
+	merged := l.Lines()
+	if len(merged) != 3 || merged[0].Translation != "你好" || merged[1].Translation != "世界" || merged[2].Translation != "" {
+		t.Errorf("Lines() = %+v", merged)
+	}
+}
@@ -0,0 +1,122 @@
+package musicapi
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// lrcTagRe LRC 的时间标签, 如 [01:02.30], [01:02:30], [01:02]
+	lrcTagRe = regexp.MustCompile(`\[(\d+):(\d+)(?:[.:](\d+))?\]`)
+	// lrcOffsetRe 整体偏移标签, 单位毫秒, 正值表示歌词提前出现
+	lrcOffsetRe = regexp.MustCompile(`^\[offset:\s*([+-]?\d+)\s*\]`)
+	// lrcMetaRe 其余的元信息标签, 如 [ar:歌手], [ti:歌名]
+	lrcMetaRe = regexp.MustCompile(`^\[[a-zA-Z]+:[^\]]*\]$`)
+)
+
+// translationTolerance 译文与原文时间标签的最大误差, 部分歌词的译文时间与原文差几毫秒
+const translationTolerance = 100 * time.Millisecond
+
+// LyricLine 一行歌词
+type LyricLine struct {
+	Time        time.Duration `json:"time"`
+	Text        string        `json:"text"`
+	Translation string        `json:"translation,omitempty"`
+}
+
+// ParseLrc 解析 LRC 文本, 按时间排序; 一行可以带多个时间标签, 支持 [offset:] 整体偏移, 没有内容的行忽略
+//
+//	@param lrc string
+//	@return []LyricLine
+func ParseLrc(lrc string) []LyricLine {
+	var (
+		lines  []LyricLine
+		offset time.Duration
+	)
+	for _, raw := range strings.Split(lrc, "\n") {
+		raw = strings.TrimSpace(raw)
+		if m := lrcOffsetRe.FindStringSubmatch(raw); m != nil {
+			ms, _ := strconv.Atoi(m[1])
+			offset = time.Duration(ms) * time.Millisecond
+			continue
+		}
+		if lrcMetaRe.MatchString(raw) {
+			continue
+		}
+		tags := lrcTagRe.FindAllStringSubmatch(raw, -1)
+		text := strings.TrimSpace(lrcTagRe.ReplaceAllString(raw, ""))
+		if len(tags) == 0 || text == "" {
+			continue
+		}
+		for _, tag := range tags {
+			lines = append(lines, LyricLine{Time: tagTime(tag), Text: text})
+		}
+	}
+	for i := range lines {
+		lines[i].Time = max(lines[i].Time-offset, 0)
+	}
+	slices.SortStableFunc(lines, func(a, b LyricLine) int { return cmp.Compare(a.Time, b.Time) })
+	return lines
+}
+
+// tagTime 时间标签对应的时间, 小数部分按位数换算, .3 .30 .300 都是 300ms
+func tagTime(tag []string) time.Duration {
+	minutes, _ := strconv.Atoi(tag[1])
+	seconds, _ := strconv.Atoi(tag[2])
+	d := time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	if frac := tag[3]; frac != "" {
+		n, _ := strconv.Atoi(frac)
+		unit := time.Second
+		for range len(frac) {
+			unit /= 10
+		}
+		d += time.Duration(n) * unit
+	}
+	return d
+}
+
+// FormatLyricTime 以 mm:ss 展示时间
+func FormatLyricTime(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Minute), int(d%time.Minute/time.Second))
+}
+
+// Lines 原文与译文按时间对齐后的歌词, 原文与译文相同的不重复
+func (l *Lyrics) Lines() []LyricLine {
+	if l == nil {
+		return nil
+	}
+	lines := ParseLrc(l.Lrc)
+	for _, t := range ParseLrc(l.Translation) {
+		i, _ := slices.BinarySearchFunc(lines, t.Time, func(line LyricLine, d time.Duration) int { return cmp.Compare(line.Time, d) })
+		// 取前后两行中更接近的一行
+		if i > 0 && (i == len(lines) || t.Time-lines[i-1].Time < lines[i].Time-t.Time) {
+			i--
+		}
+		if i == len(lines) || (lines[i].Time-t.Time).Abs() > translationTolerance {
+			continue
+		}
+		if lines[i].Translation == "" && t.Text != lines[i].Text {
+			lines[i].Translation = t.Text
+		}
+	}
+	return lines
+}
+
+// Plain 去掉时间标签的歌词, 有翻译时每行原文后跟对应的译文
+func (l *Lyrics) Plain() string {
+	b := &strings.Builder{}
+	for _, line := range l.Lines() {
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+		if line.Translation != "" {
+			b.WriteString(line.Translation)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+// Package musicapi 音乐源的抽象: 搜索歌曲、专辑、歌手, 解析播放链接, 获取歌词、评论与详情;
+// 各音乐源实现 Provider 并在 Init 时注册, 群可以选择使用哪个音乐源
+package musicapi
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Kind 资源类型
+type Kind string
+
+const (
+	KindSong     Kind = "song"
+	KindAlbum    Kind = "album"
+	KindArtist   Kind = "artist"
+	KindPlaylist Kind = "playlist"
+)
+
+var (
+	// ErrNotFound 资源不存在
+	ErrNotFound = errors.New("music resource not found")
+	// ErrNotSupported 音乐源不支持该操作
+	ErrNotSupported = errors.New("not supported by this music provider")
+)
+
+// Song 歌曲
+type Song struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Artist string `json:"artist"`
+	PicURL string `json:"pic_url"`
+	// URL 搜索时一并解析的播放链接, 为空表示无法播放或未解析
+	URL string `json:"url"`
+	// ImageKey 已上传到飞书的封面, 为空时按 PicURL 上传
+	ImageKey string `json:"image_key"`
+}
+
+// Album 专辑
+type Album struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Artist      string    `json:"artist"`
+	Type        string    `json:"type"`
+	PicURL      string    `json:"pic_url"`
+	PublishTime time.Time `json:"publish_time"`
+}
+
+// Artist 歌手
+type Artist struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Alias     []string `json:"alias"`
+	PicURL    string   `json:"pic_url"`
+	Brief     string   `json:"brief"`
+	MusicSize int      `json:"music_size"`
+	AlbumSize int      `json:"album_size"`
+}
+
+// ArtistDetail 歌手详情: 简介、热门歌曲与最近的专辑
+type ArtistDetail struct {
+	Artist   *Artist  `json:"artist"`
+	HotSongs []*Song  `json:"hot_songs"`
+	Albums   []*Album `json:"albums"`
+}
+
+// Playlist 歌单
+type Playlist struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Creator    string `json:"creator"`
+	PicURL     string `json:"pic_url"`
+	TrackCount int    `json:"track_count"`
+	PlayCount  int    `json:"play_count"`
+}
+
+// Comment 评论
+type Comment struct {
+	Content string `json:"content"`
+	Time    string `json:"time"`
+}
+
+// Lyrics 歌词, 均为 LRC 格式, 没有翻译时 Translation 为空
+type Lyrics struct {
+	Lrc         string `json:"lrc"`
+	Translation string `json:"translation"`
+}
+
+// Provider 音乐源
+type Provider interface {
+	// Name 音乐源的名称, 如 netease
+	Name() string
+	// SearchSongs 按关键词搜索歌曲, 结果带有播放链接
+	SearchSongs(ctx context.Context, keywords string, limit int) ([]*Song, error)
+	// SearchAlbums 按关键词搜索专辑
+	SearchAlbums(ctx context.Context, keywords string, limit int) ([]*Album, error)
+	// SearchArtists 按关键词搜索歌手
+	SearchArtists(ctx context.Context, keywords string, limit int) ([]*Artist, error)
+	// SongURLs 解析播放链接, 无法播放的歌曲不在结果中
+	SongURLs(ctx context.Context, songIDs ...string) (map[string]string, error)
+	// Lyrics 歌词
+	Lyrics(ctx context.Context, songID string) (*Lyrics, error)
+	// HotComment 资源的热门评论, 没有评论时返回 nil
+	HotComment(ctx context.Context, kind Kind, id string) (*Comment, error)
+	// SongDetail 歌曲详情
+	SongDetail(ctx context.Context, songID string) (*Song, error)
+	// AlbumSongs 专辑中的歌曲
+	AlbumSongs(ctx context.Context, albumID string) ([]*Song, error)
+	// ArtistDetail 歌手详情, 附带最近的 albumLimit 张专辑
+	ArtistDetail(ctx context.Context, artistID string, albumLimit int) (*ArtistDetail, error)
+}
+
+// PlaylistProvider 支持歌单的音乐源
+type PlaylistProvider interface {
+	SearchPlaylists(ctx context.Context, keywords string, limit int) ([]*Playlist, error)
+	PlaylistSongs(ctx context.Context, playlistID string, limit int) ([]*Song, error)
+}
+
+// RecommendProvider 支持推荐的音乐源
+type RecommendProvider interface {
+	// DailySongs 登录账号的每日推荐
+	DailySongs(ctx context.Context, limit int) ([]*Song, error)
+	// NewSongs 新歌推荐
+	NewSongs(ctx context.Context, limit int) ([]*Song, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{}
+	// order 注册顺序, 第一个注册的为默认音乐源
+	order []string
+)
+
+// Register 注册音乐源, 同名的覆盖
+//
+//	@param p Provider
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := providers[p.Name()]; !ok {
+		order = append(order, p.Name())
+	}
+	providers[p.Name()] = p
+}
+
+// Get 按名称获取音乐源
+func Get(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Default 第一个注册的音乐源, 没有注册任何音乐源时返回 nil
+func Default() Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(order) == 0 {
+		return nil
+	}
+	return providers[order[0]]
+}
+
+// Names 已注册的音乐源名称, 按注册顺序
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return slices.Clone(order)
+}
@@ -1,13 +1,11 @@
 package neteaseapi
 
 import (
-	"cmp"
 	"context"
 	"fmt"
 	"net/url"
 	"path"
 	"path/filepath"
-	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,14 +14,15 @@ import (
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkimg"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/miniodal"
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/xmodel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhttp"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xrequest"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	"github.com/bytedance/sonic"
-	"github.com/dlclark/regexp2"
 	"github.com/minio/minio-go/v7"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
@@ -34,67 +33,103 @@ const netEaseQRTmpFile = "/data/tmp"
 type CommentType string
 
 const (
-	CommentTypeSong  CommentType = "0"
-	CommentTypeAlbum CommentType = "3"
+	CommentTypeSong     CommentType = "0"
+	CommentTypePlaylist CommentType = "2"
+	CommentTypeAlbum    CommentType = "3"
 )
 
+// Init 未配置 API 地址时跳过, 否则注册为音乐源并保持登录
 func Init() {
 	config := config.Get().NeteaseMusicConfig
+	if config == nil || config.BaseURL == "" {
+		logs.L().Warn("netease music api is not configured, skip")
+		return
+	}
 	NetEaseAPIBaseURL = config.BaseURL
+	musicapi.Register(&Provider{c: NetEaseGCtx})
 
 	startUpCtx := context.Background()
 	NetEaseGCtx.TryGetLastCookie(startUpCtx)
 
-	go func() {
-		err := NetEaseGCtx.LoginNetEase(startUpCtx)
+	var cancel context.CancelFunc
+	xlifecycle.Register("neteaseapi", xlifecycle.PhaseWorker,
+		func(context.Context) error {
+			var loopCtx context.Context
+			loopCtx, cancel = context.WithCancel(startUpCtx)
+			go NetEaseGCtx.keepLogin(loopCtx)
+			return nil
+		},
+		func(context.Context) error {
+			cancel()
+			return nil
+		},
+	)
+}
+
+// keepLogin 登录并定期刷新登录态, ctx 取消后退出
+//
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+func (neteaseCtx *NetEaseContext) keepLogin(ctx context.Context) {
+	err := neteaseCtx.LoginNetEase(ctx)
+	if err != nil {
+		logs.L().Ctx(ctx).Error("error in init loginNetease", zap.Error(err))
+		err = neteaseCtx.LoginNetEaseQR(ctx)
 		if err != nil {
-			logs.L().Ctx(startUpCtx).Error("error in init loginNetease", zap.Error(err))
-			err = NetEaseGCtx.LoginNetEaseQR(startUpCtx)
-			if err != nil {
-				logs.L().Ctx(startUpCtx).Error("error in init loginNeteaseQR", zap.Error(err))
-			}
+			logs.L().Ctx(ctx).Error("error in init loginNeteaseQR", zap.Error(err))
 		}
-		for {
-			if NetEaseGCtx.loginType == "qr" {
-				if !NetEaseGCtx.CheckIfLogin(startUpCtx) {
-					NetEaseGCtx.LoginNetEaseQR(startUpCtx)
-				}
+	}
+	ticker := time.NewTicker(time.Second * 300)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if neteaseCtx.loginType == "qr" {
+			if !neteaseCtx.CheckIfLogin(ctx) {
+				neteaseCtx.LoginNetEaseQR(ctx)
+			}
+		} else {
+			neteaseCtx.RefreshLogin(ctx)
+			if neteaseCtx.CheckIfLogin(ctx) {
+				neteaseCtx.SaveCookie(ctx)
 			} else {
-				NetEaseGCtx.RefreshLogin(startUpCtx)
-				if NetEaseGCtx.CheckIfLogin(startUpCtx) {
-					NetEaseGCtx.SaveCookie(startUpCtx)
-				} else {
-					logs.L().Ctx(startUpCtx).Error("error in refresh login")
-				}
+				logs.L().Ctx(ctx).Error("error in refresh login")
 			}
-			time.Sleep(time.Second * 300)
 		}
-	}()
+	}
 }
 
-// GetDailyRecommendID 获取当前账号日推
+// GetDailyRecommend 获取当前账号日推的前 limit 首歌, 附带播放链接与上传到飞书的封面
 //
-//	@receiver ctx
-//	@return musicIDs
-//	@return err
-func (neteaseCtx *NetEaseContext) GetDailyRecommendID() (musicIDs map[string]string, err error) {
-	musicIDs = make(map[string]string)
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+//	@param limit int
+//	@return result []*SearchMusicItem
+//	@return err error
+func (neteaseCtx *NetEaseContext) GetDailyRecommend(ctx context.Context, limit int) (result []*SearchMusicItem, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
 
 	resp, err := xrequest.
 		ReqTimestamp().
 		SetCookies(neteaseCtx.cookies).
 		Post(NetEaseAPIBaseURL + "/recommend/songs")
-	if err != nil || resp.StatusCode() != 200 {
-		return
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("get daily recommend failed, status %d, maybe not logged in", resp.StatusCode())
 	}
-
-	body := resp.Body()
 	music := dailySongs{}
-	sonic.Unmarshal(body, &music)
-	for index := range music.Data.DailySongs {
-		musicIDs[strconv.Itoa(music.Data.DailySongs[index].ID)] = music.Data.DailySongs[index].Name
+	if err = sonic.Unmarshal(resp.Body(), &music); err != nil {
+		return nil, err
 	}
-	return
+	searchRes := SearchMusic{}
+	searchRes.Result.Songs = music.Data.DailySongs[:min(limit, len(music.Data.DailySongs))]
+	return neteaseCtx.AsyncGetSearchRes(ctx, searchRes)
 }
 
 // GetMusicURLByIDs 依据ID获取URL/Name
@@ -276,11 +311,15 @@ func (neteaseCtx *NetEaseContext) GetDetail(ctx context.Context, musicID string)
 	return
 }
 
-func (neteaseCtx *NetEaseContext) GetLyrics(ctx context.Context, songID string) (lyrics string, lyricsURL string) {
-	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
-	span.SetAttributes(attribute.Key("songID").String(songID))
-	defer span.End()
-
+// fetchLyrics 获取原始歌词
+//
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+//	@param songID string
+//	@return searchLyrics *SearchLyrics
+//	@return body string 接口返回的原文
+//	@return err error
+func (neteaseCtx *NetEaseContext) fetchLyrics(ctx context.Context, songID string) (searchLyrics *SearchLyrics, body string, err error) {
 	resp, err := xhttp.HttpClient.R().
 		SetFormDataFromValues(
 			map[string][]string{
@@ -291,11 +330,22 @@ func (neteaseCtx *NetEaseContext) GetLyrics(ctx context.Context, songID string)
 		SetQueryParam("timestamp", fmt.Sprint(time.Now().UnixNano())).
 		Post(NetEaseAPIBaseURL + "/lyric")
 	if err != nil {
-		logs.L().Ctx(ctx).Warn("Unknown error", zap.Error(err))
+		return nil, "", err
+	}
+	searchLyrics = &SearchLyrics{}
+	body = string(resp.Body())
+	if err = sonic.UnmarshalString(body, searchLyrics); err != nil {
+		return nil, "", err
 	}
-	searchLyrics := &SearchLyrics{}
-	body := string(resp.Body())
-	err = sonic.UnmarshalString(body, searchLyrics)
+	return searchLyrics, body, nil
+}
+
+func (neteaseCtx *NetEaseContext) GetLyrics(ctx context.Context, songID string) (lyrics string, lyricsURL string) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("songID").String(songID))
+	defer span.End()
+
+	searchLyrics, body, err := neteaseCtx.fetchLyrics(ctx, songID)
 	if err != nil {
 		logs.L().Ctx(ctx).Warn("Unknown error", zap.Error(err))
 		return
@@ -308,58 +358,8 @@ func (neteaseCtx *NetEaseContext) GetLyrics(ctx context.Context, songID string)
 		logs.L().Ctx(ctx).Warn("[PreUploadMusic] Get minio url failed...", zap.Error(err))
 		return
 	}
-	lyricsMerged := mergeLyrics(searchLyrics.Lrc.Lyric, searchLyrics.Tlyric.Lyric)
-	return lyricsMerged, lyricsURL
-}
-
-var lyricsRepattern = regexp2.MustCompile(`\[(?P<time>.*)\](?P<line>.*)`, regexp2.RE2)
-
-func mergeLyrics(lyrics, translatedLyrics string) string {
-	lyricsMap := map[string]string{}
-	lines := strings.Split(lyrics, "\n")
-	for _, line := range lines {
-		match, err := lyricsRepattern.FindStringMatch(line)
-		if err != nil {
-			panic(err)
-		}
-		if match != nil {
-			if lyric := match.GroupByName("line").String(); lyric != "" {
-				lyricsMap[match.GroupByName("time").String()] = lyric + "\n"
-			}
-		}
-	}
-	for _, translatedLine := range strings.Split(translatedLyrics, "\n") {
-		match, err := lyricsRepattern.FindStringMatch(translatedLine)
-		if err != nil {
-			panic(err)
-		}
-		if match != nil {
-			if lyric := match.GroupByName("line").String(); lyric != "" {
-				lyricsMap[match.GroupByName("time").String()] += lyric + "\n"
-			}
-		}
-	}
-	resStr := ""
-	type lineStruct struct {
-		time string
-		line string
-	}
-	lyricsLines := make([]*lineStruct, 0)
-	for time, line := range lyricsMap {
-		if line == "" {
-			continue
-		}
-		lyricsLines = append(lyricsLines, &lineStruct{
-			time, line,
-		})
-	}
-	slices.SortFunc(lyricsLines, func(i, j *lineStruct) int {
-		return cmp.Compare(i.time, j.time)
-	})
-	for _, line := range lyricsLines {
-		resStr += line.line + "\n"
-	}
-	return resStr
+	merged := &musicapi.Lyrics{Lrc: searchLyrics.Lrc.Lyric, Translation: searchLyrics.Tlyric.Lyric}
+	return merged.Plain(), lyricsURL
 }
 
 func (neteaseCtx *NetEaseContext) AsyncGetSearchRes(ctx context.Context, searchRes SearchMusic) (result []*SearchMusicItem, err error) {
@@ -413,8 +413,161 @@ func (neteaseCtx *NetEaseContext) SearchMusicByKeyWord(ctx context.Context, keyw
 	return
 }
 
-func (neteaseCtx *NetEaseContext) SearchPlaylistByKeyWord(ctx context.Context, keywords ...string) {
-	return
+// SearchPlaylistByKeyWord 通过关键字搜索歌单
+//
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+//	@param keywords ...string
+//	@return result []*PlaylistBrief
+//	@return err error
+func (neteaseCtx *NetEaseContext) SearchPlaylistByKeyWord(ctx context.Context, keywords ...string) (result []*PlaylistBrief, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("keywords").StringSlice(keywords))
+	defer span.End()
+
+	resp, err := xhttp.HttpClient.R().
+		SetFormDataFromValues(
+			map[string][]string{
+				"limit":    {"5"},
+				"type":     {"1000"},
+				"keywords": {strings.Join(keywords, " ")},
+			},
+		).
+		SetCookies(neteaseCtx.cookies).
+		SetQueryParam("timestamp", fmt.Sprint(time.Now().UnixNano())).
+		Post(NetEaseAPIBaseURL + "/cloudsearch")
+	if err != nil {
+		return nil, err
+	}
+
+	searchRes := searchPlaylistResult{}
+	if err = sonic.Unmarshal(resp.Body(), &searchRes); err != nil {
+		return nil, err
+	}
+	return searchRes.Result.Playlists, nil
+}
+
+// GetPlaylistTracks 获取歌单中的前 limit 首歌, 附带播放链接与上传到飞书的封面
+//
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+//	@param playlistID string
+//	@param limit int
+//	@return result []*SearchMusicItem
+//	@return err error
+func (neteaseCtx *NetEaseContext) GetPlaylistTracks(ctx context.Context, playlistID string, limit int) (result []*SearchMusicItem, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("playlistID").String(playlistID))
+	defer span.End()
+
+	resp, err := xhttp.HttpClient.R().
+		SetFormDataFromValues(
+			map[string][]string{
+				"id":    {playlistID},
+				"limit": {strconv.Itoa(limit)},
+			},
+		).
+		SetCookies(neteaseCtx.cookies).
+		SetQueryParam("timestamp", fmt.Sprint(time.Now().UnixNano())).
+		Post(NetEaseAPIBaseURL + "/playlist/track/all")
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := playlistTracks{}
+	if err = sonic.Unmarshal(resp.Body(), &tracks); err != nil {
+		return nil, err
+	}
+	searchRes := SearchMusic{}
+	searchRes.Result.Songs = tracks.Songs
+	return neteaseCtx.AsyncGetSearchRes(ctx, searchRes)
+}
+
+// SearchArtistByKeyWord 通过关键字搜索歌手
+//
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+//	@param keywords ...string
+//	@return result []*Artist
+//	@return err error
+func (neteaseCtx *NetEaseContext) SearchArtistByKeyWord(ctx context.Context, keywords ...string) (result []*Artist, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("keywords").StringSlice(keywords))
+	defer span.End()
+
+	resp, err := xhttp.HttpClient.R().
+		SetFormDataFromValues(
+			map[string][]string{
+				"limit":    {"5"},
+				"type":     {"100"},
+				"keywords": {strings.Join(keywords, " ")},
+			},
+		).
+		SetCookies(neteaseCtx.cookies).
+		SetQueryParam("timestamp", fmt.Sprint(time.Now().UnixNano())).
+		Post(NetEaseAPIBaseURL + "/cloudsearch")
+	if err != nil {
+		return nil, err
+	}
+
+	searchRes := searchArtistResult{}
+	if err = sonic.Unmarshal(resp.Body(), &searchRes); err != nil {
+		return nil, err
+	}
+	return searchRes.Result.Artists, nil
+}
+
+// GetArtistDetail 获取歌手的简介、热门歌曲与最近的 albumLimit 张专辑
+//
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+//	@param artistID string
+//	@param albumLimit int
+//	@return result *ArtistDetail
+//	@return err error
+func (neteaseCtx *NetEaseContext) GetArtistDetail(ctx context.Context, artistID string, albumLimit int) (result *ArtistDetail, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	span.SetAttributes(attribute.Key("artistID").String(artistID))
+	defer span.End()
+
+	resp, err := xhttp.HttpClient.R().
+		SetFormDataFromValues(
+			map[string][]string{
+				"id": {artistID},
+			},
+		).
+		SetCookies(neteaseCtx.cookies).
+		SetQueryParam("timestamp", fmt.Sprint(time.Now().UnixNano())).
+		Post(NetEaseAPIBaseURL + "/artists")
+	if err != nil {
+		return nil, err
+	}
+	result = &ArtistDetail{}
+	if err = sonic.Unmarshal(resp.Body(), result); err != nil {
+		return nil, err
+	}
+
+	resp, err = xhttp.HttpClient.R().
+		SetFormDataFromValues(
+			map[string][]string{
+				"id":    {artistID},
+				"limit": {strconv.Itoa(albumLimit)},
+			},
+		).
+		SetCookies(neteaseCtx.cookies).
+		SetQueryParam("timestamp", fmt.Sprint(time.Now().UnixNano())).
+		Post(NetEaseAPIBaseURL + "/artist/album")
+	if err != nil {
+		// 专辑只是附加信息, 获取失败时仍返回简介与热门歌曲
+		logs.L().Ctx(ctx).Warn("get artist albums failed", zap.Error(err))
+		return result, nil
+	}
+	albums := artistAlbums{}
+	if err := sonic.Unmarshal(resp.Body(), &albums); err != nil {
+		logs.L().Ctx(ctx).Warn("get artist albums failed", zap.Error(err))
+	}
+	result.Albums = albums.HotAlbums
+	return result, nil
 }
 
 // SearchAlbumByKeyWord  通过关键字搜索歌曲
@@ -592,48 +745,40 @@ func genArtistName(song *Song) (artistName string) {
 	return strings.Join(artistList, ", ")
 }
 
-// GetNewRecommendMusic 获得新的推荐歌曲
+// GetNewRecommendMusic 获得新歌推荐, 附带播放链接与上传到飞书的封面
 //
-//	@receiver ctx
-//	@return res
-//	@return err
-func (neteaseCtx *NetEaseContext) GetNewRecommendMusic() (res []SearchMusicItem, err error) {
+//	@receiver neteaseCtx *NetEaseContext
+//	@param ctx context.Context
+//	@param limit int
+//	@return result []*SearchMusicItem
+//	@return err error
+func (neteaseCtx *NetEaseContext) GetNewRecommendMusic(ctx context.Context, limit int) (result []*SearchMusicItem, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+
 	resp, err := xhttp.HttpClient.R().SetFormDataFromValues(
 		map[string][]string{
-			"limit": {"5"},
+			"limit": {strconv.Itoa(limit)},
 		},
 	).Post(NetEaseAPIBaseURL + "/personalized/newsong")
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	music := &GlobRecommendMusicRes{}
-	sonic.Unmarshal(resp.Body(), music)
-	for _, result := range music.Result {
-		var ArtistName string
-		for _, name := range result.Song.Artists {
-			if ArtistName != "" {
-				ArtistName += ","
-			}
-			ArtistName += name.Name
-		}
-		SongURL, errIn := neteaseCtx.GetMusicURLByID(context.Background(), []*MusicIDName{
-			{strconv.Itoa(result.Song.ID), result.Song.Name},
-		})
-		if errIn != nil {
-			err = errIn
-			return
-		}
-		if len(SongURL) == 0 {
-			continue
+	if err = sonic.Unmarshal(resp.Body(), music); err != nil {
+		return nil, err
+	}
+	searchRes := SearchMusic{}
+	for _, r := range music.Result {
+		song := Song{Name: r.Song.Name, ID: r.Song.ID}
+		for _, artist := range r.Song.Artists {
+			song.Ar = append(song.Ar, struct {
+				Name string `json:"name"`
+			}{artist.Name})
 		}
-		res = append(res, SearchMusicItem{
-			ID:         strconv.Itoa(result.Song.ID),
-			Name:       result.Song.Name,
-			ArtistName: ArtistName,
-			PicURL:     result.PicURL,
-			SongURL:    SongURL[0].URL,
-		})
+		song.Al.PicURL = r.PicURL
+		searchRes.Result.Songs = append(searchRes.Result.Songs, song)
 	}
-	return
+	return neteaseCtx.AsyncGetSearchRes(ctx, searchRes)
 }
@@ -3,6 +3,7 @@ package neteaseapi
 import (
 	"net/http"
 	"os"
+	"strconv"
 )
 
 // IsTest 是否测试环境
@@ -32,10 +33,7 @@ type NetEaseContext struct {
 
 type dailySongs struct {
 	Data struct {
-		DailySongs []struct {
-			Name string `json:"name"`
-			ID   int    `json:"id"`
-		} `json:"dailySongs"`
+		DailySongs []Song `json:"dailySongs"`
 	} `json:"data"`
 }
 type musicList struct {
@@ -70,6 +68,7 @@ type AlbumDetail struct {
 
 type Album struct {
 	Name        string `json:"name"`
+	ID          int64  `json:"id"`
 	IDStr       string `json:"idStr"`
 	Type        string `json:"type"`
 	PicURL      string `json:"picUrl"`
@@ -78,39 +77,66 @@ type Album struct {
 		Name string `json:"name"`
 	} `json:"artist"`
 }
+
+// AlbumID 专辑 ID, 搜索结果带有 idStr, 歌手的专辑列表只有数字 id
+func (album *Album) AlbumID() string {
+	if album.IDStr != "" {
+		return album.IDStr
+	}
+	return strconv.FormatInt(album.ID, 10)
+}
+
 type searchPlaylistResult struct {
 	Result struct {
-		SearchQcReminder interface{} `json:"searchQcReminder"`
-		Playlists        []struct {
-			ID          int64  `json:"id"`
-			Name        string `json:"name"`
-			CoverImgURL string `json:"coverImgUrl"`
-			Creator     struct {
-				Nickname   string      `json:"nickname"`
-				UserID     int         `json:"userId"`
-				UserType   int         `json:"userType"`
-				AvatarURL  interface{} `json:"avatarUrl"`
-				AuthStatus int         `json:"authStatus"`
-				ExpertTags interface{} `json:"expertTags"`
-				Experts    interface{} `json:"experts"`
-			} `json:"creator"`
-			Subscribed    bool        `json:"subscribed"`
-			TrackCount    int         `json:"trackCount"`
-			UserID        int         `json:"userId"`
-			PlayCount     int         `json:"playCount"`
-			BookCount     int         `json:"bookCount"`
-			SpecialType   int         `json:"specialType"`
-			OfficialTags  interface{} `json:"officialTags"`
-			Action        interface{} `json:"action"`
-			ActionType    interface{} `json:"actionType"`
-			RecommendText interface{} `json:"recommendText"`
-			Score         interface{} `json:"score"`
-			Description   string      `json:"description"`
-			HighQuality   bool        `json:"highQuality"`
-		} `json:"playlists"`
-		PlaylistCount int `json:"playlistCount"`
+		Playlists     []*PlaylistBrief `json:"playlists"`
+		PlaylistCount int              `json:"playlistCount"`
 	} `json:"result"`
 }
+
+// PlaylistBrief 搜索到的歌单
+type PlaylistBrief struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	CoverImgURL string `json:"coverImgUrl"`
+	Creator     struct {
+		Nickname string `json:"nickname"`
+	} `json:"creator"`
+	TrackCount  int    `json:"trackCount"`
+	PlayCount   int    `json:"playCount"`
+	Description string `json:"description"`
+}
+
+type playlistTracks struct {
+	Songs []Song `json:"songs"`
+}
+
+// Artist 歌手
+type Artist struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	PicURL    string   `json:"picUrl"`
+	Alias     []string `json:"alias"`
+	AlbumSize int      `json:"albumSize"`
+	MusicSize int      `json:"musicSize"`
+	BriefDesc string   `json:"briefDesc"`
+}
+
+type searchArtistResult struct {
+	Result struct {
+		Artists []*Artist `json:"artists"`
+	} `json:"result"`
+}
+
+// ArtistDetail 歌手详情: 简介、热门歌曲与最近的专辑
+type ArtistDetail struct {
+	Artist   Artist   `json:"artist"`
+	HotSongs []Song   `json:"hotSongs"`
+	Albums   []*Album `json:"-"`
+}
+
+type artistAlbums struct {
+	HotAlbums []*Album `json:"hotAlbums"`
+}
 type Playlist struct {
 	Result struct {
 		SearchQcReminder interface{} `json:"searchQcReminder"`
@@ -0,0 +1,230 @@
+package neteaseapi
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/musicapi"
+)
+
+// ProviderName 网易云音乐源的名称
+const ProviderName = "netease"
+
+// Provider 以 musicapi.Provider 封装网易云 API
+type Provider struct {
+	c *NetEaseContext
+}
+
+var (
+	_ musicapi.Provider          = (*Provider)(nil)
+	_ musicapi.PlaylistProvider  = (*Provider)(nil)
+	_ musicapi.RecommendProvider = (*Provider)(nil)
+)
+
+// Name 实现 musicapi.Provider
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+func fromSearchItem(item *SearchMusicItem) *musicapi.Song {
+	return &musicapi.Song{ID: item.ID, Name: item.Name, Artist: item.ArtistName, PicURL: item.PicURL, URL: item.SongURL, ImageKey: item.ImageKey}
+}
+
+func fromSearchItems(items []*SearchMusicItem, limit int) []*musicapi.Song {
+	songs := make([]*musicapi.Song, 0, len(items))
+	for _, item := range items {
+		if limit > 0 && len(songs) >= limit {
+			break
+		}
+		songs = append(songs, fromSearchItem(item))
+	}
+	return songs
+}
+
+func fromSong(song *Song) *musicapi.Song {
+	return &musicapi.Song{ID: strconv.Itoa(song.ID), Name: song.Name, Artist: genArtistName(song), PicURL: song.Al.PicURL}
+}
+
+func fromAlbum(album *Album) *musicapi.Album {
+	a := &musicapi.Album{ID: album.AlbumID(), Name: album.Name, Artist: album.Artist.Name, Type: album.Type, PicURL: album.PicURL}
+	if album.PublishTime > 0 {
+		a.PublishTime = time.UnixMilli(album.PublishTime)
+	}
+	return a
+}
+
+func fromArtist(artist *Artist) *musicapi.Artist {
+	return &musicapi.Artist{
+		ID:        strconv.FormatInt(artist.ID, 10),
+		Name:      artist.Name,
+		Alias:     artist.Alias,
+		PicURL:    artist.PicURL,
+		Brief:     artist.BriefDesc,
+		MusicSize: artist.MusicSize,
+		AlbumSize: artist.AlbumSize,
+	}
+}
+
+// SearchSongs 实现 musicapi.Provider
+func (p *Provider) SearchSongs(ctx context.Context, keywords string, limit int) ([]*musicapi.Song, error) {
+	items, err := p.c.SearchMusicByKeyWord(ctx, keywords)
+	if err != nil {
+		return nil, err
+	}
+	return fromSearchItems(items, limit), nil
+}
+
+// SearchAlbums 实现 musicapi.Provider
+func (p *Provider) SearchAlbums(ctx context.Context, keywords string, limit int) ([]*musicapi.Album, error) {
+	albums, err := p.c.SearchAlbumByKeyWord(ctx, keywords)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*musicapi.Album, 0, len(albums))
+	for _, album := range albums[:min(limit, len(albums))] {
+		res = append(res, fromAlbum(album))
+	}
+	return res, nil
+}
+
+// SearchArtists 实现 musicapi.Provider
+func (p *Provider) SearchArtists(ctx context.Context, keywords string, limit int) ([]*musicapi.Artist, error) {
+	artists, err := p.c.SearchArtistByKeyWord(ctx, keywords)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*musicapi.Artist, 0, len(artists))
+	for _, artist := range artists[:min(limit, len(artists))] {
+		res = append(res, fromArtist(artist))
+	}
+	return res, nil
+}
+
+// SongURLs 实现 musicapi.Provider
+func (p *Provider) SongURLs(ctx context.Context, songIDs ...string) (map[string]string, error) {
+	return p.c.GetMusicURLByIDs(ctx, songIDs)
+}
+
+// Lyrics 实现 musicapi.Provider
+func (p *Provider) Lyrics(ctx context.Context, songID string) (*musicapi.Lyrics, error) {
+	lyrics, _, err := p.c.fetchLyrics(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	return &musicapi.Lyrics{Lrc: lyrics.Lrc.Lyric, Translation: lyrics.Tlyric.Lyric}, nil
+}
+
+// HotComment 实现 musicapi.Provider, 歌手没有评论
+func (p *Provider) HotComment(ctx context.Context, kind musicapi.Kind, id string) (*musicapi.Comment, error) {
+	commentType := map[musicapi.Kind]CommentType{
+		musicapi.KindSong:     CommentTypeSong,
+		musicapi.KindAlbum:    CommentTypeAlbum,
+		musicapi.KindPlaylist: CommentTypePlaylist,
+	}
+	t, ok := commentType[kind]
+	if !ok {
+		return nil, nil
+	}
+	res, err := p.c.GetComment(ctx, t, id)
+	if err != nil || res == nil || len(res.Data.Comments) == 0 {
+		return nil, err
+	}
+	return &musicapi.Comment{Content: res.Data.Comments[0].Content, Time: res.Data.Comments[0].TimeStr}, nil
+}
+
+// SongDetail 实现 musicapi.Provider
+func (p *Provider) SongDetail(ctx context.Context, songID string) (*musicapi.Song, error) {
+	detail := p.c.GetDetail(ctx, songID)
+	if detail == nil || len(detail.Songs) == 0 {
+		return nil, musicapi.ErrNotFound
+	}
+	song := detail.Songs[0]
+	artists := make([]string, 0, len(song.Ar))
+	for _, ar := range song.Ar {
+		if ar.Name != "" {
+			artists = append(artists, ar.Name)
+		}
+	}
+	return &musicapi.Song{ID: songID, Name: song.Name, Artist: strings.Join(artists, ", "), PicURL: song.Al.PicURL}, nil
+}
+
+// AlbumSongs 实现 musicapi.Provider
+func (p *Provider) AlbumSongs(ctx context.Context, albumID string) ([]*musicapi.Song, error) {
+	album, err := p.c.GetAlbumDetail(ctx, albumID)
+	if err != nil {
+		return nil, err
+	}
+	songs := make([]*musicapi.Song, 0, len(album.Songs))
+	for i := range album.Songs {
+		songs = append(songs, fromSong(&album.Songs[i]))
+	}
+	return songs, nil
+}
+
+// ArtistDetail 实现 musicapi.Provider
+func (p *Provider) ArtistDetail(ctx context.Context, artistID string, albumLimit int) (*musicapi.ArtistDetail, error) {
+	detail, err := p.c.GetArtistDetail(ctx, artistID, albumLimit)
+	if err != nil {
+		return nil, err
+	}
+	if detail.Artist.Name == "" {
+		return nil, musicapi.ErrNotFound
+	}
+	res := &musicapi.ArtistDetail{Artist: fromArtist(&detail.Artist)}
+	for i := range detail.HotSongs {
+		res.HotSongs = append(res.HotSongs, fromSong(&detail.HotSongs[i]))
+	}
+	for _, album := range detail.Albums {
+		res.Albums = append(res.Albums, fromAlbum(album))
+	}
+	return res, nil
+}
+
+// SearchPlaylists 实现 musicapi.PlaylistProvider
+func (p *Provider) SearchPlaylists(ctx context.Context, keywords string, limit int) ([]*musicapi.Playlist, error) {
+	playlists, err := p.c.SearchPlaylistByKeyWord(ctx, keywords)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*musicapi.Playlist, 0, len(playlists))
+	for _, playlist := range playlists[:min(limit, len(playlists))] {
+		res = append(res, &musicapi.Playlist{
+			ID:         strconv.FormatInt(playlist.ID, 10),
+			Name:       playlist.Name,
+			Creator:    playlist.Creator.Nickname,
+			PicURL:     playlist.CoverImgURL,
+			TrackCount: playlist.TrackCount,
+			PlayCount:  playlist.PlayCount,
+		})
+	}
+	return res, nil
+}
+
+// PlaylistSongs 实现 musicapi.PlaylistProvider, 结果带有播放链接
+func (p *Provider) PlaylistSongs(ctx context.Context, playlistID string, limit int) ([]*musicapi.Song, error) {
+	items, err := p.c.GetPlaylistTracks(ctx, playlistID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fromSearchItems(items, limit), nil
+}
+
+// DailySongs 实现 musicapi.RecommendProvider, 结果带有播放链接
+func (p *Provider) DailySongs(ctx context.Context, limit int) ([]*musicapi.Song, error) {
+	items, err := p.c.GetDailyRecommend(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fromSearchItems(items, limit), nil
+}
+
+// NewSongs 实现 musicapi.RecommendProvider, 结果带有播放链接
+func (p *Provider) NewSongs(ctx context.Context, limit int) ([]*musicapi.Song, error) {
+	items, err := p.c.GetNewRecommendMusic(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fromSearchItems(items, limit), nil
+}
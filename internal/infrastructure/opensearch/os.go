@@ -95,3 +95,43 @@ func SearchDataStr(ctx context.Context, index string, data string) (resp *opense
 	resp, err = Client().Search(ctx, req)
 	return resp, err
 }
+
+// DeleteByQuery 按查询条件删除文档
+//
+//	@param ctx context.Context
+//	@param index string
+//	@param data any 查询体, 如 {"query": {...}}
+//	@return deleted int 删除的文档数
+//	@return err error
+func DeleteByQuery(ctx context.Context, index string, data any) (deleted int, err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	resp, err := Client().Document.DeleteByQuery(ctx, opensearchapi.DocumentDeleteByQueryReq{
+		Indices: []string{index},
+		Body:    opensearchutil.NewJSONReader(data),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Deleted, nil
+}
+
+// PutIndexTemplate 创建或覆盖索引模板, 之后新建的匹配索引使用模板中的设置与映射
+//
+//	@param ctx context.Context
+//	@param name string 模板名
+//	@param data any 模板体, 如 {"index_patterns": [...], "template": {...}}
+//	@return err error
+func PutIndexTemplate(ctx context.Context, name string, data any) (err error) {
+	ctx, span := otel.T().Start(ctx, reflecting.GetCurrentFunc())
+	defer span.End()
+	defer func() { span.RecordError(err) }()
+
+	_, err = Client().IndexTemplate.Create(ctx, opensearchapi.IndexTemplateCreateReq{
+		IndexTemplate: name,
+		Body:          opensearchutil.NewJSONReader(data),
+	})
+	return err
+}
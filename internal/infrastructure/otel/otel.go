@@ -2,13 +2,19 @@ package otel
 
 import (
 	"context"
+	"errors"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/sdk/log"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
@@ -29,6 +35,8 @@ const (
 var (
 	tracerProvider trace.TracerProvider
 	loggerProvider *log.LoggerProvider
+	meterProvider  metric.MeterProvider = metricnoop.NewMeterProvider()
+	meter          metric.Meter         = meterProvider.Meter("")
 )
 
 func OtelProvider() trace.TracerProvider {
@@ -43,21 +51,51 @@ func T() trace.Tracer {
 	return OtelTracer
 }
 
+// M 获取全局 Meter, 未初始化时为 noop
+func M() metric.Meter {
+	return meter
+}
+
 func Init(config *config.OtelConfig) {
 	if config != nil {
 		tracerProvider, _ = newTracerProvider(config)
 		loggerProvider, _ = newLoggerProvider(config)
+		if mp, err := newMeterProvider(config); err == nil {
+			meterProvider = mp
+		}
 		tracerName := config.TracerName
 		OtelTracer = tracerProvider.Tracer(tracerName)
+		meter = meterProvider.Meter(tracerName)
 	} else {
 		tracerProvider = noop.NewTracerProvider()
 		loggerProvider = log.NewLoggerProvider()
+		OtelTracer = tracerProvider.Tracer("")
+	}
+	xlifecycle.Register("otel", xlifecycle.PhaseInfra, nil, Shutdown)
+}
+
+// Shutdown 刷新并关闭 trace/log exporter, 未发送的数据会在此时发出
+//
+//	@param ctx context.Context
+//	@return error
+func Shutdown(ctx context.Context) error {
+	var errs []error
+	if tp, ok := tracerProvider.(*tracesdk.TracerProvider); ok {
+		errs = append(errs, tp.Shutdown(ctx))
+	}
+	if loggerProvider != nil {
+		errs = append(errs, loggerProvider.Shutdown(ctx))
 	}
+	if mp, ok := meterProvider.(*metricsdk.MeterProvider); ok {
+		errs = append(errs, mp.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
 }
 
 // BetaGoOtelTracer a
 var (
-	OtelTracer trace.Tracer
+	// OtelTracer 未 Init 时为 noop, 方便单测直接调用
+	OtelTracer trace.Tracer = noop.NewTracerProvider().Tracer("")
 )
 
 func newTracerProvider(config *config.OtelConfig) (*tracesdk.TracerProvider, error) {
@@ -105,3 +143,17 @@ func newLoggerProvider(config *config.OtelConfig) (*log.LoggerProvider, error) {
 		log.WithProcessor(processor),
 	), nil
 }
+
+func newMeterProvider(config *config.OtelConfig) (*metricsdk.MeterProvider, error) {
+	ctx := context.Background()
+	exporter, err := otlpmetricgrpc.New(
+		ctx, otlpmetricgrpc.WithEndpoint(config.CollectorEndpoint), otlpmetricgrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return metricsdk.NewMeterProvider(
+		metricsdk.WithResource(newResource(config)),
+		metricsdk.WithReader(metricsdk.NewPeriodicReader(exporter)),
+	), nil
+}
@@ -0,0 +1,206 @@
+package vadvisor
+
+import (
+	"context"
+	"math"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/go_utils/reflecting"
+	"github.com/bytedance/sonic"
+)
+
+// K线的涨跌分组, 按 A 股习惯红涨绿跌
+const (
+	CandleUp   = "涨"
+	CandleDown = "跌"
+)
+
+// volumeAxisScale 成交量轴的上限是最大成交量的倍数, 使成交量柱只占图表底部约 1/4
+const volumeAxisScale = 4
+
+// CandlestickGraph K线图, 与成交量柱共用横轴
+//
+//	K线用箱线图系列绘制: 上下须为最高、最低价, 箱体为开盘、收盘价
+type CandlestickGraph[X comparable] struct {
+	context.Context `json:"-"`
+	Type            string           `json:"type"`
+	Title           *TitleConf       `json:"title,omitempty"`
+	Data            []*CandleData[X] `json:"data"`
+	Series          []*CandleSeries  `json:"series"`
+	Axes            []*CandleAxis    `json:"axes"`
+	Color           *OrdinalColor    `json:"color"`
+	Legends         *LegentConf      `json:"legends,omitempty"`
+	DataZoom        []*ZoomConf      `json:"dataZoom,omitempty"`
+	Tooltip         *TooltipConf     `json:"tooltip,omitempty"`
+
+	priceMin, priceMax, volumeMax float64 `json:"-"`
+}
+
+type CandleData[X comparable] struct {
+	ID     string            `json:"id"`
+	Values []*CandleValue[X] `json:"values"`
+}
+
+// CandleValue 一根K线, BodyLow/BodyHigh 为开盘与收盘中的较小、较大值
+type CandleValue[X comparable] struct {
+	X        X       `json:"x"`
+	Open     float64 `json:"open"`
+	Close    float64 `json:"close"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	BodyLow  float64 `json:"bodyLow"`
+	BodyHigh float64 `json:"bodyHigh"`
+	Volume   float64 `json:"volume"`
+	Trend    string  `json:"trend"`
+}
+
+type CandleSeries struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	DataID      string `json:"dataId"`
+	XField      string `json:"xField"`
+	YField      string `json:"yField,omitempty"`
+	MinField    string `json:"minField,omitempty"`
+	MaxField    string `json:"maxField,omitempty"`
+	Q1Field     string `json:"q1Field,omitempty"`
+	Q3Field     string `json:"q3Field,omitempty"`
+	MedianField string `json:"medianField,omitempty"`
+	SeriesField string `json:"seriesField"`
+	BarMaxWidth int    `json:"barMaxWidth,omitempty"`
+}
+
+type CandleAxis struct {
+	Orient   string     `json:"orient"`
+	Type     string     `json:"type,omitempty"`
+	SeriesID []string   `json:"seriesId,omitempty"`
+	Zero     *bool      `json:"zero,omitempty"`
+	Min      *float64   `json:"min,omitempty"`
+	Max      *float64   `json:"max,omitempty"`
+	Label    *AxeLabel  `json:"label,omitempty"`
+	Grid     *VisibleOp `json:"grid,omitempty"`
+	Visible  *bool      `json:"visible,omitempty"`
+}
+
+type VisibleOp struct {
+	Visible bool `json:"visible"`
+}
+
+type OrdinalColor struct {
+	Type   string   `json:"type"`
+	Domain []string `json:"domain"`
+	Range  []string `json:"range"`
+}
+
+type TooltipConf struct {
+	Visible bool `json:"visible"`
+}
+
+const (
+	candleSeriesID = "candle"
+	volumeSeriesID = "volume"
+	candleDataID   = "kline"
+)
+
+// NewCandlestickGraph 新建K线图
+//
+//	@param ctx context.Context
+//	@return *CandlestickGraph[X]
+func NewCandlestickGraph[X comparable](ctx context.Context) *CandlestickGraph[X] {
+	g := &CandlestickGraph[X]{
+		Context: ctx,
+		Type:    "common",
+		Title:   &TitleConf{},
+		Data:    []*CandleData[X]{{ID: candleDataID, Values: make([]*CandleValue[X], 0)}},
+		Series: []*CandleSeries{
+			{
+				Type: "boxPlot", ID: candleSeriesID, DataID: candleDataID, XField: "x",
+				MinField: "low", MaxField: "high", Q1Field: "bodyLow", Q3Field: "bodyHigh", MedianField: "close",
+				SeriesField: "trend",
+			},
+		},
+		Color: &OrdinalColor{
+			Type:   "ordinal",
+			Domain: []string{CandleUp, CandleDown},
+			Range:  []string{"#EC4A4A", "#2FB36C"},
+		},
+		Legends:  &LegentConf{Type: "discrete", Visible: false, Orient: "top"},
+		DataZoom: []*ZoomConf{{Orient: "bottom"}},
+		Tooltip:  &TooltipConf{Visible: true},
+		priceMin: math.Inf(1),
+		priceMax: math.Inf(-1),
+	}
+	g.build()
+	return g
+}
+
+func (g *CandlestickGraph[X]) SetTitle(title string) *CandlestickGraph[X] {
+	g.Title.Text = title
+	return g
+}
+
+// AddCandle 追加一根K线, 需按横轴顺序调用; 收盘不低于开盘为涨
+//
+//	@receiver g *CandlestickGraph[X]
+//	@param x X
+//	@param open float64
+//	@param close float64
+//	@param high float64
+//	@param low float64
+//	@param volume float64 没有成交量时为 0
+//	@return *CandlestickGraph[X]
+func (g *CandlestickGraph[X]) AddCandle(x X, open, close, high, low, volume float64) *CandlestickGraph[X] {
+	v := &CandleValue[X]{
+		X: x, Open: open, Close: close, High: high, Low: low, Volume: volume,
+		BodyLow: min(open, close), BodyHigh: max(open, close), Trend: CandleUp,
+	}
+	if close < open {
+		v.Trend = CandleDown
+	}
+	g.Data[0].Values = append(g.Data[0].Values, v)
+	g.priceMin, g.priceMax = min(g.priceMin, low), max(g.priceMax, high)
+	g.volumeMax = max(g.volumeMax, volume)
+	g.build()
+	return g
+}
+
+// Len K线的根数
+func (g *CandlestickGraph[X]) Len() int {
+	return len(g.Data[0].Values)
+}
+
+// build 按已添加的数据重新生成坐标轴, 有成交量时加上成交量柱
+func (g *CandlestickGraph[X]) build() {
+	g.Series = g.Series[:1]
+	label := &AxeLabel{AutoHide: true, AutoLimit: true}
+	g.Axes = []*CandleAxis{
+		{Orient: "bottom", Type: "band", Label: label},
+	}
+	priceAxis := &CandleAxis{Orient: "left", Type: "linear", SeriesID: []string{candleSeriesID}, Zero: new(bool), Label: label}
+	if g.Len() > 0 {
+		// 价格轴上下各留出 5% 的空白
+		pad := (g.priceMax - g.priceMin) * 0.05
+		lo, hi := g.priceMin-pad, g.priceMax+pad
+		priceAxis.Min, priceAxis.Max = &lo, &hi
+	}
+	g.Axes = append(g.Axes, priceAxis)
+	if g.volumeMax > 0 {
+		g.Series = append(g.Series, &CandleSeries{
+			Type: "bar", ID: volumeSeriesID, DataID: candleDataID, XField: "x", YField: "volume",
+			SeriesField: "trend", BarMaxWidth: 8,
+		})
+		hi, hidden := g.volumeMax*volumeAxisScale, false
+		g.Axes = append(g.Axes, &CandleAxis{
+			Orient: "right", Type: "linear", SeriesID: []string{volumeSeriesID}, Max: &hi,
+			Label: label, Grid: &VisibleOp{Visible: false}, Visible: &hidden,
+		})
+	}
+}
+
+// String 序列化为 VChart 的 spec
+func (g *CandlestickGraph[X]) String() string {
+	_, span := otel.T().Start(g, reflecting.GetCurrentFunc())
+	defer span.End()
+
+	s, _ := sonic.MarshalString(g)
+	return s
+}
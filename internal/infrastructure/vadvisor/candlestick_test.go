@@ -0,0 +1,59 @@
+package vadvisor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestCandlestickGraphSpec(t *testing.T) {
+	var spec struct {
+		Data []struct {
+			Values []struct {
+				BodyLow  float64 `json:"bodyLow"`
+				BodyHigh float64 `json:"bodyHigh"`
+				Trend    string  `json:"trend"`
+			} `json:"values"`
+		} `json:"data"`
+		Series []struct {
+			Type string `json:"type"`
+		} `json:"series"`
+		Axes []struct {
+			Orient string   `json:"orient"`
+			Min    *float64 `json:"min"`
+			Max    *float64 `json:"max"`
+		} `json:"axes"`
+	}
+
+	g := NewCandlestickGraph[string](context.Background()).
+		AddCandle("2025-05-22", 10, 12, 13, 9, 100).
+		AddCandle("2025-05-23", 12, 11, 12.5, 10, 300)
+	if err := sonic.UnmarshalString(g.String(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	values := spec.Data[0].Values
+	if len(values) != 2 || values[0].Trend != CandleUp || values[1].Trend != CandleDown {
+		t.Fatalf("values = %+v", values)
+	}
+	if values[1].BodyLow != 11 || values[1].BodyHigh != 12 {
+		t.Errorf("down candle body = [%v, %v], want [11, 12]", values[1].BodyLow, values[1].BodyHigh)
+	}
+	if len(spec.Series) != 2 || spec.Series[0].Type != "boxPlot" || spec.Series[1].Type != "bar" {
+		t.Fatalf("series = %+v", spec.Series)
+	}
+	// 价格轴在最低 9、最高 13 上下各留 5%, 成交量轴为最大成交量的 4 倍
+	if len(spec.Axes) != 3 || *spec.Axes[1].Min != 8.8 || *spec.Axes[1].Max != 13.2 || *spec.Axes[2].Max != 1200 {
+		t.Fatalf("axes = %+v", spec.Axes)
+	}
+
+	// 没有成交量时不画成交量柱
+	g = NewCandlestickGraph[string](context.Background()).AddCandle("2025-05-23", 7.1, 7.2, 7.2, 7.1, 0)
+	spec.Series, spec.Axes = nil, nil
+	if err := sonic.UnmarshalString(g.String(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Series) != 1 || len(spec.Axes) != 2 {
+		t.Fatalf("series = %+v, axes = %+v", spec.Series, spec.Axes)
+	}
+}
@@ -0,0 +1,106 @@
+package lark
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const (
+	// dedupTTL 飞书的重推一般在数小时内, 留足余量
+	dedupTTL       = 12 * time.Hour
+	dedupKeyPrefix = "dedup:"
+
+	dedupKindMessage  = "message"
+	dedupKindReaction = "reaction"
+	dedupKindCard     = "card_action"
+)
+
+var dedupDroppedCounter = sync.OnceValue(func() metric.Int64Counter {
+	counter, _ := otel.M().Int64Counter(
+		"lark_event_duplicate_dropped",
+		metric.WithDescription("duplicated lark events dropped by the dedup layer"),
+	)
+	return counter
+})
+
+// eventID 取事件头中的 event_id
+func eventID(base *larkevent.EventV2Base) string {
+	if base == nil || base.Header == nil {
+		return ""
+	}
+	return base.Header.EventID
+}
+
+// claimEvent 以 Redis SETNX 标记事件, 任一 id 已出现过即认为是重复投递, 并撤销本次已设置的其余标记
+//
+//	Redis 不可用时放行, 宁可重复也不丢事件
+//	@param ctx context.Context
+//	@param kind string 事件类型, 用于 key 隔离与指标
+//	@param ids ...string 事件ID、消息ID等, 空值忽略
+//	@return duplicated bool
+//	@return release func() 撤销本次设置的标记, 事件未能处理时调用, 飞书重推时可再次处理
+func claimEvent(ctx context.Context, kind string, ids ...string) (duplicated bool, release func()) {
+	rdb := redis_dal.GetRedisClient()
+	claimed := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		key := dedupKeyPrefix + kind + ":" + id
+		set, err := rdb.SetNX(ctx, key, 1, dedupTTL).Result()
+		if err != nil {
+			logs.L().Ctx(ctx).Warn("dedup check failed, let it pass", zap.String("kind", kind), zap.String("id", id), zap.Error(err))
+			continue
+		}
+		if !set {
+			duplicated = true
+			continue
+		}
+		claimed = append(claimed, key)
+	}
+	release = func() {
+		if len(claimed) == 0 {
+			return
+		}
+		if err := rdb.Del(context.WithoutCancel(ctx), claimed...).Err(); err != nil {
+			logs.L().Ctx(ctx).Warn("release dedup keys failed", zap.String("kind", kind), zap.Strings("keys", claimed), zap.Error(err))
+		}
+	}
+	if duplicated {
+		// 其余 id 的标记是本次刚设置的, 事件被丢弃时一并撤销
+		release()
+		dedupDroppedCounter().Add(ctx, 1, metric.WithAttributes(attribute.String("kind", kind)))
+		logs.L().Ctx(ctx).Info("duplicated event dropped", zap.String("kind", kind), zap.String("ids", strings.Join(ids, ",")))
+		return true, func() {}
+	}
+	return false, release
+}
+
+// submitOnce 去重后提交事件; 提交失败(如任务池已满)时撤销去重标记并返回错误, 飞书重推的同一事件仍会被处理
+//
+//	@param ctx context.Context
+//	@param kind string
+//	@param ids []string
+//	@param submit func() error
+//	@return error 重复投递时返回 nil
+func submitOnce(ctx context.Context, kind string, ids []string, submit func() error) error {
+	duplicated, release := claimEvent(ctx, kind, ids...)
+	if duplicated {
+		return nil
+	}
+	if err := submit(); err != nil {
+		release()
+		return err
+	}
+	return nil
+}
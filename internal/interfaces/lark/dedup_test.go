@@ -0,0 +1,68 @@
+package lark
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redis_dal "github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/redis"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xpool"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSubmitOnceRetriesRejectedEvent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	prev := redis_dal.RedisClient
+	redis_dal.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redis_dal.RedisClient = prev })
+	ctx := context.Background()
+
+	// 占满任务池: 唯一的队列位被阻塞的任务占用
+	pool := xpool.New("dedup_test", 1, 1, 1)
+	block := make(chan struct{})
+	if err := pool.Submit(ctx, "chat_a", func() { <-block }); err != nil {
+		t.Fatal(err)
+	}
+	processed := make(chan struct{}, 1)
+	submit := func() error {
+		return pool.Submit(ctx, "chat_a", func() { processed <- struct{}{} })
+	}
+	ids := []string{"ev_1", "om_1"}
+
+	if err := submitOnce(ctx, dedupKindMessage, ids, submit); !errors.Is(err, xpool.ErrPoolFull) {
+		t.Fatalf("submitOnce() on a full pool = %v, want ErrPoolFull", err)
+	}
+	if mr.Exists(dedupKeyPrefix+dedupKindMessage+":ev_1") || mr.Exists(dedupKeyPrefix+dedupKindMessage+":om_1") {
+		t.Fatal("rejected event should not stay marked as seen")
+	}
+
+	// 任务池空出后, 飞书重推的同一事件被处理
+	close(block)
+	deadline := time.Now().Add(time.Second)
+	for pool.Depth() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := submitOnce(ctx, dedupKindMessage, ids, submit); err != nil {
+		t.Fatalf("submitOnce() retry = %v", err)
+	}
+	select {
+	case <-processed:
+	case <-time.After(time.Second):
+		t.Fatal("retried event was not processed")
+	}
+
+	// 处理过的事件再次投递时丢弃
+	called := false
+	if err := submitOnce(ctx, dedupKindMessage, ids, func() error { called = true; return nil }); err != nil || called {
+		t.Errorf("submitOnce() duplicate = %v, submit called %v", err, called)
+	}
+	// 新的事件ID携带已处理过的消息ID: 丢弃, 且不留下新事件ID的标记
+	if err := submitOnce(ctx, dedupKindMessage, []string{"ev_2", "om_1"}, func() error { called = true; return nil }); err != nil || called {
+		t.Errorf("submitOnce() re-pushed message = %v, submit called %v", err, called)
+	}
+	if mr.Exists(dedupKeyPrefix + dedupKindMessage + ":ev_2") {
+		t.Error("dropped event should not keep the keys it just claimed")
+	}
+}
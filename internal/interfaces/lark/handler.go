@@ -3,8 +3,10 @@ package lark
 import (
 	"context"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/cardaction"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/application/lark/messages"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/lark_dal/larkmsg"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/utils"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
 
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
@@ -22,10 +25,12 @@ import (
 	"go.uber.org/zap"
 )
 
-func isOutDated(createTime string) bool {
+// isOutDated 判断消息是否过旧, CreateTime 无法解析时不做过滤, 交由去重层兜底
+func isOutDated(ctx context.Context, createTime string) bool {
 	stamp, err := strconv.ParseInt(createTime, 10, 64)
 	if err != nil {
-		panic(err)
+		logs.L().Ctx(ctx).Warn("malformed create time", zap.String("create_time", createTime), zap.Error(err))
+		return false
 	}
 	return time.Now().Sub(time.UnixMilli(stamp)) > time.Second*10
 }
@@ -37,29 +42,62 @@ func MessageV2Handler(ctx context.Context, event *larkim.P2MessageReceiveV1) (er
 	span.SetAttributes(attribute.Key("event").String(larkcore.Prettify(event)))
 	defer func() { span.RecordError(err) }()
 
-	if isOutDated(*event.Event.Message.CreateTime) {
+	if isOutDated(ctx, utils.AddrOrNil(event.Event.Message.CreateTime)) {
 		return nil
 	}
 	if *event.Event.Sender.SenderId.OpenId == config.Get().LarkConfig.BotOpenID {
 		return nil
 	}
+	if !xlifecycle.Accepting() {
+		return xlifecycle.ErrShuttingDown
+	}
 	logs.L().Ctx(ctx).Info("Inside the child span for complex handler", zap.String("event", larkcore.Prettify(event)))
-	go func() {
-		subCtx, span := otel.T().Start(context.Background(), fn+"_RealRun")
-		defer span.End()
-		span.SetAttributes(attribute.String("msgID", utils.AddrOrNil(event.Event.Message.MessageId)))
-		messages.Handler.Clean().WithCtx(subCtx).WithData(event).Run()
-	}()
+	ids := []string{eventID(event.EventV2Base), utils.AddrOrNil(event.Event.Message.MessageId)}
+	err = submitOnce(ctx, dedupKindMessage, ids, func() error {
+		return messages.Submit(ctx, utils.AddrOrNil(event.Event.Message.ChatId), func() {
+			subCtx, span := otel.T().Start(context.Background(), fn+"_RealRun")
+			defer span.End()
+			span.SetAttributes(attribute.String("msgID", utils.AddrOrNil(event.Event.Message.MessageId)))
+			messages.Handler.Run(subCtx, event)
+		})
+	})
+	if err != nil {
+		logs.L().Ctx(ctx).Warn("message rejected by worker pool", zap.Error(err))
+		return err
+	}
 
 	logs.L().Ctx(ctx).Info("Message event received", zap.String("event", larkcore.Prettify(event)))
 	return nil
 }
 
 func MessageReactionHandler(ctx context.Context, event *larkim.P2MessageReactionCreatedV1) (err error) {
+	reactionKey := ""
+	if data := event.Event; data != nil && data.ReactionType != nil && data.UserId != nil {
+		// 同一用户对同一消息在同一时刻的同一表情视为同一次操作
+		reactionKey = strings.Join([]string{
+			utils.AddrOrNil(data.MessageId),
+			utils.AddrOrNil(data.ReactionType.EmojiType),
+			utils.AddrOrNil(data.UserId.OpenId),
+			utils.AddrOrNil(data.ActionTime),
+		}, ":")
+	}
+	// 表情回应只做去重, 之后没有会失败的处理, 无需撤销标记
+	claimEvent(ctx, dedupKindReaction, eventID(event.EventV2Base), reactionKey)
 	return
 }
 
 func CardActionHandler(ctx context.Context, cardAction *callback.CardActionTriggerEvent) (resp *callback.CardActionTriggerResponse, err error) {
+	token := ""
+	if cardAction.Event != nil {
+		token = cardAction.Event.Token
+	}
+	duplicated, release := claimEvent(ctx, dedupKindCard, eventID(cardAction.EventV2Base), token)
+	if duplicated {
+		return
+	}
+	if resp, err = cardaction.Dispatch(ctx, cardAction); err != nil {
+		release()
+	}
 	return
 }
 
@@ -0,0 +1,175 @@
+package lark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xlifecycle"
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	"github.com/larksuite/oapi-sdk-go/v3/core/httpserverext"
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
+	larkws "github.com/larksuite/oapi-sdk-go/v3/ws"
+	"go.uber.org/zap"
+)
+
+// NewEventDispatcher 构建事件分发器, ws 和 http 两种接入方式共用
+//
+//	@param conf *config.LarkConfig
+//	@return *dispatcher.EventDispatcher
+func NewEventDispatcher(conf *config.LarkConfig) *dispatcher.EventDispatcher {
+	return dispatcher.
+		NewEventDispatcher(conf.Verification, conf.Encryption).
+		OnP2MessageReactionCreatedV1(MessageReactionHandler).
+		OnP2MessageReceiveV1(MessageV2Handler).
+		OnP2ApplicationAppVersionAuditV6(AuditV6Handler).
+		OnP2CardActionTrigger(CardActionHandler)
+}
+
+// wsStartWait 等待长连接首次建连失败的时长, 超过后视为已连上
+const wsStartWait = 5 * time.Second
+
+// WSClient 以长连接方式接收事件和卡片回调
+//
+//	SDK 的客户端没有断开接口, 连接随进程退出关闭; 拒收的事件由飞书重推
+type WSClient struct {
+	cli  *larkws.Client
+	errc chan error
+}
+
+// NewWSClient 构建长连接客户端
+//
+//	@param conf *config.LarkConfig
+//	@param eventHandler *dispatcher.EventDispatcher
+//	@return *WSClient
+func NewWSClient(conf *config.LarkConfig, eventHandler *dispatcher.EventDispatcher) *WSClient {
+	return &WSClient{
+		cli: larkws.NewClient(conf.AppID, conf.AppSecret,
+			larkws.WithEventHandler(eventHandler),
+			larkws.WithLogLevel(larkcore.LogLevelInfo),
+		),
+		errc: make(chan error, 1),
+	}
+}
+
+// Start 建立长连接, 首次建连失败(如凭证错误)时返回错误; 连上后在后台运行, 客户端放弃重连时报告 xlifecycle 走正常关停
+//
+//	@receiver c *WSClient
+//	@param ctx context.Context 启动钩子的 ctx, 只取其中的值, 不随其取消
+//	@return error
+func (c *WSClient) Start(ctx context.Context) error {
+	go func() {
+		c.errc <- c.cli.Start(context.WithoutCancel(ctx))
+	}()
+	select {
+	case err := <-c.errc:
+		return fmt.Errorf("lark ws connect: %w", err)
+	case <-time.After(wsStartWait):
+		go func() {
+			xlifecycle.Fail("lark_ws", fmt.Errorf("lark ws client exited: %w", <-c.errc))
+		}()
+		return nil
+	}
+}
+
+// Stop 长连接无法主动断开, 此后新事件由各事件处理按 xlifecycle.Accepting 拒收
+//
+//	@receiver c *WSClient
+//	@param ctx context.Context
+//	@return error
+func (c *WSClient) Stop(context.Context) error {
+	logs.L().Info("lark ws intake closed, new events are rejected until exit")
+	return nil
+}
+
+// HTTPServer 以 webhook 方式接收事件和卡片回调, 同时提供健康检查
+type HTTPServer struct {
+	srv   *http.Server
+	ln    net.Listener
+	ready atomic.Bool
+}
+
+// NewHTTPServer 构建 http 事件接入服务
+//
+//	@param conf *config.LarkConfig
+//	@param eventHandler *dispatcher.EventDispatcher
+//	@return *HTTPServer
+func NewHTTPServer(conf *config.LarkConfig, eventHandler *dispatcher.EventDispatcher) *HTTPServer {
+	s := &HTTPServer{}
+	mux := http.NewServeMux()
+	handlerFunc := httpserverext.NewEventHandlerFunc(eventHandler, larkevent.WithLogLevel(larkcore.LogLevelInfo))
+	mux.HandleFunc(conf.GetEventPath(), handlerFunc)
+	if conf.GetCardPath() != conf.GetEventPath() {
+		// 新版卡片回调同样走 dispatcher 的 card.action.trigger
+		mux.HandleFunc(conf.GetCardPath(), handlerFunc)
+	}
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+
+	s.srv = &http.Server{
+		Addr:              conf.GetHTTPAddr(),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Listen 绑定监听地址, 绑定成功后 /readyz 才返回就绪
+//
+//	@receiver s *HTTPServer
+//	@return error 地址被占用等
+func (s *HTTPServer) Listen() error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+	s.ready.Store(true)
+	logs.L().Info("lark http server listening", zap.String("addr", ln.Addr().String()))
+	return nil
+}
+
+// Serve 在 Listen 绑定的地址上阻塞处理请求, 正常关闭时返回 nil
+//
+//	@receiver s *HTTPServer
+//	@return error
+func (s *HTTPServer) Serve() error {
+	err := s.srv.Serve(s.ln)
+	s.ready.Store(false)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown 停止接收新请求并等待进行中的请求完成
+//
+//	@receiver s *HTTPServer
+//	@param ctx context.Context
+//	@return error
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	s.ready.Store(false)
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *HTTPServer) healthz(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+func (s *HTTPServer) readyz(w http.ResponseWriter, _ *http.Request) {
+	if !s.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not ready"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ready"))
+}
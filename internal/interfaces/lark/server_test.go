@@ -0,0 +1,65 @@
+package lark
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
+	"github.com/larksuite/oapi-sdk-go/v3/event/dispatcher"
+)
+
+func TestHTTPServerProbes(t *testing.T) {
+	newServer := func(addr string) *HTTPServer {
+		return NewHTTPServer(&config.LarkConfig{HTTPAddr: addr}, dispatcher.NewEventDispatcher("", ""))
+	}
+	status := func(s *HTTPServer, path string) int {
+		rec := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	s := newServer("127.0.0.1:0")
+	if got := status(s, "/healthz"); got != http.StatusOK {
+		t.Errorf("healthz before listen = %d", got)
+	}
+	if got := status(s, "/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("readyz before listen = %d, want 503", got)
+	}
+	if err := s.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(s, "/readyz"); got != http.StatusOK {
+		t.Errorf("readyz after listen = %d, want 200", got)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve() }()
+	resp, err := http.Get("http://" + s.ln.Addr().String() + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /readyz = %d", resp.StatusCode)
+	}
+
+	// 端口已被占用时 Listen 失败, 不会标记就绪
+	busy := newServer(s.ln.Addr().String())
+	if err := busy.Listen(); err == nil {
+		t.Error("Listen() on a bound address should fail")
+	}
+	if got := status(busy, "/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("readyz after failed listen = %d, want 503", got)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve() after shutdown = %v", err)
+	}
+	if got := status(s, "/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("readyz after shutdown = %d, want 503", got)
+	}
+}
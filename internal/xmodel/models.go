@@ -27,6 +27,57 @@ type MessageIndex struct {
 	TokenUsage           ark_model.Usage `json:"token_usage"`
 	IsCommand            bool            `json:"is_command"`
 	MainCommand          string          `json:"main_command"`
+	ImageInsights        []*ImageInsight `json:"image_insights,omitempty"`
+}
+
+// ImageInsight 视觉模型对一张图片的理解结果
+type ImageInsight struct {
+	ImageKey string `json:"image_key"`
+	Caption  string `json:"caption"`
+	OCR      string `json:"ocr"`
+}
+
+// KnowledgePassage 群知识库中的一个段落, 来源于群里分享的链接或文件
+type KnowledgePassage struct {
+	ChatID     string    `json:"chat_id"`
+	SourceID   string    `json:"source_id"`
+	SourceType string    `json:"source_type"` // url file
+	Source     string    `json:"source"`      // 链接或文件名
+	Title      string    `json:"title"`
+	MessageID  string    `json:"message_id"`
+	UserID     string    `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	Seq        int       `json:"seq"`
+	Content    string    `json:"content"`
+	Embedding  []float32 `json:"embedding"`
+	CreateTime string    `json:"create_time"`
+}
+
+// ChatDigest 群聊日报或周报, 归档后供错过的成员回看
+type ChatDigest struct {
+	ID                  string          `json:"id"`
+	ChatID              string          `json:"chat_id"`
+	Kind                string          `json:"kind"` // daily weekly
+	StartTime           string          `json:"start_time"`
+	EndTime             string          `json:"end_time"`
+	MsgCount            int             `json:"msg_count"`
+	ChunkCount          int             `json:"chunk_count"`
+	TopTopics           []string        `json:"top_topics"`
+	TopWords            []string        `json:"top_words"`
+	ActiveMembers       []*DigestMember `json:"active_members"`
+	UnresolvedQuestions []string        `json:"unresolved_questions"`
+	Conclusions         []string        `json:"conclusions"`
+	Plans               []string        `json:"plans"`
+	Summaries           []string        `json:"summaries"`
+	Recap               string          `json:"recap"`
+	CreateTime          string          `json:"create_time"`
+}
+
+// DigestMember 报告周期内的活跃成员
+type DigestMember struct {
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name"`
+	MsgCount int    `json:"msg_count"`
 }
 
 type CardActionIndex struct {
@@ -137,4 +188,8 @@ type PromptTemplateArg struct {
 	Context        []string `json:"context" gorm:"-"`
 	Topics         []string `json:"topics" gorm:"-"`
 	UserInput      []string `json:"user_input" gorm:"-"`
+	// Persona 说话风格描述, 见 persona.Profile.Describe
+	Persona string `json:"persona" gorm:"-"`
+	// Memories 发言人与被 @ 成员的长期记忆, 见 memory.Recall
+	Memories []string `json:"memories" gorm:"-"`
 }
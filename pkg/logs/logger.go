@@ -12,7 +12,8 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var logger *ContextualLogger
+// logger 未 Init 时丢弃所有日志, 方便单测直接调用
+var logger = NewContextualLogger(zap.NewNop(), zap.NewNop())
 
 func L() *ContextualLogger {
 	return logger.Ctx(context.Background()) // 默认都要搞一个context出来
@@ -1,12 +1,15 @@
 package xchunk
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -91,6 +94,23 @@ type Chunk struct {
 type Management struct {
 	redisClient     *redis.Client
 	processingQueue chan *Chunk
+	// merging 正在执行 OnMerge 的 chunk 数量, 关停时需等待
+	merging      sync.WaitGroup
+	consumerDone chan struct{}
+	scannerDone  chan struct{}
+	// indexedHooks chunk 写入索引后依次调用
+	indexedHooks []IndexedHook
+}
+
+// IndexedHook chunk 总结并写入索引后的回调, 在合并协程中同步执行
+type IndexedHook func(ctx context.Context, chunk *xmodel.MessageChunkLogV3)
+
+// OnIndexed 注册 chunk 写入索引后的回调, 需在 StartBackgroundCleaner 之前调用
+//
+//	@receiver m *Management
+//	@param hook IndexedHook
+func (m *Management) OnIndexed(hook IndexedHook) {
+	m.indexedHooks = append(m.indexedHooks, hook)
 }
 
 type GenericMsg interface {
@@ -201,6 +221,8 @@ func (m *Management) OnMerge(ctx context.Context, chunk *Chunk) (err error) {
 	if chunk == nil || len(chunk.Messages) == 0 {
 		return nil
 	}
+	// 消息可能因异步预处理(如图片理解)晚于后续消息提交, 按时间戳恢复顺序
+	slices.SortStableFunc(chunk.Messages, func(a, b StandardMsg) int { return cmp.Compare(a.TimeStamp(), b.TimeStamp()) })
 	// 写入大模型
 	chunkLines := make([]string, len(chunk.Messages))
 	msgIDs := make([]string, len(chunk.Messages))
@@ -261,6 +283,9 @@ func (m *Management) OnMerge(ctx context.Context, chunk *Chunk) (err error) {
 		logs.L().Ctx(ctx).Error("insert chunk log error", zap.String("groupID", chunk.GroupID), zap.Error(err))
 		return
 	}
+	for _, hook := range m.indexedHooks {
+		hook(ctx, chunkLog)
+	}
 
 	return
 }
@@ -269,23 +294,35 @@ func (m *Management) OnMerge(ctx context.Context, chunk *Chunk) (err error) {
 func (m *Management) StartBackgroundCleaner(ctx context.Context) {
 	logs.L().Ctx(ctx).Info("Starting background cleaner for timed-out sessions...")
 	// Start the consumer goroutine
+	m.consumerDone = make(chan struct{})
 	go func() {
-		for chunk := range m.processingQueue {
+		defer close(m.consumerDone)
+		for {
+			var chunk *Chunk
+			select {
+			case <-ctx.Done():
+				return
+			case chunk = <-m.processingQueue:
+			}
 			if chunk == nil {
 				continue
 			}
 			// Each chunk is processed in its own goroutine to avoid blocking the queue consumer
-			go func(c *Chunk) {
-				logs.L().Ctx(ctx).Info("Processing a merged chunk", zap.Int("message_count", len(c.Messages)))
-				if err := m.OnMerge(ctx, c); err != nil {
-					logs.L().Ctx(ctx).Error("Error during OnMerge", zap.Error(err))
+			m.merging.Go(func() {
+				// 合并不跟随 cleaner 的 ctx 取消, 关停时由 Flush 等待其完成
+				mergeCtx := context.WithoutCancel(ctx)
+				logs.L().Ctx(mergeCtx).Info("Processing a merged chunk", zap.Int("message_count", len(chunk.Messages)))
+				if err := m.OnMerge(mergeCtx, chunk); err != nil {
+					logs.L().Ctx(mergeCtx).Error("Error during OnMerge", zap.Error(err))
 				}
-			}(chunk)
+			})
 		}
 	}()
 
 	// Start the ticker for scanning Redis
+	m.scannerDone = make(chan struct{})
 	go func() {
+		defer close(m.scannerDone)
 		ticker := time.NewTicker(INACTIVITY_TIMEOUT / 10)
 		defer ticker.Stop()
 		for {
@@ -300,6 +337,83 @@ func (m *Management) StartBackgroundCleaner(ctx context.Context) {
 	}()
 }
 
+// Flush 关停时调用: 等待扫描与消费协程退出、进行中的合并完成, 队列中尚未处理的 chunk 写回 Redis 会话缓冲,
+// 以便下次启动时由 cleaner 继续处理。需在 StartBackgroundCleaner 的 ctx 取消后调用。
+//
+//	@receiver m *Management
+//	@param ctx context.Context 截止时间
+//	@return lost 既未合并也未能写回的 chunk 数
+//	@return err
+func (m *Management) Flush(ctx context.Context) (lost int, err error) {
+	for _, done := range []chan struct{}{m.scannerDone, m.consumerDone} {
+		if done == nil {
+			continue
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	// 1. 队列中的 chunk 写回 Redis
+	for {
+		var chunk *Chunk
+		select {
+		case chunk = <-m.processingQueue:
+		default:
+		}
+		if chunk == nil {
+			break
+		}
+		if restoreErr := m.restoreChunk(ctx, chunk); restoreErr != nil {
+			logs.L().Ctx(ctx).Error("Failed to restore chunk on flush", zap.String("groupID", chunk.GroupID), zap.Error(restoreErr))
+			lost++
+			err = restoreErr
+		}
+	}
+
+	// 2. 等待进行中的合并
+	done := make(chan struct{})
+	go func() {
+		m.merging.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return lost, fmt.Errorf("wait merging chunks: %w", ctx.Err())
+	}
+	return
+}
+
+// restoreChunk 将 chunk 的消息合并回 Redis 中的会话缓冲
+func (m *Management) restoreChunk(ctx context.Context, chunk *Chunk) error {
+	sessionKey := redisSessionKeyPrefix + chunk.GroupID
+	var buffer SessionBuffer
+	val, err := m.redisClient.Get(ctx, sessionKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if err == nil {
+		if err := sonic.UnmarshalString(val, &buffer); err != nil {
+			buffer = SessionBuffer{}
+		}
+	}
+	buffer.Messages = append(chunk.Messages, buffer.Messages...)
+	for _, msg := range buffer.Messages {
+		buffer.LastActiveTs = max(buffer.LastActiveTs, msg.TimeStamp())
+	}
+	bufferJSON, err := sonic.Marshal(buffer)
+	if err != nil {
+		return err
+	}
+	pipe := m.redisClient.Pipeline()
+	pipe.Set(ctx, sessionKey, bufferJSON, 0)
+	pipe.ZAdd(ctx, redisActiveSessionsKey, redis.Z{Score: float64(buffer.LastActiveTs), Member: chunk.GroupID})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
 // scanAndProcessTimeouts is the internal logic for the background cleaner.
 func (m *Management) scanAndProcessTimeouts(ctx context.Context) {
 	logs.L().Ctx(ctx).Debug("Scanning for timed-out sessions...")
@@ -349,10 +463,19 @@ func (m *Management) scanAndProcessTimeouts(ctx context.Context) {
 
 		// Send the collected messages to the processing queue
 		if len(buffer.Messages) > 0 {
-			m.processingQueue <- &Chunk{
+			chunk := &Chunk{
 				GroupID:  groupID,
 				Messages: buffer.Messages,
 			}
+			select {
+			case m.processingQueue <- chunk:
+			case <-ctx.Done():
+				// 关停时队列已无人消费, 取出的会话写回 Redis
+				if err := m.restoreChunk(context.WithoutCancel(ctx), chunk); err != nil {
+					logs.L().Ctx(ctx).Error("Failed to restore chunk on stop", zap.String("groupID", groupID), zap.Error(err))
+				}
+				return
+			}
 		}
 	}
 }
@@ -0,0 +1,518 @@
+package xcommand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
+)
+
+// ArgType 参数类型, 由绑定结构体字段的 Go 类型与 tag 决定
+type ArgType string
+
+const (
+	ArgString    ArgType = "string"
+	ArgInt       ArgType = "int"
+	ArgBool      ArgType = "bool"
+	ArgDuration  ArgType = "duration"
+	ArgEnum      ArgType = "enum"
+	ArgTime      ArgType = "time"
+	ArgTimeRange ArgType = "time_range"
+	ArgUser      ArgType = "user"
+)
+
+// Placeholder 用法说明中参数值的占位
+func (t ArgType) Placeholder() string {
+	switch t {
+	case ArgDuration:
+		return "<30m|2h|7d>"
+	case ArgTime, ArgTimeRange:
+		return `<"2006-01-02 15:04:05">`
+	case ArgUser:
+		return "<me|@成员|open_id>"
+	}
+	return "<" + string(t) + ">"
+}
+
+// TimeRange 时间范围 [Start, End), 由一对参数(如 --st --et)给出, 两者需同时给出;
+// 声明三个参数名时(如 "range,st,et")第一个参数接受自然语言表达式, 如 --range=上周, 见 xtime.Parse,
+// 再声明 input:"true" 时命令后的文本也会作为表达式解析, 如 /wc 过去3天
+type TimeRange = xtime.Range
+
+// TimeRangeHelp TimeRange 参数未声明 help 时的说明
+const TimeRangeHelp = "时间范围, 如 上周、昨天 9-18、过去3天、2025-05、since @msg, 优先于其他时间参数"
+
+// User 成员的 open_id, 参数可以是 me、@成员 或 open_id
+type User string
+
+// UserResolver 将 @成员 等输入解析为 open_id, 由具体平台在根节点上设置
+type UserResolver[T any] func(ctx context.Context, data T, metaData *xhandler.BaseMetaData, raw string) (openID string, ok bool)
+
+// TimeResolver 提供解析时间参数的上下文(会话时区、since @msg 等引用), 由具体平台在根节点上设置
+type TimeResolver[T any] func(ctx context.Context, data T, metaData *xhandler.BaseMetaData) xtime.Options
+
+// ArgSpec 一个参数的声明
+//
+//	在绑定结构体的字段上通过 tag 声明:
+//	  arg:"name"        参数名, 即 --name; TimeRange 字段为 "st,et" 或 "range,st,et"
+//	  help:"..."        说明, TimeRange 字段省略时为 TimeRangeHelp
+//	  default:"..."     未给出时的默认值
+//	  required:"true"   必填
+//	  enum:"a|b"        可选值, 用于 string 字段
+//	  min:"1" max:"100" 取值范围, 用于 int 字段
+//	  input:"true"      绑定命令后的非参数文本, 用于 string 字段或三个参数名的 TimeRange 字段, 至多一个
+type ArgSpec struct {
+	Names    []string
+	Type     ArgType
+	Help     string
+	Default  string
+	Required bool
+	Enum     []string
+	Min, Max *int64
+	Input    bool
+
+	field []int
+}
+
+// Flag 用法说明中的参数形式, 如 --d=<int>
+func (s *ArgSpec) Flag() string {
+	if s.Input && s.Type != ArgTimeRange {
+		return "<text>"
+	}
+	if s.Type == ArgBool {
+		return "--" + s.Names[0]
+	}
+	if s.Type == ArgTimeRange && len(s.Names) == 3 {
+		flag := fmt.Sprintf(`--%s=<"上周"|"昨天 9-18"|"2025-05"> | --%s=%s --%s=%s`,
+			s.Names[0], s.Names[1], s.Type.Placeholder(), s.Names[2], s.Type.Placeholder())
+		if s.Input {
+			flag = "<text> | " + flag
+		}
+		return flag
+	}
+	flags := make([]string, 0, len(s.Names))
+	for _, name := range s.Names {
+		flags = append(flags, fmt.Sprintf("--%s=%s", name, s.Type.Placeholder()))
+	}
+	return strings.Join(flags, " ")
+}
+
+// Constraint 取值约束的说明, 如 "pie|bar"、">= 1"
+func (s *ArgSpec) Constraint() string {
+	parts := make([]string, 0, 3)
+	if len(s.Enum) > 0 {
+		parts = append(parts, strings.Join(s.Enum, "|"))
+	}
+	if s.Min != nil {
+		parts = append(parts, fmt.Sprintf(">= %d", *s.Min))
+	}
+	if s.Max != nil {
+		parts = append(parts, fmt.Sprintf("<= %d", *s.Max))
+	}
+	if s.Required {
+		parts = append(parts, "必填")
+	}
+	return strings.Join(parts, ", ")
+}
+
+var (
+	timeRangeType = reflect.TypeFor[TimeRange]()
+	timeType      = reflect.TypeFor[time.Time]()
+	durationType  = reflect.TypeFor[time.Duration]()
+	userType      = reflect.TypeFor[User]()
+)
+
+// specsOf 从结构体的 tag 生成参数声明, 声明有误时 panic, 在注册命令时即可发现
+func specsOf[A any]() []*ArgSpec {
+	t := reflect.TypeFor[A]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("xcommand: args type %s is not a struct", t))
+	}
+	specs := make([]*ArgSpec, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, ok := f.Tag.Lookup("arg")
+		input := f.Tag.Get("input") == "true"
+		if !ok && !input {
+			continue
+		}
+		spec := &ArgSpec{
+			Help:     f.Tag.Get("help"),
+			Default:  f.Tag.Get("default"),
+			Required: f.Tag.Get("required") == "true",
+			Input:    input,
+			field:    f.Index,
+		}
+		if name != "" {
+			spec.Names = strings.Split(name, ",")
+		}
+		switch {
+		case f.Type == timeRangeType:
+			if len(spec.Names) != 2 && len(spec.Names) != 3 {
+				panic(fmt.Sprintf("xcommand: time range field %s needs two or three names", f.Name))
+			}
+			if input && len(spec.Names) != 3 {
+				panic(fmt.Sprintf("xcommand: input time range field %s needs three names", f.Name))
+			}
+			spec.Type = ArgTimeRange
+			if spec.Help == "" {
+				spec.Help = TimeRangeHelp
+			}
+		case input:
+			if f.Type.Kind() != reflect.String {
+				panic(fmt.Sprintf("xcommand: input field %s must be string", f.Name))
+			}
+			spec.Type = ArgString
+		case f.Type == timeType:
+			spec.Type = ArgTime
+		case f.Type == durationType:
+			spec.Type = ArgDuration
+		case f.Type == userType:
+			spec.Type = ArgUser
+		case f.Type.Kind() == reflect.Bool:
+			spec.Type = ArgBool
+		case f.Type.Kind() == reflect.Int, f.Type.Kind() == reflect.Int64:
+			spec.Type = ArgInt
+		case f.Type.Kind() == reflect.String:
+			spec.Type = ArgString
+			if enum := f.Tag.Get("enum"); enum != "" {
+				spec.Type = ArgEnum
+				spec.Enum = strings.Split(enum, "|")
+			}
+		default:
+			panic(fmt.Sprintf("xcommand: unsupported arg type %s of field %s", f.Type, f.Name))
+		}
+		if !input && len(spec.Names) != 1 && spec.Type != ArgTimeRange {
+			panic(fmt.Sprintf("xcommand: field %s needs exactly one name", f.Name))
+		}
+		for key, dst := range map[string]**int64{"min": &spec.Min, "max": &spec.Max} {
+			if v, ok := f.Tag.Lookup(key); ok {
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					panic(fmt.Sprintf("xcommand: invalid %s tag of field %s", key, f.Name))
+				}
+				*dst = &n
+			}
+		}
+		specs = append(specs, spec)
+	}
+	inputs := 0
+	for _, spec := range specs {
+		if spec.Input {
+			inputs++
+		}
+	}
+	if inputs > 1 {
+		panic(fmt.Sprintf("xcommand: args type %s has more than one input field", t))
+	}
+	return specs
+}
+
+// splitArgs 拆分 GetCommand 得到的参数, --name=value 或 --name, 第一个非参数项及之后的内容为 input
+func splitArgs(args []string) (values map[string]string, input string) {
+	values = make(map[string]string)
+	for idx, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			input = strings.TrimSpace(strings.Join(args[idx:], " "))
+			break
+		}
+		name, value, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		values[name] = value
+	}
+	return values, input
+}
+
+// bindEnv 绑定参数时依赖的调用上下文
+type bindEnv struct {
+	// resolve 解析 @成员, 可以为 nil
+	resolve func(string) (string, bool)
+	// self 发送者的 open_id, 用于 me
+	self string
+	// time 解析时间参数的时区与引用
+	time xtime.Options
+}
+
+// bindArgs 按声明解析参数并写入 dst 指向的结构体
+//
+//	@param specs []*ArgSpec
+//	@param args []string
+//	@param dst any 结构体指针
+//	@param env bindEnv
+//	@return error 参数不合法时的原因
+func bindArgs(specs []*ArgSpec, args []string, dst any, env bindEnv) error {
+	values, input := splitArgs(args)
+	known := make(map[string]bool)
+	v := reflect.ValueOf(dst).Elem()
+	for _, spec := range specs {
+		field := v.FieldByIndex(spec.field)
+		if spec.Input && spec.Type != ArgTimeRange {
+			if input == "" && spec.Required {
+				return errors.New("missing input text")
+			}
+			field.SetString(input)
+			input = ""
+			continue
+		}
+		for _, name := range spec.Names {
+			known[name] = true
+		}
+		if spec.Type == ArgTimeRange {
+			pair := spec.Names[len(spec.Names)-2:]
+			st, okSt := values[pair[0]]
+			et, okEt := values[pair[1]]
+			if okSt != okEt {
+				return fmt.Errorf("--%s and --%s must be given together", pair[0], pair[1])
+			}
+			r := TimeRange{}
+			if len(spec.Names) == 3 {
+				expr, ok := values[spec.Names[0]]
+				if !ok && spec.Input && input != "" {
+					expr, ok, input = input, true, ""
+				}
+				if ok && okSt {
+					return fmt.Errorf("--%s conflicts with --%s and --%s", spec.Names[0], pair[0], pair[1])
+				}
+				if ok {
+					var err error
+					if r, err = xtime.Parse(expr, env.time); err != nil {
+						return fmt.Errorf("--%s: %w", spec.Names[0], err)
+					}
+				}
+			}
+			if okSt {
+				var err error
+				if r.Start, err = parseTimeIn(st, env.time.Loc); err != nil {
+					return fmt.Errorf("--%s: %w", pair[0], err)
+				}
+				if r.End, err = parseTimeIn(et, env.time.Loc); err != nil {
+					return fmt.Errorf("--%s: %w", pair[1], err)
+				}
+				if !r.Start.Before(r.End) {
+					return fmt.Errorf("--%s must be before --%s", pair[0], pair[1])
+				}
+			}
+			if r.IsZero() {
+				if spec.Required {
+					return fmt.Errorf("--%s and --%s are required", pair[0], pair[1])
+				}
+				continue
+			}
+			field.Set(reflect.ValueOf(r))
+			continue
+		}
+		name := spec.Names[0]
+		raw, ok := values[name]
+		if !ok {
+			if spec.Required {
+				return fmt.Errorf("--%s is required", name)
+			}
+			if spec.Default == "" {
+				continue
+			}
+			raw = spec.Default
+		}
+		if err := setValue(spec, field, raw, env); err != nil {
+			return fmt.Errorf("--%s: %w", name, err)
+		}
+	}
+	for name := range values {
+		if !known[name] {
+			return fmt.Errorf("unknown argument --%s", name)
+		}
+	}
+	if input != "" {
+		return fmt.Errorf("unexpected text %q", input)
+	}
+	return nil
+}
+
+func setValue(spec *ArgSpec, field reflect.Value, raw string, env bindEnv) error {
+	switch spec.Type {
+	case ArgBool:
+		if raw == "" {
+			field.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		field.SetBool(b)
+	case ArgInt:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		if (spec.Min != nil && n < *spec.Min) || (spec.Max != nil && n > *spec.Max) {
+			return fmt.Errorf("must be %s", spec.Constraint())
+		}
+		field.SetInt(n)
+	case ArgDuration:
+		d, err := ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case ArgTime:
+		t, err := parseTimeIn(raw, env.time.Loc)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+	case ArgEnum:
+		if !slices.Contains(spec.Enum, raw) {
+			return fmt.Errorf("must be one of %s", strings.Join(spec.Enum, "|"))
+		}
+		field.SetString(raw)
+	case ArgUser:
+		switch {
+		case raw == "me" && env.self != "":
+			raw = env.self
+		case strings.HasPrefix(raw, "ou_"):
+		default:
+			id, ok := "", false
+			if env.resolve != nil {
+				id, ok = env.resolve(raw)
+			}
+			if !ok {
+				return fmt.Errorf("unknown user %q", raw)
+			}
+			raw = id
+		}
+		field.SetString(raw)
+	default:
+		if raw == "" {
+			return errors.New("value is empty")
+		}
+		field.SetString(raw)
+	}
+	return nil
+}
+
+// timeLayouts 时间参数支持的格式, 不带时区的按 UTC+8 解析
+var timeLayouts = []string{time.DateTime, "2006-01-02T15:04:05", "2006-01-02 15:04", time.DateOnly}
+
+// ParseTime 解析时间参数, 支持 RFC3339 与不带时区的 "2006-01-02 15:04:05"、"2006-01-02 15:04"、"2006-01-02"(UTC+8)
+func ParseTime(s string) (time.Time, error) {
+	return parseTimeIn(s, utc8)
+}
+
+// parseTimeIn 同 ParseTime, 不带时区的按 loc 解析, loc 为空时为 UTC+8
+func parseTimeIn(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = utc8
+	}
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use \"2006-01-02 15:04:05\"", s)
+}
+
+var utc8 = time.FixedZone("UTC+8", 8*60*60)
+
+// ParseDuration 解析时长参数, 在 time.ParseDuration 的基础上支持以天为单位, 如 7d、1d12h
+func ParseDuration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid duration %q, use 30m, 2h or 7d", s)
+	rest := strings.TrimSpace(s)
+	var days time.Duration
+	if idx := strings.IndexByte(rest, 'd'); idx > 0 {
+		n, err := strconv.Atoi(rest[:idx])
+		if err != nil {
+			return 0, invalid
+		}
+		days, rest = time.Duration(n)*24*time.Hour, rest[idx+1:]
+	}
+	var d time.Duration
+	if rest != "" {
+		var err error
+		if d, err = time.ParseDuration(rest); err != nil {
+			return 0, invalid
+		}
+	}
+	if d+days <= 0 {
+		return 0, invalid
+	}
+	return d + days, nil
+}
+
+// UsageError 命令用法错误或 --help, 带有参数声明, 便于渲染为用法卡片
+type UsageError struct {
+	// Kind xerror.ErrCheckUsage 或 xerror.ErrArgsIncompelete
+	Kind error
+	// Command 命令路径, 如 /stock gold
+	Command string
+	Usage   string
+	Args    []*ArgSpec
+	// Reason 参数不合法的原因, --help 时为空
+	Reason string
+}
+
+func (e *UsageError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%v: %s\n%s", e.Kind, e.Reason, e.Usage)
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Usage)
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Kind
+}
+
+// TypedCommandFunc 参数已按 A 的声明解析并校验的执行方法
+type TypedCommandFunc[T, A any] func(ctx context.Context, data T, metaData *xhandler.BaseMetaData, args *A) (err error)
+
+// NewTypedCommand 创建参数由结构体 A 的 tag 声明的 Command, 参数在执行前解析、校验并绑定到 A,
+// 同时用于生成 --help 与用法说明, 声明见 ArgSpec; 给出的时间范围同时写入 metaData.TimeRange
+//
+//	@param name string
+//	@param fn TypedCommandFunc[T, A]
+//	@return *Command[T]
+func NewTypedCommand[T, A any](name string, fn TypedCommandFunc[T, A]) *Command[T] {
+	c := NewCommand[T](name, nil)
+	c.Args = specsOf[A]()
+	for _, spec := range c.Args {
+		for _, name := range spec.Names {
+			c.SupportArgs[name] = struct{}{}
+		}
+	}
+	c.Func = func(ctx context.Context, data T, metaData *xhandler.BaseMetaData, args ...string) error {
+		a := new(A)
+		env := bindEnv{}
+		if metaData != nil {
+			env.self = metaData.UserID
+		}
+		if c.userResolver != nil {
+			env.resolve = func(raw string) (string, bool) { return c.userResolver(ctx, data, metaData, raw) }
+		}
+		if c.timeResolver != nil {
+			env.time = c.timeResolver(ctx, data, metaData)
+		}
+		if err := bindArgs(c.Args, args, a, env); err != nil {
+			return c.usageError(xerror.ErrArgsIncompelete, err.Error())
+		}
+		if metaData != nil {
+			v := reflect.ValueOf(a).Elem()
+			for _, spec := range c.Args {
+				if r, ok := v.FieldByIndex(spec.field).Interface().(TimeRange); ok && !r.IsZero() {
+					metaData.TimeRange = r
+				}
+			}
+		}
+		return fn(ctx, data, metaData, a)
+	}
+	return c
+}
@@ -0,0 +1,117 @@
+package xcommand
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
+)
+
+type testArgs struct {
+	Days   int           `arg:"days" help:"最近 N 天" default:"7" min:"1" max:"30"`
+	Play   string        `arg:"play" enum:"line|pie" default:"line"`
+	Every  time.Duration `arg:"every"`
+	Who    User          `arg:"who"`
+	Force  bool          `arg:"force"`
+	Range  TimeRange     `arg:"st,et"`
+	Code   string        `arg:"code" required:"true"`
+	Ignore string
+}
+
+func TestBindArgs(t *testing.T) {
+	specs := specsOf[testArgs]()
+	resolve := func(raw string) (string, bool) { return "ou_mentioned", raw == "@_user_1" }
+
+	a := &testArgs{}
+	err := bindArgs(specs, []string{"--code=600519", "--every=1d12h", "--who=@_user_1", "--force", "--st=2026-10-01", "--et=2026-10-02 12:00:00"}, a, bindEnv{resolve: resolve, self: "ou_self"})
+	if err != nil {
+		t.Fatalf("bindArgs() error = %v", err)
+	}
+	loc := time.FixedZone("UTC+8", 8*3600)
+	if a.Days != 7 || a.Play != "line" || a.Every != 36*time.Hour || a.Who != "ou_mentioned" || !a.Force || a.Code != "600519" {
+		t.Errorf("bindArgs() = %+v", a)
+	}
+	if !a.Range.Start.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, loc)) || !a.Range.End.Equal(time.Date(2026, 10, 2, 12, 0, 0, 0, loc)) {
+		t.Errorf("Range = %+v", a.Range)
+	}
+
+	for _, args := range [][]string{
+		{"--days=3"},                                       // 缺少必填
+		{"--code=1", "--days=0"},                           // 小于 min
+		{"--code=1", "--play=bar"},                         // 不在 enum 中
+		{"--code=1", "--st=2026-10-01"},                    // 时间范围不完整
+		{"--code=1", "--who=@_user_2"},                     // 无法解析的成员
+		{"--code=1", "--unknown=1"},                        // 未声明的参数
+		{"--code=1", "extra"},                              // 多余的文本
+		{"--code=1", "--every=soon"},                       // 非法时长
+		{"--code=1", "--st=2026-10-02", "--et=2026-10-01"}, // 开始晚于结束
+	} {
+		if err := bindArgs(specs, args, &testArgs{}, bindEnv{resolve: resolve, self: "ou_self"}); err == nil {
+			t.Errorf("bindArgs(%v) should fail", args)
+		}
+	}
+}
+
+func TestBindTimeRange(t *testing.T) {
+	type rangeArgs struct {
+		Range TimeRange `arg:"range,st,et" input:"true"`
+	}
+	specs := specsOf[rangeArgs]()
+	loc := time.FixedZone("UTC+8", 8*3600)
+	env := bindEnv{time: xtime.Options{Loc: loc, Now: time.Date(2026, 10, 21, 15, 0, 0, 0, loc)}}
+	yesterday := TimeRange{Start: time.Date(2026, 10, 20, 0, 0, 0, 0, loc), End: time.Date(2026, 10, 21, 0, 0, 0, 0, loc)}
+
+	for _, args := range [][]string{{"--range=yesterday"}, {"昨天"}, {"--st=2026-10-20", "--et=2026-10-21"}} {
+		a := &rangeArgs{}
+		if err := bindArgs(specs, args, a, env); err != nil || a.Range != yesterday {
+			t.Errorf("bindArgs(%v) = %v, %v", args, a.Range, err)
+		}
+	}
+	for _, args := range [][]string{{"--range=someday"}, {"--range=昨天", "--st=2026-10-20", "--et=2026-10-21"}} {
+		if err := bindArgs(specs, args, &rangeArgs{}, env); err == nil {
+			t.Errorf("bindArgs(%v) should fail", args)
+		}
+	}
+	// 没有声明 input 时, 命令后的文本不作为时间表达式
+	type flagOnly struct {
+		Range TimeRange `arg:"range,st,et"`
+	}
+	if err := bindArgs(specsOf[flagOnly](), []string{"昨天"}, &flagOnly{}, env); err == nil {
+		t.Error("bindArgs(昨天) without input should fail")
+	}
+}
+
+func TestTypedCommand(t *testing.T) {
+	var got *testArgs
+	root := NewRootCommand[string](nil).AddSubCommand(
+		NewTypedCommand("run", func(ctx context.Context, data string, metaData *xhandler.BaseMetaData, args *testArgs) error {
+			got = args
+			return nil
+		}),
+	)
+	root.BuildChain()
+	meta := &xhandler.BaseMetaData{UserID: "ou_self"}
+
+	if err := root.Execute(context.Background(), "", meta, []string{"run", "--code=1", "--who=me"}); err != nil || got == nil || got.Who != "ou_self" {
+		t.Fatalf("Execute() = %v, %+v", err, got)
+	}
+
+	var usageErr *UsageError
+	err := root.Execute(context.Background(), "", meta, []string{"run", "--help"})
+	if !errors.As(err, &usageErr) || !errors.Is(err, xerror.ErrCheckUsage) || usageErr.Command != "/run" {
+		t.Fatalf("Execute(--help) = %v", err)
+	}
+	if !strings.Contains(usageErr.Usage, "--days=<int>") || !strings.Contains(usageErr.Usage, "默认 7") {
+		t.Errorf("Usage = %q", usageErr.Usage)
+	}
+
+	err = root.Execute(context.Background(), "", meta, []string{"run", "--days=3"})
+	if !errors.As(err, &usageErr) || !errors.Is(err, xerror.ErrArgsIncompelete) || usageErr.Reason == "" {
+		t.Fatalf("Execute(invalid) = %v", err)
+	}
+}
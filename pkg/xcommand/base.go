@@ -4,6 +4,7 @@ package xcommand
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
@@ -24,12 +25,22 @@ type CommandFunc[T any] func(ctx context.Context, data T, metaData *xhandler.Bas
 //	@author heyuhengmatt
 //	@update 2024-07-18 04:43:37
 type Command[T any] struct {
-	Name        string
+	Name string
+	// Aliases 别名, 如中文名 词云, 与 Name 一样可以匹配
+	Aliases []string
+	// Desc 一句话说明, 用于 /help
+	Desc string
+	// SubCommands 子命令; 与 Func 同时存在时(如 /music), 首个参数是子命令名或别名则交给子命令,
+	// 想把这样的词当作普通参数(如搜索名为 下一首 的歌)时在前面加 --, 即 /music -- 下一首
 	SubCommands map[string]*Command[T]
 	Func        CommandFunc[T]
 	Usage       string
 	SupportArgs map[string]struct{}
-	curComChain []string
+	// Args 通过 NewTypedCommand 声明的参数
+	Args         []*ArgSpec
+	curComChain  []string
+	userResolver UserResolver[T]
+	timeResolver TimeResolver[T]
 }
 
 // Execute 从当前节点开始，执行Command
@@ -41,6 +52,22 @@ type Command[T any] struct {
 func (c *Command[T]) Execute(ctx context.Context, data T, metaData *xhandler.BaseMetaData, args []string) error {
 	l := logs.L().Ctx(ctx).With(zap.String("command_name", c.Name), zap.Strings("args", args), zap.Any("meta", metaData))
 	l.Debug("Executing On Command")
+	// 同时有执行方法与子命令时(如 /music), 首个参数是子命令则交给子命令, 否则执行自身; 以 -- 开头时跳过子命令匹配
+	if len(args) > 0 && args[0] == "--" && c.Func != nil {
+		return c.Func(ctx, data, metaData, args[1:]...)
+	}
+	if len(args) > 0 {
+		if subcommand, ok := c.subCommand(args[0]); ok {
+			if target, ok := subcommand.helpTarget(args[1:]...); ok {
+				return target.usageError(xerror.ErrCheckUsage, "")
+			}
+			err := subcommand.Execute(ctx, data, metaData, args[1:])
+			if err != nil && err == xerror.ErrArgsIncompelete {
+				return subcommand.usageError(xerror.ErrArgsIncompelete, "")
+			}
+			return err
+		}
+	}
 	if c.Func != nil { // 当前Command有执行方法，直接执行
 		l.Info("Executing on Command Function", zap.String("func_name", reflecting.GetFunctionName(c.Func)))
 		return c.Func(ctx, data, metaData, args...)
@@ -49,23 +76,105 @@ func (c *Command[T]) Execute(ctx context.Context, data T, metaData *xhandler.Bas
 		return fmt.Errorf("%w: %s", xerror.ErrCommandIncomplete, c.FormatUsage())
 	}
 
-	if subcommand, ok := c.SubCommands[args[0]]; ok {
-		if usage, ok := subcommand.CheckUsage(args[1:]...); ok {
-			return fmt.Errorf("%w: %s", xerror.ErrCheckUsage, usage)
+	return &NotFoundError{Name: args[0], Suggestions: c.Suggest(args[0]), Available: c.GetSubCommands()}
+}
+
+// NotFoundError 子命令不存在, 带有按编辑距离给出的建议
+type NotFoundError struct {
+	Name string
+	// Suggestions 相近的命令路径, 如 /wc
+	Suggestions []string
+	// Available 当前节点可用的子命令
+	Available []string
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Suggestions) > 0 {
+		return fmt.Sprintf(
+			"%v: Command <b>%s</b> not found, did you mean <b>%s</b>? available sub-commands: [%s]",
+			xerror.ErrCommandNotFound, e.Name, strings.Join(e.Suggestions, "</b> / <b>"), strings.Join(e.Available, ", "),
+		)
+	}
+	return fmt.Sprintf("%v: Command <b>%s</b> not found, available sub-commands: [%s]", xerror.ErrCommandNotFound, e.Name, strings.Join(e.Available, ", "))
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return xerror.ErrCommandNotFound
+}
+
+// subCommand 按名称或别名查找子节点
+func (c *Command[T]) subCommand(name string) (*Command[T], bool) {
+	if subcommand, ok := c.SubCommands[name]; ok {
+		return subcommand, true
+	}
+	for _, subcommand := range c.SubCommands {
+		if slices.Contains(subcommand.Aliases, name) {
+			return subcommand, true
+		}
+	}
+	return nil, false
+}
+
+// Suggest 与 name 编辑距离相近的子命令路径, 用于 "did you mean"; 每 3 个字符容许 1 处差异, 最多 2 处
+//
+//	@param c *Command[T]
+//	@param name string
+//	@return []string 如 /wc, 按距离、名称排序
+func (c *Command[T]) Suggest(name string) []string {
+	type candidate struct {
+		path string
+		dist int
+	}
+	limit := min(2, len([]rune(name))/3)
+	candidates := make([]candidate, 0)
+	for _, subName := range c.GetSubCommands() {
+		subcommand := c.SubCommands[subName]
+		best := -1
+		for _, n := range append([]string{subcommand.Name}, subcommand.Aliases...) {
+			if d := editDistance(strings.ToLower(name), strings.ToLower(n)); best < 0 || d < best {
+				best = d
+			}
+		}
+		if best <= limit {
+			candidates = append(candidates, candidate{subcommand.Path(), best})
 		}
-		err := subcommand.Execute(ctx, data, metaData, args[1:])
-		if err != nil && err == xerror.ErrArgsIncompelete {
-			return fmt.Errorf("%w: %s", xerror.ErrArgsIncompelete, subcommand.FormatUsage())
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.dist - b.dist })
+	suggestions := make([]string, 0, len(candidates))
+	for _, cand := range candidates {
+		suggestions = append(suggestions, cand.path)
+	}
+	return suggestions
+}
+
+// Find 按名称或别名逐级查找子节点, 如 Find("stock", "gold")
+//
+//	@param c *Command[T]
+//	@param path ...string
+//	@return *Command[T]
+//	@return bool
+func (c *Command[T]) Find(path ...string) (*Command[T], bool) {
+	target := c
+	for _, name := range path {
+		subcommand, ok := target.subCommand(name)
+		if !ok {
+			return nil, false
 		}
-		return err
+		target = subcommand
 	}
+	return target, true
+}
 
-	return fmt.Errorf(
-		"%w: Command <b>%s</b> not found, available sub-commands: %s",
-		xerror.ErrCommandNotFound,
-		args[0],
-		fmt.Sprintf(" [%s]", strings.Join(c.GetSubCommands(), ", ")),
-	)
+// Walk 按名称顺序深度优先遍历当前节点之下的所有子节点
+//
+//	@param c *Command[T]
+//	@param fn func(*Command[T])
+func (c *Command[T]) Walk(fn func(*Command[T])) {
+	for _, name := range c.GetSubCommands() {
+		subcommand := c.SubCommands[name]
+		fn(subcommand)
+		subcommand.Walk(fn)
+	}
 }
 
 // BuildChain 从当前节点开始，执行Command
@@ -76,7 +185,13 @@ func (c *Command[T]) Execute(ctx context.Context, data T, metaData *xhandler.Bas
 //	@update 2024-07-18 05:30:21
 func (c *Command[T]) BuildChain() {
 	for _, subcommand := range c.SubCommands {
-		subcommand.curComChain = append(c.curComChain, subcommand.Name)
+		subcommand.curComChain = append(slices.Clone(c.curComChain), subcommand.Name)
+		if subcommand.userResolver == nil {
+			subcommand.userResolver = c.userResolver
+		}
+		if subcommand.timeResolver == nil {
+			subcommand.timeResolver = c.timeResolver
+		}
 		subcommand.BuildChain()
 	}
 }
@@ -87,7 +202,10 @@ func (c *Command[T]) BuildChain() {
 //	@return GetSubCommands
 func (c *Command[T]) FormatUsage() string {
 	if c.Usage == "" {
-		baseUsage := fmt.Sprintf("Usage: %s", "/"+strings.Join(c.curComChain, " "))
+		baseUsage := fmt.Sprintf("Usage: %s", c.Path())
+		if len(c.Args) != 0 {
+			return baseUsage + formatArgs(c.Args)
+		}
 		if len(c.SupportArgs) != 0 {
 			baseUsage += fmt.Sprintf(" <%s>", strings.Join(c.GetSupportArgs(), ", "))
 		}
@@ -100,28 +218,103 @@ func (c *Command[T]) FormatUsage() string {
 	return c.Usage
 }
 
+// Path 命令路径, 如 /stock gold
+func (c *Command[T]) Path() string {
+	return "/" + strings.Join(c.curComChain, " ")
+}
+
+// AliasPaths 各别名的完整调用路径, 与 Path 一样带上父命令, 如 /stock 金价
+//
+//	@receiver c *Command[T]
+//	@return []string
+func (c *Command[T]) AliasPaths() []string {
+	parent := c.curComChain[:max(len(c.curComChain)-1, 0)]
+	res := make([]string, 0, len(c.Aliases))
+	for _, alias := range c.Aliases {
+		res = append(res, "/"+strings.Join(append(slices.Clip(parent), alias), " "))
+	}
+	return res
+}
+
 // CheckUsage 获取当前节点的所有SubCommands
 //
 //	@param c
 //	@return GetSubCommands
 func (c *Command[T]) CheckUsage(args ...string) (usage string, isHelp bool) {
+	if target, ok := c.helpTarget(args...); ok {
+		return target.FormatUsage(), true
+	}
+	return "", false
+}
+
+// helpTarget 参数中带有 --help 时, 返回需要展示用法的节点
+func (c *Command[T]) helpTarget(args ...string) (*Command[T], bool) {
 	if len(args) == 1 {
 		if args[0] == "--help" {
-			return c.FormatUsage(), true
+			return c, true
 		}
 	}
 	for index, arg := range args {
 		if _, ok := c.SupportArgs[arg]; ok {
 			continue
 		}
-		if subcommand, ok := c.SubCommands[arg]; ok {
-			return subcommand.CheckUsage(args[index+1:]...)
+		if subcommand, ok := c.subCommand(arg); ok {
+			return subcommand.helpTarget(args[index+1:]...)
 		}
 	}
-	return "", false
+	return nil, false
+}
+
+// usageError 当前节点的用法错误
+func (c *Command[T]) usageError(kind error, reason string) *UsageError {
+	return &UsageError{Kind: kind, Command: c.Path(), Usage: c.FormatUsage(), Args: c.Args, Reason: reason}
+}
+
+// WithUserResolver 设置 @成员 参数的解析方法, BuildChain 时传递给未设置的子节点
+//
+//	@param c *Command[T]
+//	@return *Command[T]
+func (c *Command[T]) WithUserResolver(fn UserResolver[T]) *Command[T] {
+	c.userResolver = fn
+	return c
 }
 
-// GetSubCommands 获取当前节点的所有SubCommands
+// WithTimeResolver 设置时间参数的解析上下文, BuildChain 时传递给未设置的子节点
+//
+//	@param c *Command[T]
+//	@return *Command[T]
+func (c *Command[T]) WithTimeResolver(fn TimeResolver[T]) *Command[T] {
+	c.timeResolver = fn
+	return c
+}
+
+// formatArgs 由参数声明生成的用法说明
+func formatArgs(specs []*ArgSpec) string {
+	b := &strings.Builder{}
+	for _, spec := range specs {
+		if spec.Required {
+			fmt.Fprintf(b, " %s", spec.Flag())
+		} else {
+			fmt.Fprintf(b, " [%s]", spec.Flag())
+		}
+	}
+	for _, spec := range specs {
+		fmt.Fprintf(b, "\n  %s  %s", spec.Flag(), spec.Help)
+		notes := make([]string, 0, 2)
+		if c := spec.Constraint(); c != "" {
+			notes = append(notes, c)
+		}
+		if spec.Default != "" {
+			notes = append(notes, "默认 "+spec.Default)
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(b, " (%s)", strings.Join(notes, ", "))
+		}
+	}
+	return b.String()
+}
+
+// GetSubCommands 获取当前节点的所有SubCommands, 按名称排序
 //
 //	@param c
 //	@return GetSubCommands
@@ -130,6 +323,7 @@ func (c *Command[T]) GetSubCommands() []string {
 	for k := range c.SubCommands {
 		availableComs = append(availableComs, k)
 	}
+	slices.Sort(availableComs)
 	return availableComs
 }
 
@@ -142,6 +336,7 @@ func (c *Command[T]) GetSupportArgs() []string {
 	for k := range c.SupportArgs {
 		supportArgs = append(supportArgs, k)
 	}
+	slices.Sort(supportArgs)
 	return supportArgs
 }
 
@@ -152,16 +347,15 @@ func (c *Command[T]) GetSupportArgs() []string {
 //	@author heyuhengmatt
 //	@update 2024-07-18 05:30:21
 func (c *Command[T]) Validate(ctx context.Context, data T, args []string) bool {
+	if len(args) > 0 {
+		if subcommand, ok := c.subCommand(args[0]); ok {
+			return subcommand.Validate(ctx, data, args[1:])
+		}
+	}
 	if c.Func != nil { // 当前Command有执行方法，直接执行
 		return true
 	}
-	if len(args) == 0 { // 无执行方法且无后续参数
-		return false
-	}
-	if subcommand, ok := c.SubCommands[args[0]]; ok {
-		return subcommand.Validate(ctx, data, args[1:])
-	}
-	return true
+	return len(args) > 0 // 无执行方法且无后续参数时不合法
 }
 
 // AddSubCommand 添加一个SubCommand
@@ -171,10 +365,33 @@ func (c *Command[T]) Validate(ctx context.Context, data T, args []string) bool {
 //	@author heyuhengmatt
 //	@update 2024-07-18 05:30:07
 func (c *Command[T]) AddSubCommand(subCommand *Command[T]) *Command[T] {
+	for _, name := range append([]string{subCommand.Name}, subCommand.Aliases...) {
+		if existing, ok := c.subCommand(name); ok {
+			panic(fmt.Sprintf("xcommand: %q of /%s conflicts with /%s", name, subCommand.Name, existing.Name))
+		}
+	}
 	c.SubCommands[subCommand.Name] = subCommand
 	return c
 }
 
+// AddAliases 添加别名, 如中文名; 需在 AddSubCommand 之前调用, 与同级命令的名称或别名重复时注册会 panic
+//
+//	@param c *Command[T]
+//	@return *Command[T]
+func (c *Command[T]) AddAliases(aliases ...string) *Command[T] {
+	c.Aliases = append(c.Aliases, aliases...)
+	return c
+}
+
+// AddDesc 设置一句话说明, 用于 /help
+//
+//	@param c *Command[T]
+//	@return *Command[T]
+func (c *Command[T]) AddDesc(desc string) *Command[T] {
+	c.Desc = desc
+	return c
+}
+
 // AddUsage 添加一个SubCommand
 //
 //	@param c *Command[T]
@@ -208,7 +425,7 @@ func (c *Command[T]) IsCommand(ctx context.Context, text string) bool {
 	}
 
 	targetName := cmds[0]
-	if _, ok := c.SubCommands[targetName]; ok {
+	if _, ok := c.subCommand(targetName); ok {
 		return true
 	}
 	if c.Name == targetName {
@@ -218,6 +435,15 @@ func (c *Command[T]) IsCommand(ctx context.Context, text string) bool {
 	return false
 }
 
+// IsMisspelled 传入的文本是否为拼写相近的命令, 如 /wcc; 交给 Execute 时由其给出 "did you mean"
+//
+//	@param text string 原始文本
+//	@return bool
+func (c *Command[T]) IsMisspelled(ctx context.Context, text string) bool {
+	cmds := GetCommand(ctx, text)
+	return len(cmds) > 0 && len(c.Suggest(cmds[0])) > 0
+}
+
 // NewCommand 创建一个新的Command结构
 //
 //	@param name string
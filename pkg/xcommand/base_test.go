@@ -0,0 +1,110 @@
+package xcommand
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xhandler"
+)
+
+func TestAliasesAndSuggest(t *testing.T) {
+	ran := ""
+	run := func(name string) CommandFunc[string] {
+		return func(ctx context.Context, data string, metaData *xhandler.BaseMetaData, args ...string) error {
+			ran = name
+			return nil
+		}
+	}
+	root := NewRootCommand[string](nil).
+		AddSubCommand(NewCommand("wc", run("wc")).AddAliases("词云")).
+		AddSubCommand(NewCommand("talkrate", run("talkrate")).AddAliases("水群")).
+		AddSubCommand(NewCommand[string]("stock", nil).AddSubCommand(NewCommand("gold", run("gold")).AddAliases("金价"))).
+		AddSubCommand(NewCommand("music", run("music")).AddSubCommand(NewCommand("next", run("next"))))
+	root.BuildChain()
+	ctx := context.Background()
+
+	for args, want := range map[string][]string{"wc": {"词云"}, "talkrate": {"水群"}, "gold": {"stock", "金价"}} {
+		if err := root.Execute(ctx, "", &xhandler.BaseMetaData{}, want); err != nil || ran != args {
+			t.Errorf("Execute(%v) = %v, ran %q", want, err, ran)
+		}
+	}
+	// 有执行方法的节点: 首个参数是子命令时执行子命令, 否则执行自身; -- 之后不再匹配子命令
+	var gotArgs []string
+	root.SubCommands["music"].Func = func(ctx context.Context, data string, metaData *xhandler.BaseMetaData, args ...string) error {
+		ran, gotArgs = "music", args
+		return nil
+	}
+	for _, c := range []struct {
+		args     []string
+		want     string
+		wantArgs []string
+	}{
+		{[]string{"music", "next"}, "next", nil},
+		{[]string{"music", "晴天"}, "music", []string{"晴天"}},
+		{[]string{"music"}, "music", []string{}},
+		{[]string{"music", "--", "next"}, "music", []string{"next"}},
+		{[]string{"music", "--", "next", "--pick=2"}, "music", []string{"next", "--pick=2"}},
+	} {
+		ran, gotArgs = "", nil
+		if err := root.Execute(ctx, "", &xhandler.BaseMetaData{}, c.args); err != nil || ran != c.want {
+			t.Errorf("Execute(%v) = %v, ran %q", c.args, err, ran)
+		}
+		if c.wantArgs != nil && !slices.Equal(gotArgs, c.wantArgs) {
+			t.Errorf("Execute(%v) args = %v, want %v", c.args, gotArgs, c.wantArgs)
+		}
+	}
+	if c, ok := root.Find("stock", "金价"); !ok || c.Name != "gold" {
+		t.Errorf("Find(stock 金价) = %v, %v", c, ok)
+	}
+	if got := root.SubCommands["stock"].SubCommands["gold"].AliasPaths(); !slices.Equal(got, []string{"/stock 金价"}) {
+		t.Errorf("AliasPaths(stock gold) = %v", got)
+	}
+	if got := root.GetSubCommands(); !slices.Equal(got, []string{"music", "stock", "talkrate", "wc"}) {
+		t.Errorf("GetSubCommands() = %v", got)
+	}
+
+	var notFound *NotFoundError
+	err := root.Execute(ctx, "", &xhandler.BaseMetaData{}, []string{"talkrat"})
+	if !errors.As(err, &notFound) || !errors.Is(err, xerror.ErrCommandNotFound) || !slices.Equal(notFound.Suggestions, []string{"/talkrate"}) {
+		t.Fatalf("Execute(talkrat) = %v", err)
+	}
+	err = root.Execute(ctx, "", &xhandler.BaseMetaData{}, []string{"stock", "gld"})
+	if !errors.As(err, &notFound) || !slices.Equal(notFound.Suggestions, []string{"/stock gold"}) {
+		t.Fatalf("Execute(stock gld) = %v", err)
+	}
+	if got := root.Suggest("xyz"); len(got) != 0 {
+		t.Errorf("Suggest(xyz) = %v", got)
+	}
+	if !root.IsCommand(ctx, "/词云 --days=3") || root.IsCommand(ctx, "/wcc") || root.IsCommand(ctx, "/hello") {
+		t.Error("IsCommand() mismatch")
+	}
+	if !root.IsMisspelled(ctx, "/wcc") || root.IsMisspelled(ctx, "/hello") {
+		t.Error("IsMisspelled() mismatch")
+	}
+	// 与同级命令重名的别名在注册时即报错
+	defer func() {
+		if recover() == nil {
+			t.Error("AddSubCommand() with a duplicate alias should panic")
+		}
+	}()
+	root.AddSubCommand(NewCommand("cloud", run("cloud")).AddAliases("词云"))
+}
+
+func TestSplitScript(t *testing.T) {
+	cases := map[string][][]string{
+		"/wc --days=1 | /bb summarize this": {{"/wc --days=1", "/bb summarize this"}},
+		"/stock gold; /oneword":             {{"/stock gold"}, {"/oneword"}},
+		"/music 晴天 | /music --pick=1；/help": {{"/music 晴天", "/music --pick=1"}, {"/help"}},
+		"/bb a|b; c":                           {{"/bb a|b; c"}},
+		`/reply add --word="x | /y" --reply=z`: {{`/reply add --word="x | /y" --reply=z`}},
+	}
+	for raw, want := range cases {
+		got := SplitScript(raw)
+		if !slices.EqualFunc(got, want, slices.Equal[[]string]) {
+			t.Errorf("SplitScript(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package xcommand
+
+import "strings"
+
+// SplitScript 拆分命令脚本: ";" 分隔依次执行的步骤, "|" 分隔同一步骤内的管道阶段, 如
+//
+//	/wc --days=1 | /bb 总结一下; /oneword
+//
+//	只有紧跟 "/" 的分隔符才会拆分, 引号内的不拆分, 因此普通文本中的 ";"、"|" 不受影响
+//	@param raw string
+//	@return steps [][]string 每个步骤的管道阶段, 均已去掉首尾空白
+func SplitScript(raw string) (steps [][]string) {
+	runes := []rune(raw)
+	stages := make([]string, 0, 1)
+	start, quoted := 0, false
+	// nextIsCommand 分隔符之后跳过空白是否为 "/"
+	nextIsCommand := func(i int) bool {
+		for j := i + 1; j < len(runes); j++ {
+			switch runes[j] {
+			case ' ', '\t', '\n':
+				continue
+			case '/':
+				return true
+			}
+			return false
+		}
+		return false
+	}
+	flush := func(end int) {
+		if stage := strings.TrimSpace(string(runes[start:end])); stage != "" {
+			stages = append(stages, stage)
+		}
+		start = end + 1
+	}
+	for i, r := range runes {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '|' && nextIsCommand(i):
+			flush(i)
+		case (r == ';' || r == '；') && nextIsCommand(i):
+			flush(i)
+			if len(stages) > 0 {
+				steps = append(steps, stages)
+			}
+			stages = make([]string, 0, 1)
+		}
+	}
+	flush(len(runes))
+	if len(stages) > 0 {
+		steps = append(steps, stages)
+	}
+	return steps
+}
@@ -10,8 +10,8 @@ import (
 )
 
 var (
-	commandMsgRepattern  = regexp2.MustCompile(`\/(?P<commands>[^--]+)( --)*`, regexp2.RE2)                                                                   // 只校验是不是合法命令
-	commandFullRepattern = regexp2.MustCompile(`((@[^ ]+\s+)|^)\/(?P<commands>\w+( )*)+( )*(--(?P<arg_name>\w+)=(?P<arg_value>("[^"]*"|\S+)))*`, regexp2.RE2) // command+参数格式校验
+	commandMsgRepattern  = regexp2.MustCompile(`\/(?P<commands>[^--]+)( --)*`, regexp2.RE2)                                                                            // 只校验是不是合法命令
+	commandFullRepattern = regexp2.MustCompile(`((@[^ ]+\s+)|^)\/(?P<commands>[\w\p{Han}]+( )*)+( )*(--(?P<arg_name>\w+)=(?P<arg_value>("[^"]*"|\S+)))*`, regexp2.RE2) // command+参数格式校验
 	commandArgRepattern  = regexp2.MustCompile(`--(?P<arg_name>\w+)(=(?P<arg_value>("[^"]*"|\S+)))?`, regexp2.RE2)
 )
 
@@ -37,9 +37,11 @@ func GetCommand(ctx context.Context, content string) (commands []string) {
 			return
 		}
 		if match != nil {
+			// regexp2 的下标以 rune 计
+			runes := []rune(content)
 			lastIdx := 0
 			for match, err = commandArgRepattern.FindStringMatch(content); match != nil; {
-				lastIdx = match.Index + len(match.String()) + 1
+				lastIdx = match.Index + match.Length + 1
 				commands = append(commands, ReBuildArgs(
 					match.GroupByName("arg_name").String(),
 					match.GroupByName("arg_value").String()),
@@ -49,8 +51,8 @@ func GetCommand(ctx context.Context, content string) (commands []string) {
 				}
 				match, err = commandArgRepattern.FindNextMatch(match)
 			}
-			if lastIdx < len(content) {
-				commands = append(commands, content[lastIdx:])
+			if lastIdx < len(runes) {
+				commands = append(commands, string(runes[lastIdx:]))
 			}
 		}
 	}
@@ -65,3 +67,25 @@ func ReBuildArgs(argName, argValue string) string {
 		return strings.Join([]string{"--", argName}, "")
 	}
 }
+
+// editDistance 按 rune 计算的 Levenshtein 编辑距离
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -2,17 +2,27 @@ package xhandler
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
 	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xtime"
 	"github.com/BetaGoRobot/go_utils/reflecting"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var shedCounter = sync.OnceValue(func() metric.Int64Counter {
+	counter, _ := otel.M().Int64Counter("xhandler_operator_shed", metric.WithDescription("low priority operators skipped under pressure"))
+	return counter
+})
+
 type Operator[T, K any] interface {
 	Name() string
 
@@ -23,6 +33,31 @@ type Operator[T, K any] interface {
 	MetaInit() *K
 }
 
+// Priority 算子优先级, 负载过高时低优先级算子会被丢弃
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// PriorityOperator 声明了优先级的算子, 未声明的视为 PriorityNormal
+type PriorityOperator interface {
+	Priority() Priority
+}
+
+// OperatorPriority 获取算子优先级
+//
+//	@param op any
+//	@return Priority
+func OperatorPriority(op any) Priority {
+	if p, ok := op.(PriorityOperator); ok {
+		return p.Priority()
+	}
+	return PriorityNormal
+}
+
 type (
 	OperatorBase[T, K any] struct{}
 	BaseMetaData           struct {
@@ -36,14 +71,32 @@ type (
 
 		ForceReplyDirect bool
 		SkipDone         bool
-		Extra            map[string]any
+		// Handled 消息在并行阶段开始前已被完整处理(如猜中歌名), 其余会回复的算子应跳过
+		Handled bool
+		Extra   map[string]any
 
-		// TODO: 暂时没有用上，后续改造替换掉st、et的反复解析，搞成通用参数
-		StartTime string
-		EndTime   string
+		// TimeRange 命令参数中给出的时间范围 [st, et), 未给出时为零值
+		TimeRange xtime.Range
 	}
 )
 
+const (
+	// ExtraResult 命令的结构化结果, 在管道(/a | /b)中作为下一条命令的输入
+	ExtraResult = "result"
+	// ExtraPipeInput 管道中上一条命令的结构化结果
+	ExtraPipeInput = "pipe_input"
+)
+
+// SetResult 记录命令的结构化结果, 供管道中的下一条命令使用
+func (m *BaseMetaData) SetResult(val any) {
+	m.SetExtra(ExtraResult, val)
+}
+
+// PipeInput 管道中上一条命令的结果, 不在管道中或上一条命令没有结果时 ok 为 false
+func (m *BaseMetaData) PipeInput() (val any, ok bool) {
+	return m.GetExtra(ExtraPipeInput)
+}
+
 func (m *BaseMetaData) GetExtra(key string) (any, bool) {
 	if m.Extra == nil {
 		m.Extra = make(map[string]any)
@@ -64,21 +117,35 @@ type (
 	ProcPanicFunc[T, K any] func(context.Context, error, *T, *K)
 	ProcDeferFunc[T, K any] func(context.Context, *T, *K)
 	MetaInitFunc[T, K any]  func(*T) *K
-	Processor[T, K any]     struct {
-		context.Context
+	PreRunFunc[T, K any]    func(e *Execution[T, K])
 
-		needBreak       bool
-		data            *T
-		metaData        *K
+	// Processor 流水线定义, 只描述"有哪些阶段、怎么处理", 不持有任何事件数据。
+	//	所有构建方法都返回新的副本, 不会修改已共享出去的定义, 因此可以被多个事件并发复用。
+	Processor[T, K any] struct {
 		stages          []Operator[T, K]
 		parrallelStages []Operator[T, K]
 		onPanicFn       ProcPanicFunc[T, K]
 		deferFn         []ProcDeferFunc[T, K]
 		metaInitFn      MetaInitFunc[T, K]
-		preRunFn        func(p *Processor[T, K])
+		preRunFn        PreRunFunc[T, K]
+		shedFn          func() bool
+	}
+
+	// Execution 单个事件的一次执行, 由 Processor.NewExecution 创建, 不可跨事件复用
+	Execution[T, K any] struct {
+		context.Context
+
+		proc     *Processor[T, K]
+		data     *T
+		metaData *K
 	}
 )
 
+// NewProcessor 创建空的流水线定义
+func NewProcessor[T, K any]() *Processor[T, K] {
+	return &Processor[T, K]{}
+}
+
 func (op *OperatorBase[T, K]) Name() string {
 	return "NotImplementBaseName"
 }
@@ -99,52 +166,51 @@ func (op *OperatorBase[T, K]) MetaInit() *K {
 	return new(K)
 }
 
-func (p *Processor[T, K]) WithCtx(ctx context.Context) *Processor[T, K] {
-	p.Context = ctx
-	return p
+// clone 复制一份定义, 构建方法在副本上修改
+func (p *Processor[T, K]) clone() *Processor[T, K] {
+	if p == nil {
+		return &Processor[T, K]{}
+	}
+	c := *p
+	c.stages = slices.Clone(p.stages)
+	c.parrallelStages = slices.Clone(p.parrallelStages)
+	c.deferFn = slices.Clone(p.deferFn)
+	return &c
 }
 
 func (p *Processor[T, K]) OnPanic(fn ProcPanicFunc[T, K]) *Processor[T, K] {
-	p.onPanicFn = fn
-	return p
+	c := p.clone()
+	c.onPanicFn = fn
+	return c
 }
 
 func (p *Processor[T, K]) WithDefer(fns ...ProcDeferFunc[T, K]) *Processor[T, K] {
-	p.deferFn = append(p.deferFn, fns...)
-	return p
+	c := p.clone()
+	c.deferFn = append(c.deferFn, fns...)
+	return c
 }
 
 func (p *Processor[T, K]) WithMetaDataProcess(fn MetaInitFunc[T, K]) *Processor[T, K] {
-	p.metaInitFn = fn
-	return p
-}
-
-func (p *Processor[T, K]) WithPreRun(f func(p *Processor[T, K])) *Processor[T, K] {
-	p.preRunFn = f
-	return p
-}
-
-func (p *Processor[T, K]) WithData(event *T) *Processor[T, K] {
-	p.data = event
-	return p
-}
-
-func (p *Processor[T, K]) Data() *T {
-	return p.data
+	c := p.clone()
+	c.metaInitFn = fn
+	return c
 }
 
-func (p *Processor[T, K]) Clean() *Processor[T, K] {
-	p.data = nil
-	p.Context = nil
-	return p
+func (p *Processor[T, K]) WithPreRun(f PreRunFunc[T, K]) *Processor[T, K] {
+	c := p.clone()
+	c.preRunFn = f
+	return c
 }
 
-func (p *Processor[T, K]) Defer() {
-	if err := recover(); err != nil {
-		if p.onPanicFn != nil {
-			p.onPanicFn(p.Context, err.(error), p.data, p.metaData)
-		}
-	}
+// WithShedding 设置负载判断, 返回 true 时跳过低优先级的并行算子
+//
+//	@receiver p
+//	@param fn
+//	@return *Processor[T, K]
+func (p *Processor[T, K]) WithShedding(fn func() bool) *Processor[T, K] {
+	c := p.clone()
+	c.shedFn = fn
+	return c
 }
 
 // AddStages  添加处理阶段
@@ -153,8 +219,9 @@ func (p *Processor[T, K]) Defer() {
 //	@param stage
 //	@return *Processor[T]
 func (p *Processor[T, K]) AddStages(stage Operator[T, K]) *Processor[T, K] {
-	p.stages = append(p.stages, stage)
-	return p
+	c := p.clone()
+	c.stages = append(c.stages, stage)
+	return c
 }
 
 // AddParallelStages  添加并行处理阶段
@@ -163,49 +230,86 @@ func (p *Processor[T, K]) AddStages(stage Operator[T, K]) *Processor[T, K] {
 //	@param stage
 //	@return *Processor[T]
 func (p *Processor[T, K]) AddParallelStages(stage Operator[T, K]) *Processor[T, K] {
-	p.parrallelStages = append(p.parrallelStages, stage)
-	return p
+	c := p.clone()
+	c.parrallelStages = append(c.parrallelStages, stage)
+	return c
 }
 
-// RunStages  运行处理阶段
+// NewExecution 为单个事件创建执行对象
+//
+//	@receiver p
+//	@param ctx
+//	@param event
+//	@return *Execution[T, K]
+func (p *Processor[T, K]) NewExecution(ctx context.Context, event *T) *Execution[T, K] {
+	return &Execution[T, K]{Context: ctx, proc: p, data: event}
+}
+
+// Run 以 event 运行一次流水线
 //
 //	@receiver p
 //	@param ctx
 //	@param event
-func (p *Processor[T, K]) RunStages() (err error) {
-	var span trace.Span
-	p.Context, span = otel.T().Start(p.Context, reflecting.GetCurrentFunc())
+func (p *Processor[T, K]) Run(ctx context.Context, event *T) {
+	p.NewExecution(ctx, event).Run()
+}
+
+func (e *Execution[T, K]) Data() *T {
+	return e.data
+}
+
+func (e *Execution[T, K]) MetaData() *K {
+	return e.metaData
+}
+
+func (e *Execution[T, K]) Defer() {
+	if err := recover(); err != nil {
+		if e.proc.onPanicFn != nil {
+			panicErr, ok := err.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", err)
+			}
+			e.proc.onPanicFn(e.Context, panicErr, e.data, e.metaData)
+		}
+	}
+}
+
+// RunStages  运行处理阶段
+//
+//	@receiver e
+func (e *Execution[T, K]) RunStages() (err error) {
+	ctx, span := otel.T().Start(e.Context, reflecting.GetCurrentFunc())
 	defer span.End()
 
-	for _, s := range p.stages {
-		defer p.Defer()
-		err = s.PreRun(p.Context, p.data, p.metaData)
+	for _, s := range e.proc.stages {
+		defer e.Defer()
+		err = s.PreRun(ctx, e.data, e.metaData)
 		if err != nil {
-			trace.SpanFromContext(p.Context).RecordError(err)
+			trace.SpanFromContext(ctx).RecordError(err)
 			if errors.Is(err, xerror.ErrStageSkip) {
-				logs.L().Ctx(p).Warn("Skipped pre run stage", zap.Error(err))
+				logs.L().Ctx(ctx).Warn("Skipped pre run stage", zap.Error(err))
 			} else {
-				logs.L().Ctx(p).Error("Skipped pre run stage", zap.Error(err))
+				logs.L().Ctx(ctx).Error("Skipped pre run stage", zap.Error(err))
 			}
 			return
 		}
-		err = s.Run(p.Context, p.data, p.metaData)
+		err = s.Run(ctx, e.data, e.metaData)
 		if err != nil {
-			trace.SpanFromContext(p.Context).RecordError(err)
+			trace.SpanFromContext(ctx).RecordError(err)
 			if errors.Is(err, xerror.ErrStageSkip) {
-				logs.L().Ctx(p).Warn("run stage skipped", zap.Error(err))
+				logs.L().Ctx(ctx).Warn("run stage skipped", zap.Error(err))
 			} else {
-				logs.L().Ctx(p).Error("run stage skipped", zap.Error(err))
+				logs.L().Ctx(ctx).Error("run stage skipped", zap.Error(err))
 			}
 			return
 		}
-		err = s.PostRun(p.Context, p.data, p.metaData)
+		err = s.PostRun(ctx, e.data, e.metaData)
 		if err != nil {
-			trace.SpanFromContext(p.Context).RecordError(err)
+			trace.SpanFromContext(ctx).RecordError(err)
 			if errors.Is(err, xerror.ErrStageSkip) {
-				logs.L().Ctx(p).Warn("post run stage skipped", zap.Error(err))
+				logs.L().Ctx(ctx).Warn("post run stage skipped", zap.Error(err))
 			} else {
-				logs.L().Ctx(p).Error("post run stage skipped", zap.Error(err))
+				logs.L().Ctx(ctx).Error("post run stage skipped", zap.Error(err))
 			}
 			return
 		}
@@ -215,46 +319,49 @@ func (p *Processor[T, K]) RunStages() (err error) {
 
 // Run  运行
 //
-//	@receiver p
-//	@param ctx
-//	@param event
-func (p *Processor[T, K]) Run() {
-	if p.metaInitFn == nil {
-		p.metaInitFn = func(*T) *K { return new(K) }
+//	@receiver e
+func (e *Execution[T, K]) Run() {
+	metaInitFn := e.proc.metaInitFn
+	if metaInitFn == nil {
+		metaInitFn = func(*T) *K { return new(K) }
 	}
-	p.metaData = p.metaInitFn(p.Data())
+	e.metaData = metaInitFn(e.Data())
 
-	if p.preRunFn != nil {
-		p.preRunFn(p)
+	if e.proc.preRunFn != nil {
+		e.proc.preRunFn(e)
 	}
-	for _, fn := range p.deferFn {
+	for _, fn := range e.proc.deferFn {
 		if fn != nil {
-			defer fn(p.Context, p.data, p.metaData)
+			defer fn(e.Context, e.data, e.metaData)
 		}
 	}
 	wg := sync.WaitGroup{}
-	wg.Go(func() { p.RunStages() })
-	wg.Go(func() { p.RunParallelStages() })
+	wg.Go(func() { e.RunStages() })
+	wg.Go(func() { e.RunParallelStages() })
 	wg.Wait()
 }
 
 // RunParallelStages  运行并行处理阶段
 //
-//	@receiver p
-//	@param ctx
-//	@param event
+//	@receiver e
 //	@return error
-func (p *Processor[T, K]) RunParallelStages() error {
-	var span trace.Span
-	p.Context, span = otel.T().Start(p.Context, reflecting.GetCurrentFunc())
+func (e *Execution[T, K]) RunParallelStages() error {
+	ctx, span := otel.T().Start(e.Context, reflecting.GetCurrentFunc())
 	defer span.End()
 
+	stages := e.proc.parrallelStages
 	wg := &sync.WaitGroup{}
-	errorChan := make(chan error, len(p.parrallelStages))
-	for _, operator := range p.parrallelStages {
+	errorChan := make(chan error, len(stages))
+	shedding := e.proc.shedFn != nil && e.proc.shedFn()
+	for _, operator := range stages {
+		if shedding && OperatorPriority(operator) < PriorityNormal {
+			shedCounter().Add(ctx, 1, metric.WithAttributes(attribute.String("operator", operator.Name())))
+			logs.L().Ctx(ctx).Info("operator shed under pressure", zap.String("stage", operator.Name()))
+			continue
+		}
 		wg.Add(1)
 		go func(op Operator[T, K]) {
-			defer p.Defer()
+			defer e.Defer()
 			var err error
 			defer func() {
 				if err != nil && !errors.Is(err, xerror.ErrStageSkip) {
@@ -262,35 +369,35 @@ func (p *Processor[T, K]) RunParallelStages() error {
 				}
 				wg.Done()
 			}()
-			err = op.PreRun(p, p.data, p.metaData)
+			err = op.PreRun(ctx, e.data, e.metaData)
 			if err != nil {
 				if errors.Is(err, xerror.ErrStageSkip) {
-					logs.L().Ctx(p).Info("Skipped pre run stage", zap.Error(err))
+					logs.L().Ctx(ctx).Info("Skipped pre run stage", zap.Error(err))
 				} else {
-					trace.SpanFromContext(p.Context).RecordError(err)
-					logs.L().Ctx(p).Error("pre run stage error", zap.Error(err))
+					trace.SpanFromContext(ctx).RecordError(err)
+					logs.L().Ctx(ctx).Error("pre run stage error", zap.Error(err))
 				}
 				return
 			}
 
-			logs.L().Ctx(p).Info("Run Handler", zap.String("handler", reflecting.GetFunctionName(op.Run)))
-			err = op.Run(p.Context, p.data, p.metaData)
+			logs.L().Ctx(ctx).Info("Run Handler", zap.String("handler", reflecting.GetFunctionName(op.Run)))
+			err = op.Run(ctx, e.data, e.metaData)
 			if err != nil {
 				if errors.Is(err, xerror.ErrStageSkip) {
-					logs.L().Ctx(p).Info("run stage skipped", zap.String("stage", op.Name()), zap.Error(err))
+					logs.L().Ctx(ctx).Info("run stage skipped", zap.String("stage", op.Name()), zap.Error(err))
 				} else {
-					trace.SpanFromContext(p.Context).RecordError(err)
-					logs.L().Ctx(p).Error("run stage error", zap.String("stage", op.Name()), zap.Error(err), zap.Stack("stack"))
+					trace.SpanFromContext(ctx).RecordError(err)
+					logs.L().Ctx(ctx).Error("run stage error", zap.String("stage", op.Name()), zap.Error(err), zap.Stack("stack"))
 				}
 				return
 			}
-			err = op.PostRun(p.Context, p.data, p.metaData)
+			err = op.PostRun(ctx, e.data, e.metaData)
 			if err != nil {
-				trace.SpanFromContext(p.Context).RecordError(err)
+				trace.SpanFromContext(ctx).RecordError(err)
 				if errors.Is(err, xerror.ErrStageSkip) {
-					logs.L().Ctx(p).Info("post run stage skipped", zap.Error(err))
+					logs.L().Ctx(ctx).Info("post run stage skipped", zap.Error(err))
 				} else {
-					logs.L().Ctx(p).Error("post run stage error", zap.Error(err))
+					logs.L().Ctx(ctx).Error("post run stage error", zap.Error(err))
 				}
 				return
 			}
@@ -303,8 +410,12 @@ func (p *Processor[T, K]) RunParallelStages() error {
 	var mergedErr error
 	for err := range errorChan {
 		if err != nil {
-			mergedErr = errors.Wrap(mergedErr, err.Error())
-			logs.L().Ctx(p).Warn("error in parallel stages", zap.Error(err))
+			if mergedErr == nil {
+				mergedErr = err
+			} else {
+				mergedErr = errors.Wrap(mergedErr, err.Error())
+			}
+			logs.L().Ctx(ctx).Warn("error in parallel stages", zap.Error(err))
 		}
 	}
 	return mergedErr
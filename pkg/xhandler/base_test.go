@@ -0,0 +1,148 @@
+package xhandler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/xerror"
+)
+
+type testEvent struct {
+	ID string
+}
+
+type testMeta struct {
+	ID string
+}
+
+// recordOp 记录每个事件在各阶段看到的数据, 用于检查事件间是否串数据
+type recordOp struct {
+	OperatorBase[testEvent, testMeta]
+	name     string
+	priority Priority
+
+	mu   sync.Mutex
+	seen map[string]string
+}
+
+func (o *recordOp) Name() string { return o.name }
+
+func (o *recordOp) Priority() Priority { return o.priority }
+
+func (o *recordOp) Run(ctx context.Context, event *testEvent, meta *testMeta) error {
+	if event.ID != meta.ID {
+		return fmt.Errorf("event %s got meta of %s", event.ID, meta.ID)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.seen == nil {
+		o.seen = make(map[string]string)
+	}
+	o.seen[event.ID] = meta.ID
+	return nil
+}
+
+type skipOp struct {
+	OperatorBase[testEvent, testMeta]
+}
+
+func (o *skipOp) Run(context.Context, *testEvent, *testMeta) error {
+	return xerror.ErrStageSkip
+}
+
+func newTestProcessor(ops ...*recordOp) *Processor[testEvent, testMeta] {
+	p := NewProcessor[testEvent, testMeta]().
+		WithMetaDataProcess(func(e *testEvent) *testMeta { return &testMeta{ID: e.ID} }).
+		AddStages(&skipOp{})
+	for _, op := range ops {
+		p = p.AddParallelStages(op)
+	}
+	return p
+}
+
+func TestProcessorConcurrentEvents(t *testing.T) {
+	serial, parallel := &recordOp{name: "serial"}, &recordOp{name: "parallel"}
+	var deferred sync.Map
+	p := newTestProcessor(parallel).
+		AddStages(serial).
+		WithDefer(func(ctx context.Context, event *testEvent, meta *testMeta) {
+			deferred.Store(event.ID, meta.ID)
+		})
+
+	const n = 200
+	wg := sync.WaitGroup{}
+	for i := range n {
+		wg.Go(func() {
+			p.Run(context.Background(), &testEvent{ID: fmt.Sprint(i)})
+		})
+	}
+	wg.Wait()
+
+	// skipOp 在 serial 之前返回 ErrStageSkip, 串行阶段不应执行
+	if len(serial.seen) != 0 {
+		t.Fatalf("serial stage should be skipped, got %d", len(serial.seen))
+	}
+	if len(parallel.seen) != n {
+		t.Fatalf("parallel stage saw %d events, want %d", len(parallel.seen), n)
+	}
+	for id, metaID := range parallel.seen {
+		if id != metaID {
+			t.Fatalf("event %s processed with meta %s", id, metaID)
+		}
+	}
+	for i := range n {
+		v, ok := deferred.Load(fmt.Sprint(i))
+		if !ok || v != fmt.Sprint(i) {
+			t.Fatalf("defer of event %d got %v", i, v)
+		}
+	}
+}
+
+func TestProcessorBuilderDoesNotMutateShared(t *testing.T) {
+	base := newTestProcessor(&recordOp{name: "a"})
+	extended := base.AddParallelStages(&recordOp{name: "b"})
+	if len(base.parrallelStages) != 1 || len(extended.parrallelStages) != 2 {
+		t.Fatalf("builder mutated shared definition: base=%d extended=%d", len(base.parrallelStages), len(extended.parrallelStages))
+	}
+}
+
+func TestProcessorShedsLowPriority(t *testing.T) {
+	low, normal := &recordOp{name: "low", priority: PriorityLow}, &recordOp{name: "normal"}
+	var pressure atomic.Bool
+	p := newTestProcessor(low, normal).WithShedding(pressure.Load)
+
+	p.Run(context.Background(), &testEvent{ID: "1"})
+	pressure.Store(true)
+	p.Run(context.Background(), &testEvent{ID: "2"})
+
+	if _, ok := low.seen["2"]; ok || len(low.seen) != 1 {
+		t.Fatalf("low priority operator should be shed under pressure: %v", low.seen)
+	}
+	if len(normal.seen) != 2 {
+		t.Fatalf("normal operator should always run: %v", normal.seen)
+	}
+}
+
+func TestProcessorRecoversPanic(t *testing.T) {
+	var recovered atomic.Value
+	p := NewProcessor[testEvent, testMeta]().
+		OnPanic(func(ctx context.Context, err error, event *testEvent, meta *testMeta) {
+			recovered.Store(event.ID)
+		}).
+		AddParallelStages(&panicOp{})
+	p.Run(context.Background(), &testEvent{ID: "boom"})
+	if recovered.Load() != "boom" {
+		t.Fatalf("panic not recovered with its own event, got %v", recovered.Load())
+	}
+}
+
+type panicOp struct {
+	OperatorBase[testEvent, testMeta]
+}
+
+func (o *panicOp) Run(context.Context, *testEvent, *testMeta) error {
+	panic("non-error panic value")
+}
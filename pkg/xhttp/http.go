@@ -1,11 +1,19 @@
 package xhttp
 
 import (
+	"sync"
+
 	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/config"
 	"github.com/go-resty/resty/v2"
 )
 
 var (
-	HttpClient          = resty.New()
-	HttpClientWithProxy = resty.New().SetProxy(config.Get().ProxyConfig.PrivateProxy)
+	HttpClient = resty.New()
+	// HttpClientWithProxy 走私有代理的客户端, 首次使用时读取配置, 未配置代理时退化为直连
+	HttpClientWithProxy = sync.OnceValue(func() *resty.Client {
+		if conf := config.Get().ProxyConfig; conf != nil && conf.PrivateProxy != "" {
+			return resty.New().SetProxy(conf.PrivateProxy)
+		}
+		return HttpClient
+	})
 )
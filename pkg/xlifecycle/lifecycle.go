@@ -0,0 +1,273 @@
+package xlifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Phase 决定启动/停止顺序: 按 Phase 升序启动, 降序停止
+//
+//	Intake 停止后等待进行中的任务排空, 再依次停止 Worker、Infra
+type Phase int
+
+const (
+	// PhaseInfra 基础设施, 如 otel、db, 最先启动最后停止
+	PhaseInfra Phase = iota
+	// PhaseWorker 后台任务, 如 chunk 合并、登录保活
+	PhaseWorker
+	// PhaseIntake 事件接入, 如 ws/http, 最后启动最先停止
+	PhaseIntake
+)
+
+type HookFunc func(ctx context.Context) error
+
+// Hook 生命周期钩子, OnStart/OnStop 均可为空
+type Hook struct {
+	Name    string
+	Phase   Phase
+	OnStart HookFunc
+	OnStop  HookFunc
+}
+
+// Report 关停结果
+type Report struct {
+	// InFlightLost 截止时间到达时仍未完成的任务数
+	InFlightLost int
+	// StopErrors 各个停止钩子返回的错误
+	StopErrors map[string]error
+	// Fatal 运行期间导致关停的组件故障, 见 Manager.Fail
+	Fatal error
+}
+
+// Clean 是否无损关停
+func (r *Report) Clean() bool {
+	return r.InFlightLost == 0 && len(r.StopErrors) == 0 && r.Fatal == nil
+}
+
+// ExitCode 无损关停返回0, 否则返回1
+func (r *Report) ExitCode() int {
+	if r.Clean() {
+		return 0
+	}
+	return 1
+}
+
+func (r *Report) String() string {
+	if r.Clean() {
+		return "shutdown clean"
+	}
+	return fmt.Sprintf("shutdown lossy: fatal=%v, in-flight lost=%d, stop errors=%v", r.Fatal, r.InFlightLost, r.StopErrors)
+}
+
+// Manager 管理钩子与进行中的任务
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []*Hook
+	started []*Hook
+
+	closing atomic.Bool
+
+	inFlightMu sync.Mutex
+	inFlight   int
+	idle       chan struct{}
+
+	failOnce sync.Once
+	failed   chan struct{}
+	fatal    error
+}
+
+func New() *Manager {
+	return &Manager{failed: make(chan struct{})}
+}
+
+// Default 进程级别的默认管理器, 各个 Init 向其注册
+var Default = New()
+
+// Register 注册钩子, 需在 Start 之前调用
+//
+//	@receiver m *Manager
+//	@param hook *Hook
+func (m *Manager) Register(hook *Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Start 按 Phase 升序启动所有钩子, 任一失败即返回
+//
+//	@receiver m *Manager
+//	@param ctx context.Context
+//	@return error
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := slices.Clone(m.hooks)
+	m.mu.Unlock()
+
+	slices.SortStableFunc(hooks, func(a, b *Hook) int { return int(a.Phase - b.Phase) })
+	for _, h := range hooks {
+		if h.OnStart != nil {
+			if err := h.OnStart(ctx); err != nil {
+				return fmt.Errorf("start %s: %w", h.Name, err)
+			}
+		}
+		m.mu.Lock()
+		m.started = append(m.started, h)
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Fail 报告运行期间不可恢复的组件故障, 如长连接放弃重连; 只记录第一次, 由等待 Failed 的一方走正常关停
+//
+//	@receiver m *Manager
+//	@param name string 组件名
+//	@param err error
+func (m *Manager) Fail(name string, err error) {
+	m.failOnce.Do(func() {
+		m.mu.Lock()
+		m.fatal = fmt.Errorf("%s: %w", name, err)
+		m.mu.Unlock()
+		close(m.failed)
+	})
+}
+
+// Failed 有组件调用 Fail 后关闭
+//
+//	@receiver m *Manager
+//	@return <-chan struct{}
+func (m *Manager) Failed() <-chan struct{} {
+	return m.failed
+}
+
+// Accepting 是否仍在接收新事件, 关停开始后返回false
+func (m *Manager) Accepting() bool {
+	return !m.closing.Load()
+}
+
+// Track 登记一个进行中的任务, 返回的函数需在任务结束时调用
+//
+//	@receiver m *Manager
+//	@return func()
+func (m *Manager) Track() func() {
+	m.inFlightMu.Lock()
+	m.inFlight++
+	m.inFlightMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.inFlightMu.Lock()
+			defer m.inFlightMu.Unlock()
+			m.inFlight--
+			if m.inFlight == 0 && m.idle != nil {
+				close(m.idle)
+				m.idle = nil
+			}
+		})
+	}
+}
+
+// Go 以被追踪的方式启动一个goroutine
+//
+//	@receiver m *Manager
+//	@param fn func()
+func (m *Manager) Go(fn func()) {
+	done := m.Track()
+	go func() {
+		defer done()
+		fn()
+	}()
+}
+
+// InFlight 当前进行中的任务数
+func (m *Manager) InFlight() int {
+	m.inFlightMu.Lock()
+	defer m.inFlightMu.Unlock()
+	return m.inFlight
+}
+
+// waitIdle 等待进行中的任务排空, 返回截止时仍未完成的数量
+func (m *Manager) waitIdle(ctx context.Context) int {
+	m.inFlightMu.Lock()
+	if m.inFlight == 0 {
+		m.inFlightMu.Unlock()
+		return 0
+	}
+	if m.idle == nil {
+		m.idle = make(chan struct{})
+	}
+	idle := m.idle
+	m.inFlightMu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+		return m.InFlight()
+	}
+}
+
+// Shutdown 停止接收事件, 在 ctx 截止前排空进行中的任务, 然后按 Phase 降序停止钩子
+//
+//	@receiver m *Manager
+//	@param ctx context.Context 截止时间
+//	@return *Report
+func (m *Manager) Shutdown(ctx context.Context) *Report {
+	m.closing.Store(true)
+	report := &Report{StopErrors: make(map[string]error)}
+
+	m.mu.Lock()
+	started := slices.Clone(m.started)
+	report.Fatal = m.fatal
+	m.mu.Unlock()
+	slices.Reverse(started)
+
+	stop := func(h *Hook) {
+		if h.OnStop == nil {
+			return
+		}
+		if err := h.OnStop(ctx); err != nil {
+			report.StopErrors[h.Name] = err
+		}
+	}
+
+	drained := false
+	for _, h := range started {
+		if !drained && h.Phase < PhaseIntake {
+			report.InFlightLost = m.waitIdle(ctx)
+			drained = true
+		}
+		stop(h)
+	}
+	if !drained {
+		report.InFlightLost = m.waitIdle(ctx)
+	}
+	return report
+}
+
+// ErrShuttingDown 关停期间拒绝新事件时返回
+var ErrShuttingDown = errors.New("shutting down, event rejected")
+
+// Register 向默认管理器注册钩子
+func Register(name string, phase Phase, onStart, onStop HookFunc) {
+	Default.Register(&Hook{Name: name, Phase: phase, OnStart: onStart, OnStop: onStop})
+}
+
+// Accepting 默认管理器是否仍在接收新事件
+func Accepting() bool {
+	return Default.Accepting()
+}
+
+// Fail 向默认管理器报告组件故障
+func Fail(name string, err error) {
+	Default.Fail(name, err)
+}
+
+// Go 在默认管理器下启动被追踪的goroutine
+func Go(fn func()) {
+	Default.Go(fn)
+}
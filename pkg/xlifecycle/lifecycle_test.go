@@ -0,0 +1,96 @@
+package xlifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrderAndDrain(t *testing.T) {
+	m := New()
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, s)
+	}
+	hook := func(name string, phase Phase) {
+		m.Register(&Hook{
+			Name:    name,
+			Phase:   phase,
+			OnStart: func(context.Context) error { record("start:" + name); return nil },
+			OnStop:  func(context.Context) error { record("stop:" + name); return nil },
+		})
+	}
+	hook("intake", PhaseIntake)
+	hook("infra", PhaseInfra)
+	hook("worker", PhaseWorker)
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m.Go(func() {
+		time.Sleep(20 * time.Millisecond)
+		record("drained")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	report := m.Shutdown(ctx)
+	if !report.Clean() || report.ExitCode() != 0 {
+		t.Fatalf("expect clean shutdown, got %s", report)
+	}
+	want := []string{"start:infra", "start:worker", "start:intake", "stop:intake", "drained", "stop:worker", "stop:infra"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+	if m.Accepting() {
+		t.Fatal("manager should reject new events after shutdown")
+	}
+}
+
+func TestShutdownReportsLoss(t *testing.T) {
+	m := New()
+	m.Register(&Hook{Name: "worker", Phase: PhaseWorker, OnStop: func(context.Context) error { return errors.New("boom") }})
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	block := make(chan struct{})
+	defer close(block)
+	m.Go(func() { <-block })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report := m.Shutdown(ctx)
+	if report.InFlightLost != 1 || report.StopErrors["worker"] == nil || report.ExitCode() != 1 {
+		t.Fatalf("unexpected report: %s", report)
+	}
+}
+
+func TestFailReported(t *testing.T) {
+	m := New()
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m.Fail("ws", errors.New("gave up"))
+	m.Fail("http", errors.New("ignored"))
+	select {
+	case <-m.Failed():
+	default:
+		t.Fatal("Failed() should be closed after Fail")
+	}
+	report := m.Shutdown(context.Background())
+	if report.Fatal == nil || report.Fatal.Error() != "ws: gave up" || report.ExitCode() != 1 {
+		t.Fatalf("unexpected report: %s", report)
+	}
+}
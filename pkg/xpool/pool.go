@@ -0,0 +1,154 @@
+package xpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BetaGoRobot/BetaGo-Redefine/internal/infrastructure/otel"
+	"github.com/BetaGoRobot/BetaGo-Redefine/pkg/logs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrPoolFull 全局队列已满
+	ErrPoolFull = errors.New("worker pool is full")
+	// ErrKeyQueueFull 单个 key 的队列已满
+	ErrKeyQueueFull = errors.New("worker pool key queue is full")
+)
+
+// Pool 按 key 保序、全局限并发的任务池
+//
+//	同一个 key 的任务严格按提交顺序串行执行, 不同 key 之间并发, 并发总数不超过 workers;
+//	排队+执行中的任务总数不超过 maxQueue, 单个 key 排队数不超过 perKeyQueue
+type Pool struct {
+	name        string
+	maxQueue    int
+	perKeyQueue int
+	sem         chan struct{}
+
+	mu     sync.Mutex
+	queues map[string][]*task
+	depth  int
+
+	attrs    metric.MeasurementOption
+	depthCnt metric.Int64UpDownCounter
+	rejected metric.Int64Counter
+	waitHist metric.Float64Histogram
+}
+
+type task struct {
+	fn       func()
+	submitAt time.Time
+}
+
+// New 创建任务池
+//
+//	@param name string 用于指标区分
+//	@param workers int 全局并发上限
+//	@param maxQueue int 全局排队+执行中上限
+//	@param perKeyQueue int 单个 key 排队上限
+//	@return *Pool
+func New(name string, workers, maxQueue, perKeyQueue int) *Pool {
+	p := &Pool{
+		name:        name,
+		maxQueue:    max(maxQueue, 1),
+		perKeyQueue: max(perKeyQueue, 1),
+		sem:         make(chan struct{}, max(workers, 1)),
+		queues:      make(map[string][]*task),
+		attrs:       metric.WithAttributes(attribute.String("pool", name)),
+	}
+	meter := otel.M()
+	p.depthCnt, _ = meter.Int64UpDownCounter("xpool_queue_depth", metric.WithDescription("queued and running tasks"))
+	p.rejected, _ = meter.Int64Counter("xpool_rejected", metric.WithDescription("tasks rejected by queue limits"))
+	p.waitHist, _ = meter.Float64Histogram("xpool_wait_ms", metric.WithDescription("time between submit and start"), metric.WithUnit("ms"))
+	return p
+}
+
+// Submit 提交任务, 队列超限时返回错误
+//
+//	@receiver p *Pool
+//	@param ctx context.Context
+//	@param key string 保序的维度, 如 chat_id
+//	@param fn func()
+//	@return error
+func (p *Pool) Submit(ctx context.Context, key string, fn func()) error {
+	p.mu.Lock()
+	if p.depth >= p.maxQueue {
+		p.mu.Unlock()
+		p.rejected.Add(ctx, 1, p.attrs, metric.WithAttributes(attribute.String("reason", "pool_full")))
+		return ErrPoolFull
+	}
+	queue, active := p.queues[key]
+	if len(queue) >= p.perKeyQueue {
+		p.mu.Unlock()
+		p.rejected.Add(ctx, 1, p.attrs, metric.WithAttributes(attribute.String("reason", "key_full")))
+		return fmt.Errorf("%w: %s", ErrKeyQueueFull, key)
+	}
+	p.queues[key] = append(queue, &task{fn: fn, submitAt: time.Now()})
+	p.depth++
+	p.mu.Unlock()
+
+	p.depthCnt.Add(ctx, 1, p.attrs)
+	if !active {
+		go p.drain(key)
+	}
+	return nil
+}
+
+// Pressure 当前负载, 排队+执行中任务数 / maxQueue
+//
+//	@receiver p *Pool
+//	@return float64
+func (p *Pool) Pressure() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return float64(p.depth) / float64(p.maxQueue)
+}
+
+// Depth 排队+执行中的任务数
+func (p *Pool) Depth() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.depth
+}
+
+// drain 串行消费一个 key 的队列, 队列为空时退出
+func (p *Pool) drain(key string) {
+	for {
+		p.mu.Lock()
+		queue := p.queues[key]
+		if len(queue) == 0 {
+			delete(p.queues, key)
+			p.mu.Unlock()
+			return
+		}
+		t := queue[0]
+		queue[0] = nil
+		p.queues[key] = queue[1:]
+		p.mu.Unlock()
+
+		p.sem <- struct{}{}
+		p.run(t)
+	}
+}
+
+func (p *Pool) run(t *task) {
+	ctx := context.Background()
+	defer func() {
+		<-p.sem
+		p.mu.Lock()
+		p.depth--
+		p.mu.Unlock()
+		p.depthCnt.Add(ctx, -1, p.attrs)
+		if err := recover(); err != nil {
+			logs.L().Error("panic in pool task", zap.String("pool", p.name), zap.Any("panic", err), zap.Stack("stack"))
+		}
+	}()
+	p.waitHist.Record(ctx, float64(time.Since(t.submitAt).Milliseconds()), p.attrs)
+	t.fn()
+}
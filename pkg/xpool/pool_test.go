@@ -0,0 +1,89 @@
+package xpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolKeepsOrderPerKey(t *testing.T) {
+	p := New("test", 4, 1000, 1000)
+	var (
+		mu  sync.Mutex
+		got = map[string][]int{}
+		wg  sync.WaitGroup
+	)
+	for i := range 100 {
+		for _, key := range []string{"a", "b", "c"} {
+			wg.Add(1)
+			err := p.Submit(context.Background(), key, func() {
+				defer wg.Done()
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	wg.Wait()
+	for key, seq := range got {
+		for i, v := range seq {
+			if v != i {
+				t.Fatalf("key %s out of order at %d: %v", key, i, seq)
+			}
+		}
+	}
+}
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	p := New("test", 2, 100, 10)
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		if err := p.Submit(context.Background(), fmt.Sprint(i), func() {
+			defer wg.Done()
+			cur := running.Add(1)
+			for {
+				old := peak.Load()
+				if cur <= old || peak.CompareAndSwap(old, cur) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if peak.Load() > 2 {
+		t.Fatalf("peak concurrency %d exceeds 2", peak.Load())
+	}
+}
+
+func TestPoolRejectsWhenFull(t *testing.T) {
+	p := New("test", 1, 2, 1)
+	block, started := make(chan struct{}), make(chan struct{})
+	defer close(block)
+	if err := p.Submit(context.Background(), "a", func() { close(started); <-block }); err != nil {
+		t.Fatal(err)
+	}
+	// 等待第一个任务出队执行
+	<-started
+	if err := p.Submit(context.Background(), "a", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Submit(context.Background(), "a", func() {}); !errors.Is(err, ErrPoolFull) {
+		t.Fatalf("expect ErrPoolFull, got %v", err)
+	}
+	if p.Pressure() != 1 {
+		t.Fatalf("expect pressure 1, got %v", p.Pressure())
+	}
+}
@@ -0,0 +1,306 @@
+// Package xtime 自然语言时间范围: 将 "上周"、"过去3天"、"yesterday 9-18"、"2025-05"、"since @msg" 等表达式
+// 在指定时区下解析为左闭右开的 [Start, End) 区间, 供各类统计命令与模型工具共用
+package xtime
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknown 无法识别的时间表达式
+var ErrUnknown = errors.New("unknown time range expression")
+
+// Range 左闭右开的时间区间 [Start, End)
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsZero 是否未指定
+func (r Range) IsZero() bool {
+	return r.Start.IsZero() && r.End.IsZero()
+}
+
+// Contains t 是否落在区间内
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+func (r Range) String() string {
+	return r.Start.Format(time.DateTime) + " ~ " + r.End.Format(time.DateTime)
+}
+
+// Options 解析时的上下文
+type Options struct {
+	// Loc 解析所用的时区, 为空时使用 UTC+8
+	Loc *time.Location
+	// Now 当前时间, 为空时使用 time.Now()
+	Now time.Time
+	// Anchor 解析 "since @msg" 中以 @ 开头的引用, 返回其时间
+	Anchor func(ref string) (time.Time, bool)
+}
+
+func (o Options) normalize() Options {
+	if o.Loc == nil {
+		o.Loc = time.FixedZone("UTC+8", 8*60*60)
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+	o.Now = o.Now.In(o.Loc)
+	return o
+}
+
+var (
+	sinceRe   = regexp.MustCompile(`^(?:since\s+|从|自)(.+?)(?:以来|开始|起|至今)?$`)
+	betweenRe = regexp.MustCompile(`^(.+?)\s*(?:~|～|\s+to\s+|到|至)\s*(.+)$`)
+	windowRe  = regexp.MustCompile(`^(.*?)\s*(\d{1,2})(?:[:：](\d{2}))?\s*[点时]?\s*(?:-|~|～|到|至)\s*(\d{1,2})(?:[:：](\d{2}))?\s*[点时]?$`)
+	lastNRe   = regexp.MustCompile(`^(?:last|past|过去|最近|近)\s*([0-9零一二两三四五六七八九十百]*)\s*(?:个)?\s*(minutes?|mins?|hours?|days?|weeks?|months?|分钟|小时|天|日|周|星期|月)(?:内|以来)?$`)
+	nDaysRe   = regexp.MustCompile(`^([0-9零一二两三四五六七八九十百]+)\s*(?:个)?\s*(分钟|小时|天|日|周|星期|月)(?:内|以来)$`)
+	yearRe    = regexp.MustCompile(`^(\d{4})年?$`)
+	monthRe   = regexp.MustCompile(`^(?:(\d{4})(?:-|/|年))?(\d{1,2})月?$`)
+	dayRe     = regexp.MustCompile(`^(?:(\d{4})(?:-|/|年))?(\d{1,2})(?:-|/|月)(\d{1,2})[日号]?$`)
+	pointRe   = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}[ T]\d{1,2}:\d{2}(?::\d{2})?$`)
+)
+
+// Parse 解析时间范围表达式
+//
+//	支持: today/今天、yesterday/昨天、前天、this|last week/本周/上周、this|last month/本月/上个月、今年/去年,
+//	last|past N days/过去N天/最近N小时/3天内, 2025、2025-05/2025年5月、2025-05-20/5月20日,
+//	以上单日后接时段如 "yesterday 9-18"、"昨天9点到18点", 区间 "A ~ B"、"A 到 B", 以及 "since X"/"从X开始"(X 可以是 @msg 等引用)
+//	@param expr string
+//	@param opt Options
+//	@return Range
+//	@return error
+func Parse(expr string, opt Options) (Range, error) {
+	opt = opt.normalize()
+	s := strings.ToLower(strings.Join(strings.Fields(expr), " "))
+	if s == "" {
+		return Range{}, ErrUnknown
+	}
+	if m := sinceRe.FindStringSubmatch(s); m != nil {
+		ref := strings.TrimSpace(m[1])
+		if strings.HasPrefix(ref, "@") {
+			if opt.Anchor == nil {
+				return Range{}, fmt.Errorf("%w: cannot resolve %s", ErrUnknown, ref)
+			}
+			t, ok := opt.Anchor(ref)
+			if !ok {
+				return Range{}, fmt.Errorf("%w: cannot resolve %s", ErrUnknown, ref)
+			}
+			return Range{Start: t.In(opt.Loc), End: opt.Now}, nil
+		}
+		r, err := parseWindow(ref, opt)
+		if err != nil {
+			return Range{}, err
+		}
+		return Range{Start: r.Start, End: opt.Now}, nil
+	}
+	if r, err := parseWindow(s, opt); err == nil {
+		return r, nil
+	}
+	if m := betweenRe.FindStringSubmatch(s); m != nil {
+		from, err := parseWindow(m[1], opt)
+		if err != nil {
+			return Range{}, err
+		}
+		to, err := parseWindow(m[2], opt)
+		if err != nil {
+			return Range{}, err
+		}
+		if !from.Start.Before(to.End) {
+			return Range{}, fmt.Errorf("%w: %q ends before it starts", ErrUnknown, expr)
+		}
+		return Range{Start: from.Start, End: to.End}, nil
+	}
+	return Range{}, fmt.Errorf("%w: %q", ErrUnknown, expr)
+}
+
+// parseWindow 单个时间段, 可以在单日后接时段
+func parseWindow(s string, opt Options) (Range, error) {
+	if r, ok := parseSpan(s, opt); ok {
+		return r, nil
+	}
+	m := windowRe.FindStringSubmatch(s)
+	if m == nil {
+		return Range{}, ErrUnknown
+	}
+	day := Range{Start: startOfDay(opt.Now), End: startOfDay(opt.Now).AddDate(0, 0, 1)}
+	if prefix := strings.TrimSpace(m[1]); prefix != "" {
+		// 前缀以数字或分隔符结尾时是日期的一部分, 如 2025-05-01~2025-05-10
+		if last := prefix[len(prefix)-1]; last >= '0' && last <= '9' || strings.ContainsRune("-~/:", rune(last)) {
+			return Range{}, ErrUnknown
+		}
+		r, ok := parseSpan(prefix, opt)
+		if !ok || !r.End.Equal(r.Start.AddDate(0, 0, 1)) {
+			return Range{}, ErrUnknown
+		}
+		day = r
+	}
+	clock := func(h, min string) (time.Duration, bool) {
+		hour, _ := strconv.Atoi(h)
+		minute, _ := strconv.Atoi(min)
+		if hour > 24 || minute > 59 || (hour == 24 && minute > 0) {
+			return 0, false
+		}
+		return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+	}
+	from, ok1 := clock(m[2], m[3])
+	to, ok2 := clock(m[4], m[5])
+	if !ok1 || !ok2 || from >= to {
+		return Range{}, ErrUnknown
+	}
+	return Range{Start: day.Start.Add(from), End: day.Start.Add(to)}, nil
+}
+
+// parseSpan 不带时段的时间段
+func parseSpan(s string, opt Options) (Range, bool) {
+	now := opt.Now
+	today := startOfDay(now)
+	days := func(st time.Time, n int) (Range, bool) { return Range{Start: st, End: st.AddDate(0, 0, n)}, true }
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, opt.Loc)
+	year := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, opt.Loc)
+	switch s {
+	case "today", "今天", "今日":
+		return days(today, 1)
+	case "yesterday", "昨天", "昨日":
+		return days(today.AddDate(0, 0, -1), 1)
+	case "day before yesterday", "前天":
+		return days(today.AddDate(0, 0, -2), 1)
+	case "this week", "本周", "这周", "这星期", "本星期":
+		return days(week, 7)
+	case "last week", "上周", "上星期", "上个星期":
+		return days(week.AddDate(0, 0, -7), 7)
+	case "this month", "本月", "这个月":
+		return Range{Start: month, End: month.AddDate(0, 1, 0)}, true
+	case "last month", "上月", "上个月":
+		return Range{Start: month.AddDate(0, -1, 0), End: month}, true
+	case "this year", "今年":
+		return Range{Start: year, End: year.AddDate(1, 0, 0)}, true
+	case "last year", "去年":
+		return Range{Start: year.AddDate(-1, 0, 0), End: year}, true
+	}
+	if m := lastNRe.FindStringSubmatch(s); m != nil {
+		return lastN(m[1], m[2], now)
+	}
+	if m := nDaysRe.FindStringSubmatch(s); m != nil {
+		return lastN(m[1], m[2], now)
+	}
+	if pointRe.MatchString(s) {
+		for _, layout := range []string{time.DateTime, "2006-1-2 15:04:05", "2006-1-2 15:04", "2006-1-2T15:04:05", "2006-1-2T15:04"} {
+			if t, err := time.ParseInLocation(layout, s, opt.Loc); err == nil {
+				return Range{Start: t, End: t}, true
+			}
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return Range{Start: t.In(opt.Loc), End: t.In(opt.Loc)}, true
+	}
+	if m := yearRe.FindStringSubmatch(s); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		st := time.Date(y, 1, 1, 0, 0, 0, 0, opt.Loc)
+		return Range{Start: st, End: st.AddDate(1, 0, 0)}, true
+	}
+	// 不带年份时需带"月", 避免与 9-18 这样的时段混淆
+	if m := dayRe.FindStringSubmatch(s); m != nil && (m[1] != "" || strings.Contains(s, "月")) {
+		y := now.Year()
+		if m[1] != "" {
+			y, _ = strconv.Atoi(m[1])
+		}
+		mon, _ := strconv.Atoi(m[2])
+		d, _ := strconv.Atoi(m[3])
+		st := time.Date(y, time.Month(mon), d, 0, 0, 0, 0, opt.Loc)
+		if mon < 1 || mon > 12 || st.Day() != d {
+			return Range{}, false
+		}
+		return days(st, 1)
+	}
+	// 只有月份时需带"月"或年份, 避免把纯数字当作月份
+	if m := monthRe.FindStringSubmatch(s); m != nil && (m[1] != "" || strings.HasSuffix(s, "月")) {
+		y := now.Year()
+		if m[1] != "" {
+			y, _ = strconv.Atoi(m[1])
+		}
+		mon, _ := strconv.Atoi(m[2])
+		if mon < 1 || mon > 12 {
+			return Range{}, false
+		}
+		st := time.Date(y, time.Month(mon), 1, 0, 0, 0, 0, opt.Loc)
+		return Range{Start: st, End: st.AddDate(0, 1, 0)}, true
+	}
+	return Range{}, false
+}
+
+// lastN 截止到现在的最近 N 个单位, 数量为空时为 1
+func lastN(num, unit string, now time.Time) (Range, bool) {
+	n := 1
+	if num != "" {
+		var ok bool
+		if n, ok = Number(num); !ok || n <= 0 {
+			return Range{}, false
+		}
+	}
+	var st time.Time
+	switch strings.TrimSuffix(unit, "s") {
+	case "minute", "min", "分钟":
+		st = now.Add(-time.Duration(n) * time.Minute)
+	case "hour", "小时":
+		st = now.Add(-time.Duration(n) * time.Hour)
+	case "day", "天", "日":
+		st = now.AddDate(0, 0, -n)
+	case "week", "周", "星期":
+		st = now.AddDate(0, 0, -7*n)
+	case "month", "月":
+		st = now.AddDate(0, -n, 0)
+	default:
+		return Range{}, false
+	}
+	return Range{Start: st, End: now}, true
+}
+
+var cnDigits = map[rune]int{'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+
+// Number 解析阿拉伯数字或一百以内的中文数字, 如 "12"、"十二"、"两"、"一百"; 时间表达式与提醒的时间解析共用
+//
+//	@param s string
+//	@return int
+//	@return bool 为空或含有其他字符时为 false
+func Number(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	if s == "" {
+		return 0, false
+	}
+	n, cur := 0, 0
+	for _, r := range s {
+		switch {
+		case r == '十':
+			if cur == 0 {
+				cur = 1
+			}
+			n, cur = n+cur*10, 0
+		case r == '百':
+			if cur == 0 {
+				cur = 1
+			}
+			n, cur = n+cur*100, 0
+		default:
+			d, ok := cnDigits[r]
+			if !ok {
+				return 0, false
+			}
+			cur = d
+		}
+	}
+	return n + cur, true
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
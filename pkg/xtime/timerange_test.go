@@ -0,0 +1,74 @@
+package xtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	// 2026-10-21 是周三
+	now := time.Date(2026, 10, 21, 15, 30, 0, 0, loc)
+	msgAt := time.Date(2026, 10, 21, 10, 0, 0, 0, time.UTC)
+	opt := Options{Loc: loc, Now: now, Anchor: func(ref string) (time.Time, bool) { return msgAt, ref == "@msg" }}
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, loc) }
+
+	cases := []struct {
+		expr   string
+		st, et time.Time
+	}{
+		{"today", day(21), day(22)},
+		{"昨天", day(20), day(21)},
+		{"前天", day(19), day(20)},
+		{"Last Week", day(12), day(19)},
+		{"本周", day(19), day(26)},
+		{"上个月", time.Date(2026, 9, 1, 0, 0, 0, 0, loc), day(1)},
+		{"去年", time.Date(2025, 1, 1, 0, 0, 0, 0, loc), time.Date(2026, 1, 1, 0, 0, 0, 0, loc)},
+		{"过去3天", day(18).Add(15*time.Hour + 30*time.Minute), now},
+		{"最近两小时", now.Add(-2 * time.Hour), now},
+		{"last 24 hours", now.Add(-24 * time.Hour), now},
+		{"past hour", now.Add(-time.Hour), now},
+		{"七天内", day(14).Add(15*time.Hour + 30*time.Minute), now},
+		{"yesterday 9-18", day(20).Add(9 * time.Hour), day(20).Add(18 * time.Hour)},
+		{"昨天9点到18点", day(20).Add(9 * time.Hour), day(20).Add(18 * time.Hour)},
+		{"9:30-18:00", day(21).Add(9*time.Hour + 30*time.Minute), day(21).Add(18 * time.Hour)},
+		{"2025-05", time.Date(2025, 5, 1, 0, 0, 0, 0, loc), time.Date(2025, 6, 1, 0, 0, 0, 0, loc)},
+		{"2025年5月", time.Date(2025, 5, 1, 0, 0, 0, 0, loc), time.Date(2025, 6, 1, 0, 0, 0, 0, loc)},
+		{"2025", time.Date(2025, 1, 1, 0, 0, 0, 0, loc), time.Date(2026, 1, 1, 0, 0, 0, 0, loc)},
+		{"10月1日", day(1), day(2)},
+		{"2025-05-01 ~ 2025-05-10", time.Date(2025, 5, 1, 0, 0, 0, 0, loc), time.Date(2025, 5, 11, 0, 0, 0, 0, loc)},
+		{"2026-10-01 08:00 to 2026-10-02 20:00", day(1).Add(8 * time.Hour), day(2).Add(20 * time.Hour)},
+		{"since @msg", msgAt, now},
+		{"从昨天开始", day(20), now},
+	}
+	for _, c := range cases {
+		r, err := Parse(c.expr, opt)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", c.expr, err)
+			continue
+		}
+		if !r.Start.Equal(c.st) || !r.End.Equal(c.et) {
+			t.Errorf("Parse(%q) = %v, want %v ~ %v", c.expr, r, c.st, c.et)
+		}
+	}
+
+	for _, expr := range []string{"", "someday", "since @other", "2025-13", "18-9", "上周 9-18", "2025-05-10 ~ 2025-05-01"} {
+		if _, err := Parse(expr, opt); !errors.Is(err, ErrUnknown) {
+			t.Errorf("Parse(%q) should fail, got %v", expr, err)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	for s, want := range map[string]int{"3": 3, "十": 10, "十二": 12, "二十": 20, "二十五": 25, "两": 2, "一百": 100} {
+		if got, ok := Number(s); !ok || got != want {
+			t.Errorf("Number(%q) = %d, %v, want %d", s, got, ok, want)
+		}
+	}
+	for _, s := range []string{"", "x", "十x"} {
+		if _, ok := Number(s); ok {
+			t.Errorf("Number(%q) should fail", s)
+		}
+	}
+}